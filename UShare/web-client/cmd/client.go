@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/commands"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/credentials"
 )
 
 func main() {
@@ -32,39 +33,113 @@ func commandsWithHostURL(command string) {
 	switch command {
 	case "login":
 		commands.Login(hostURL)
+	case "login-sso":
+		commands.LoginSSO(hostURL)
 	case "register":
 		commands.RegisterUser(hostURL)
+	case "forgot-password":
+		commands.RequestPasswordReset(hostURL)
+	case "reset-password":
+		commands.ResetPassword(hostURL)
 	default:
 		commandsWithAuth(command, hostURL)
 	}
 }
 
 func commandsWithAuth(command, hostURL string) {
-	token := os.Getenv("JWT")
-	if hostURL == "" {
-		fmt.Print("JWT hasnt been set. Please set this environment variable. To get its value, please login again")
+	//the env variables take precedence over the credentials, saved during the login
+	token := os.Getenv("ACCESS_TOKEN")
+	if token == "" {
+		token = os.Getenv("JWT")
+	}
+	if token == "" {
+		if creds, err := credentials.Load(); err == nil && creds.HostURL == hostURL {
+			token = creds.Token
+		}
+	}
+
+	if token == "" {
+		fmt.Print("You arent logged in. Please login first")
 		return
 	}
 
 	switch command {
+	case "logout":
+		commands.Logout(hostURL, token)
+	case "change-password":
+		commands.ChangePassword(hostURL, token)
+	case "enable-2fa":
+		commands.EnableTwoFactor(hostURL, token)
+	case "activate-2fa":
+		commands.ActivateTwoFactor(hostURL, token)
+	case "disable-2fa":
+		commands.DisableTwoFactor(hostURL, token)
+	case "create-token":
+		commands.CreateAccessToken(hostURL, token)
+	case "show-tokens":
+		commands.ShowAccessTokens(hostURL, token)
+	case "revoke-token":
+		commands.RevokeAccessToken(hostURL, token)
 	case "create-group":
 		commands.CreateGroup(hostURL, token)
 	case "delete-group":
 		commands.DeleteGroup(hostURL, token)
+	case "show-deleted-groups":
+		commands.ShowDeletedGroups(hostURL, token)
+	case "restore-group":
+		commands.RestoreGroup(hostURL, token)
 	case "add-member":
 		commands.AddMember(hostURL, token)
+	case "revoke-invite":
+		commands.RevokeInvitation(hostURL, token)
+	case "invitations":
+		commands.ShowInvitations(hostURL, token)
+	case "accept-invite":
+		commands.AcceptInvitation(hostURL, token)
+	case "decline-invite":
+		commands.DeclineInvitation(hostURL, token)
 	case "remove-member":
 		commands.RemoveMember(hostURL, token)
+	case "change-role":
+		commands.ChangeMemberRole(hostURL, token)
+	case "transfer-ownership":
+		commands.TransferOwnership(hostURL, token)
 	case "upload-file":
 		commands.UploadFile(hostURL, token)
 	case "download-file":
 		commands.DownloadFile(hostURL, token)
 	case "delete-file":
 		commands.DeleteFile(hostURL, token)
+	case "show-trash":
+		commands.ShowTrash(hostURL, token)
+	case "restore-file":
+		commands.RestoreFile(hostURL, token)
 	case "show-all-files":
 		commands.ShowAllFilesInGroup(hostURL, token)
+	case "show-file-versions":
+		commands.ShowFileVersions(hostURL, token)
+	case "restore-file-version":
+		commands.RestoreFileVersion(hostURL, token)
+	case "tag-file":
+		commands.TagFile(hostURL, token)
+	case "search-files":
+		commands.SearchFiles(hostURL, token)
+	case "share-file":
+		commands.ShareFile(hostURL, token)
+	case "show-shares":
+		commands.ShowShareLinks(hostURL, token)
+	case "revoke-share":
+		commands.RevokeShareLink(hostURL, token)
 	case "show-all-groups":
 		commands.ShowAllGroups(hostURL, token)
+	case "show-group-info":
+		commands.ShowGroupInfo(hostURL, token)
+	case "update-group-quota":
+		commands.UpdateGroupQuota(hostURL, token)
+	case "require-2fa":
+		commands.RequireTwoFactor(hostURL, token)
+	case "show-audit":
+		commands.ShowAuditEvents(hostURL, token)
 	case "show-all-users":
 		commands.ShowAllUsers(hostURL, token)
 	case "show-all-members":
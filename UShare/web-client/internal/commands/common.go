@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"flag"
+	"fmt"
+	"net/url"
 	"os"
 
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
@@ -19,3 +23,72 @@ func PrintTable(columNames table.Row, records []table.Row) {
 	t.AppendRows(records)
 	t.Render()
 }
+
+//ListPage - part of the responses of the listings, the next cursor is empty on the last page
+type ListPage struct {
+	NextCursor string `json:"next_cursor"`
+}
+
+func (p *ListPage) getNextCursor() string {
+	return p.NextCursor
+}
+
+//listFlags - flags for the paging, the sorting and the filtering of a listing
+type listFlags struct {
+	limit *int
+	page  *int
+	sort  *string
+	desc  *bool
+	query *string
+}
+
+//addListFlags - registers the flags of a listing to a command, the sort fields are shown in the usage
+func addListFlags(command *flag.FlagSet, sortFields string) listFlags {
+	return listFlags{
+		limit: command.Int("limit", 50, "Number of items per page"),
+		page:  command.Int("page", 1, "Number of the page"),
+		sort:  command.String("sort", "", fmt.Sprintf("Field to sort by (%s)", sortFields)),
+		desc:  command.Bool("desc", false, "Sort in descending order"),
+		query: command.String("q", "", "Text, which the names should contain"),
+	}
+}
+
+func (f listFlags) valid() bool {
+	return *f.limit >= 1 && *f.page >= 1
+}
+
+//getListPage - fetches the requested page of a listing, by following the cursors of the previous pages
+//the body of the response should embed ListPage
+func getListPage(restClient *restclient.RestClientImpl, endpointURL string, params url.Values, flags listFlags, body interface{ getNextCursor() string }) error {
+	params.Set("limit", fmt.Sprint(*flags.limit))
+	if *flags.sort != "" {
+		params.Set("sort", *flags.sort)
+	}
+	if *flags.desc {
+		params.Set("order", "desc")
+	}
+	if *flags.query != "" {
+		params.Set("q", *flags.query)
+	}
+
+	for page := 1; ; page++ {
+		if err := restClient.Get(endpointURL+"?"+params.Encode(), body); err != nil {
+			return err
+		} else if page == *flags.page {
+			return nil
+		}
+
+		cursor := body.getNextCursor()
+		if cursor == "" {
+			return fmt.Errorf("There are only %d pages", page)
+		}
+		params.Set("cursor", cursor)
+	}
+}
+
+//printMoreHint - tells how to see the next page of a listing, if there is one
+func printMoreHint(flags listFlags, body interface{ getNextCursor() string }) {
+	if body.getNextCursor() != "" {
+		fmt.Printf("There are more items, use -page=%d to see them\n", *flags.page+1)
+	}
+}
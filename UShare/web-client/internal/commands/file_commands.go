@@ -3,7 +3,9 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
@@ -28,14 +30,59 @@ type FileInfo struct {
 	Name       string    `json:"file_name"`
 	OwnerID    uint      `json:"owner_id"`
 	UploadedAt time.Time `json:"uploaded_at"`
+	Version    uint      `json:"version"`
+	Checksum   string    `json:"checksum"`
 }
 
 //FilesInfoResponse - response, containing information about multiple files
 type FilesInfoResponse struct {
+	ListPage
 	Status    int        `json:"status"`
 	FilesInfo []FileInfo `json:"files"`
 }
 
+//TrashedFileInfo - contains the information about a file in the trash, when and by whom it was deleted
+type TrashedFileInfo struct {
+	FileInfo
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy uint      `json:"deleted_by"`
+}
+
+//TrashResponse - response, containing the files in the trash of a group
+type TrashResponse struct {
+	Status uint              `json:"status"`
+	Files  []TrashedFileInfo `json:"files"`
+}
+
+//RestoredFileResponse - response, containing the id and the version of a file, restored from the trash
+type RestoredFileResponse struct {
+	FileID  uint `json:"file_id"`
+	Version uint `json:"version"`
+}
+
+//FileTagsRequest - request for replacing the tags of a file
+type FileTagsRequest struct {
+	FileRequest
+	Tags []string `json:"tags"`
+}
+
+//FoundFileInfo - contains the information about a found file, its group and its tags
+type FoundFileInfo struct {
+	FileInfo
+	GroupName string   `json:"group_name"`
+	Uploader  *string  `json:"uploader,omitempty"`
+	Size      int64    `json:"size"`
+	Tags      []string `json:"tags"`
+}
+
+//FileSearchResponse - response, containing a page of the found files
+type FileSearchResponse struct {
+	ListPage
+	Status uint            `json:"status"`
+	Files  []FoundFileInfo `json:"files"`
+}
+
 //UploadFile - command for uploading a file to the server
 func UploadFile(hostURL, token string) {
 	uploadFileCommand := flag.NewFlagSet("upload-file", flag.ExitOnError)
@@ -51,8 +98,7 @@ func UploadFile(hostURL, token string) {
 	successBody := FileUploadResponse{}
 
 	restClient := restclient.NewRestClientImpl(token)
-	url := fmt.Sprintf("%s%s?group_name=%s", hostURL, endpoints.UploadFileAPIEndpoint, *groupName)
-	err := restClient.UploadFile(url, *filePath, &successBody)
+	err := restClient.UploadFile(hostURL, *groupName, *filePath, &successBody)
 
 	if err != nil {
 		fmt.Printf("Problem with the file upload request. %s\n", err.Error())
@@ -115,34 +161,245 @@ func DeleteFile(hostURL, token string) {
 		return
 	}
 
-	fmt.Println("File was successfully deleted")
+	fmt.Println("File was successfully moved to the trash")
+}
+
+//ShowTrash - command for fetching information about the files in the trash of a group
+func ShowTrash(hostURL, token string) {
+	showTrashCommand := flag.NewFlagSet("show-trash", flag.ExitOnError)
+	groupName := showTrashCommand.String("grp", "", "Name of the group")
+
+	showTrashCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		showTrashCommand.PrintDefaults()
+		return
+	}
+
+	successBody := TrashResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := fmt.Sprintf("%s%s?group_name=%s", hostURL, endpoints.TrashAPIEndpoint, url.QueryEscape(*groupName))
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the trash. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Files))
+	for _, fileInfo := range successBody.Files {
+		tableRows = append(tableRows, table.Row{fileInfo.ID, fileInfo.Name, fileInfo.Version, fileInfo.Size, fileInfo.DeletedAt, fileInfo.DeletedBy})
+	}
+	PrintTable(table.Row{"ID", "Name", "Version", "Size", "DeletedAt", "DeletedBy"}, tableRows)
+}
+
+//RestoreFile - command for restoring a file from the trash of a group
+func RestoreFile(hostURL, token string) {
+	restoreFileCommand := flag.NewFlagSet("restore-file", flag.ExitOnError)
+	fileID := restoreFileCommand.Int("fileid", -1, "Id of the deleted file")
+	groupName := restoreFileCommand.String("grp", "", "Name of the group")
+
+	restoreFileCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		restoreFileCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := FileRequest{
+		FileID: uint(*fileID),
+	}
+	reqBody.GroupName = *groupName
+
+	successBody := RestoredFileResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RestoreTrashedFileAPIEndpoint
+	err := restClient.Post(url, &reqBody, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the file restoration request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("File %d was successfully restored as version %d\n", successBody.FileID, successBody.Version)
 }
 
 //ShowAllFilesInGroup - command for fetching information about all files uploaded for a specific group
 func ShowAllFilesInGroup(hostURL, token string) {
 	getAllFilesCommand := flag.NewFlagSet("show-all-files", flag.ExitOnError)
 	groupName := getAllFilesCommand.String("grp", "", "Name of the group")
+	listing := addListFlags(getAllFilesCommand, "name, size, uploaded_at or id")
 
 	getAllFilesCommand.Parse(os.Args[2:])
 
-	if *groupName == "" {
-		flag.PrintDefaults()
+	if *groupName == "" || !listing.valid() {
+		getAllFilesCommand.PrintDefaults()
 		os.Exit(1)
 	}
 
+	params := url.Values{}
+	params.Set("group_name", *groupName)
+
 	successBody := FilesInfoResponse{}
 	restClient := restclient.NewRestClientImpl(token)
-	url := fmt.Sprintf("%s%s?group_name=%s", hostURL, endpoints.GetAllFilesAPIEndpoint, *groupName)
-	err := restClient.Get(url, &successBody)
+	err := getListPage(restClient, hostURL+endpoints.GetAllFilesAPIEndpoint, params, listing, &successBody)
 
 	if err != nil {
 		fmt.Printf("Problem with the retrieval of group files. %s\n", err.Error())
 		return
 	}
 
-	tableRows := make([]table.Row, len(successBody.FilesInfo))
+	tableRows := make([]table.Row, 0, len(successBody.FilesInfo))
 	for _, fileInfo := range successBody.FilesInfo {
-		tableRows = append(tableRows, table.Row{fileInfo.ID, fileInfo.Name, fileInfo.UploadedAt, fileInfo.OwnerID})
+		tableRows = append(tableRows, table.Row{fileInfo.ID, fileInfo.Name, fileInfo.Version, fileInfo.UploadedAt, fileInfo.OwnerID, fileInfo.Checksum})
+	}
+	PrintTable(table.Row{"ID", "Name", "Version", "UploadedAt", "OwnerID", "Checksum"}, tableRows)
+	printMoreHint(listing, &successBody)
+}
+
+//ShowFileVersions - command for fetching information about all versions of a file
+func ShowFileVersions(hostURL, token string) {
+	getVersionsCommand := flag.NewFlagSet("show-file-versions", flag.ExitOnError)
+	fileID := getVersionsCommand.Int("fileid", -1, "Id of any version of the file")
+	groupName := getVersionsCommand.String("grp", "", "Name of the group")
+
+	getVersionsCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		getVersionsCommand.PrintDefaults()
+		return
+	}
+
+	successBody := FilesInfoResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := fmt.Sprintf("%s%s?group_name=%s&file_id=%d", hostURL, endpoints.GetFileVersionsAPIEndpoint, *groupName, *fileID)
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of file versions. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.FilesInfo))
+	for _, fileInfo := range successBody.FilesInfo {
+		tableRows = append(tableRows, table.Row{fileInfo.ID, fileInfo.Name, fileInfo.Version, fileInfo.UploadedAt, fileInfo.OwnerID, fileInfo.Checksum})
+	}
+	PrintTable(table.Row{"ID", "Name", "Version", "UploadedAt", "OwnerID", "Checksum"}, tableRows)
+}
+
+//RestoreFileVersion - command for making an older version of a file the latest one
+func RestoreFileVersion(hostURL, token string) {
+	restoreVersionCommand := flag.NewFlagSet("restore-file-version", flag.ExitOnError)
+	fileID := restoreVersionCommand.Int("fileid", -1, "Id of the version to be restored")
+	groupName := restoreVersionCommand.String("grp", "", "Name of the group")
+
+	restoreVersionCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		restoreVersionCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := FileRequest{
+		FileID: uint(*fileID),
+	}
+	reqBody.GroupName = *groupName
+
+	successBody := FileUploadResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RestoreFileVersionAPIEndpoint
+	err := restClient.Post(url, &reqBody, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the file version restoration request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("File version was successfully restored.\n The id of the latest version is %d\n", successBody.FileID)
+}
+
+//TagFile - command for replacing the tags of a file
+func TagFile(hostURL, token string) {
+	tagFileCommand := flag.NewFlagSet("tag-file", flag.ExitOnError)
+	fileID := tagFileCommand.Int("fileid", -1, "File id")
+	groupName := tagFileCommand.String("grp", "", "Name of the group")
+	tags := tagFileCommand.String("tags", "", "Comma separated tags, empty to remove all tags")
+
+	tagFileCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		tagFileCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := FileTagsRequest{Tags: make([]string, 0)}
+	reqBody.FileID = uint(*fileID)
+	reqBody.GroupName = *groupName
+	if *tags != "" {
+		reqBody.Tags = strings.Split(*tags, ",")
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.FileTagsAPIEndpoint
+	err := restClient.Put(url, &reqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the file tagging request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("The tags of the file were successfully changed")
+}
+
+//SearchFiles - command for searching the latest versions of the files in all groups of the user
+func SearchFiles(hostURL, token string) {
+	searchFilesCommand := flag.NewFlagSet("search-files", flag.ExitOnError)
+	groupName := searchFilesCommand.String("grp", "", "Name of the group")
+	uploader := searchFilesCommand.String("uploader", "", "Username of the uploader of the latest version")
+	since := searchFilesCommand.String("since", "", "Start of the upload time range (RFC3339)")
+	until := searchFilesCommand.String("until", "", "End of the upload time range (RFC3339)")
+	minSize := searchFilesCommand.Int64("min-size", -1, "Minimum size of the file (in bytes)")
+	maxSize := searchFilesCommand.Int64("max-size", -1, "Maximum size of the file (in bytes)")
+	tags := searchFilesCommand.String("tags", "", "Comma separated tags, which the file should have")
+	listing := addListFlags(searchFilesCommand, "name, size, uploaded_at, uploader or group")
+
+	searchFilesCommand.Parse(os.Args[2:])
+
+	if !listing.valid() {
+		searchFilesCommand.PrintDefaults()
+		return
+	}
+
+	params := url.Values{}
+	for key, value := range map[string]string{"group_name": *groupName, "uploader": *uploader, "since": *since, "until": *until, "tags": *tags} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	if *minSize != -1 {
+		params.Set("min_size", fmt.Sprint(*minSize))
+	}
+	if *maxSize != -1 {
+		params.Set("max_size", fmt.Sprint(*maxSize))
+	}
+
+	successBody := FileSearchResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	err := getListPage(restClient, hostURL+endpoints.SearchFilesAPIEndpoint, params, listing, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the file search. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Files))
+	for _, file := range successBody.Files {
+		uploader := "-"
+		if file.Uploader != nil {
+			uploader = *file.Uploader
+		}
+		tableRows = append(tableRows, table.Row{file.ID, file.GroupName, file.Name, file.Version, file.Size, file.UploadedAt.Format(time.RFC3339), uploader, strings.Join(file.Tags, ",")})
 	}
-	PrintTable(table.Row{"ID", "Name", "UploadedAt", "OwnerID"}, tableRows)
+	PrintTable(table.Row{"ID", "Group", "Name", "Version", "Size(bytes)", "UploadedAt", "Uploader", "Tags"}, tableRows)
+	printMoreHint(listing, &successBody)
 }
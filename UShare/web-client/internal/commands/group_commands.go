@@ -3,7 +3,9 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
@@ -16,6 +18,12 @@ type MembershipRequest struct {
 	Username string `json:"username"`
 }
 
+//MemberRoleRequest - request for changing the role of a member in a group
+type MemberRoleRequest struct {
+	MembershipRequest
+	Role string `json:"role"`
+}
+
 //GroupInfo - contains all information about a group
 type GroupInfo struct {
 	ID      uint
@@ -25,10 +33,69 @@ type GroupInfo struct {
 
 //GroupsInfoResponse - response, containing information about multiple groups
 type GroupsInfoResponse struct {
+	ListPage
 	Status     uint        `json:"status"`
 	GroupsInfo []GroupInfo `json:"groups"`
 }
 
+//DeletedGroupInfo - contains the information about a group in the trash and when it was deleted
+type DeletedGroupInfo struct {
+	GroupInfo
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+//DeletedGroupsResponse - response, containing the groups in the trash of the user
+type DeletedGroupsResponse struct {
+	Status uint               `json:"status"`
+	Groups []DeletedGroupInfo `json:"groups"`
+}
+
+//GroupDetailsResponse - response, containing information about a group and the usage of its quota
+type GroupDetailsResponse struct {
+	Status uint `json:"status"`
+	GroupInfo
+	Quota       int64  `json:"quota"`
+	MaxFileSize int64  `json:"max_file_size"`
+	Usage       int64  `json:"usage"`
+	Role        string `json:"role"`
+	//RequireTwoFactor - the files of the group are accessible only to members with two-factor authentication
+	RequireTwoFactor bool `json:"require_two_factor"`
+}
+
+//GroupTwoFactorRequest - request for changing if the members of a group need two-factor authentication
+type GroupTwoFactorRequest struct {
+	GroupPayload
+	Required bool `json:"required"`
+}
+
+//AuditEventInfo - contains the details about an audit event of a group
+type AuditEventInfo struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Action     string    `json:"action"`
+	Actor      *string   `json:"actor,omitempty"`
+	TargetUser *string   `json:"target_user,omitempty"`
+	FileID     *uint     `json:"file_id,omitempty"`
+	Details    string    `json:"details"`
+	IP         string    `json:"ip"`
+}
+
+//AuditEventsResponse - response, containing a page of the audit events of a group
+type AuditEventsResponse struct {
+	Status   uint             `json:"status"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	More     bool             `json:"more"`
+	Events   []AuditEventInfo `json:"events"`
+}
+
+//GroupQuotaRequest - request for changing the limits of a group, the missing limits stay unchanged
+type GroupQuotaRequest struct {
+	GroupPayload
+	Quota       *int64 `json:"quota,omitempty"`
+	MaxFileSize *int64 `json:"max_file_size,omitempty"`
+}
+
 //CreateGroup - command for creation of group
 func CreateGroup(hostURL, token string) {
 	createGroupCommand := flag.NewFlagSet("create-group", flag.ExitOnError)
@@ -80,14 +147,65 @@ func DeleteGroup(hostURL, token string) {
 		return
 	}
 
-	fmt.Printf("Group %s was succesfully deleted", *groupName)
+	fmt.Printf("Group %s was succesfully moved to the trash\n", *groupName)
+}
+
+//ShowDeletedGroups - command for showing the groups in the trash of the user
+func ShowDeletedGroups(hostURL, token string) {
+	successBody := DeletedGroupsResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.DeletedGroupsAPIEndpoint
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the deleted groups. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Groups))
+	for _, groupInfo := range successBody.Groups {
+		deletedAt := "-"
+		if groupInfo.DeletedAt != nil {
+			deletedAt = groupInfo.DeletedAt.Format(time.RFC3339)
+		}
+		tableRows = append(tableRows, table.Row{groupInfo.ID, groupInfo.Name, deletedAt})
+	}
+	PrintTable(table.Row{"ID", "Name", "DeletedAt"}, tableRows)
+}
+
+//RestoreGroup - command for restoring a group from the trash, together with its files and members
+func RestoreGroup(hostURL, token string) {
+	restoreGroupCommand := flag.NewFlagSet("restore-group", flag.ExitOnError)
+	groupName := restoreGroupCommand.String("grp", "", "Name of the group to be restored")
+	restoreGroupCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		restoreGroupCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := GroupPayload{
+		GroupName: *groupName,
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RestoreGroupAPIEndpoint
+	err := restClient.Post(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the group restoration request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Group %s was succesfully restored\n", *groupName)
 }
 
-//AddMember - command for creation of membership
+//AddMember - command for inviting a user to a group, the user becomes a member after accepting the invitation
 func AddMember(hostURL, token string) {
 	addMemberCommand := flag.NewFlagSet("add-member", flag.ExitOnError)
-	username := addMemberCommand.String("usr", "", "Name of the user to be added to the group")
+	username := addMemberCommand.String("usr", "", "Name of the user to be invited to the group")
 	groupName := addMemberCommand.String("grp", "", "Name of the group")
+	expiresIn := addMemberCommand.Uint("expires-in", 0, "Number of hours, after which the invitation expires (0 - never)")
 	addMemberCommand.Parse(os.Args[2:])
 
 	if *groupName == "" || *username == "" {
@@ -95,21 +213,22 @@ func AddMember(hostURL, token string) {
 		return
 	}
 
-	rqBody := MembershipRequest{
-		Username: *username,
+	rqBody := InvitationRequest{
+		ExpiresIn: *expiresIn,
 	}
+	rqBody.Username = *username
 	rqBody.GroupName = *groupName
 
 	restClient := restclient.NewRestClientImpl(token)
-	url := hostURL + endpoints.AddMemberAPIEndpoint
+	url := hostURL + endpoints.InviteMemberAPIEndpoint
 	err := restClient.Post(url, &rqBody, nil)
 
 	if err != nil {
-		fmt.Printf("Problem with the group creation request. %s\n", err.Error())
+		fmt.Printf("Problem with the invitation request. %s\n", err.Error())
 		return
 	}
 
-	fmt.Printf("User %s was successfully added to group %s\n", *username, *groupName)
+	fmt.Printf("User %s was successfully invited to group %s\n", *username, *groupName)
 }
 
 //RemoveMember - command for revocation of membership
@@ -142,22 +261,235 @@ func RemoveMember(hostURL, token string) {
 	fmt.Printf("User %s was successfully removed from group %s\n", *username, *groupName)
 }
 
+//ChangeMemberRole - command for changing the role of a member in a group
+func ChangeMemberRole(hostURL, token string) {
+	changeRoleCommand := flag.NewFlagSet("change-role", flag.ExitOnError)
+
+	username := changeRoleCommand.String("usr", "", "Name of the member")
+	groupName := changeRoleCommand.String("grp", "", "Name of the group")
+	role := changeRoleCommand.String("role", "", "New role of the member - admin, contributor or viewer")
+
+	changeRoleCommand.Parse(os.Args[2:])
+	if *groupName == "" || *username == "" || *role == "" {
+		changeRoleCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := MemberRoleRequest{Role: *role}
+	rqBody.Username = *username
+	rqBody.GroupName = *groupName
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.ChangeMemberRoleAPIEndpoint
+	err := restClient.Put(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the role change request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("User %s is now %s in group %s\n", *username, *role, *groupName)
+}
+
+//TransferOwnership - command for transferring the ownership of a group to another member
+func TransferOwnership(hostURL, token string) {
+	transferOwnershipCommand := flag.NewFlagSet("transfer-ownership", flag.ExitOnError)
+
+	username := transferOwnershipCommand.String("usr", "", "Name of the member, who becomes the owner")
+	groupName := transferOwnershipCommand.String("grp", "", "Name of the group")
+
+	transferOwnershipCommand.Parse(os.Args[2:])
+	if *groupName == "" || *username == "" {
+		transferOwnershipCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := MembershipRequest{
+		Username: *username,
+	}
+	rqBody.GroupName = *groupName
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.TransferOwnershipAPIEndpoint
+	err := restClient.Put(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the ownership transfer request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("User %s is now the owner of group %s\n", *username, *groupName)
+}
+
 //ShowAllGroups - command for showing information about all groups
 func ShowAllGroups(hostURL, token string) {
-	successBody := GroupsInfoResponse{}
+	getAllGroups := flag.NewFlagSet("show-all-groups", flag.ExitOnError)
+	listing := addListFlags(getAllGroups, "name, created_at or id")
 
+	getAllGroups.Parse(os.Args[2:])
+
+	if !listing.valid() {
+		getAllGroups.PrintDefaults()
+		return
+	}
+
+	successBody := GroupsInfoResponse{}
 	restClient := restclient.NewRestClientImpl(token)
-	url := hostURL + endpoints.GetAllGroupsAPIEndpoint
-	err := restClient.Get(url, &successBody)
+	err := getListPage(restClient, hostURL+endpoints.GetAllGroupsAPIEndpoint, url.Values{}, listing, &successBody)
 
 	if err != nil {
-		fmt.Printf("Problem with the group creation request. %s\n", err.Error())
+		fmt.Printf("Problem with the retrieval of the groups. %s\n", err.Error())
 		return
 	}
 
-	tableRows := make([]table.Row, len(successBody.GroupsInfo))
+	tableRows := make([]table.Row, 0, len(successBody.GroupsInfo))
 	for _, groupInfo := range successBody.GroupsInfo {
 		tableRows = append(tableRows, table.Row{groupInfo.ID, groupInfo.Name, groupInfo.OwnerID})
 	}
 	PrintTable(table.Row{"ID", "Name", "OwnerID"}, tableRows)
+	printMoreHint(listing, &successBody)
+}
+
+//ShowGroupInfo - command for showing information about a group and the usage of its quota
+func ShowGroupInfo(hostURL, token string) {
+	groupInfoCommand := flag.NewFlagSet("show-group-info", flag.ExitOnError)
+	groupName := groupInfoCommand.String("grp", "", "Name of the group")
+	groupInfoCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		groupInfoCommand.PrintDefaults()
+		return
+	}
+
+	successBody := GroupDetailsResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := fmt.Sprintf("%s%s?group_name=%s", hostURL, endpoints.GroupInfoAPIEndpoint, *groupName)
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of group info. %s\n", err.Error())
+		return
+	}
+
+	tableRows := []table.Row{{successBody.ID, successBody.Name, successBody.OwnerID, successBody.Role, successBody.Usage, successBody.Quota, successBody.MaxFileSize, successBody.RequireTwoFactor}}
+	PrintTable(table.Row{"ID", "Name", "OwnerID", "YourRole", "Usage(bytes)", "Quota(bytes)", "MaxFileSize(bytes)", "Requires2FA"}, tableRows)
+}
+
+//UpdateGroupQuota - command for changing the quota and the maximum file size of a group
+func UpdateGroupQuota(hostURL, token string) {
+	updateQuotaCommand := flag.NewFlagSet("update-group-quota", flag.ExitOnError)
+	groupName := updateQuotaCommand.String("grp", "", "Name of the group")
+	quota := updateQuotaCommand.Int64("quota", -1, "Total size of the group files (in bytes)")
+	maxFileSize := updateQuotaCommand.Int64("max-file-size", -1, "Maximum size of a single file (in bytes)")
+	updateQuotaCommand.Parse(os.Args[2:])
+
+	if *groupName == "" || (*quota == -1 && *maxFileSize == -1) {
+		updateQuotaCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := GroupQuotaRequest{}
+	rqBody.GroupName = *groupName
+	if *quota != -1 {
+		rqBody.Quota = quota
+	}
+	if *maxFileSize != -1 {
+		rqBody.MaxFileSize = maxFileSize
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.GroupQuotaAPIEndpoint
+	err := restClient.Put(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the quota change request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("The limits of group %s were successfully changed\n", *groupName)
+}
+
+//RequireTwoFactor - command for changing if the members of a group need two-factor authentication to access its files
+func RequireTwoFactor(hostURL, token string) {
+	requireCommand := flag.NewFlagSet("require-2fa", flag.ExitOnError)
+	groupName := requireCommand.String("grp", "", "Name of the group")
+	required := requireCommand.Bool("required", true, "Whether two-factor authentication is required")
+	requireCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		requireCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := GroupTwoFactorRequest{Required: *required}
+	rqBody.GroupName = *groupName
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.GroupTwoFactorAPIEndpoint
+	err := restClient.Put(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the two-factor requirement request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("The two-factor requirement of group %s was successfully changed\n", *groupName)
+}
+
+//ShowAuditEvents - command for showing the audit events of a group, newest first
+func ShowAuditEvents(hostURL, token string) {
+	showAuditCommand := flag.NewFlagSet("show-audit", flag.ExitOnError)
+	groupName := showAuditCommand.String("grp", "", "Name of the group")
+	action := showAuditCommand.String("action", "", "Action of the events, for example file.downloaded")
+	actor := showAuditCommand.String("actor", "", "Username of the user, who made the changes")
+	since := showAuditCommand.String("since", "", "Start of the time range (RFC3339)")
+	until := showAuditCommand.String("until", "", "End of the time range (RFC3339)")
+	page := showAuditCommand.Int("page", 1, "Number of the page")
+	pageSize := showAuditCommand.Int("page-size", 50, "Number of events per page")
+	showAuditCommand.Parse(os.Args[2:])
+
+	if *groupName == "" || *page < 1 || *pageSize < 1 {
+		showAuditCommand.PrintDefaults()
+		return
+	}
+
+	query := url.Values{}
+	query.Set("group_name", *groupName)
+	query.Set("page", fmt.Sprint(*page))
+	query.Set("page_size", fmt.Sprint(*pageSize))
+	for key, value := range map[string]string{"action": *action, "actor": *actor, "since": *since, "until": *until} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	successBody := AuditEventsResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	requestURL := fmt.Sprintf("%s%s?%s", hostURL, endpoints.GroupAuditAPIEndpoint, query.Encode())
+	err := restClient.Get(requestURL, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the audit log. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Events))
+	for _, event := range successBody.Events {
+		actor, targetUser, fileID := "-", "-", "-"
+		if event.Actor != nil {
+			actor = *event.Actor
+		}
+		if event.TargetUser != nil {
+			targetUser = *event.TargetUser
+		}
+		if event.FileID != nil {
+			fileID = fmt.Sprint(*event.FileID)
+		}
+		tableRows = append(tableRows, table.Row{event.ID, event.CreatedAt.Format(time.RFC3339), event.Action, actor, targetUser, fileID, event.Details, event.IP})
+	}
+	PrintTable(table.Row{"ID", "Time", "Action", "Actor", "TargetUser", "FileID", "Details", "IP"}, tableRows)
+
+	if successBody.More {
+		fmt.Printf("There are more events, use -page=%d to see them\n", successBody.Page+1)
+	}
 }
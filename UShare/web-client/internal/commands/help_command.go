@@ -6,18 +6,50 @@ import "github.com/jedib0t/go-pretty/v6/table"
 func Help() {
 	commands := []table.Row{
 		{"register", "register a new user", "-usr=<username>(Required) and -pass=<password>(Required)"},
-		{"login", "login as a registered user", "-usr=<username>(Required) and -pass=<password>(Required)"},
-		{"show-all-users", "show all existing users", "None"},
+		{"login", "login as a registered user", "-usr=<username>(Required), -pass=<password>(Required) and -code=<2fa_code>"},
+		{"login-sso", "login through the identity provider of the server, a user is created on the first login", "-port=<local_port> and -code=<2fa_code>"},
+		{"logout", "logout, the saved tokens can no longer be used", "None"},
+		{"change-password", "change your password, all sessions are revoked", "-old-pass=<password>(Required) and -new-pass=<password>(Required)"},
+		{"forgot-password", "request a password reset token", "-usr=<username>(Required)"},
+		{"reset-password", "set a new password with a reset token", "-token=<reset_token>(Required) and -new-pass=<password>(Required)"},
+		{"enable-2fa", "start the enrollment of two-factor authentication", "None"},
+		{"activate-2fa", "activate the two-factor authentication", "-code=<2fa_code>(Required)"},
+		{"disable-2fa", "disable the two-factor authentication", "-code=<2fa_code_or_recovery_code>(Required)"},
+		{"create-token", "create a personal access token for automated clients", "-name=<token_name>(Required), -scopes=<read,upload>, -grp=<group_name> and -expires-in=<hours>"},
+		{"show-tokens", "show your personal access tokens", "None"},
+		{"revoke-token", "revoke a personal access token", "-tokenid=<id_of_token>(Required)"},
+		{"show-all-users", "show all existing users", "-limit=<count>, -page=<page>, -sort=<field>, -desc and -q=<text>"},
 		{"create-group", "create a new group", "-grp=<group_name>(Required)"},
-		{"delete-group", "delete group", "-grp=<group_name>(Required)"},
-		{"show-all-groups", "show all existing groups", "None"},
-		{"add-member", "add a new member to a group", "-usr=<username>(Required) and -grp=<group_name>(Required)"},
+		{"delete-group", "move a group to your trash", "-grp=<group_name>(Required)"},
+		{"show-deleted-groups", "show the groups in your trash", "None"},
+		{"restore-group", "restore a group from your trash", "-grp=<group_name>(Required)"},
+		{"show-all-groups", "show all existing groups", "-limit=<count>, -page=<page>, -sort=<field>, -desc and -q=<text>"},
+		{"show-group-info", "show a group and the usage of its quota", "-grp=<group_name>(Required)"},
+		{"update-group-quota", "change the quota and the maximum file size of a group", "-grp=<group_name>(Required), -quota=<bytes> and/or -max-file-size=<bytes>"},
+		{"require-2fa", "require two-factor authentication for the files of a group", "-grp=<group_name>(Required) and -required=<true|false>"},
+		{"show-audit", "show the audit log of a group", "-grp=<group_name>(Required), -action=<action>, -actor=<username>, -since=<RFC3339_time>, -until=<RFC3339_time>, -page=<page> and -page-size=<size>"},
+		{"add-member", "invite a user to a group", "-usr=<username>(Required), -grp=<group_name>(Required) and -expires-in=<hours>"},
+		{"revoke-invite", "revoke a pending invitation", "-usr=<username>(Required) and -grp=<group_name>(Required)"},
+		{"invitations", "show your pending invitations", "None"},
+		{"accept-invite", "accept an invitation and join the group", "-grp=<group_name>(Required)"},
+		{"decline-invite", "decline an invitation", "-grp=<group_name>(Required)"},
 		{"remove-member", "revoke membership", "-usr=<username>(Required) and -grp=<group_name>(Required)"},
-		{"show-all-members", "show all members of a group", "-grp=<group_name>(Required)"},
+		{"show-all-members", "show all members of a group", "-grp=<group_name>(Required), -role=<role>, -limit=<count>, -page=<page>, -sort=<field>, -desc and -q=<text>"},
+		{"change-role", "change the role of a member", "-usr=<username>(Required), -grp=<group_name>(Required) and -role=<admin|contributor|viewer>(Required)"},
+		{"transfer-ownership", "make another member the owner of a group", "-usr=<username>(Required) and -grp=<group_name>(Required)"},
 		{"upload-file", "upload a file to a group", "-grp=<group_name>(Required) and -filepath=<path_to_file>(Required)"},
 		{"download-file", "download a file from a group", "-grp=<group_name>(Required), -fileid=<id_of_file>(Required) and -target=<output_file_path>(Required)"},
-		{"delete-file", "delete file from a group", "-grp=<group_name>(Required) and -fileid=<id_of_file>(Required)"},
-		{"show-all-files", "show all files from a group", "-grp=<group_name>(Required)"},
+		{"delete-file", "move a file to the trash of its group", "-grp=<group_name>(Required) and -fileid=<id_of_file>(Required)"},
+		{"show-trash", "show the files in the trash of a group", "-grp=<group_name>(Required)"},
+		{"restore-file", "restore a file from the trash of a group", "-grp=<group_name>(Required) and -fileid=<id_of_file>(Required)"},
+		{"show-all-files", "show the latest versions of all files from a group", "-grp=<group_name>(Required), -limit=<count>, -page=<page>, -sort=<field>, -desc and -q=<text>"},
+		{"show-file-versions", "show all versions of a file", "-grp=<group_name>(Required) and -fileid=<id_of_file>(Required)"},
+		{"restore-file-version", "make an older version of a file the latest one", "-grp=<group_name>(Required) and -fileid=<id_of_version>(Required)"},
+		{"tag-file", "replace the tags of a file", "-grp=<group_name>(Required), -fileid=<id_of_file>(Required) and -tags=<comma_separated_tags>"},
+		{"search-files", "search files in all of your groups", "-q=<words_of_name>, -grp=<group_name>, -uploader=<username>, -since=<RFC3339_time>, -until=<RFC3339_time>, -min-size=<bytes>, -max-size=<bytes>, -tags=<comma_separated_tags>, -limit=<count>, -page=<page>, -sort=<name|size|uploaded_at|uploader|group> and -desc"},
+		{"share-file", "create a public link to a file", "-grp=<group_name>(Required), -fileid=<id_of_file>(Required), -expires-in=<hours> and -max-uses=<count>"},
+		{"show-shares", "show the active public links to a file", "-grp=<group_name>(Required) and -fileid=<id_of_file>(Required)"},
+		{"revoke-share", "revoke a public link to a file", "-grp=<group_name>(Required) and -shareid=<id_of_link>(Required)"},
 		{"help", "show all available commands", "None"},
 	}
 
@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+//InvitationRequest - request for inviting a user to a group
+type InvitationRequest struct {
+	MembershipRequest
+	ExpiresIn uint `json:"expires_in_hours,omitempty"`
+}
+
+//InvitationInfo - contains all information about a pending invitation
+type InvitationInfo struct {
+	GroupName string     `json:"group_name"`
+	Inviter   string     `json:"inviter"`
+	InvitedAt time.Time  `json:"invited_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+//InvitationsResponse - response, containing the pending invitations of the user
+type InvitationsResponse struct {
+	Status      uint             `json:"status"`
+	Invitations []InvitationInfo `json:"invitations"`
+}
+
+//ShowInvitations - command for showing the pending invitations of the user
+func ShowInvitations(hostURL, token string) {
+	successBody := InvitationsResponse{}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.InvitationsAPIEndpoint
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the invitations. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Invitations))
+	for _, invitation := range successBody.Invitations {
+		expiresAt := "never"
+		if invitation.ExpiresAt != nil {
+			expiresAt = invitation.ExpiresAt.Format(time.RFC3339)
+		}
+		tableRows = append(tableRows, table.Row{invitation.GroupName, invitation.Inviter, invitation.InvitedAt.Format(time.RFC3339), expiresAt})
+	}
+	PrintTable(table.Row{"Group", "Inviter", "InvitedAt", "ExpiresAt"}, tableRows)
+}
+
+//AcceptInvitation - command for accepting an invitation, the user becomes a member of the group
+func AcceptInvitation(hostURL, token string) {
+	acceptInviteCommand := flag.NewFlagSet("accept-invite", flag.ExitOnError)
+	groupName := acceptInviteCommand.String("grp", "", "Name of the group")
+	acceptInviteCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		acceptInviteCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := GroupPayload{
+		GroupName: *groupName,
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.AcceptInvitationAPIEndpoint
+	err := restClient.Post(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with accepting the invitation. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("You are now a member of group %s\n", *groupName)
+}
+
+//DeclineInvitation - command for declining an invitation
+func DeclineInvitation(hostURL, token string) {
+	declineInviteCommand := flag.NewFlagSet("decline-invite", flag.ExitOnError)
+	groupName := declineInviteCommand.String("grp", "", "Name of the group")
+	declineInviteCommand.Parse(os.Args[2:])
+
+	if *groupName == "" {
+		declineInviteCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := GroupPayload{
+		GroupName: *groupName,
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.DeclineInvitationAPIEndpoint
+	err := restClient.Delete(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with declining the invitation. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("The invitation for group %s was declined\n", *groupName)
+}
+
+//RevokeInvitation - command for revocation of a pending invitation
+func RevokeInvitation(hostURL, token string) {
+	revokeInviteCommand := flag.NewFlagSet("revoke-invite", flag.ExitOnError)
+	username := revokeInviteCommand.String("usr", "", "Name of the invited user")
+	groupName := revokeInviteCommand.String("grp", "", "Name of the group")
+	revokeInviteCommand.Parse(os.Args[2:])
+
+	if *groupName == "" || *username == "" {
+		revokeInviteCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := MembershipRequest{
+		Username: *username,
+	}
+	rqBody.GroupName = *groupName
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RevokeInvitationAPIEndpoint
+	err := restClient.Delete(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the revocation of the invitation. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("The invitation of user %s for group %s was revoked\n", *username, *groupName)
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+//ShareLinkRequest - request for creating a public link to a file
+type ShareLinkRequest struct {
+	FileRequest
+	ExpiresIn uint `json:"expires_in_hours,omitempty"`
+	MaxUses   uint `json:"max_uses,omitempty"`
+}
+
+//ShareLinkResponse - response, containing the created public link to a file
+type ShareLinkResponse struct {
+	Status    int       `json:"status"`
+	ID        uint      `json:"share_id"`
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+//ShareLinkInfo - contains all information about an active public link
+type ShareLinkInfo struct {
+	ID        uint      `json:"share_id"`
+	CreatorID uint      `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Uses      uint      `json:"uses"`
+	MaxUses   uint      `json:"max_uses"`
+}
+
+//ShareLinksResponse - response, containing the active public links to a file
+type ShareLinksResponse struct {
+	Status int             `json:"status"`
+	Shares []ShareLinkInfo `json:"shares"`
+}
+
+//ShareLinkRevocationRequest - request for revoking a public link
+type ShareLinkRevocationRequest struct {
+	GroupPayload
+	ShareID uint `json:"share_id"`
+}
+
+//ShareFile - command for creating a public link to a file, which can be used without login
+func ShareFile(hostURL, token string) {
+	shareFileCommand := flag.NewFlagSet("share-file", flag.ExitOnError)
+	fileID := shareFileCommand.Int("fileid", -1, "File id")
+	groupName := shareFileCommand.String("grp", "", "Name of the group")
+	expiresIn := shareFileCommand.Uint("expires-in", 0, "Number of hours, after which the link expires (24 by default)")
+	maxUses := shareFileCommand.Uint("max-uses", 0, "Number of times the link can be used (unlimited by default)")
+
+	shareFileCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		shareFileCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := ShareLinkRequest{
+		ExpiresIn: *expiresIn,
+		MaxUses:   *maxUses,
+	}
+	reqBody.FileID = uint(*fileID)
+	reqBody.GroupName = *groupName
+
+	successBody := ShareLinkResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.ShareFileAPIEndpoint
+	err := restClient.Post(url, &reqBody, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the file sharing request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Share link with id %d was successfully created.\n Link: %s%s\n Expires at: %s\n",
+		successBody.ID, hostURL, successBody.Path, successBody.ExpiresAt.Format(time.RFC3339))
+}
+
+//ShowShareLinks - command for showing the active public links to a file
+func ShowShareLinks(hostURL, token string) {
+	showSharesCommand := flag.NewFlagSet("show-shares", flag.ExitOnError)
+	fileID := showSharesCommand.Int("fileid", -1, "File id")
+	groupName := showSharesCommand.String("grp", "", "Name of the group")
+
+	showSharesCommand.Parse(os.Args[2:])
+
+	if *fileID == -1 || *groupName == "" {
+		showSharesCommand.PrintDefaults()
+		return
+	}
+
+	successBody := ShareLinksResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := fmt.Sprintf("%s%s?group_name=%s&file_id=%d", hostURL, endpoints.ShareLinksAPIEndpoint, *groupName, *fileID)
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the share links. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Shares))
+	for _, share := range successBody.Shares {
+		maxUses := "unlimited"
+		if share.MaxUses != 0 {
+			maxUses = fmt.Sprint(share.MaxUses)
+		}
+		tableRows = append(tableRows, table.Row{share.ID, share.CreatorID, share.CreatedAt.Format(time.RFC3339), share.ExpiresAt.Format(time.RFC3339), share.Uses, maxUses})
+	}
+	PrintTable(table.Row{"ID", "CreatorID", "CreatedAt", "ExpiresAt", "Uses", "MaxUses"}, tableRows)
+}
+
+//RevokeShareLink - command for revoking a public link to a file
+func RevokeShareLink(hostURL, token string) {
+	revokeShareCommand := flag.NewFlagSet("revoke-share", flag.ExitOnError)
+	shareID := revokeShareCommand.Int("shareid", -1, "Id of the share link")
+	groupName := revokeShareCommand.String("grp", "", "Name of the group")
+
+	revokeShareCommand.Parse(os.Args[2:])
+
+	if *shareID == -1 || *groupName == "" {
+		revokeShareCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := ShareLinkRevocationRequest{
+		ShareID: uint(*shareID),
+	}
+	reqBody.GroupName = *groupName
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RevokeShareAPIEndpoint
+	err := restClient.Delete(url, &reqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the share link revocation request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Share link was successfully revoked")
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
+)
+
+//ssoLoginTimeout - the time, in which the user has to authenticate to the identity provider
+const ssoLoginTimeout = 10 * time.Minute
+
+//ExternalLoginStartRequest - request for starting a login through the identity provider
+type ExternalLoginStartRequest struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+//ExternalLoginStartResponse - response, containing the url, where the user authenticates to the identity provider
+type ExternalLoginStartResponse struct {
+	Status           int    `json:"status"`
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+//ExternalLoginRequest - request for completing a login through the identity provider
+type ExternalLoginRequest struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+//authorizationResult - the parameters, which the identity provider sent to the redirect uri
+type authorizationResult struct {
+	state string
+	code  string
+	err   string
+}
+
+//LoginSSO - command for login through the identity provider of the server (single sign-on)
+//the identity provider redirects the browser to a listener on the loopback interface, which receives the authorization code
+func LoginSSO(hostURL string) {
+	loginCommand := flag.NewFlagSet("login-sso", flag.ExitOnError)
+	port := loginCommand.Int("port", 0, "port of the local listener for the redirect from the identity provider (random by default)")
+	code := loginCommand.String("code", "", "code from the authenticator app or a recovery code, if two-factor authentication is enabled")
+
+	loginCommand.Parse(os.Args[2:])
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *port))
+	if err != nil {
+		fmt.Printf("Problem with starting the local listener. %s\n", err.Error())
+		return
+	}
+	defer listener.Close()
+
+	results := make(chan authorizationResult, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		select {
+		case results <- authorizationResult{state: query.Get("state"), code: query.Get("code"), err: query.Get("error")}:
+			fmt.Fprintln(w, "The authentication is complete. You can close this window")
+		default:
+			w.WriteHeader(http.StatusGone)
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	startBody := ExternalLoginStartResponse{}
+	rqBody := ExternalLoginStartRequest{
+		RedirectURI: fmt.Sprintf("http://%s/callback", listener.Addr().String()),
+	}
+
+	restClient := restclient.NewRestClientImpl("")
+	url := hostURL + endpoints.StartExternalLoginAPIEndpoint
+	if err = restClient.Post(url, &rqBody, &startBody); err != nil {
+		fmt.Printf("Problem with the login request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Please open the following url in your browser and authenticate:\n%s\n", startBody.AuthorizationURL)
+
+	var result authorizationResult
+	select {
+	case result = <-results:
+	case <-time.After(ssoLoginTimeout):
+		fmt.Println("The authentication took too long. Please login again")
+		return
+	}
+
+	if result.err != "" {
+		fmt.Printf("The identity provider rejected the login. %s\n", result.err)
+		return
+	}
+
+	successBody := LoginResponse{}
+	url = hostURL + endpoints.ExternalLoginAPIEndpoint
+	err = restClient.Post(url, &ExternalLoginRequest{State: result.state, Code: result.code}, &successBody)
+	if err != nil {
+		fmt.Printf("Problem with the login request. %s\n", err.Error())
+		return
+	}
+
+	completeLogin(hostURL, restClient, successBody, *code)
+}
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+//AccessTokenRequest - request for creating a personal access token
+type AccessTokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes,omitempty"`
+	GroupName string   `json:"group_name,omitempty"`
+	ExpiresIn uint     `json:"expires_in_hours,omitempty"`
+}
+
+//AccessTokenResponse - response, containing the created personal access token
+type AccessTokenResponse struct {
+	Status    int        `json:"status"`
+	ID        uint       `json:"token_id"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+//AccessTokenInfo - contains all information about a personal access token
+type AccessTokenInfo struct {
+	ID         uint       `json:"token_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	GroupName  *string    `json:"group_name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+//AccessTokensResponse - response, containing the personal access tokens of the user
+type AccessTokensResponse struct {
+	Status int               `json:"status"`
+	Tokens []AccessTokenInfo `json:"tokens"`
+}
+
+//AccessTokenRevocationRequest - request for revoking a personal access token
+type AccessTokenRevocationRequest struct {
+	TokenID uint `json:"token_id"`
+}
+
+//CreateAccessToken - command for creating a personal access token, which can be used instead of login
+func CreateAccessToken(hostURL, token string) {
+	createTokenCommand := flag.NewFlagSet("create-token", flag.ExitOnError)
+	name := createTokenCommand.String("name", "", "Name of the token")
+	scopes := createTokenCommand.String("scopes", "", "Comma separated scopes of the token - read and/or upload (full access by default)")
+	groupName := createTokenCommand.String("grp", "", "Name of the only group, which the token can access")
+	expiresIn := createTokenCommand.Uint("expires-in", 0, "Number of hours, after which the token expires (never by default)")
+
+	createTokenCommand.Parse(os.Args[2:])
+
+	if *name == "" {
+		createTokenCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := AccessTokenRequest{
+		Name:      *name,
+		GroupName: *groupName,
+		ExpiresIn: *expiresIn,
+	}
+	if *scopes != "" {
+		reqBody.Scopes = strings.Split(*scopes, ",")
+	}
+
+	successBody := AccessTokenResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.CreateAccessTokenAPIEndpoint
+	err := restClient.Post(url, &reqBody, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the access token creation request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Access token with id %d was successfully created. Store it now, it wont be shown again.\n Token: %s\n", successBody.ID, successBody.Token)
+	if successBody.ExpiresAt != nil {
+		fmt.Printf(" Expires at: %s\n", successBody.ExpiresAt.Format(time.RFC3339))
+	}
+}
+
+//ShowAccessTokens - command for showing the personal access tokens of the user
+func ShowAccessTokens(hostURL, token string) {
+	successBody := AccessTokensResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.AccessTokensAPIEndpoint
+	err := restClient.Get(url, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the retrieval of the access tokens. %s\n", err.Error())
+		return
+	}
+
+	tableRows := make([]table.Row, 0, len(successBody.Tokens))
+	for _, accessToken := range successBody.Tokens {
+		scopes, group, expiresAt, lastUsedAt := "full access", "all", "never", "never"
+		if len(accessToken.Scopes) != 0 {
+			scopes = strings.Join(accessToken.Scopes, ",")
+		}
+		if accessToken.GroupName != nil {
+			group = *accessToken.GroupName
+		}
+		if accessToken.ExpiresAt != nil {
+			expiresAt = accessToken.ExpiresAt.Format(time.RFC3339)
+		}
+		if accessToken.LastUsedAt != nil {
+			lastUsedAt = accessToken.LastUsedAt.Format(time.RFC3339)
+		}
+		tableRows = append(tableRows, table.Row{accessToken.ID, accessToken.Name, scopes, group, accessToken.CreatedAt.Format(time.RFC3339), expiresAt, lastUsedAt})
+	}
+	PrintTable(table.Row{"ID", "Name", "Scopes", "Group", "CreatedAt", "ExpiresAt", "LastUsedAt"}, tableRows)
+}
+
+//RevokeAccessToken - command for revoking a personal access token
+func RevokeAccessToken(hostURL, token string) {
+	revokeTokenCommand := flag.NewFlagSet("revoke-token", flag.ExitOnError)
+	tokenID := revokeTokenCommand.Int("tokenid", -1, "Id of the access token")
+
+	revokeTokenCommand.Parse(os.Args[2:])
+
+	if *tokenID == -1 {
+		revokeTokenCommand.PrintDefaults()
+		return
+	}
+
+	reqBody := AccessTokenRevocationRequest{
+		TokenID: uint(*tokenID),
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.RevokeAccessTokenAPIEndpoint
+	err := restClient.Delete(url, &reqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the access token revocation request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Access token was successfully revoked")
+}
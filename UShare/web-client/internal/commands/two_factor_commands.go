@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
+)
+
+//TwoFactorEnrollmentResponse - response, containing the secret of the authenticator app and the recovery codes
+type TwoFactorEnrollmentResponse struct {
+	Status          int      `json:"status"`
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+//TwoFactorCodeRequest - request, containing a code from the authenticator app or a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+//TwoFactorLoginRequest - request for completing a login with the second factor
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+//EnableTwoFactor - command for the enrollment of two-factor authentication
+//the enrollment has to be activated with a code from the authenticator app
+func EnableTwoFactor(hostURL, token string) {
+	successBody := TwoFactorEnrollmentResponse{}
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.EnrollTwoFactorAPIEndpoint
+	err := restClient.Post(url, nil, &successBody)
+
+	if err != nil {
+		fmt.Printf("Problem with the two-factor enrollment request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Secret: %s\n", successBody.Secret)
+	fmt.Printf("Add it to your authenticator app or use the following uri:\n%s\n", successBody.ProvisioningURI)
+	fmt.Printf("Recovery codes, each of them can be used once, if the authenticator app isnt available:\n%s\n", strings.Join(successBody.RecoveryCodes, "\n"))
+	fmt.Println("Run activate-2fa with a code from the authenticator app to enable the two-factor authentication")
+}
+
+//ActivateTwoFactor - command for the activation of the pending two-factor enrollment
+func ActivateTwoFactor(hostURL, token string) {
+	activateCommand := flag.NewFlagSet("activate-2fa", flag.ExitOnError)
+	code := activateCommand.String("code", "", "code from the authenticator app")
+
+	activateCommand.Parse(os.Args[2:])
+
+	if *code == "" {
+		activateCommand.PrintDefaults()
+		return
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.ActivateTwoFactorAPIEndpoint
+	err := restClient.Post(url, &TwoFactorCodeRequest{Code: *code}, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the two-factor activation request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Two-factor authentication successfully enabled")
+}
+
+//DisableTwoFactor - command for disabling the two-factor authentication
+func DisableTwoFactor(hostURL, token string) {
+	disableCommand := flag.NewFlagSet("disable-2fa", flag.ExitOnError)
+	code := disableCommand.String("code", "", "code from the authenticator app or a recovery code")
+
+	disableCommand.Parse(os.Args[2:])
+
+	if *code == "" {
+		disableCommand.PrintDefaults()
+		return
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.DisableTwoFactorAPIEndpoint
+	err := restClient.Delete(url, &TwoFactorCodeRequest{Code: *code}, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the two-factor deactivation request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Two-factor authentication successfully disabled")
+}
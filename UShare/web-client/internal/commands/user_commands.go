@@ -3,17 +3,23 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/credentials"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/restclient"
 	"github.com/jedib0t/go-pretty/v6/table"
 )
 
-//LoginResponse - response, containing the jw token
+//LoginResponse - response, containing the jw token and the refresh token, used for its renewal
+//if the user has two-factor authentication, it contains only a challenge, which is exchanged for the tokens with a code
 type LoginResponse struct {
-	Status int    `json:"status"`
-	Token  string `json:"token"`
+	Status            int    `json:"status"`
+	Token             string `json:"token"`
+	RefreshToken      string `json:"refresh_token"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	Challenge         string `json:"challenge"`
 }
 
 //CredentialsPayload - information used for the login and registration of user
@@ -22,6 +28,23 @@ type CredentialsPayload struct {
 	Password string `json:"password"`
 }
 
+//PasswordChangePayload - information used for the change of the password of the logged in user
+type PasswordChangePayload struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+//PasswordResetRequestPayload - information used for requesting a password reset token
+type PasswordResetRequestPayload struct {
+	Username string `json:"username"`
+}
+
+//PasswordResetPayload - information used for setting a new password with a reset token
+type PasswordResetPayload struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
 //UserInfo - contains information about a user
 type UserInfo struct {
 	ID       uint   `json:"id"`
@@ -30,6 +53,7 @@ type UserInfo struct {
 
 //UsersInfoResponse - response containing information about multiple users
 type UsersInfoResponse struct {
+	ListPage
 	Status    uint       `json:"status"`
 	UsersInfo []UserInfo `json:"users"`
 }
@@ -71,6 +95,7 @@ func Login(hostURL string) {
 
 	username := loginCommand.String("usr", "", "username")
 	password := loginCommand.String("pass", "", "password")
+	code := loginCommand.String("code", "", "code from the authenticator app or a recovery code, if two-factor authentication is enabled")
 
 	loginCommand.Parse(os.Args[2:])
 
@@ -95,55 +120,220 @@ func Login(hostURL string) {
 		return
 	}
 
+	completeLogin(hostURL, restClient, successBody, *code)
+}
+
+//completeLogin - provides the second factor, if the user has two-factor authentication, and saves the issued tokens
+func completeLogin(hostURL string, restClient *restclient.RestClientImpl, successBody LoginResponse, code string) {
+	if successBody.TwoFactorRequired {
+		if code == "" {
+			fmt.Println("Two-factor authentication is enabled. Please login again with the -code flag")
+			return
+		}
+
+		rqBody := TwoFactorLoginRequest{
+			Challenge: successBody.Challenge,
+			Code:      code,
+		}
+		url := hostURL + endpoints.LoginTwoFactorAPIEndpoint
+		if err := restClient.Post(url, &rqBody, &successBody); err != nil {
+			fmt.Printf("Problem with the two-factor login request. %s\n", err.Error())
+			return
+		}
+	}
+
+	err := credentials.Save(credentials.Credentials{
+		HostURL:      hostURL,
+		Token:        successBody.Token,
+		RefreshToken: successBody.RefreshToken,
+	})
+	if err != nil {
+		fmt.Printf("Problem with saving the credentials. %s\n", err.Error())
+		fmt.Printf("Please set the env variable 'JWT' with the following value:\n%s\n", successBody.Token)
+		return
+	}
+
 	fmt.Println("Login is successful")
-	fmt.Printf("Please set the env variable 'JWT' with the following value:\n%s\n", successBody.Token)
+}
+
+//Logout - command for logout of user, the tokens of the user can no longer be used
+func Logout(hostURL, token string) {
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.LogoutAPIEndpoint
+	err := restClient.Post(url, nil, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the logout request. %s\n", err.Error())
+		return
+	}
+
+	if err = credentials.Remove(); err != nil {
+		fmt.Printf("Problem with removing the credentials. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Logout is successful")
+}
+
+//ChangePassword - command for changing the password of the logged in user
+//all sessions of the user are revoked, so he has to login again
+func ChangePassword(hostURL, token string) {
+	changePasswordCommand := flag.NewFlagSet("change-password", flag.ExitOnError)
+
+	oldPassword := changePasswordCommand.String("old-pass", "", "current password")
+	newPassword := changePasswordCommand.String("new-pass", "", "new password")
+
+	changePasswordCommand.Parse(os.Args[2:])
+
+	if *oldPassword == "" || *newPassword == "" {
+		changePasswordCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := PasswordChangePayload{
+		OldPassword: *oldPassword,
+		NewPassword: *newPassword,
+	}
+
+	restClient := restclient.NewRestClientImpl(token)
+	url := hostURL + endpoints.ChangePasswordAPIEndpoint
+	err := restClient.Put(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the password change request. %s\n", err.Error())
+		return
+	}
+
+	if err = credentials.Remove(); err != nil {
+		fmt.Printf("Problem with removing the credentials. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Password successfully changed. Please login again")
+}
+
+//RequestPasswordReset - command for requesting a token for the reset of a forgotten password
+func RequestPasswordReset(hostURL string) {
+	requestResetCommand := flag.NewFlagSet("forgot-password", flag.ExitOnError)
+
+	username := requestResetCommand.String("usr", "", "username")
+
+	requestResetCommand.Parse(os.Args[2:])
+
+	if *username == "" {
+		requestResetCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := PasswordResetRequestPayload{
+		Username: *username,
+	}
+
+	restClient := restclient.NewRestClientImpl("")
+	url := hostURL + endpoints.RequestPasswordResetAPIEndpoint
+	err := restClient.Post(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the password reset request. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("If the user exists, a password reset token was sent to him")
+}
+
+//ResetPassword - command for setting a new password with a password reset token
+func ResetPassword(hostURL string) {
+	resetPasswordCommand := flag.NewFlagSet("reset-password", flag.ExitOnError)
+
+	resetToken := resetPasswordCommand.String("token", "", "password reset token")
+	newPassword := resetPasswordCommand.String("new-pass", "", "new password")
+
+	resetPasswordCommand.Parse(os.Args[2:])
+
+	if *resetToken == "" || *newPassword == "" {
+		resetPasswordCommand.PrintDefaults()
+		return
+	}
+
+	rqBody := PasswordResetPayload{
+		Token:       *resetToken,
+		NewPassword: *newPassword,
+	}
+
+	restClient := restclient.NewRestClientImpl("")
+	url := hostURL + endpoints.ResetPasswordAPIEndpoint
+	err := restClient.Post(url, &rqBody, nil)
+
+	if err != nil {
+		fmt.Printf("Problem with the password reset. %s\n", err.Error())
+		return
+	}
+
+	fmt.Println("Password successfully reset. Please login again")
 }
 
 //ShowAllUsers - command for showing information about all users
 func ShowAllUsers(hostURL string, token string) {
-	successBody := UsersInfoResponse{}
+	getAllUsers := flag.NewFlagSet("show-all-users", flag.ExitOnError)
+	listing := addListFlags(getAllUsers, "username, created_at or id")
+
+	getAllUsers.Parse(os.Args[2:])
 
+	if !listing.valid() {
+		getAllUsers.PrintDefaults()
+		return
+	}
+
+	successBody := UsersInfoResponse{}
 	restClient := restclient.NewRestClientImpl(token)
-	url := hostURL + endpoints.GetAllUsersAPIEndpoint
-	err := restClient.Get(url, &successBody)
+	err := getListPage(restClient, hostURL+endpoints.GetAllUsersAPIEndpoint, url.Values{}, listing, &successBody)
 
 	if err != nil {
-		fmt.Printf("Problem with the group creation request. %s\n", err.Error())
+		fmt.Printf("Problem with the retrieval of the users. %s\n", err.Error())
 		return
 	}
 
-	tableRows := make([]table.Row, len(successBody.UsersInfo))
+	tableRows := make([]table.Row, 0, len(successBody.UsersInfo))
 	for _, userInfo := range successBody.UsersInfo {
 		tableRows = append(tableRows, table.Row{userInfo.ID, userInfo.Username})
 	}
 	PrintTable(table.Row{"ID", "Username"}, tableRows)
+	printMoreHint(listing, &successBody)
 }
 
 //ShowAllMembers - command for showing information about all members of a group
 func ShowAllMembers(hostURL, token string) {
 	getAllMembers := flag.NewFlagSet("show-all-members", flag.ExitOnError)
 	groupName := getAllMembers.String("grp", "", "Name of the group")
+	role := getAllMembers.String("role", "", "Role of the members (owner, admin, contributor or viewer)")
+	listing := addListFlags(getAllMembers, "username, joined_at or id")
 
 	getAllMembers.Parse(os.Args[2:])
 
-	if *groupName == "" {
+	if *groupName == "" || !listing.valid() {
 		getAllMembers.PrintDefaults()
 		os.Exit(1)
 	}
 
+	params := url.Values{}
+	params.Set("group_name", *groupName)
+	if *role != "" {
+		params.Set("role", *role)
+	}
+
 	successBody := UsersInfoResponse{}
 	restClient := restclient.NewRestClientImpl(token)
-	url := fmt.Sprintf("%s%s?group_name=%s", hostURL, endpoints.GetAllMembersAPIEndpoint, *groupName)
-	err := restClient.Get(url, &successBody)
+	err := getListPage(restClient, hostURL+endpoints.GetAllMembersAPIEndpoint, params, listing, &successBody)
 
 	if err != nil {
-		fmt.Printf("Problem with the group creation request. %s\n", err.Error())
+		fmt.Printf("Problem with the retrieval of the group members. %s\n", err.Error())
 		return
 	}
 
-	tableRows := make([]table.Row, len(successBody.UsersInfo))
+	tableRows := make([]table.Row, 0, len(successBody.UsersInfo))
 	for _, userInfo := range successBody.UsersInfo {
 		tableRows = append(tableRows, table.Row{userInfo.ID, userInfo.Username})
 	}
 	PrintTable(table.Row{"ID", "Username"}, tableRows)
+	printMoreHint(listing, &successBody)
 }
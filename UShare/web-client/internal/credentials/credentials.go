@@ -0,0 +1,79 @@
+package credentials
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	//credentialsDir - directory in the home of the user, which contains the credentials file
+	credentialsDir = ".ushare"
+	//credentialsFile - file, containing the tokens of the logged in user
+	credentialsFile = "credentials"
+)
+
+//Credentials - tokens of the logged in user and the server, which issued them
+type Credentials struct {
+	HostURL      string `json:"host_url"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+//Load - reads the credentials of the logged in user
+func Load() (Credentials, error) {
+	credentials := Credentials{}
+
+	path, err := getPath()
+	if err != nil {
+		return credentials, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return credentials, err
+	}
+
+	err = json.Unmarshal(content, &credentials)
+	return credentials, err
+}
+
+//Save - stores the credentials of the logged in user, only he can read them
+func Save(credentials Credentials) error {
+	path, err := getPath()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+//Remove - deletes the stored credentials
+func Remove() error {
+	path, err := getPath()
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func getPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, credentialsDir, credentialsFile), nil
+}
@@ -9,24 +9,102 @@ const (
 	protectedAPIPath = apiVersionPath + "/protected"
 	//LoginAPIEndpoint - api endpoint for user login
 	LoginAPIEndpoint = publicAPIPath + "/user/login"
+	//LoginTwoFactorAPIEndpoint - api endpoint for completing the login of user with two-factor authentication
+	LoginTwoFactorAPIEndpoint = publicAPIPath + "/user/login/2fa"
+	//StartExternalLoginAPIEndpoint - api endpoint for starting a login through the identity provider
+	StartExternalLoginAPIEndpoint = publicAPIPath + "/user/oidc/authorization"
+	//ExternalLoginAPIEndpoint - api endpoint for completing a login through the identity provider
+	ExternalLoginAPIEndpoint = publicAPIPath + "/user/oidc/login"
+	//RefreshTokenAPIEndpoint - api endpoint for the renewal of the token of the logged in user
+	RefreshTokenAPIEndpoint = publicAPIPath + "/user/token/refresh"
+	//LogoutAPIEndpoint - api endpoint for user logout
+	LogoutAPIEndpoint = protectedAPIPath + "/user/logout"
+	//ChangePasswordAPIEndpoint - api endpoint for changing the password of the logged in user
+	ChangePasswordAPIEndpoint = protectedAPIPath + "/user/password"
+	//RequestPasswordResetAPIEndpoint - api endpoint for requesting a token for the reset of a forgotten password
+	RequestPasswordResetAPIEndpoint = publicAPIPath + "/user/password/reset/request"
+	//ResetPasswordAPIEndpoint - api endpoint for setting a new password with a reset token
+	ResetPasswordAPIEndpoint = publicAPIPath + "/user/password/reset"
+	//EnrollTwoFactorAPIEndpoint - api endpoint for the enrollment of two-factor authentication
+	EnrollTwoFactorAPIEndpoint = protectedAPIPath + "/user/2fa/enrollment"
+	//ActivateTwoFactorAPIEndpoint - api endpoint for the activation of the pending two-factor enrollment
+	ActivateTwoFactorAPIEndpoint = protectedAPIPath + "/user/2fa/activation"
+	//DisableTwoFactorAPIEndpoint - api endpoint for disabling the two-factor authentication
+	DisableTwoFactorAPIEndpoint = protectedAPIPath + "/user/2fa"
+	//CreateAccessTokenAPIEndpoint - api endpoint for creating a personal access token
+	CreateAccessTokenAPIEndpoint = protectedAPIPath + "/user/token"
+	//AccessTokensAPIEndpoint - api endpoint for fetching the personal access tokens of the user
+	AccessTokensAPIEndpoint = protectedAPIPath + "/user/tokens"
+	//RevokeAccessTokenAPIEndpoint - api endpoint for revoking a personal access token
+	RevokeAccessTokenAPIEndpoint = protectedAPIPath + "/user/token/revocation"
 	//RegisterAPIEndpoint - api endpoint for user registration
 	RegisterAPIEndpoint = publicAPIPath + "/user/registration"
 	//CreateGroupAPIEndpoint - api endpoint for group creation
 	CreateGroupAPIEndpoint = protectedAPIPath + "/group/creation"
 	//DeleteGroupAPIEndpoint - api endpoint for group deletion
 	DeleteGroupAPIEndpoint = protectedAPIPath + "/group/deletion"
-	//AddMemberAPIEndpoint - api endpoint for adding an user to a group
-	AddMemberAPIEndpoint = protectedAPIPath + "/group/invitation"
+	//DeletedGroupsAPIEndpoint - api endpoint for fetching the groups in the trash of the user
+	DeletedGroupsAPIEndpoint = protectedAPIPath + "/groups/trash"
+	//RestoreGroupAPIEndpoint - api endpoint for restoring a group from the trash
+	RestoreGroupAPIEndpoint = protectedAPIPath + "/group/restoration"
+	//ChangeMemberRoleAPIEndpoint - api endpoint for changing the role of a member in a group
+	ChangeMemberRoleAPIEndpoint = protectedAPIPath + "/group/member/role"
+	//TransferOwnershipAPIEndpoint - api endpoint for transferring the ownership of a group to another member
+	TransferOwnershipAPIEndpoint = protectedAPIPath + "/group/ownership"
+	//GroupInfoAPIEndpoint - api endpoint for fetching information about a group and the usage of its quota
+	GroupInfoAPIEndpoint = protectedAPIPath + "/group/info"
+	//GroupQuotaAPIEndpoint - api endpoint for changing the quota and the maximum file size of a group
+	GroupQuotaAPIEndpoint = protectedAPIPath + "/group/quota"
+	//GroupTwoFactorAPIEndpoint - api endpoint for changing if the members of a group need two-factor authentication
+	GroupTwoFactorAPIEndpoint = protectedAPIPath + "/group/2fa"
+	//GroupAuditAPIEndpoint - api endpoint for fetching the audit events of a group
+	GroupAuditAPIEndpoint = protectedAPIPath + "/group/audit"
+	//InviteMemberAPIEndpoint - api endpoint for inviting an user to a group
+	InviteMemberAPIEndpoint = protectedAPIPath + "/group/invitation"
+	//RevokeInvitationAPIEndpoint - api endpoint for revoking a pending invitation
+	RevokeInvitationAPIEndpoint = protectedAPIPath + "/group/invitation/revocation"
+	//InvitationsAPIEndpoint - api endpoint for fetching the pending invitations of the user
+	InvitationsAPIEndpoint = protectedAPIPath + "/invitations"
+	//AcceptInvitationAPIEndpoint - api endpoint for accepting an invitation
+	AcceptInvitationAPIEndpoint = protectedAPIPath + "/invitation/acceptance"
+	//DeclineInvitationAPIEndpoint - api endpoint for declining an invitation
+	DeclineInvitationAPIEndpoint = protectedAPIPath + "/invitation/rejection"
 	//RemoveMemberAPIEndpoint - api endpoint for removing an user from a group
 	RemoveMemberAPIEndpoint = protectedAPIPath + "/group/membership/revocation"
 	//UploadFileAPIEndpoint - api endpoint for uploading a file for a specific group
 	UploadFileAPIEndpoint = protectedAPIPath + "/group/file/upload"
+	//StartUploadAPIEndpoint - api endpoint for starting a chunked upload of a file for a specific group
+	StartUploadAPIEndpoint = protectedAPIPath + "/group/file/upload/session"
+	//UploadChunkAPIEndpoint - api endpoint for uploading a chunk of a file
+	UploadChunkAPIEndpoint = protectedAPIPath + "/group/file/upload/chunk"
+	//UploadStatusAPIEndpoint - api endpoint for fetching the ranges of a file, which the server already has
+	UploadStatusAPIEndpoint = protectedAPIPath + "/group/file/upload/status"
+	//CompleteUploadAPIEndpoint - api endpoint for finalizing a chunked upload
+	CompleteUploadAPIEndpoint = protectedAPIPath + "/group/file/upload/completion"
 	//DownloadFileAPIEndpoint - api endpoint for downloading a file from a specific group
 	DownloadFileAPIEndpoint = protectedAPIPath + "/group/file/download"
 	//DeleteFileAPIEndpoint - api endpoint for deleting file, given a group
 	DeleteFileAPIEndpoint = protectedAPIPath + "/group/file/deletion"
+	//TrashAPIEndpoint - api endpoint for fetching the files in the trash of a group
+	TrashAPIEndpoint = protectedAPIPath + "/group/trash"
+	//RestoreTrashedFileAPIEndpoint - api endpoint for restoring a file from the trash of a group
+	RestoreTrashedFileAPIEndpoint = protectedAPIPath + "/group/trash/restoration"
 	//GetAllFilesAPIEndpoint - api endpoint for fetching all files, uploaded for a specific group
 	GetAllFilesAPIEndpoint = protectedAPIPath + "/group/files"
+	//GetFileVersionsAPIEndpoint - api endpoint for fetching all versions of a file
+	GetFileVersionsAPIEndpoint = protectedAPIPath + "/group/file/versions"
+	//RestoreFileVersionAPIEndpoint - api endpoint for making an older version of a file the latest one
+	RestoreFileVersionAPIEndpoint = protectedAPIPath + "/group/file/version/restoration"
+	//FileTagsAPIEndpoint - api endpoint for replacing the tags of a file
+	FileTagsAPIEndpoint = protectedAPIPath + "/group/file/tags"
+	//SearchFilesAPIEndpoint - api endpoint for searching files in all groups of the user
+	SearchFilesAPIEndpoint = protectedAPIPath + "/files/search"
+	//ShareFileAPIEndpoint - api endpoint for creating a public link to a file
+	ShareFileAPIEndpoint = protectedAPIPath + "/group/file/share"
+	//ShareLinksAPIEndpoint - api endpoint for fetching the active public links to a file
+	ShareLinksAPIEndpoint = protectedAPIPath + "/group/file/shares"
+	//RevokeShareAPIEndpoint - api endpoint for revoking a public link to a file
+	RevokeShareAPIEndpoint = protectedAPIPath + "/group/file/share/revocation"
 	//GetAllGroupsAPIEndpoint - api endpoint for fetching all existing groups
 	GetAllGroupsAPIEndpoint = protectedAPIPath + "/groups"
 	//GetAllUsersAPIEndpoint - api endpoint for fetching all users
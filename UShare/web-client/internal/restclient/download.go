@@ -0,0 +1,115 @@
+package restclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+//downloadStateSuffix - suffix of the file, next to the target one, which keeps the etag of an unfinished download
+const downloadStateSuffix = ".download"
+
+//DownloadFile - similar to GET, but it requires the target location where the file will be downloaded
+//if a previous download to the same target was interrupted, only the missing part of the file is downloaded
+func (i *RestClientImpl) DownloadFile(url string, targetPath string) error {
+	req := i.client.R().SetDoNotParseResponse(true)
+	if i.jwtToken != "" {
+		req.SetAuthToken(i.jwtToken)
+	}
+
+	statePath := targetPath + downloadStateSuffix
+	offset := getPartialDownloadSize(targetPath, statePath)
+	if offset > 0 {
+		etag, _ := ioutil.ReadFile(statePath)
+		req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset)).
+			SetHeader("If-Range", string(etag))
+	}
+
+	resp, err := req.Get(url)
+	if err != nil {
+		return err
+	}
+	body := resp.RawBody()
+	defer body.Close()
+
+	var flags int
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	case http.StatusPartialContent:
+		flags = os.O_WRONLY | os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			//the previous download was interrupted right after the last byte
+			os.Remove(statePath)
+			return nil
+		}
+		fallthrough
+	default:
+		errorBody := errorResponse{}
+		json.NewDecoder(body).Decode(&errorBody)
+		return fmt.Errorf("Problem with the Download file request. Reason: %s", errorBody.ErrorMsg)
+	}
+
+	if err = ioutil.WriteFile(statePath, []byte(resp.Header().Get("ETag")), 0644); err != nil {
+		return err
+	}
+
+	target, err := os.OpenFile(targetPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(target, body)
+	target.Close()
+	if err != nil {
+		return fmt.Errorf("Download interrupted, run the command again to resume it. Reason: %s", err)
+	}
+
+	os.Remove(statePath)
+	return verifyChecksum(targetPath, resp.Header().Get("ETag"))
+}
+
+//verifyChecksum - checks if the downloaded file matches the sha256 checksum, which the server sends as a strong etag
+//weak etags are sent for files without checksum, so they arent verified
+func verifyChecksum(targetPath, etag string) error {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return nil
+	}
+
+	file, err := os.Open(targetPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return err
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != strings.Trim(etag, "\"") {
+		return fmt.Errorf("The downloaded file is corrupted. Expected checksum %s, but got %s", strings.Trim(etag, "\""), checksum)
+	}
+	return nil
+}
+
+//getPartialDownloadSize - returns the size of the already downloaded part of the target file
+//if there isnt an unfinished download to the target, 0 is returned
+func getPartialDownloadSize(targetPath, statePath string) int64 {
+	if _, err := os.Stat(statePath); err != nil {
+		return 0
+	}
+
+	info, err := os.Stat(targetPath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/credentials"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -12,14 +14,16 @@ type RestClient interface {
 	Post(url string, rqBody, successBody interface{}) error
 	Get(url string, successBody, errorBody interface{}) error
 	Delete(url string, rqBody, successBody interface{}) error
-	UploadFile(url string, filePath string, successBody interface{}) error
-	DownloadFile(url string, targetPath string, reqBody interface{}) error
+	Put(url string, rqBody, successBody interface{}) error
+	UploadFile(hostURL string, groupName string, filePath string, successBody interface{}) error
+	DownloadFile(url string, targetPath string) error
 }
 
 //RestClientImpl - implementation of RestClient
 type RestClientImpl struct {
-	client   *resty.Client
-	jwtToken string
+	client      *resty.Client
+	jwtToken    string
+	credentials *credentials.Credentials
 }
 
 type errorResponse struct {
@@ -27,15 +31,35 @@ type errorResponse struct {
 	ErrorMsg string `json:"message"`
 }
 
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type refreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 //NewRestClientImpl - used for creation of instances of RestClientImpl
+//if the token was issued during the login of the client, it is renewed transparently, when it expires
 func NewRestClientImpl(jwtToken string) *RestClientImpl {
-	return &RestClientImpl{
+	restClient := &RestClientImpl{
 		client:   resty.New(),
 		jwtToken: jwtToken,
 	}
+
+	if creds, err := credentials.Load(); err == nil && jwtToken != "" && creds.Token == jwtToken && creds.RefreshToken != "" {
+		restClient.credentials = &creds
+		restClient.client.
+			SetRetryCount(1).
+			AddRetryCondition(restClient.renewRejectedToken).
+			OnBeforeRequest(restClient.setCurrentToken)
+	}
+
+	return restClient
 }
 
-//Post - creation of resources
+//Post - creation of resources and actions, which dont return resources
 func (i *RestClientImpl) Post(url string, rqBody, successBody interface{}) error {
 	errorBody := errorResponse{}
 	resp, err := i.basicRequest(successBody, &errorBody).
@@ -46,7 +70,7 @@ func (i *RestClientImpl) Post(url string, rqBody, successBody interface{}) error
 		return err
 	}
 
-	if resp.StatusCode() != http.StatusCreated {
+	if resp.StatusCode() != http.StatusCreated && resp.StatusCode() != http.StatusOK {
 		return fmt.Errorf("Problem with Post request. Reason: %s", errorBody.ErrorMsg)
 	}
 	return nil
@@ -85,69 +109,82 @@ func (i *RestClientImpl) Delete(url string, reqBody, successBody interface{}) er
 	return nil
 }
 
-//UploadFile - similar to POST, but it uses form-data to include the payload (file)
-func (i *RestClientImpl) UploadFile(url string, filePath string, successBody interface{}) error {
+//Put - modification of resources
+func (i *RestClientImpl) Put(url string, reqBody, successBody interface{}) error {
 	errorBody := errorResponse{}
-	req := i.client.R().
-		SetFile("file", filePath).
-		SetError(&errorBody)
-
-	if i.jwtToken != "" {
-		req.SetAuthToken(i.jwtToken)
-	}
-
-	if successBody != nil {
-		req.SetResult(successBody)
-	}
+	resp, err := i.basicRequest(successBody, &errorBody).
+		SetBody(reqBody).
+		Put(url)
 
-	resp, err := req.Post(url)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode() != http.StatusCreated {
-		return fmt.Errorf("Problem with the Upload file request. Reason: %s", errorBody.ErrorMsg)
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("Problem with Put request. Reason: %s", errorBody.ErrorMsg)
 	}
-
 	return nil
 }
 
-//DownloadFile - similar to GET, but it requires the target location where the file will be downloaded
-func (i *RestClientImpl) DownloadFile(url string, targetPath string) error {
-	errorBody := errorResponse{}
+func (i *RestClientImpl) basicRequest(successBody, errorBody interface{}) *resty.Request {
 	req := i.client.R().
-		SetOutput(targetPath).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
 		SetError(&errorBody)
 
 	if i.jwtToken != "" {
 		req.SetAuthToken(i.jwtToken)
 	}
 
-	resp, err := req.Get(url)
-	if err != nil {
-		return err
+	if successBody != nil {
+		req.SetResult(&successBody)
 	}
 
-	if resp.StatusCode() != http.StatusOK {
-		return fmt.Errorf("Problem with the Download file request. Reason: %s", errorBody.ErrorMsg)
-	}
+	return req
+}
 
+//setCurrentToken - sets the latest token, because it might be renewed after the creation of the request
+func (i *RestClientImpl) setCurrentToken(_ *resty.Client, req *resty.Request) error {
+	req.SetAuthToken(i.jwtToken)
 	return nil
 }
 
-func (i *RestClientImpl) basicRequest(successBody, errorBody interface{}) *resty.Request {
-	req := i.client.R().
+//renewRejectedToken - retry condition, which renews the token, when the server rejects it
+//the request is retried only if the renewal succeeds
+func (i *RestClientImpl) renewRejectedToken(resp *resty.Response, err error) bool {
+	if err != nil || resp == nil || resp.StatusCode() != http.StatusUnauthorized {
+		return false
+	}
+
+	if body := resp.RawBody(); body != nil {
+		body.Close()
+	}
+	return i.refreshToken() == nil
+}
+
+//refreshToken - exchanges the refresh token for a new pair of tokens and stores them
+func (i *RestClientImpl) refreshToken() error {
+	successBody := refreshTokenResponse{}
+	errorBody := errorResponse{}
+
+	resp, err := resty.New().R().
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Accept", "application/json").
-		SetError(&errorBody)
+		SetBody(refreshTokenRequest{RefreshToken: i.credentials.RefreshToken}).
+		SetResult(&successBody).
+		SetError(&errorBody).
+		Post(i.credentials.HostURL + endpoints.RefreshTokenAPIEndpoint)
 
-	if i.jwtToken != "" {
-		req.SetAuthToken(i.jwtToken)
+	if err != nil {
+		return err
 	}
 
-	if successBody != nil {
-		req.SetResult(&successBody)
+	if resp.StatusCode() != http.StatusCreated {
+		return fmt.Errorf("Problem with the renewal of the token. Reason: %s", errorBody.ErrorMsg)
 	}
 
-	return req
+	i.jwtToken = successBody.Token
+	i.credentials.Token = successBody.Token
+	i.credentials.RefreshToken = successBody.RefreshToken
+	return credentials.Save(*i.credentials)
 }
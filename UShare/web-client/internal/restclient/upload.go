@@ -0,0 +1,219 @@
+package restclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-client/internal/endpoints"
+)
+
+const (
+	//uploadStateSuffix - suffix of the file, next to the uploaded one, which keeps the state of an unfinished upload
+	uploadStateSuffix = ".upload"
+	//maxChunkAttempts - how many times a chunk upload is tried before giving up
+	maxChunkAttempts = 5
+	//defaultChunkSize - chunk size, used when the server doesnt suggest one
+	defaultChunkSize int64 = 4 << 20
+)
+
+//uploadState - information about an unfinished upload, used to resume it
+type uploadState struct {
+	UploadID  string    `json:"upload_id"`
+	GroupName string    `json:"group_name"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	ChunkSize int64     `json:"chunk_size"`
+}
+
+type uploadSessionRequest struct {
+	GroupName string `json:"group_name"`
+	FileName  string `json:"file_name"`
+	Size      int64  `json:"size"`
+}
+
+type uploadSessionResponse struct {
+	UploadID  string `json:"upload_id"`
+	ChunkSize int64  `json:"chunk_size"`
+}
+
+type uploadRequest struct {
+	GroupName string `json:"group_name"`
+	UploadID  string `json:"upload_id"`
+}
+
+type byteRange struct {
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+}
+
+type uploadStatusResponse struct {
+	Size   int64       `json:"size"`
+	Ranges []byteRange `json:"ranges"`
+}
+
+//UploadFile - uploads a file in chunks, given a group
+//if a previous upload of the same file was interrupted, only the missing chunks are uploaded
+func (i *RestClientImpl) UploadFile(hostURL string, groupName string, filePath string, successBody interface{}) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	statePath := filePath + uploadStateSuffix
+	state, ranges := i.resumeUpload(hostURL, statePath, groupName, fileInfo)
+	if state == nil {
+		if state, err = i.startUpload(hostURL, groupName, fileInfo); err != nil {
+			return err
+		}
+		saveUploadState(statePath, state)
+	}
+
+	buffer := make([]byte, state.ChunkSize)
+	for offset := int64(0); offset < state.Size; offset += state.ChunkSize {
+		size := state.ChunkSize
+		if offset+size > state.Size {
+			size = state.Size - offset
+		}
+
+		if isRangeUploaded(ranges, offset, size) {
+			continue
+		}
+
+		if _, err = file.ReadAt(buffer[:size], offset); err != nil && err != io.EOF {
+			return err
+		}
+
+		number := offset / state.ChunkSize
+		if err = i.uploadChunk(hostURL, state, number, offset, buffer[:size]); err != nil {
+			return fmt.Errorf("Upload interrupted at chunk %d, run the command again to resume it. Reason: %s", number, err)
+		}
+	}
+
+	rqBody := uploadRequest{
+		GroupName: state.GroupName,
+		UploadID:  state.UploadID,
+	}
+	if err = i.Post(hostURL+endpoints.CompleteUploadAPIEndpoint, &rqBody, successBody); err != nil {
+		return err
+	}
+
+	os.Remove(statePath)
+	return nil
+}
+
+//resumeUpload - returns the state of an unfinished upload of the file and the ranges, which the server already has
+//if the upload cannot be resumed, nil state is returned
+func (i *RestClientImpl) resumeUpload(hostURL, statePath, groupName string, fileInfo os.FileInfo) (*uploadState, []byteRange) {
+	content, err := ioutil.ReadFile(statePath)
+	if err != nil {
+		return nil, nil
+	}
+
+	state := uploadState{}
+	if err = json.Unmarshal(content, &state); err != nil {
+		return nil, nil
+	}
+
+	if state.GroupName != groupName || state.Size != fileInfo.Size() ||
+		!state.ModTime.Equal(fileInfo.ModTime()) || state.ChunkSize <= 0 {
+		return nil, nil
+	}
+
+	successBody := uploadStatusResponse{}
+	statusURL := fmt.Sprintf("%s%s?group_name=%s&upload_id=%s", hostURL, endpoints.UploadStatusAPIEndpoint,
+		url.QueryEscape(groupName), url.QueryEscape(state.UploadID))
+	if err = i.Get(statusURL, &successBody); err != nil {
+		return nil, nil
+	}
+
+	return &state, successBody.Ranges
+}
+
+func (i *RestClientImpl) startUpload(hostURL, groupName string, fileInfo os.FileInfo) (*uploadState, error) {
+	rqBody := uploadSessionRequest{
+		GroupName: groupName,
+		FileName:  fileInfo.Name(),
+		Size:      fileInfo.Size(),
+	}
+
+	successBody := uploadSessionResponse{}
+	if err := i.Post(hostURL+endpoints.StartUploadAPIEndpoint, &rqBody, &successBody); err != nil {
+		return nil, err
+	} else if successBody.ChunkSize <= 0 {
+		successBody.ChunkSize = defaultChunkSize
+	}
+
+	return &uploadState{
+		UploadID:  successBody.UploadID,
+		GroupName: groupName,
+		Size:      fileInfo.Size(),
+		ModTime:   fileInfo.ModTime(),
+		ChunkSize: successBody.ChunkSize,
+	}, nil
+}
+
+//uploadChunk - uploads a single chunk, retrying with a backoff when the request fails
+func (i *RestClientImpl) uploadChunk(hostURL string, state *uploadState, number, offset int64, data []byte) error {
+	chunkURL := fmt.Sprintf("%s%s?group_name=%s&upload_id=%s&chunk=%d&offset=%d", hostURL, endpoints.UploadChunkAPIEndpoint,
+		url.QueryEscape(state.GroupName), url.QueryEscape(state.UploadID), number, offset)
+
+	var err error
+	for attempt := 1; attempt <= maxChunkAttempts; attempt++ {
+		if err = i.putChunk(chunkURL, data); err == nil {
+			return nil
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	return err
+}
+
+func (i *RestClientImpl) putChunk(url string, data []byte) error {
+	errorBody := errorResponse{}
+	req := i.client.R().
+		SetHeader("Content-Type", "application/octet-stream").
+		SetBody(data).
+		SetError(&errorBody)
+
+	if i.jwtToken != "" {
+		req.SetAuthToken(i.jwtToken)
+	}
+
+	resp, err := req.Put(url)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("Problem with the chunk upload request. Reason: %s", errorBody.ErrorMsg)
+	}
+	return nil
+}
+
+func saveUploadState(statePath string, state *uploadState) {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(statePath, content, 0644)
+}
+
+func isRangeUploaded(ranges []byteRange, offset, size int64) bool {
+	for _, r := range ranges {
+		if r.Offset <= offset && offset+size <= r.Offset+r.Size {
+			return true
+		}
+	}
+	return false
+}
@@ -3,7 +3,9 @@ package common
 import (
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
 	"github.com/gin-gonic/gin"
@@ -24,8 +26,34 @@ func GetIDFromContext(c *gin.Context) (uint, error) {
 	return userID, nil
 }
 
+//GetSessionIDFromContext - extracts the id of the session of the access token from the context
+func GetSessionIDFromContext(c *gin.Context) (uint, error) {
+	id, ok := c.Get("sessionID")
+	if !ok {
+		log.Println("Problem retieval of sessionID from context.")
+		return 0, myerr.NewServerError("Cannot retrieve the session id")
+	}
+
+	var sessionID uint
+	if sessionID, ok = id.(uint); !ok {
+		return 0, myerr.NewClientError("Invalid session ID")
+	}
+	return sessionID, nil
+}
+
+//IsAccessTokenRequest - checks if the request was authorized with a personal access token instead of a login session
+func IsAccessTokenRequest(c *gin.Context) bool {
+	_, ok := c.Get("accessTokenID")
+	return ok
+}
+
 //SendErrorResponse - generic method for sending error response to the user
+//the locked errors tell the client, after how many seconds it can retry, in the Retry-After header
 func SendErrorResponse(c *gin.Context, err error) {
+	if lockedErr, ok := err.(*myerr.LockedError); ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+	}
+
 	errorCode, errorMsg := getErrorResponseArguments(err)
 	c.JSON(errorCode, ErrorResponse{
 		ErrorCode: errorCode,
@@ -41,6 +69,9 @@ func getErrorResponseArguments(err error) (errorCode int, errorMsg string) {
 	case *myerr.ItemNotFoundError:
 		errorCode = http.StatusNotFound
 		errorMsg = err.Error()
+	case *myerr.LockedError:
+		errorCode = http.StatusTooManyRequests
+		errorMsg = err.Error()
 	default:
 		log.Println(err)
 		errorCode = http.StatusInternalServerError
@@ -6,6 +6,67 @@ type RequestWithCredentials struct {
 	Password string `json:"password"`
 }
 
+//RefreshTokenPayload - request payload, containing the refresh token of a session
+type RefreshTokenPayload struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+//TwoFactorLoginPayload - request payload, used to complete the login of a user with two-factor authentication
+//the code is either a totp code or a recovery code
+type TwoFactorLoginPayload struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+//ExternalLoginStartPayload - request payload, used to start a login through the external identity provider
+//the identity provider redirects the user to the redirect uri with the state and the authorization code
+type ExternalLoginStartPayload struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+//ExternalLoginPayload - request payload, used to complete a login through the external identity provider
+type ExternalLoginPayload struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+//TwoFactorCodePayload - request payload, containing a totp code or a recovery code
+type TwoFactorCodePayload struct {
+	Code string `json:"code"`
+}
+
+//PasswordChangePayload - request payload, used to change the password of the logged in user
+type PasswordChangePayload struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+//PasswordResetRequestPayload - request payload, used to request a reset token for a forgotten password
+type PasswordResetRequestPayload struct {
+	Username string `json:"username"`
+}
+
+//PasswordResetPayload - request payload, used to set a new password with a reset token
+type PasswordResetPayload struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+//AccessTokenPayload - request payload, used to create a personal access token
+//no scopes mean full access, the group name restricts the token to a single group
+//the token expires after the given number of hours, zero means that it never expires
+type AccessTokenPayload struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	GroupName string   `json:"group_name"`
+	ExpiresIn uint     `json:"expires_in_hours"`
+}
+
+//AccessTokenRevocationPayload - request payload, containing the id of a personal access token
+type AccessTokenRevocationPayload struct {
+	TokenID uint `json:"token_id"`
+}
+
 //GroupPayload - request payload, containing the group name
 type GroupPayload struct {
 	GroupName string `json:"group_name"`
@@ -17,8 +78,68 @@ type GroupMembershipPayload struct {
 	Username string `json:"username"`
 }
 
+//InvitationPayload - request payload, used to invite a user to a group
+//the invitation expires after the given number of hours, zero means that it never expires
+type InvitationPayload struct {
+	GroupMembershipPayload
+	ExpiresIn uint `json:"expires_in_hours"`
+}
+
+//GroupRolePayload - request payload, containing the group name, the username of a member and his new role
+type GroupRolePayload struct {
+	GroupMembershipPayload
+	Role string `json:"role"`
+}
+
 //FileRequestPayload - request payload, containing the group name and the file id, owned by that group
 type FileRequestPayload struct {
 	GroupPayload
 	FileID uint `json:"file_id"`
 }
+
+//FileTagsPayload - request payload, used to replace the tags of a file, an empty list removes all of them
+type FileTagsPayload struct {
+	FileRequestPayload
+	Tags []string `json:"tags"`
+}
+
+//ShareLinkPayload - request payload, used to create a public link to a file
+//the link expires after the given number of hours, zero max uses means that the number of uses isnt limited
+type ShareLinkPayload struct {
+	FileRequestPayload
+	ExpiresIn uint `json:"expires_in_hours"`
+	MaxUses   uint `json:"max_uses"`
+}
+
+//ShareLinkRevocationPayload - request payload, containing the group name and the id of a share link to one of its files
+type ShareLinkRevocationPayload struct {
+	GroupPayload
+	ShareID uint `json:"share_id"`
+}
+
+//UploadSessionPayload - request payload, used to start a chunked upload of a file in a group
+type UploadSessionPayload struct {
+	GroupPayload
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+}
+
+//UploadPayload - request payload, containing the group name and the id of an upload session
+type UploadPayload struct {
+	GroupPayload
+	UploadID string `json:"upload_id"`
+}
+
+//GroupTwoFactorPayload - request payload, used to change if the members of a group need two-factor authentication
+type GroupTwoFactorPayload struct {
+	GroupPayload
+	Required bool `json:"required"`
+}
+
+//GroupQuotaPayload - request payload, used to change the limits of a group (in bytes)
+//the limits, which arent specified, stay unchanged
+type GroupQuotaPayload struct {
+	GroupPayload
+	Quota       *int64 `json:"quota"`
+	MaxFileSize *int64 `json:"max_file_size"`
+}
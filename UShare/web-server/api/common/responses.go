@@ -22,9 +22,29 @@ type RegistrationResponse struct {
 }
 
 //LoginResponse - when the login is succesfull a JWT is sent to the user
+//together with a refresh token, which is used to obtain a new JWT, when the current one expires
+//if the user has two-factor authentication, only a challenge is sent, which is exchanged for the tokens with a code
 type LoginResponse struct {
-	Status int    `json:"status"`
-	Token  string `json:"token"`
+	Status            int    `json:"status"`
+	Token             string `json:"token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	Challenge         string `json:"challenge,omitempty"`
+}
+
+//ExternalLoginStartResponse - response, containing the url, where the user authenticates to the external identity provider
+type ExternalLoginStartResponse struct {
+	Status           int    `json:"status"`
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+//TwoFactorEnrollmentResponse - response of a request for enrolling two-factor authentication
+//the secret and the recovery codes are returned only once
+type TwoFactorEnrollmentResponse struct {
+	Status          int      `json:"status"`
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
 }
 
 //GroupInfo - response payload, containing only the most important details about a group
@@ -34,16 +54,168 @@ type GroupInfo struct {
 	OwnerID uint   `josn:"owner_id"`
 }
 
+//DeletedGroupInfo - response payload, containing the details about a group in the trash of its owner
+//the time of deletion is missing for the groups, deleted before the introduction of the trash
+type DeletedGroupInfo struct {
+	GroupInfo
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
 //UserInfo - response payload, containing only the most important details about a user
 type UserInfo struct {
 	ID       uint   `json:"id"`
 	Username string `json:"username"`
 }
 
+//AccessTokenResponse - response of a request for creating a personal access token
+//the token is returned only once, the server stores only its hash
+type AccessTokenResponse struct {
+	Status    int        `json:"status"`
+	ID        uint       `json:"token_id"`
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+//AccessTokenInfo - response payload, containing the details about a personal access token
+type AccessTokenInfo struct {
+	ID         uint       `json:"token_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	GroupName  *string    `json:"group_name,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+//AccessTokensResponse - response of a request for fetching the personal access tokens of the user
+type AccessTokensResponse struct {
+	Status int               `json:"status"`
+	Tokens []AccessTokenInfo `json:"tokens"`
+}
+
+//InvitationInfo - response payload, containing the details about a pending invitation
+type InvitationInfo struct {
+	GroupName string     `json:"group_name"`
+	Inviter   string     `json:"inviter"`
+	InvitedAt time.Time  `json:"invited_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+//InvitationsResponse - response of a request for fetching the pending invitations of the user
+type InvitationsResponse struct {
+	Status      int              `json:"status"`
+	Invitations []InvitationInfo `json:"invitations"`
+}
+
+//GroupDetailsResponse - response of a request for fetching information about a group and its usage of space
+//the role is the role of the user, who made the request
+type GroupDetailsResponse struct {
+	Status int `json:"status"`
+	GroupInfo
+	Quota       int64  `json:"quota"`
+	MaxFileSize int64  `json:"max_file_size"`
+	Usage       int64  `json:"usage"`
+	Role        string `json:"role"`
+	//RequireTwoFactor - the files of the group are accessible only to members with two-factor authentication
+	RequireTwoFactor bool `json:"require_two_factor"`
+}
+
 //FileInfoResponse - response of a request for fetching information about file
 type FileInfoResponse struct {
 	ID         uint      `json:"file_id"`
 	Name       string    `json:"file_name"`
 	UploadedAt time.Time `json:"uploaded_at"`
 	OwnerID    uint      `json:"owner_id"`
+	Version    uint      `json:"version"`
+	Checksum   string    `json:"checksum,omitempty"`
+}
+
+//TrashedFileInfo - response payload, containing the details about a file in the trash of a group and the user, who deleted it
+type TrashedFileInfo struct {
+	FileInfoResponse
+	Size      int64     `json:"size"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy uint      `json:"deleted_by"`
+}
+
+//FileSearchInfo - response payload, containing the latest version of a found file, its group and its tags
+//the uploader is missing, if the user was deleted
+type FileSearchInfo struct {
+	FileInfoResponse
+	GroupName string   `json:"group_name"`
+	Uploader  *string  `json:"uploader,omitempty"`
+	Size      int64    `json:"size"`
+	Tags      []string `json:"tags"`
+}
+
+//FileSearchResponse - response of a request for searching files in the groups of the user
+//the next cursor is empty on the last page
+type FileSearchResponse struct {
+	Status     int              `json:"status"`
+	Files      []FileSearchInfo `json:"files"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+//ShareLinkResponse - response of a request for creating a public link to a file
+//the path is relative to the host of the server
+type ShareLinkResponse struct {
+	Status    int       `json:"status"`
+	ID        uint      `json:"share_id"`
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+//ShareLinkInfo - response payload, containing the details about an active share link
+type ShareLinkInfo struct {
+	ID        uint      `json:"share_id"`
+	CreatorID uint      `json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Uses      uint      `json:"uses"`
+	MaxUses   uint      `json:"max_uses"`
+}
+
+//UploadSessionResponse - response of a request for starting a chunked upload
+type UploadSessionResponse struct {
+	Status    int       `json:"status"`
+	UploadID  string    `json:"upload_id"`
+	ChunkSize int64     `json:"chunk_size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+//ByteRange - continuous range of bytes, described by its offset and size
+type ByteRange struct {
+	Offset int64 `json:"offset"`
+	Size   int64 `json:"size"`
+}
+
+//UploadStatusResponse - response, containing the ranges of a file, which the server already has
+type UploadStatusResponse struct {
+	Status int         `json:"status"`
+	Size   int64       `json:"size"`
+	Ranges []ByteRange `json:"ranges"`
+}
+
+//AuditEventInfo - response payload, containing the details about an audit event of a group
+//the actor is missing for the anonymous downloads through share links, the target user and the file - if the event doesnt concern them
+type AuditEventInfo struct {
+	ID         uint      `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Action     string    `json:"action"`
+	Actor      *string   `json:"actor,omitempty"`
+	TargetUser *string   `json:"target_user,omitempty"`
+	FileID     *uint     `json:"file_id,omitempty"`
+	Details    string    `json:"details"`
+	IP         string    `json:"ip"`
+}
+
+//AuditEventsResponse - response of a request for fetching a page of the audit trail of a group
+//more is true, if there are older events after the page
+type AuditEventsResponse struct {
+	Status   int              `json:"status"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+	More     bool             `json:"more"`
+	Events   []AuditEventInfo `json:"events"`
 }
@@ -1,15 +1,30 @@
 package rest
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
 	"net/http"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,27 +34,69 @@ type FileManagementEndpoint interface {
 	DownloadFile(*gin.Context)
 	DeleteFile(*gin.Context)
 	RetrieveAllFilesInfo(c *gin.Context)
+	RetrieveFileVersions(*gin.Context)
+	RestoreFileVersion(*gin.Context)
+	RetrieveTrashedFiles(*gin.Context)
+	RestoreTrashedFile(*gin.Context)
+	SetFileTags(*gin.Context)
+	SearchFiles(*gin.Context)
+
+	StartUpload(*gin.Context)
+	UploadChunk(*gin.Context)
+	GetUploadStatus(*gin.Context)
+	CompleteUpload(*gin.Context)
+
+	CreateShareLink(*gin.Context)
+	RetrieveShareLinks(*gin.Context)
+	RevokeShareLink(*gin.Context)
+	DownloadSharedFile(*gin.Context)
 }
 
+const (
+	//uploadSessionLifetime - how long an upload can be completed, after it is started
+	uploadSessionLifetime = 24 * time.Hour
+	//defaultChunkSize - the chunk size, suggested to the clients when an upload is started
+	defaultChunkSize int64 = 4 << 20
+	//maxChunkSize - the biggest chunk the server accepts in a single request
+	maxChunkSize int64 = 16 << 20
+	//multipartOverhead - the size of the multipart headers and boundaries, tolerated on top of the file size
+	multipartOverhead int64 = 64 << 10
+	//defaultShareExpiration - the lifetime of a share link (in hours), if none is specified
+	defaultShareExpiration uint = 24
+	//maxShareExpiration - the longest lifetime of a share link (in hours)
+	maxShareExpiration uint = 30 * 24
+	//sharePath - the public path, under which the shared files are downloaded
+	sharePath = "/v1/public/share/"
+	//maxFileTags - the biggest number of tags of a file
+	maxFileTags = 20
+)
+
+//tagPattern - the tags consist of lowercase letters, digits, dots, dashes and underscores
+var tagPattern = regexp.MustCompile(`^[a-z0-9._-]{1,32}$`)
+
 //FileManagementEndpointImpl - implementation of FileManagementEndpoint interface
 type FileManagementEndpointImpl struct {
-	UamDAO    dao.UamDAO
-	groupsDir string
-	FmDAO     dao.FmDAO
+	UamDAO      dao.UamDAO
+	blobStore   storage.BlobStore
+	FmDAO       dao.FmDAO
+	permissions permission.Service
+	shareSigner auth.ShareTokenSigner
 }
 
 //NewFileManagementEndpointImpl - instance creation of FileManagementEndpointImpl
-func NewFileManagementEndpointImpl(uam dao.UamDAO, fm dao.FmDAO, groupsDir string) *FileManagementEndpointImpl {
+func NewFileManagementEndpointImpl(uam dao.UamDAO, fm dao.FmDAO, blobStore storage.BlobStore, permissions permission.Service, shareSigner auth.ShareTokenSigner) *FileManagementEndpointImpl {
 	return &FileManagementEndpointImpl{
-		UamDAO:    uam,
-		FmDAO:     fm,
-		groupsDir: groupsDir,
+		UamDAO:      uam,
+		FmDAO:       fm,
+		blobStore:   blobStore,
+		permissions: permissions,
+		shareSigner: shareSigner,
 	}
 }
 
 //UploadFile - handler for the upload of files from a user of specific group
 //returns 500, if there is a problem with the server
-//returns 400, if the user input is invalid
+//returns 400, if the user input is invalid or the file exceeds the limits of the group
 //returns 201, if the file is uploaded
 func (i *FileManagementEndpointImpl) UploadFile(c *gin.Context) {
 	var (
@@ -52,8 +109,7 @@ func (i *FileManagementEndpointImpl) UploadFile(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("file")
-	if err != nil {
+	if c.ContentType() != "multipart/form-data" {
 		common.SendErrorResponse(c, myerr.NewClientError("Problem with the file"))
 		return
 	}
@@ -65,35 +121,65 @@ func (i *FileManagementEndpointImpl) UploadFile(c *gin.Context) {
 		return
 	}
 
-	group, err = i.UamDAO.GetGroup(groupName)
-	switch err.(type) {
-	case nil:
-		break
-	case (*myerr.ItemNotFoundError):
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid group"))
+	group, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.UploadFiles)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	usage, err := i.UamDAO.GetGroupUsage(group.ID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//the request is rejected before its body is read, if it cannot fit in the limits of the group
+	maxSize := getMaxUploadSize(group, usage)
+	if c.Request.ContentLength > maxSize+multipartOverhead {
+		common.SendErrorResponse(c, dao.CheckGroupLimits(group, usage, c.Request.ContentLength-multipartOverhead))
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	file, err := c.FormFile("file")
+	if err != nil && strings.Contains(err.Error(), "request body too large") {
+		common.SendErrorResponse(c, myerr.NewClientError("The file exceeds the limits of the group"))
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Problem with the file"))
 		return
-	default:
+	}
+
+	if err = dao.CheckGroupLimits(group, usage, file.Size); err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	if exists, err := i.UamDAO.MemberExists(userID, group.ID); err != nil {
+	content, err := file.Open()
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Problem with the file"))
+		return
+	}
+	defer content.Close()
+
+	checksum, err := computeChecksum(content)
+	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
-	} else if exists != true {
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid user input"))
+	} else if _, err = content.Seek(0, io.SeekStart); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with reading the file"))
 		return
 	}
 
-	fileID, err := i.FmDAO.AddFileInfo(userID, file.Filename, groupName)
+	event := newAuditEvent(c, userID, models.AuditFileUploaded, fmt.Sprintf("Uploaded [%s] (%d bytes)", file.Filename, file.Size))
+	fileID, created, err := i.FmDAO.AddFileInfo(userID, file.Filename, checksum, file.Size, groupName, event)
 	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	dst := fmt.Sprintf("%s/%s/%d", i.groupsDir, groupName, fileID)
-	if err = c.SaveUploadedFile(file, dst); err != nil {
-		i.FmDAO.RemoveFileInfo(userID, fileID, groupName)
+	if err = i.storeContent(checksum, content, file.Size, created); err != nil {
+		i.FmDAO.RemoveFileInfo(fileID, groupName, newAuditEvent(c, userID, models.AuditFileDeleted, fmt.Sprintf("Removed [%s] after its upload failed", file.Filename)))
 		common.SendErrorResponse(c, myerr.NewServerError(fmt.Sprintf("Couldnt save the file in the group dir [%s]", groupName)))
 		return
 	}
@@ -105,9 +191,14 @@ func (i *FileManagementEndpointImpl) UploadFile(c *gin.Context) {
 }
 
 //DownloadFile - downloads a file given group
+//supports partial downloads via the Range header and conditional requests via If-None-Match, If-Modified-Since and If-Range
+//the download is recorded in the audit log, once the content is sent
 //returns 500, if an error occurs due to system failure
 //returns 400 - if the user doesnt have enough permissions
+//returns 404 - if the file doesnt exist in the group
 //returns 200 + the downloaded file if the users has the permissions
+//returns 206 + the requested part of the file if a range is requested
+//returns 304 if the file wasnt modified since the client fetched it
 func (i *FileManagementEndpointImpl) DownloadFile(c *gin.Context) {
 	var (
 		userID uint
@@ -135,26 +226,50 @@ func (i *FileManagementEndpointImpl) DownloadFile(c *gin.Context) {
 		return
 	}
 
-	group, err := i.UamDAO.GetGroup(groupName)
-	if exists, err := i.UamDAO.MemberExists(userID, group.ID); err != nil {
+	group, _, err := i.permissions.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
-	} else if exists != true {
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid user input"))
-		return
 	}
 
 	fileInfo, err := i.FmDAO.GetFileInfo(userID, uint(fileID), groupName)
 	if err != nil {
 		common.SendErrorResponse(c, err)
+		return
+	} else if fileInfo.GroupID != group.ID {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("File does not exist"))
+		return
+	}
+
+	content, err := i.blobStore.Get(getContentKey(groupName, fileInfo))
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+	defer content.Close()
+
+	serveContent(c, fileInfo, content)
+	if status := c.Writer.Status(); status != http.StatusOK && status != http.StatusPartialContent {
+		return
+	}
+
+	//the content is already sent, so a failure to record the download can only be logged
+	event := newAuditEvent(c, userID, models.AuditFileDownloaded, fmt.Sprintf("Downloaded [%s] version %d", fileInfo.Name, fileInfo.Version))
+	event.GroupID, event.FileID = &group.ID, &fileInfo.ID
+	if err = i.UamDAO.CreateAuditEvent(event); err != nil {
+		log.Printf("Couldnt record the download of file [%d]. Reason: %v\n", fileInfo.ID, err)
 	}
+}
 
-	c.Writer.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileInfo.Name))
-	filePath := fmt.Sprintf("%s/%s/%d", i.groupsDir, groupName, fileInfo.ID)
-	c.File(filePath)
+//serveContent - sends the content of a file as an attachment, supporting partial and conditional requests
+func serveContent(c *gin.Context, fileInfo models.FileInfo, content storage.Blob) {
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileInfo.Name}))
+	c.Header("ETag", getETag(fileInfo))
+	http.ServeContent(c.Writer, c.Request, fileInfo.Name, fileInfo.CreatedAt, content)
 }
 
-//DeleteFile - deletes a file from the system
+//DeleteFile - moves a file with all of its versions to the trash of its group, from where the owner of the group can restore it
+//the file is erased, when the retention period of the trash expires
 //returns 500, if an error occurs due to system failure
 //returns 400, if the user doesnt have enough permissions
 //returns 200, if the file is succesfully deleted
@@ -175,29 +290,37 @@ func (i *FileManagementEndpointImpl) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	group, err := i.UamDAO.GetGroup(rq.GroupName)
-	if exists, err := i.UamDAO.MemberExists(userID, group.ID); err != nil {
+	group, role, err := i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.DeleteOwnFiles)
+	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
-	} else if exists != true {
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid user input"))
-		return
 	}
 
-	if err := i.FmDAO.RemoveFileInfo(userID, rq.FileID, rq.GroupName); err != nil {
+	fileInfo, err := i.FmDAO.GetFileInfo(userID, rq.FileID, rq.GroupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if fileInfo.GroupID != group.ID {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("File does not exist"))
+		return
+	} else if err = i.permissions.AuthorizeFileChange(userID, role, fileInfo, permission.DeleteOwnFiles); err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	path := fmt.Sprintf("%s/%s/%d", i.groupsDir, rq.GroupName, rq.FileID)
-	os.Remove(path)
+	event := newAuditEvent(c, userID, models.AuditFileDeleted, fmt.Sprintf("Deleted [%s] version %d", fileInfo.Name, fileInfo.Version))
+	if err = i.FmDAO.TrashFile(userID, rq.FileID, rq.GroupName, event); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, common.BasicResponse{
 		Status: http.StatusOK,
 	})
 }
 
-//RetrieveAllFilesInfo - retrieves info about all files owned by a particular group
+//RetrieveAllFilesInfo - retrieves info about all files owned by a particular group, a page at a time
+//the files can be filtered by name and sorted by name, size, uploaded_at or id
 //returns 500, if error occurrs due to system failure
 //returns 400, if the user doesnt have enough permissions
 //returns 200 + info about files
@@ -218,7 +341,18 @@ func (i *FileManagementEndpointImpl) RetrieveAllFilesInfo(c *gin.Context) {
 		return
 	}
 
-	fileInfos, err := i.FmDAO.GetAllFilesInfo(userID, groupName)
+	options, err := parseListOptions(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.ViewGroup); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfos, cursor, err := i.FmDAO.GetAllFilesInfo(userID, groupName, options)
 	if _, ok := err.(*myerr.ClientError); ok {
 		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with file retrieval"))
 		return
@@ -227,18 +361,1270 @@ func (i *FileManagementEndpointImpl) RetrieveAllFilesInfo(c *gin.Context) {
 		return
 	}
 
-	fileResponses := make([]common.FileInfoResponse, 0, len(fileInfos))
-	for _, fileInfo := range fileInfos {
-		fileResponses = append(fileResponses, common.FileInfoResponse{
-			ID:         fileInfo.ID,
-			Name:       fileInfo.Name,
-			UploadedAt: fileInfo.CreatedAt,
-			OwnerID:    fileInfo.OwnerID,
-		})
+	c.JSON(http.StatusOK, gin.H{
+		"status":      http.StatusOK,
+		"files":       toFileInfoResponses(fileInfos),
+		"next_cursor": cursor,
+	})
+}
+
+//RetrieveFileVersions - retrieves info about all versions of a file, starting from the latest one
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions
+//returns 404, if the file doesnt exist in the group
+//returns 200 + info about the versions
+func (i *FileManagementEndpointImpl) RetrieveFileVersions(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	fileID, err := strconv.ParseUint(c.Query("file_id"), 10, 32)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Unvalid format of file id"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.ViewGroup); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfos, err := i.FmDAO.GetFileVersions(userID, uint(fileID), groupName)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with file versions retrieval"))
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": http.StatusOK,
-		"files":  fileResponses,
+		"files":  toFileInfoResponses(fileInfos),
+	})
+}
+
+//SetFileTags - replaces the tags of a file, all versions of the file share them
+//the members can tag their own files, the files of others can be tagged only by the admins and the owner
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions or the tags are invalid
+//returns 404, if the file doesnt exist in the group
+//returns 200, if the tags are succesfully changed
+func (i *FileManagementEndpointImpl) SetFileTags(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.FileTagsPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	tags, err := normalizeTags(rq.Tags)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	group, role, err := i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.TagFiles)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfo, err := i.FmDAO.GetFileInfo(userID, rq.FileID, rq.GroupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if fileInfo.GroupID != group.ID {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("File does not exist"))
+		return
+	} else if err = i.permissions.AuthorizeFileChange(userID, role, fileInfo, permission.TagFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditFileTagged, fmt.Sprintf("Tagged [%s] with [%s]", fileInfo.Name, strings.Join(tags, ",")))
+	if err = i.FmDAO.SetFileTags(rq.FileID, rq.GroupName, tags, event); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//SearchFiles - searches the latest versions of the files in all groups of the user by name, group, uploader, upload time, size and tags
+//returns 500, if error occurrs due to system failure
+//returns 400, if the search parameters are invalid
+//returns 200 + a page of the found files and the cursor of the next page
+func (i *FileManagementEndpointImpl) SearchFiles(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	options, err := parseListOptions(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if options.SortBy != "" && !dao.IsFileSortField(options.SortBy) {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("The files cannot be sorted by [%s]", options.SortBy)))
+		return
+	}
+
+	filter := dao.FileSearchFilter{
+		Group:    c.Query("group_name"),
+		Uploader: c.Query("uploader"),
+	}
+
+	if filter.Since, err = parseQueryTime(c.Query("since")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if filter.Until, err = parseQueryTime(c.Query("until")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if filter.MinSize, err = parseQuerySize(c.Query("min_size")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if filter.MaxSize, err = parseQuerySize(c.Query("max_size")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if tags := c.Query("tags"); tags != "" {
+		if filter.Tags, err = normalizeTags(strings.Split(tags, ",")); err != nil {
+			common.SendErrorResponse(c, err)
+			return
+		}
+	}
+
+	files, cursor, err := i.FmDAO.SearchFiles(userID, filter, options)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with the file search"))
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the file search."))
+		return
+	}
+
+	filesInfo := make([]common.FileSearchInfo, 0, len(files))
+	for _, file := range files {
+		filesInfo = append(filesInfo, common.FileSearchInfo{
+			FileInfoResponse: common.FileInfoResponse{
+				ID:         file.ID,
+				Name:       file.Name,
+				UploadedAt: file.CreatedAt,
+				OwnerID:    file.OwnerID,
+				Version:    file.Version,
+				Checksum:   file.ETag,
+			},
+			GroupName: file.GroupName,
+			Uploader:  file.Uploader,
+			Size:      file.Size,
+			Tags:      file.Tags,
+		})
+	}
+
+	c.JSON(http.StatusOK, common.FileSearchResponse{
+		Status:     http.StatusOK,
+		Files:      filesInfo,
+		NextCursor: cursor,
+	})
+}
+
+//RestoreFileVersion - makes an older version of a file the latest one
+//the members can restore their own versions, the versions of others can be restored only by the admins and the owner
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions
+//returns 404, if the file doesnt exist in the group
+//returns 201 + the id of the new version
+func (i *FileManagementEndpointImpl) RestoreFileVersion(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.FileRequestPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	group, role, err := i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.UploadFiles)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfo, err := i.FmDAO.GetFileInfo(userID, rq.FileID, rq.GroupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if fileInfo.GroupID != group.ID {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("File does not exist"))
+		return
+	} else if err = i.permissions.AuthorizeFileChange(userID, role, fileInfo, permission.UploadFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditFileRestored, fmt.Sprintf("Restored version %d of [%s]", fileInfo.Version, fileInfo.Name))
+	restored, err := i.FmDAO.RestoreFileVersion(rq.FileID, rq.GroupName, event)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//the restored version shares the content with the original one, unless it was uploaded before the deduplication
+	if restored.BlobID == 0 {
+		if err = i.copyBlob(storage.FileKey(rq.GroupName, rq.FileID), storage.FileKey(rq.GroupName, restored.ID)); err != nil {
+			i.FmDAO.RemoveFileInfo(restored.ID, rq.GroupName, newAuditEvent(c, userID, models.AuditFileDeleted, fmt.Sprintf("Removed version %d of [%s] after its restoration failed", restored.Version, restored.Name)))
+			common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Couldnt restore the file version"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  http.StatusCreated,
+		"file_id": restored.ID,
+		"version": restored.Version,
 	})
 }
+
+//RetrieveTrashedFiles - retrieves info about the files in the trash of a group, starting from the latest deleted one
+//only the owner of the group can see its trash
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions
+//returns 200 + info about the deleted files
+func (i *FileManagementEndpointImpl) RetrieveTrashedFiles(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.ManageTrash); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfos, err := i.FmDAO.GetTrashedFiles(groupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	trashedFiles := make([]common.TrashedFileInfo, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		trashedFile := common.TrashedFileInfo{
+			FileInfoResponse: toFileInfoResponse(fileInfo),
+			Size:             fileInfo.Size,
+		}
+		if fileInfo.TrashedAt != nil {
+			trashedFile.DeletedAt = *fileInfo.TrashedAt
+		}
+		if fileInfo.TrashedBy != nil {
+			trashedFile.DeletedBy = *fileInfo.TrashedBy
+		}
+		trashedFiles = append(trashedFiles, trashedFile)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": http.StatusOK,
+		"files":  trashedFiles,
+	})
+}
+
+//RestoreTrashedFile - moves a file from the trash back to the files of its group, together with the versions, which were deleted with it
+//only the owner of the group can restore the deleted files
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions or the file exceeds the limits of the group
+//returns 404, if the file isnt in the trash of the group
+//returns 200 + the id and the version of the latest restored version
+func (i *FileManagementEndpointImpl) RestoreTrashedFile(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.FileRequestPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.ManageTrash); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditFileRecovered, fmt.Sprintf("Restored file [%d] from the trash", rq.FileID))
+	restored, err := i.FmDAO.RestoreTrashedFile(rq.FileID, rq.GroupName, event)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    http.StatusOK,
+		"file_id":   restored.ID,
+		"file_name": restored.Name,
+		"version":   restored.Version,
+	})
+}
+
+//CreateShareLink - creates a public link to a file, which can be used by anyone, who isnt a member of the group
+//the link expires after the given number of hours and can be limited to a number of uses
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user input is invalid or the user isnt a member of the group
+//returns 404, if the file doesnt exist in the group
+//returns 201 + the token and the path of the link
+func (i *FileManagementEndpointImpl) CreateShareLink(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.ShareLinkPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if rq.ExpiresIn == 0 {
+		rq.ExpiresIn = defaultShareExpiration
+	} else if rq.ExpiresIn > maxShareExpiration {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("The share link cannot be valid for more than %d hours", maxShareExpiration)))
+		return
+	}
+
+	fileInfo, err := i.getSharedFile(userID, rq.GroupName, rq.FileID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//the expiry is truncated to seconds, the precision of the token, so that the token cannot outlive the link
+	expiresAt := time.Now().Add(time.Duration(rq.ExpiresIn) * time.Hour).Truncate(time.Second)
+	event := newAuditEvent(c, userID, models.AuditShareLinkCreated, fmt.Sprintf("Shared [%s] until %s", fileInfo.Name, expiresAt.Format(time.RFC3339)))
+	linkID, err := i.FmDAO.CreateShareLink(userID, rq.FileID, expiresAt, rq.MaxUses, event)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	token, err := i.shareSigner.GenerateShareToken(linkID, expiresAt)
+	if err != nil {
+		i.FmDAO.RevokeShareLink(linkID, newAuditEvent(c, userID, models.AuditShareLinkRevoked, fmt.Sprintf("Revoked share link [%d] after its signing failed", linkID)))
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Couldnt sign the share link"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.ShareLinkResponse{
+		Status:    http.StatusCreated,
+		ID:        linkID,
+		Token:     token,
+		Path:      sharePath + token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+//RetrieveShareLinks - retrieves the active public links to a file
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user input is invalid or the user isnt a member of the group
+//returns 404, if the file doesnt exist in the group
+//returns 200 + info about the links
+func (i *FileManagementEndpointImpl) RetrieveShareLinks(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	fileID, err := strconv.ParseUint(c.Query("file_id"), 10, 32)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Unvalid format of file id"))
+		return
+	}
+
+	if _, err = i.getSharedFile(userID, groupName, uint(fileID)); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	links, err := i.FmDAO.GetActiveShareLinks(uint(fileID))
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	linksInfo := make([]common.ShareLinkInfo, 0, len(links))
+	for _, link := range links {
+		linksInfo = append(linksInfo, common.ShareLinkInfo{
+			ID:        link.ID,
+			CreatorID: link.CreatorID,
+			CreatedAt: link.CreatedAt,
+			ExpiresAt: link.ExpiresAt,
+			Uses:      link.Uses,
+			MaxUses:   link.MaxUses,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": http.StatusOK,
+		"shares": linksInfo,
+	})
+}
+
+//RevokeShareLink - makes a public link to a file unusable
+//the links can be revoked by their creators and by the members, allowed to manage the files of others
+//returns 500, if error occurrs due to system failure
+//returns 400, if the user doesnt have enough permissions
+//returns 404, if the link doesnt exist in the group
+//returns 200, if the link is revoked
+func (i *FileManagementEndpointImpl) RevokeShareLink(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.ShareLinkRevocationPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	group, role, err := i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.ViewGroup)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	link, err := i.FmDAO.GetShareLink(rq.ShareID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfo, err := i.FmDAO.GetFileInfo(userID, link.FileID, rq.GroupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if fileInfo.GroupID != group.ID {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("Share link not found"))
+		return
+	}
+
+	if link.CreatorID != userID && !permission.HasPermission(role, permission.ManageFiles) {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("Your role (%s) doesnt allow you to revoke the share links of others", role)))
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditShareLinkRevoked, fmt.Sprintf("Revoked share link [%d] of [%s]", link.ID, fileInfo.Name))
+	if err = i.FmDAO.RevokeShareLink(link.ID, event); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//DownloadSharedFile - downloads a file through a public link, no authentication is needed
+//supports the same partial and conditional requests as DownloadFile
+//only the full downloads count as uses of the link and are recorded in the audit log
+//returns 500, if an error occurs due to system failure
+//returns 400, if the token of the link is invalid or expired
+//returns 404, if the link is revoked, used up or the file doesnt exist anymore
+//returns 200 + the downloaded file
+//returns 206 + the requested part of the file if a range is requested
+//returns 304 if the file wasnt modified since the client fetched it
+func (i *FileManagementEndpointImpl) DownloadSharedFile(c *gin.Context) {
+	linkID, err := i.shareSigner.ValidateShareToken(c.Param("token"))
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	fileInfo, groupName, err := i.FmDAO.GetSharedFile(linkID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//the content is opened before the use is counted, so that a failure of the storage doesnt use up the link
+	content, err := i.blobStore.Get(getContentKey(groupName, fileInfo))
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+	defer content.Close()
+
+	full, err := isFullDownload(c.Request, fileInfo, content)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if full {
+		//the downloads through the links are anonymous, so the event has no actor
+		event := &models.AuditEvent{
+			Action:  models.AuditFileDownloaded,
+			Details: fmt.Sprintf("Downloaded through share link [%d]", linkID),
+			IP:      c.ClientIP(),
+		}
+		if err = i.FmDAO.UseShareLink(linkID, event); err != nil {
+			common.SendErrorResponse(c, err)
+			return
+		}
+	}
+
+	serveContent(c, fileInfo, content)
+}
+
+//isFullDownload - checks if every byte of the content is sent in response to the request
+//the conditional requests, answered with 304, and the ranges, which leave out a part of the content, arent full downloads
+func isFullDownload(r *http.Request, fileInfo models.FileInfo, content storage.Blob) (bool, error) {
+	if r.Method != http.MethodGet || isNotModified(r, fileInfo) {
+		return false, nil
+	}
+
+	ranges := r.Header.Get("Range")
+	if ranges == "" || !matchesIfRange(r, fileInfo) {
+		return true, nil
+	}
+
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, myerr.NewServerErrorWrap(err, "Couldnt find the size of the content")
+	} else if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return false, myerr.NewServerErrorWrap(err, "Couldnt rewind the content")
+	}
+	return coversContent(ranges, size), nil
+}
+
+//coversContent - checks if the ranges of a Range header send every byte of a content with the given size
+//the ranges are parsed as http.ServeContent parses them, it sends the whole content, if the ranges are bigger than it
+func coversContent(header string, size int64) bool {
+	if !strings.HasPrefix(header, "bytes=") {
+		return false
+	}
+
+	ranges := make([]common.ByteRange, 0)
+	var total int64
+	for _, spec := range strings.Split(strings.TrimPrefix(header, "bytes="), ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		dash := strings.Index(spec, "-")
+		if dash < 0 {
+			return false
+		}
+		start, end := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+		var byteRange common.ByteRange
+		if start == "" {
+			//a suffix range - the last bytes of the content
+			suffix, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || suffix < 0 {
+				return false
+			} else if suffix > size {
+				suffix = size
+			}
+			byteRange = common.ByteRange{Offset: size - suffix, Size: suffix}
+		} else {
+			offset, err := strconv.ParseInt(start, 10, 64)
+			if err != nil || offset < 0 {
+				return false
+			} else if offset >= size {
+				continue
+			}
+
+			last := size - 1
+			if end != "" {
+				if last, err = strconv.ParseInt(end, 10, 64); err != nil || offset > last {
+					return false
+				} else if last >= size {
+					last = size - 1
+				}
+			}
+			byteRange = common.ByteRange{Offset: offset, Size: last - offset + 1}
+		}
+
+		ranges = append(ranges, byteRange)
+		total += byteRange.Size
+	}
+
+	if len(ranges) == 0 {
+		return false
+	} else if total > size {
+		return true
+	}
+
+	sort.Slice(ranges, func(a, b int) bool { return ranges[a].Offset < ranges[b].Offset })
+	var covered int64
+	for _, byteRange := range ranges {
+		if byteRange.Offset > covered {
+			return false
+		} else if byteRange.Offset+byteRange.Size > covered {
+			covered = byteRange.Offset + byteRange.Size
+		}
+	}
+	return covered >= size
+}
+
+//isNotModified - checks if the file is answered with 304 by its If-None-Match or If-Modified-Since headers
+func isNotModified(r *http.Request, fileInfo models.FileInfo) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		etag := strings.TrimPrefix(getETag(fileInfo), "W/")
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !fileInfo.CreatedAt.IsZero() && !fileInfo.CreatedAt.Truncate(time.Second).After(since)
+}
+
+//matchesIfRange - checks if the If-Range header allows the requested range to be sent instead of the whole file
+func matchesIfRange(r *http.Request, fileInfo models.FileInfo) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	} else if strings.HasPrefix(ifRange, "\"") {
+		return ifRange == getETag(fileInfo)
+	}
+
+	modified, err := http.ParseTime(ifRange)
+	return err == nil && fileInfo.CreatedAt.Truncate(time.Second).Equal(modified)
+}
+
+//getSharedFile - fetches a file, which the user can share, from a group
+func (i *FileManagementEndpointImpl) getSharedFile(userID uint, groupName string, fileID uint) (models.FileInfo, error) {
+	group, _, err := i.permissions.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+	if err != nil {
+		return models.FileInfo{}, err
+	}
+
+	fileInfo, err := i.FmDAO.GetFileInfo(userID, fileID, groupName)
+	if err != nil {
+		return models.FileInfo{}, err
+	} else if fileInfo.GroupID != group.ID {
+		return models.FileInfo{}, myerr.NewItemNotFoundError("File does not exist")
+	}
+	return fileInfo, nil
+}
+
+func toFileInfoResponses(fileInfos []models.FileInfo) []common.FileInfoResponse {
+	fileResponses := make([]common.FileInfoResponse, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		fileResponses = append(fileResponses, toFileInfoResponse(fileInfo))
+	}
+	return fileResponses
+}
+
+func toFileInfoResponse(fileInfo models.FileInfo) common.FileInfoResponse {
+	return common.FileInfoResponse{
+		ID:         fileInfo.ID,
+		Name:       fileInfo.Name,
+		UploadedAt: fileInfo.CreatedAt,
+		OwnerID:    fileInfo.OwnerID,
+		Version:    fileInfo.Version,
+		Checksum:   fileInfo.ETag,
+	}
+}
+
+//normalizeTags - lowercases the tags and removes the duplicates and the blank ones
+//returns an error, if a tag is invalid or there are too many of them
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		} else if !tagPattern.MatchString(tag) {
+			return nil, myerr.NewClientError(fmt.Sprintf("Invalid tag [%s]. The tags should be up to 32 letters, digits, dots, dashes or underscores", tag))
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxFileTags {
+		return nil, myerr.NewClientError(fmt.Sprintf("A file can have at most %d tags", maxFileTags))
+	}
+	return normalized, nil
+}
+
+//parseQuerySize - parses a size limit (in bytes) from a query parameter, the empty limit is nil
+func parseQuerySize(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return nil, myerr.NewClientError(fmt.Sprintf("Invalid size [%s]. The size should be a non-negative number of bytes", value))
+	}
+	return &size, nil
+}
+
+//storeContent - saves the content of a file in the storage, unless a file with the same checksum is already saved
+//the content of a newly created blob is always saved, because the stored one may belong to an erased blob and be deleted any time
+func (i *FileManagementEndpointImpl) storeContent(checksum string, content io.Reader, size int64, created bool) error {
+	key := storage.ContentKey(checksum)
+	if created {
+		return i.blobStore.Put(key, content, size)
+	}
+
+	_, err := i.blobStore.Stat(key)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok {
+		return i.blobStore.Put(key, content, size)
+	}
+	return err
+}
+
+func (i *FileManagementEndpointImpl) copyBlob(srcKey, dstKey string) error {
+	info, err := i.blobStore.Stat(srcKey)
+	if err != nil {
+		return err
+	}
+
+	content, err := i.blobStore.Get(srcKey)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return i.blobStore.Put(dstKey, content, info.Size)
+}
+
+//StartUpload - starts a chunked upload of a file in a specific group
+//returns 500, if there is a problem with the server
+//returns 400, if the user input is invalid
+//returns 201 + the id of the upload, if the upload is started
+func (i *FileManagementEndpointImpl) StartUpload(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.UploadSessionPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if rq.GroupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	} else if rq.FileName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Filename isnt specified"))
+		return
+	} else if rq.Size < 0 {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid file size"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.UploadFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	uploadID, err := generateUploadID()
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(uploadSessionLifetime)
+	if _, err = i.FmDAO.CreateUploadSession(userID, uploadID, rq.FileName, rq.Size, rq.GroupName, expiresAt); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.UploadSessionResponse{
+		Status:    http.StatusCreated,
+		UploadID:  uploadID,
+		ChunkSize: defaultChunkSize,
+		ExpiresAt: expiresAt,
+	})
+}
+
+//UploadChunk - saves a chunk of a file, which is being uploaded
+//returns 500, if there is a problem with the server
+//returns 400, if the user input is invalid
+//returns 404, if the upload doesnt exist
+//returns 200, if the chunk is saved
+func (i *FileManagementEndpointImpl) UploadChunk(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	uploadID := c.Query("upload_id")
+	if uploadID == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Upload id isnt specified"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.UploadFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	number, err := strconv.ParseUint(c.Query("chunk"), 10, 32)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid format of chunk number"))
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid format of chunk offset"))
+		return
+	}
+
+	session, err := i.FmDAO.GetUploadSession(userID, uploadID, groupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxChunkSize+1))
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with the chunk"))
+		return
+	} else if int64(len(data)) > maxChunkSize {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("Chunk is bigger than %d bytes", maxChunkSize)))
+		return
+	} else if offset+int64(len(data)) > session.Size {
+		common.SendErrorResponse(c, myerr.NewClientError("Chunk exceeds the size of the file"))
+		return
+	}
+
+	if err = i.blobStore.Put(getUploadChunkKey(groupName, uploadID, uint(number)), bytes.NewReader(data), int64(len(data))); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.FmDAO.AddUploadChunk(session.ID, uint(number), offset, int64(len(data))); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//GetUploadStatus - retrieves the ranges of the file, which were already received by the server
+//returns 500, if there is a problem with the server
+//returns 400, if the user input is invalid
+//returns 404, if the upload doesnt exist
+//returns 200 + the received ranges
+func (i *FileManagementEndpointImpl) GetUploadStatus(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	uploadID := c.Query("upload_id")
+	if uploadID == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Upload id isnt specified"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, groupName, permission.UploadFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	session, err := i.FmDAO.GetUploadSession(userID, uploadID, groupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	chunks, err := i.FmDAO.GetUploadChunks(session.ID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.UploadStatusResponse{
+		Status: http.StatusOK,
+		Size:   session.Size,
+		Ranges: mergeChunks(chunks),
+	})
+}
+
+//CompleteUpload - finalizes a chunked upload, after all chunks of the file were received
+//returns 500, if there is a problem with the server
+//returns 400, if the user input is invalid or there are missing chunks
+//returns 404, if the upload doesnt exist
+//returns 201 + the id of the file, if the file is uploaded
+func (i *FileManagementEndpointImpl) CompleteUpload(c *gin.Context) {
+	var (
+		userID uint
+		err    error
+	)
+
+	if userID, err = common.GetIDFromContext(c); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.UploadPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if _, _, err = i.permissions.AuthorizeFileAccess(userID, rq.GroupName, permission.UploadFiles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	session, err := i.FmDAO.GetUploadSession(userID, rq.UploadID, rq.GroupName)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	chunks, err := i.FmDAO.GetUploadChunks(session.ID)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if ranges := mergeChunks(chunks); session.Size > 0 && (len(ranges) != 1 || ranges[0].Offset != 0 || ranges[0].Size != session.Size) {
+		common.SendErrorResponse(c, myerr.NewClientError("Not all chunks of the file were uploaded"))
+		return
+	}
+
+	content, checksum, err := i.stageUploadContent(rq.GroupName, rq.UploadID, chunks)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+	defer removeTempFile(content)
+
+	event := newAuditEvent(c, userID, models.AuditFileUploaded, fmt.Sprintf("Uploaded [%s] (%d bytes)", session.FileName, session.Size))
+	fileID, created, err := i.FmDAO.AddUploadedFileInfo(userID, session, checksum, rq.GroupName, event)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.storeContent(checksum, content, session.Size, created); err != nil {
+		i.FmDAO.RemoveFileInfo(fileID, rq.GroupName, newAuditEvent(c, userID, models.AuditFileDeleted, fmt.Sprintf("Removed [%s] after its upload failed", session.FileName)))
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, fmt.Sprintf("Couldnt save the file in the group dir [%s]", rq.GroupName)))
+		return
+	}
+
+	if err = i.FmDAO.RemoveUploadSession(session.ID); err != nil {
+		log.Printf("Couldnt remove finished upload session [%s]. Reason: %v\n", rq.UploadID, err)
+	}
+	i.deleteUploadChunks(rq.GroupName, rq.UploadID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  http.StatusCreated,
+		"file_id": fileID,
+	})
+}
+
+//stageUploadContent - copies the content of an uploaded file from its chunks to a temporary file and computes its checksum in the same pass
+//the returned file is positioned at its beginning, it has to be removed with removeTempFile
+func (i *FileManagementEndpointImpl) stageUploadContent(groupName, uploadID string, chunks []models.UploadChunk) (*os.File, string, error) {
+	content := i.joinUploadChunks(groupName, uploadID, chunks)
+	defer content.Close()
+
+	file, err := ioutil.TempFile("", "upload-")
+	if err != nil {
+		return nil, "", myerr.NewServerErrorWrap(err, "Problem with the creation of a temporary file")
+	}
+
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(file, hash), content); err != nil {
+		removeTempFile(file)
+		return nil, "", myerr.NewServerErrorWrap(err, "Problem with joining the chunks of the file")
+	} else if _, err = file.Seek(0, io.SeekStart); err != nil {
+		removeTempFile(file)
+		return nil, "", myerr.NewServerErrorWrap(err, "Problem with reading the joined file")
+	}
+	return file, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func removeTempFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+//joinUploadChunks - returns the content of an uploaded file, composed of its chunks (ordered by their offset)
+//the parts of the chunks, which overlap with the previous ones, are skipped
+//closing the content closes the chunks, which were opened
+func (i *FileManagementEndpointImpl) joinUploadChunks(groupName, uploadID string, chunks []models.UploadChunk) io.ReadCloser {
+	content := &chunkedContent{}
+	readers := make([]io.Reader, 0, len(chunks))
+	var position int64
+	for _, chunk := range chunks {
+		end := chunk.Offset + chunk.Size
+		if end <= position {
+			continue
+		}
+
+		reader := &lazyBlobReader{
+			blobStore: i.blobStore,
+			key:       getUploadChunkKey(groupName, uploadID, chunk.Number),
+			skip:      position - chunk.Offset,
+		}
+		content.chunks = append(content.chunks, reader)
+		readers = append(readers, reader)
+		position = end
+	}
+	content.Reader = io.MultiReader(readers...)
+	return content
+}
+
+func (i *FileManagementEndpointImpl) deleteUploadChunks(groupName, uploadID string) {
+	keys, err := i.blobStore.List(storage.UploadKeyPrefix(groupName, uploadID))
+	if err != nil {
+		log.Printf("Couldnt list the chunks of upload [%s]. Reason: %v\n", uploadID, err)
+		return
+	}
+
+	for _, key := range keys {
+		if err = i.blobStore.Delete(key); err != nil {
+			log.Printf("Couldnt delete chunk [%s]. Reason: %v\n", key, err)
+		}
+	}
+}
+
+//chunkedContent - content of a file, read from its chunks one after another
+type chunkedContent struct {
+	io.Reader
+	chunks []*lazyBlobReader
+}
+
+//Close - closes all chunks, which are still open
+func (c *chunkedContent) Close() error {
+	var err error
+	for _, chunk := range c.chunks {
+		if closeErr := chunk.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+//lazyBlobReader - opens the blob on the first read and closes it when its end is reached
+type lazyBlobReader struct {
+	blobStore storage.BlobStore
+	key       string
+	skip      int64
+	blob      storage.Blob
+	closed    bool
+}
+
+func (r *lazyBlobReader) Read(p []byte) (int, error) {
+	if r.closed {
+		return 0, io.EOF
+	} else if r.blob == nil {
+		blob, err := r.blobStore.Get(r.key)
+		if err != nil {
+			return 0, err
+		}
+		r.blob = blob
+
+		if _, err = blob.Seek(r.skip, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.blob.Read(p)
+	if err == io.EOF {
+		r.Close()
+	}
+	return n, err
+}
+
+//Close - closes the blob, if it was opened and isnt closed yet
+func (r *lazyBlobReader) Close() error {
+	if r.blob == nil || r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.blob.Close()
+}
+
+//getContentKey - returns the key of the content of a file
+func getContentKey(groupName string, fileInfo models.FileInfo) string {
+	if fileInfo.BlobID == 0 {
+		return storage.FileKey(groupName, fileInfo.ID)
+	}
+	return storage.ContentKey(fileInfo.ETag)
+}
+
+func getUploadChunkKey(groupName, uploadID string, number uint) string {
+	return fmt.Sprintf("%s%d", storage.UploadKeyPrefix(groupName, uploadID), number)
+}
+
+//getMaxUploadSize - returns the size of the biggest file, which can be uploaded in the group
+func getMaxUploadSize(group models.Group, usage int64) int64 {
+	maxSize := group.Quota - usage
+	if group.MaxFileSize < maxSize {
+		maxSize = group.MaxFileSize
+	}
+
+	if maxSize < 0 {
+		return 0
+	}
+	return maxSize
+}
+
+//mergeChunks - merges the chunks (ordered by their offset) into continuous ranges of bytes
+func mergeChunks(chunks []models.UploadChunk) []common.ByteRange {
+	ranges := make([]common.ByteRange, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.Size == 0 {
+			continue
+		}
+
+		last := len(ranges) - 1
+		if last >= 0 && chunk.Offset <= ranges[last].Offset+ranges[last].Size {
+			if end := chunk.Offset + chunk.Size; end > ranges[last].Offset+ranges[last].Size {
+				ranges[last].Size = end - ranges[last].Offset
+			}
+			continue
+		}
+
+		ranges = append(ranges, common.ByteRange{
+			Offset: chunk.Offset,
+			Size:   chunk.Size,
+		})
+	}
+	return ranges
+}
+
+//getETag - returns the etag header value of a file
+//files, uploaded before etags were introduced, get a weak etag, based on their metadata
+func getETag(fileInfo models.FileInfo) string {
+	if fileInfo.ETag == "" {
+		return fmt.Sprintf("W/\"%d-%d\"", fileInfo.ID, fileInfo.CreatedAt.UnixNano())
+	}
+	return fmt.Sprintf("\"%s\"", fileInfo.ETag)
+}
+
+//computeChecksum - computes the sha256 checksum of the file content, also used as its etag
+func computeChecksum(content io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", myerr.NewServerErrorWrap(err, "Problem with computing the checksum of the file")
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func generateUploadID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", myerr.NewServerErrorWrap(err, "Problem with generation of upload id")
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -2,19 +2,32 @@ package rest_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/rest"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth/auth_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission/permission_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage/storage_mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -24,6 +37,8 @@ import (
 func setupRouterFmEndpoint(fmRest rest.FileManagementEndpoint, userID uint) *gin.Engine {
 	r := gin.Default()
 
+	r.GET("/public/share/:token", fmRest.DownloadSharedFile)
+
 	protected := r.Group("/protected").Use(func(c *gin.Context) {
 		c.Set("userID", userID)
 		c.Next()
@@ -32,6 +47,20 @@ func setupRouterFmEndpoint(fmRest rest.FileManagementEndpoint, userID uint) *gin
 		protected.POST("/group/file/upload", fmRest.UploadFile)
 		protected.GET("/group/file/download", fmRest.DownloadFile)
 		protected.DELETE("/group/file/delete", fmRest.DeleteFile)
+		protected.PUT("/group/file/tags", fmRest.SetFileTags)
+		protected.GET("/files/search", fmRest.SearchFiles)
+		protected.GET("/group/files", fmRest.RetrieveAllFilesInfo)
+		protected.POST("/group/file/upload/session", fmRest.StartUpload)
+		protected.PUT("/group/file/upload/chunk", fmRest.UploadChunk)
+		protected.GET("/group/file/upload/status", fmRest.GetUploadStatus)
+		protected.POST("/group/file/upload/completion", fmRest.CompleteUpload)
+		protected.GET("/group/file/versions", fmRest.RetrieveFileVersions)
+		protected.POST("/group/file/version/restoration", fmRest.RestoreFileVersion)
+		protected.POST("/group/file/share", fmRest.CreateShareLink)
+		protected.GET("/group/file/shares", fmRest.RetrieveShareLinks)
+		protected.DELETE("/group/file/share/revocation", fmRest.RevokeShareLink)
+		protected.GET("/group/trash", fmRest.RetrieveTrashedFiles)
+		protected.POST("/group/trash/restoration", fmRest.RestoreTrashedFile)
 	}
 	return r
 }
@@ -49,13 +78,36 @@ func createFormFile(filePath string) (*bytes.Buffer, string) {
 	return body, writer.FormDataContentType()
 }
 
+//auditEventMatcher - matches an audit event with the given action, made by the given actor (zero for anonymous events)
+type auditEventMatcher struct {
+	action  string
+	actorID uint
+}
+
+func (m auditEventMatcher) Matches(x interface{}) bool {
+	event, ok := x.(*models.AuditEvent)
+	if !ok || event.Action != m.action {
+		return false
+	} else if m.actorID == 0 {
+		return event.ActorID == nil
+	}
+	return event.ActorID != nil && *event.ActorID == m.actorID
+}
+
+func (m auditEventMatcher) String() string {
+	return fmt.Sprintf("is audit event [%s] of actor [%d]", m.action, m.actorID)
+}
+
 var _ = Describe("UamEndpoint", func() {
 	var (
-		router   *gin.Engine
-		recorder *httptest.ResponseRecorder
-		fmDAO    *dao_mocks.MockFmDAO
-		uamDAO   *dao_mocks.MockUamDAO
-		req      *http.Request
+		router      *gin.Engine
+		recorder    *httptest.ResponseRecorder
+		controller  *gomock.Controller
+		fmDAO       *dao_mocks.MockFmDAO
+		uamDAO      *dao_mocks.MockUamDAO
+		permissions *permission_mocks.MockService
+		shareSigner *auth_mocks.MockShareTokenSigner
+		req         *http.Request
 	)
 
 	const (
@@ -69,16 +121,24 @@ var _ = Describe("UamEndpoint", func() {
 		fileID    = 3
 	)
 
+	//contentPath - returns the path of a deduplicated content in the local storage
+	contentPath := func(content string) string {
+		checksum := sha256.Sum256([]byte(content))
+		return path.Join(groupsDir, storage.ContentKey(hex.EncodeToString(checksum[:])))
+	}
+
 	var (
 		inputFilePath  string
 		outputFilePath string
 	)
 
 	BeforeEach(func() {
-		controller := gomock.NewController(GinkgoT())
+		controller = gomock.NewController(GinkgoT())
 		uamDAO = dao_mocks.NewMockUamDAO(controller)
 		fmDAO = dao_mocks.NewMockFmDAO(controller)
-		fmRest := rest.NewFileManagementEndpointImpl(uamDAO, fmDAO, groupsDir)
+		permissions = permission_mocks.NewMockService(controller)
+		shareSigner = auth_mocks.NewMockShareTokenSigner(controller)
+		fmRest := rest.NewFileManagementEndpointImpl(uamDAO, fmDAO, storage.NewLocalBlobStore(groupsDir), permissions, shareSigner)
 
 		router = setupRouterFmEndpoint(fmRest, userID)
 		recorder = httptest.NewRecorder()
@@ -96,22 +156,19 @@ var _ = Describe("UamEndpoint", func() {
 
 		AfterEach(func() {
 			os.RemoveAll(path.Join(groupsDir, groupName))
+			os.RemoveAll(path.Join(groupsDir, "blobs"))
 		})
 
 		When("upload request is sent and authentication passes", func() {
 
 			Context("and 'file' key not used for the file attachment", func() {
 				BeforeEach(func() {
-					uamDAO.EXPECT().
-						GetGroup(gomock.Any()).
-						Times(0)
-
-					uamDAO.EXPECT().
-						MemberExists(gomock.Any(), gomock.Any()).
+					permissions.EXPECT().
+						AuthorizeFileAccess(gomock.Any(), gomock.Any(), gomock.Any()).
 						Times(0)
 
 					fmDAO.EXPECT().
-						AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
+						AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 						Times(0)
 
 					req, _ = http.NewRequest("POST", "/protected/group/file/upload", nil)
@@ -136,16 +193,12 @@ var _ = Describe("UamEndpoint", func() {
 
 				Context("and group_name is not specified as query param", func() {
 					BeforeEach(func() {
-						uamDAO.EXPECT().
-							GetGroup(gomock.Any()).
-							Times(0)
-
-						uamDAO.EXPECT().
-							MemberExists(gomock.Any(), gomock.Any()).
+						permissions.EXPECT().
+							AuthorizeFileAccess(gomock.Any(), gomock.Any(), gomock.Any()).
 							Times(0)
 
 						fmDAO.EXPECT().
-							AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
+							AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 							Times(0)
 
 						req, _ = http.NewRequest("POST", "/protected/group/file/upload", form)
@@ -166,18 +219,14 @@ var _ = Describe("UamEndpoint", func() {
 						req.Header.Set("Authorization", "Bearer sometoken")
 					})
 
-					Context("and query to get group fails fue to system failure", func() {
+					Context("and the authorization fails due to system failure", func() {
 						BeforeEach(func() {
-							uamDAO.EXPECT().
-								GetGroup(groupName).
-								Return(models.Group{}, myerr.NewServerError("test-error"))
-
-							uamDAO.EXPECT().
-								MemberExists(gomock.Any(), gomock.Any()).
-								Times(0)
+							permissions.EXPECT().
+								AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+								Return(models.Group{}, "", myerr.NewServerError("test-error"))
 
 							fmDAO.EXPECT().
-								AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
+								AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 								Times(0)
 						})
 
@@ -187,135 +236,139 @@ var _ = Describe("UamEndpoint", func() {
 						})
 					})
 
-					Context("and query to get group succeeds", func() {
-						Context("and group not found", func() {
+					Context("and the user isnt allowed to upload files", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+								Return(models.Group{}, "", myerr.NewClientError("test-error"))
+
+							fmDAO.EXPECT().
+								AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+								Times(0)
+						})
+
+						It("returns bad request response", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+						})
+					})
+
+					Context("and the user is allowed to upload files", func() {
+						var group models.Group
+						BeforeEach(func() {
+							group = models.Group{
+								Name:        groupName,
+								ID:          groupID,
+								Quota:       1000,
+								MaxFileSize: 100,
+							}
+						})
+
+						Context("and the request is bigger than the free space of the group", func() {
 							BeforeEach(func() {
-								uamDAO.EXPECT().
-									GetGroup(groupName).
-									Return(models.Group{}, myerr.NewClientError("test-error"))
+								ioutil.WriteFile(inputFilePath, make([]byte, 80), 0644)
+								form, contentType = createFormFile(inputFilePath)
+								req, _ = http.NewRequest("POST", fmt.Sprintf("/protected/group/file/upload?group_name=%s", groupName), form)
+								req.Header.Add("Content-Type", contentType)
+								req.ContentLength = 64<<10 + 100
+								group.MaxFileSize = 1 << 20
+
+								gomock.InOrder(
+									permissions.EXPECT().
+										AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+										Return(group, models.RoleContributor, nil),
 
-								uamDAO.EXPECT().
-									MemberExists(gomock.Any(), gomock.Any()).
+									uamDAO.EXPECT().
+										GetGroupUsage(uint(groupID)).
+										Return(int64(950), nil),
+								)
+
+								fmDAO.EXPECT().
+									AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 									Times(0)
+							})
+
+							It("rejects the upload before reading the file", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusBadRequest, "The file exceeds the quota of the group (950 of 1000 bytes are used)")
+							})
+						})
+
+						Context("and the file exceeds the maximum file size of the group", func() {
+							BeforeEach(func() {
+								ioutil.WriteFile(inputFilePath, make([]byte, 101), 0644)
+								form, contentType = createFormFile(inputFilePath)
+								req, _ = http.NewRequest("POST", fmt.Sprintf("/protected/group/file/upload?group_name=%s", groupName), form)
+								req.Header.Add("Content-Type", contentType)
+
+								gomock.InOrder(
+									permissions.EXPECT().
+										AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+										Return(group, models.RoleContributor, nil),
+
+									uamDAO.EXPECT().
+										GetGroupUsage(uint(groupID)).
+										Return(int64(0), nil),
+								)
 
 								fmDAO.EXPECT().
-									AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
+									AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 									Times(0)
 							})
 
-							It("returns bad request response", func() {
+							It("returns bad request error response", func() {
 								router.ServeHTTP(recorder, req)
-								assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+								assertErrorResponse(recorder, http.StatusBadRequest, "The file exceeds the maximum file size of the group (100 bytes)")
 							})
 						})
 
-						Context("and group is found", func() {
-							var group models.Group
+						Context("and query for adding file info fails", func() {
 							BeforeEach(func() {
-								group = models.Group{
-									Name: group.Name,
-									ID:   groupID,
-								}
+								gomock.InOrder(
+									permissions.EXPECT().
+										AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+										Return(group, models.RoleContributor, nil),
+
+									uamDAO.EXPECT().
+										GetGroupUsage(uint(groupID)).
+										Return(int64(0), nil),
+
+									fmDAO.EXPECT().
+										AddFileInfo(uint(userID), fileName, gomock.Any(), gomock.Any(), groupName, gomock.Any()).
+										Return(uint(fileID), false, myerr.NewServerError("test-error")),
+								)
+
+							})
+
+							It("returns internal server error", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
 							})
+						})
 
-							Context("and query to check if membership exists fails", func() {
-								BeforeEach(func() {
-									gomock.InOrder(
-										uamDAO.EXPECT().
-											GetGroup(groupName).
-											Return(group, nil),
+						Context("and file is uploaded successfully", func() {
+							BeforeEach(func() {
+								gomock.InOrder(
+									permissions.EXPECT().
+										AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+										Return(group, models.RoleContributor, nil),
 
-										uamDAO.EXPECT().
-											MemberExists(uint(userID), uint(groupID)).
-											Return(false, myerr.NewServerError("test-error")),
-									)
+									uamDAO.EXPECT().
+										GetGroupUsage(uint(groupID)).
+										Return(int64(0), nil),
 
 									fmDAO.EXPECT().
-										AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
-										Times(0)
-								})
-
-								It("returns internal server error", func() {
-									router.ServeHTTP(recorder, req)
-									assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
-								})
+										AddFileInfo(uint(userID), fileName, gomock.Any(), gomock.Any(), groupName, auditEventMatcher{action: models.AuditFileUploaded, actorID: userID}).
+										Return(uint(fileID), true, nil),
+								)
+
 							})
 
-							Context("and query to check if membership exists succeeds", func() {
-								Context("and membership doesnt exist", func() {
-									BeforeEach(func() {
-										gomock.InOrder(
-											uamDAO.EXPECT().
-												GetGroup(groupName).
-												Return(group, nil),
-
-											uamDAO.EXPECT().
-												MemberExists(uint(userID), uint(groupID)).
-												Return(false, nil),
-										)
-
-										fmDAO.EXPECT().
-											AddFileInfo(gomock.Any(), gomock.Any(), gomock.Any()).
-											Times(0)
-									})
-
-									It("returns bad request error response", func() {
-										router.ServeHTTP(recorder, req)
-										assertErrorResponse(recorder, http.StatusBadRequest, "Invalid user input")
-									})
-								})
-
-								Context("and membership exists", func() {
-									Context("and query for adding file info fails", func() {
-										BeforeEach(func() {
-											gomock.InOrder(
-												uamDAO.EXPECT().
-													GetGroup(groupName).
-													Return(group, nil),
-
-												uamDAO.EXPECT().
-													MemberExists(uint(userID), uint(groupID)).
-													Return(true, nil),
-
-												fmDAO.EXPECT().
-													AddFileInfo(uint(userID), fileName, groupName).
-													Return(uint(fileID), myerr.NewServerError("test-error")),
-											)
-
-										})
-
-										It("returns internal server error", func() {
-											router.ServeHTTP(recorder, req)
-											assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
-										})
-									})
-
-									Context("and file is uploaded successfully", func() {
-										BeforeEach(func() {
-											gomock.InOrder(
-												uamDAO.EXPECT().
-													GetGroup(groupName).
-													Return(group, nil),
-
-												uamDAO.EXPECT().
-													MemberExists(uint(userID), uint(groupID)).
-													Return(true, nil),
-
-												fmDAO.EXPECT().
-													AddFileInfo(uint(userID), fileName, groupName).
-													Return(uint(fileID), nil),
-											)
-
-										})
-
-										It("succeeds", func() {
-											router.ServeHTTP(recorder, req)
-											Expect(recorder.Code).To(Equal(http.StatusCreated))
-											_, err := os.Stat(outputFilePath)
-											Expect(err).To(BeNil())
-										})
-									})
-								})
+							It("saves the content by its checksum", func() {
+								router.ServeHTTP(recorder, req)
+								Expect(recorder.Code).To(Equal(http.StatusCreated))
+								_, err := os.Stat(contentPath(""))
+								Expect(err).To(BeNil())
 							})
 						})
 
@@ -325,4 +378,1471 @@ var _ = Describe("UamEndpoint", func() {
 
 		})
 	})
+
+	Context("DownloadFile", func() {
+		const content = "0123456789"
+
+		var (
+			url      string
+			group    models.Group
+			fileInfo models.FileInfo
+		)
+
+		BeforeEach(func() {
+			os.Mkdir(path.Join(groupsDir, groupName), 0777)
+			ioutil.WriteFile(outputFilePath, []byte(content), 0644)
+
+			url = fmt.Sprintf("/protected/group/file/download?group_name=%s&file_id=%d", groupName, fileID)
+			group = models.Group{ID: groupID, Name: groupName}
+			fileInfo = models.FileInfo{
+				ID:        fileID,
+				Name:      fileName,
+				GroupID:   groupID,
+				OwnerID:   userID,
+				ETag:      "test-etag",
+				CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path.Join(groupsDir, groupName))
+			controller.Finish()
+		})
+
+		expectFileLookup := func(info models.FileInfo) {
+			gomock.InOrder(
+				permissions.EXPECT().
+					AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+					Return(group, models.RoleContributor, nil),
+
+				fmDAO.EXPECT().
+					GetFileInfo(uint(userID), uint(fileID), groupName).
+					Return(info, nil),
+			)
+		}
+
+		expectDownloadRecorded := func() {
+			uamDAO.EXPECT().
+				CreateAuditEvent(auditEventMatcher{action: models.AuditFileDownloaded, actorID: userID}).
+				Return(nil)
+		}
+
+		When("download request is sent", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", url, nil)
+			})
+
+			Context("and the file belongs to another group", func() {
+				BeforeEach(func() {
+					fileInfo.GroupID = groupID + 1
+					expectFileLookup(fileInfo)
+				})
+
+				It("returns not found error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusNotFound, "File does not exist")
+				})
+			})
+
+			Context("and the download cannot be recorded", func() {
+				BeforeEach(func() {
+					expectFileLookup(fileInfo)
+
+					uamDAO.EXPECT().
+						CreateAuditEvent(gomock.Any()).
+						Return(myerr.NewServerError("some error"))
+				})
+
+				It("still returns the file, which is already sent", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(Equal(content))
+				})
+			})
+
+			Context("and the content of the file is missing", func() {
+				BeforeEach(func() {
+					os.Remove(outputFilePath)
+					expectFileLookup(fileInfo)
+
+					uamDAO.EXPECT().
+						CreateAuditEvent(gomock.Any()).
+						Times(0)
+				})
+
+				It("returns not found error response without recording a download", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusNotFound))
+				})
+			})
+
+			Context("and the file exists", func() {
+				BeforeEach(func() {
+					expectFileLookup(fileInfo)
+					expectDownloadRecorded()
+				})
+
+				It("returns the whole file with its etag", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(Equal(content))
+					Expect(recorder.Header().Get("ETag")).To(Equal("\"test-etag\""))
+					Expect(recorder.Header().Get("Last-Modified")).To(Equal("Fri, 01 Jan 2021 00:00:00 GMT"))
+					Expect(recorder.Header().Get("Content-Disposition")).To(Equal("attachment; filename=test"))
+				})
+			})
+
+			Context("and the file content is deduplicated", func() {
+				BeforeEach(func() {
+					os.MkdirAll(path.Dir(contentPath("shared")), 0777)
+					ioutil.WriteFile(contentPath("shared"), []byte("shared"), 0644)
+
+					checksum := sha256.Sum256([]byte("shared"))
+					fileInfo.ETag = hex.EncodeToString(checksum[:])
+					fileInfo.BlobID = 1
+					expectFileLookup(fileInfo)
+					expectDownloadRecorded()
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(path.Join(groupsDir, "blobs"))
+				})
+
+				It("returns the content, referenced by the file", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(Equal("shared"))
+				})
+			})
+
+			Context("and the file was uploaded without etag", func() {
+				BeforeEach(func() {
+					fileInfo.ETag = ""
+					expectFileLookup(fileInfo)
+					expectDownloadRecorded()
+				})
+
+				It("returns a weak etag", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Header().Get("ETag")).To(HavePrefix("W/"))
+				})
+			})
+		})
+
+		When("range download request is sent", func() {
+			BeforeEach(func() {
+				expectFileLookup(fileInfo)
+				expectDownloadRecorded()
+				req, _ = http.NewRequest("GET", url, nil)
+				req.Header.Set("Range", "bytes=4-")
+			})
+
+			It("returns only the requested part of the file", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+				Expect(recorder.Body.String()).To(Equal(content[4:]))
+				Expect(recorder.Header().Get("Content-Range")).To(Equal("bytes 4-9/10"))
+			})
+
+			Context("and the file has changed since the client fetched it", func() {
+				BeforeEach(func() {
+					req.Header.Set("If-Range", "\"old-etag\"")
+				})
+
+				It("returns the whole file", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(Equal(content))
+				})
+			})
+		})
+
+		When("conditional download request is sent", func() {
+			BeforeEach(func() {
+				expectFileLookup(fileInfo)
+				req, _ = http.NewRequest("GET", url, nil)
+				req.Header.Set("If-None-Match", "\"test-etag\"")
+			})
+
+			It("returns not modified response without recording a download", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusNotModified))
+				Expect(recorder.Body.Len()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("DeleteFile", func() {
+		group := models.Group{ID: groupID, Name: groupName}
+		fileInfo := models.FileInfo{ID: fileID, Name: fileName, GroupID: groupID, OwnerID: userID + 1}
+
+		BeforeEach(func() {
+			rqBody := common.FileRequestPayload{FileID: fileID}
+			rqBody.GroupName = groupName
+			jsonBody, _ := json.Marshal(rqBody)
+			req, _ = http.NewRequest("DELETE", "/protected/group/file/delete", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			os.MkdirAll(path.Dir(contentPath("content")), 0777)
+			ioutil.WriteFile(contentPath("content"), []byte("content"), 0644)
+
+			permissions.EXPECT().
+				AuthorizeFileAccess(uint(userID), groupName, permission.DeleteOwnFiles).
+				Return(group, models.RoleContributor, nil)
+
+			fmDAO.EXPECT().
+				GetFileInfo(uint(userID), uint(fileID), groupName).
+				Return(fileInfo, nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path.Join(groupsDir, "blobs"))
+		})
+
+		When("the user isnt allowed to delete the file", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.DeleteOwnFiles).
+					Return(myerr.NewClientError("test-error"))
+
+				fmDAO.EXPECT().
+					TrashFile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			})
+
+			It("returns bad request error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+				_, err := os.Stat(contentPath("content"))
+				Expect(err).To(BeNil())
+			})
+		})
+
+		When("the file cannot be moved to the trash", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.DeleteOwnFiles).
+					Return(nil)
+
+				fmDAO.EXPECT().
+					TrashFile(uint(userID), uint(fileID), groupName, gomock.Any()).
+					Return(myerr.NewItemNotFoundError("test-error"))
+			})
+
+			It("returns not found error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusNotFound, "test-error")
+			})
+		})
+
+		When("the file is moved to the trash", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.DeleteOwnFiles).
+					Return(nil)
+
+				fmDAO.EXPECT().
+					TrashFile(uint(userID), uint(fileID), groupName, auditEventMatcher{action: models.AuditFileDeleted, actorID: userID}).
+					Return(nil)
+			})
+
+			It("keeps the content", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				_, err := os.Stat(contentPath("content"))
+				Expect(err).To(BeNil())
+			})
+		})
+	})
+
+	Context("SetFileTags", func() {
+		group := models.Group{ID: groupID, Name: groupName}
+		fileInfo := models.FileInfo{ID: fileID, Name: fileName, GroupID: groupID, OwnerID: userID}
+
+		sendRequest := func(tags []string) {
+			rqBody := common.FileTagsPayload{Tags: tags}
+			rqBody.GroupName = groupName
+			rqBody.FileID = fileID
+			jsonBody, _ := json.Marshal(rqBody)
+			req, _ = http.NewRequest("PUT", "/protected/group/file/tags", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("a tag is invalid", func() {
+			It("returns bad request error response", func() {
+				sendRequest([]string{"reports", "not valid"})
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid tag [not valid]")
+			})
+		})
+
+		When("there are too many tags", func() {
+			It("returns bad request error response", func() {
+				tags := make([]string, 0)
+				for i := 0; i <= 20; i++ {
+					tags = append(tags, fmt.Sprintf("tag%d", i))
+				}
+				sendRequest(tags)
+				assertErrorResponse(recorder, http.StatusBadRequest, "A file can have at most 20 tags")
+			})
+		})
+
+		When("the user isnt allowed to change the file", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.TagFiles).
+						Return(group, models.RoleContributor, nil),
+
+					fmDAO.EXPECT().
+						GetFileInfo(uint(userID), uint(fileID), groupName).
+						Return(fileInfo, nil),
+
+					permissions.EXPECT().
+						AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.TagFiles).
+						Return(myerr.NewClientError("test-error")),
+				)
+
+				fmDAO.EXPECT().
+					SetFileTags(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			})
+
+			It("returns bad request error response", func() {
+				sendRequest([]string{"reports"})
+				assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+			})
+		})
+
+		When("the user is allowed to change the file", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.TagFiles).
+						Return(group, models.RoleContributor, nil),
+
+					fmDAO.EXPECT().
+						GetFileInfo(uint(userID), uint(fileID), groupName).
+						Return(fileInfo, nil),
+
+					permissions.EXPECT().
+						AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.TagFiles).
+						Return(nil),
+
+					fmDAO.EXPECT().
+						SetFileTags(uint(fileID), groupName, []string{"reports", "2021"}, auditEventMatcher{action: models.AuditFileTagged, actorID: userID}).
+						Return(nil),
+				)
+			})
+
+			It("saves the normalized tags", func() {
+				sendRequest([]string{" Reports", "2021", "reports", ""})
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("RetrieveAllFilesInfo", func() {
+		When("the limit isnt a number", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/group/files?group_name="+groupName+"&limit=all", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The limit should be between 1 and 500")
+			})
+		})
+
+		When("there are more files than the limit", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+						Return(models.Group{ID: groupID}, models.RoleViewer, nil),
+
+					fmDAO.EXPECT().
+						GetAllFilesInfo(uint(userID), groupName, dao.ListOptions{Limit: 1, SortBy: "size", Descending: true, Query: "report"}).
+						Return([]models.FileInfo{{ID: fileID, Name: "report", GroupID: groupID, Size: 10}}, "next", nil),
+				)
+			})
+
+			It("returns the page and the next cursor", func() {
+				req, _ = http.NewRequest("GET", "/protected/group/files?group_name="+groupName+"&limit=1&sort=size&order=desc&q=report", nil)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				var body struct {
+					Files      []common.FileInfoResponse `json:"files"`
+					NextCursor string                    `json:"next_cursor"`
+				}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Files).To(HaveLen(1))
+				Expect(body.NextCursor).To(Equal("next"))
+			})
+		})
+	})
+
+	Context("File versions", func() {
+		const restoredFileID = fileID + 1
+
+		group := models.Group{ID: groupID, Name: groupName}
+		fileInfo := models.FileInfo{ID: fileID, Name: fileName, GroupID: groupID, OwnerID: userID}
+
+		BeforeEach(func() {
+			os.Mkdir(path.Join(groupsDir, groupName), 0777)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path.Join(groupsDir, groupName))
+		})
+
+		When("request for the versions of a file is sent", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", fmt.Sprintf("/protected/group/file/versions?group_name=%s&file_id=%d", groupName, fileID), nil)
+			})
+
+			Context("and the user isnt a member of the group", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+						Return(models.Group{}, "", myerr.NewClientError("test-error"))
+
+					fmDAO.EXPECT().
+						GetFileVersions(gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+				})
+			})
+
+			Context("and the versions are fetched", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+						Return(group, models.RoleViewer, nil)
+
+					fmDAO.EXPECT().
+						GetFileVersions(uint(userID), uint(fileID), groupName).
+						Return([]models.FileInfo{
+							{ID: restoredFileID, Name: fileName, Version: 2},
+							{ID: fileID, Name: fileName, Version: 1},
+						}, nil)
+				})
+
+				It("returns all versions", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					body := struct {
+						Files []common.FileInfoResponse `json:"files"`
+					}{}
+					json.Unmarshal(recorder.Body.Bytes(), &body)
+					Expect(body.Files).To(HaveLen(2))
+					Expect(body.Files[0].Version).To(Equal(uint(2)))
+					Expect(body.Files[1].ID).To(Equal(uint(fileID)))
+				})
+			})
+		})
+
+		When("request for restoring a file version is sent", func() {
+			BeforeEach(func() {
+				rqBody := common.FileRequestPayload{FileID: fileID}
+				rqBody.GroupName = groupName
+				jsonBody, _ := json.Marshal(rqBody)
+				req, _ = http.NewRequest("POST", "/protected/group/file/version/restoration", bytes.NewBuffer(jsonBody))
+				req.Header.Set("Content-Type", "application/json")
+
+				permissions.EXPECT().
+					AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+					Return(group, models.RoleContributor, nil)
+
+				fmDAO.EXPECT().
+					GetFileInfo(uint(userID), uint(fileID), groupName).
+					Return(fileInfo, nil)
+			})
+
+			Context("and the user isnt allowed to restore it", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.UploadFiles).
+						Return(myerr.NewClientError("test-error"))
+
+					fmDAO.EXPECT().
+						RestoreFileVersion(gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+				})
+			})
+
+			Context("and the restored version shares the content of the original one", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.UploadFiles).
+						Return(nil)
+
+					fmDAO.EXPECT().
+						RestoreFileVersion(uint(fileID), groupName, gomock.Any()).
+						Return(models.FileInfo{ID: restoredFileID, Name: fileName, Version: 3, BlobID: 1}, nil)
+				})
+
+				It("doesnt copy the content", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+
+					_, err := os.Stat(path.Join(groupsDir, groupName, fmt.Sprint(restoredFileID)))
+					Expect(os.IsNotExist(err)).To(BeTrue())
+				})
+			})
+
+			Context("and the version, uploaded before the deduplication, is restored", func() {
+				BeforeEach(func() {
+					ioutil.WriteFile(outputFilePath, []byte("content"), 0644)
+
+					permissions.EXPECT().
+						AuthorizeFileChange(uint(userID), models.RoleContributor, fileInfo, permission.UploadFiles).
+						Return(nil)
+
+					fmDAO.EXPECT().
+						RestoreFileVersion(uint(fileID), groupName, gomock.Any()).
+						Return(models.FileInfo{ID: restoredFileID, Name: fileName, Version: 3}, nil)
+				})
+
+				It("saves the content as the new version", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+
+					content, err := ioutil.ReadFile(path.Join(groupsDir, groupName, fmt.Sprint(restoredFileID)))
+					Expect(err).To(BeNil())
+					Expect(string(content)).To(Equal("content"))
+				})
+			})
+		})
+	})
+
+	Context("Trash", func() {
+		group := models.Group{ID: groupID, Name: groupName}
+
+		When("request for the trash of a group is sent", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", fmt.Sprintf("/protected/group/trash?group_name=%s", groupName), nil)
+			})
+
+			Context("and the user isnt the owner of the group", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ManageTrash).
+						Return(models.Group{}, "", myerr.NewClientError("test-error"))
+
+					fmDAO.EXPECT().
+						GetTrashedFiles(gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+				})
+			})
+
+			Context("and the trash is fetched", func() {
+				trashedAt := time.Now()
+				trashedBy := uint(userID + 1)
+
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ManageTrash).
+						Return(group, models.RoleOwner, nil)
+
+					fmDAO.EXPECT().
+						GetTrashedFiles(groupName).
+						Return([]models.FileInfo{
+							{ID: fileID, Name: fileName, Version: 2, Size: 7, TrashedAt: &trashedAt, TrashedBy: &trashedBy},
+						}, nil)
+				})
+
+				It("returns the deleted files", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					body := struct {
+						Files []common.TrashedFileInfo `json:"files"`
+					}{}
+					json.Unmarshal(recorder.Body.Bytes(), &body)
+					Expect(body.Files).To(HaveLen(1))
+					Expect(body.Files[0].ID).To(Equal(uint(fileID)))
+					Expect(body.Files[0].Size).To(Equal(int64(7)))
+					Expect(body.Files[0].DeletedBy).To(Equal(trashedBy))
+					Expect(body.Files[0].DeletedAt).To(BeTemporally("~", trashedAt, time.Second))
+				})
+			})
+		})
+
+		When("request for restoring a deleted file is sent", func() {
+			BeforeEach(func() {
+				rqBody := common.FileRequestPayload{FileID: fileID}
+				rqBody.GroupName = groupName
+				jsonBody, _ := json.Marshal(rqBody)
+				req, _ = http.NewRequest("POST", "/protected/group/trash/restoration", bytes.NewBuffer(jsonBody))
+				req.Header.Set("Content-Type", "application/json")
+			})
+
+			Context("and the user isnt the owner of the group", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ManageTrash).
+						Return(models.Group{}, "", myerr.NewClientError("test-error"))
+
+					fmDAO.EXPECT().
+						RestoreTrashedFile(gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+				})
+			})
+
+			Context("and the file isnt in the trash", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ManageTrash).
+						Return(group, models.RoleOwner, nil)
+
+					fmDAO.EXPECT().
+						RestoreTrashedFile(uint(fileID), groupName, gomock.Any()).
+						Return(models.FileInfo{}, myerr.NewItemNotFoundError("test-error"))
+				})
+
+				It("returns not found error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusNotFound, "test-error")
+				})
+			})
+
+			Context("and the file is restored", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ManageTrash).
+						Return(group, models.RoleOwner, nil)
+
+					fmDAO.EXPECT().
+						RestoreTrashedFile(uint(fileID), groupName, auditEventMatcher{action: models.AuditFileRecovered, actorID: userID}).
+						Return(models.FileInfo{ID: fileID, Name: fileName, Version: 2}, nil)
+				})
+
+				It("returns the restored file", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					body := struct {
+						FileID  uint `json:"file_id"`
+						Version uint `json:"version"`
+					}{}
+					json.Unmarshal(recorder.Body.Bytes(), &body)
+					Expect(body.FileID).To(Equal(uint(fileID)))
+					Expect(body.Version).To(Equal(uint(2)))
+				})
+			})
+		})
+	})
+
+	Context("Chunked upload", func() {
+		const (
+			uploadID  = "test-upload"
+			sessionID = 4
+			fileSize  = 10
+		)
+
+		var (
+			uploadDirPath string
+			session       models.UploadSession
+		)
+
+		BeforeEach(func() {
+			uploadDirPath = path.Join(groupsDir, groupName, "uploads", uploadID)
+			os.MkdirAll(uploadDirPath, 0777)
+
+			session = models.UploadSession{
+				ID:       sessionID,
+				UploadID: uploadID,
+				FileName: fileName,
+				Size:     fileSize,
+				OwnerID:  userID,
+				GroupID:  groupID,
+			}
+
+			permissions.EXPECT().
+				AuthorizeFileAccess(uint(userID), groupName, permission.UploadFiles).
+				Return(models.Group{ID: groupID, Name: groupName}, models.RoleContributor, nil).
+				AnyTimes()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(path.Join(groupsDir, groupName))
+		})
+
+		When("start upload request is sent", func() {
+			Context("with non-json body", func() {
+				BeforeEach(func() {
+					fmDAO.EXPECT().
+						CreateUploadSession(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+
+					req, _ = http.NewRequest("POST", "/protected/group/file/upload/session", bytes.NewBufferString("test"))
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+				})
+			})
+
+			Context("with valid body", func() {
+				BeforeEach(func() {
+					rqBody := common.UploadSessionPayload{FileName: fileName, Size: fileSize}
+					rqBody.GroupName = groupName
+					jsonBody, _ := json.Marshal(rqBody)
+					req, _ = http.NewRequest("POST", "/protected/group/file/upload/session", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Content-Type", "application/json")
+				})
+
+				Context("and the user isnt a member of the group", func() {
+					BeforeEach(func() {
+						fmDAO.EXPECT().
+							CreateUploadSession(uint(userID), gomock.Any(), fileName, int64(fileSize), groupName, gomock.Any()).
+							Return(uint(0), myerr.NewClientError("test-error"))
+					})
+
+					It("returns bad request error response", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+					})
+				})
+
+				Context("and the session is created", func() {
+					var sessionExpiresAt time.Time
+
+					BeforeEach(func() {
+						fmDAO.EXPECT().
+							CreateUploadSession(uint(userID), gomock.Any(), fileName, int64(fileSize), groupName, gomock.Any()).
+							DoAndReturn(func(userID uint, uploadID, fileName string, size int64, groupName string, expiresAt time.Time) (uint, error) {
+								sessionExpiresAt = expiresAt
+								return uint(sessionID), nil
+							})
+					})
+
+					It("returns the upload id and when the upload expires", func() {
+						router.ServeHTTP(recorder, req)
+						Expect(recorder.Code).To(Equal(http.StatusCreated))
+						Expect(sessionExpiresAt).To(BeTemporally("~", time.Now().Add(24*time.Hour), time.Minute))
+
+						body := common.UploadSessionResponse{}
+						json.Unmarshal(recorder.Body.Bytes(), &body)
+						Expect(body.UploadID).NotTo(BeEmpty())
+						Expect(body.ChunkSize).To(BeNumerically(">", 0))
+						Expect(body.ExpiresAt).To(BeTemporally("==", sessionExpiresAt))
+					})
+				})
+			})
+		})
+
+		When("chunk upload request is sent", func() {
+			Context("and the upload session doesnt exist", func() {
+				BeforeEach(func() {
+					fmDAO.EXPECT().
+						GetUploadSession(uint(userID), uploadID, groupName).
+						Return(models.UploadSession{}, myerr.NewItemNotFoundError("test-error"))
+
+					fmDAO.EXPECT().
+						AddUploadChunk(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+
+					url := fmt.Sprintf("/protected/group/file/upload/chunk?group_name=%s&upload_id=%s&chunk=0&offset=0", groupName, uploadID)
+					req, _ = http.NewRequest("PUT", url, bytes.NewBufferString("data"))
+				})
+
+				It("returns not found error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusNotFound, "test-error")
+				})
+			})
+
+			Context("and the chunk exceeds the size of the file", func() {
+				BeforeEach(func() {
+					fmDAO.EXPECT().
+						GetUploadSession(uint(userID), uploadID, groupName).
+						Return(session, nil)
+
+					fmDAO.EXPECT().
+						AddUploadChunk(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+
+					url := fmt.Sprintf("/protected/group/file/upload/chunk?group_name=%s&upload_id=%s&chunk=1&offset=8", groupName, uploadID)
+					req, _ = http.NewRequest("PUT", url, bytes.NewBufferString("data"))
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Chunk exceeds the size of the file")
+				})
+			})
+
+			Context("and the chunk is valid", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						fmDAO.EXPECT().
+							GetUploadSession(uint(userID), uploadID, groupName).
+							Return(session, nil),
+
+						fmDAO.EXPECT().
+							AddUploadChunk(uint(sessionID), uint(1), int64(4), int64(4)).
+							Return(nil),
+					)
+
+					url := fmt.Sprintf("/protected/group/file/upload/chunk?group_name=%s&upload_id=%s&chunk=1&offset=4", groupName, uploadID)
+					req, _ = http.NewRequest("PUT", url, bytes.NewBufferString("data"))
+				})
+
+				It("stores the chunk", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					content, err := ioutil.ReadFile(path.Join(uploadDirPath, "1"))
+					Expect(err).To(BeNil())
+					Expect(content).To(Equal([]byte("data")))
+				})
+			})
+		})
+
+		When("upload status request is sent", func() {
+			BeforeEach(func() {
+				chunks := []models.UploadChunk{
+					{Number: 0, Offset: 0, Size: 2},
+					{Number: 1, Offset: 2, Size: 2},
+					{Number: 3, Offset: 6, Size: 2},
+				}
+
+				gomock.InOrder(
+					fmDAO.EXPECT().
+						GetUploadSession(uint(userID), uploadID, groupName).
+						Return(session, nil),
+
+					fmDAO.EXPECT().
+						GetUploadChunks(uint(sessionID)).
+						Return(chunks, nil),
+				)
+
+				url := fmt.Sprintf("/protected/group/file/upload/status?group_name=%s&upload_id=%s", groupName, uploadID)
+				req, _ = http.NewRequest("GET", url, nil)
+			})
+
+			It("returns the merged received ranges", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				body := common.UploadStatusResponse{}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Size).To(Equal(int64(fileSize)))
+				Expect(body.Ranges).To(Equal([]common.ByteRange{
+					{Offset: 0, Size: 4},
+					{Offset: 6, Size: 2},
+				}))
+			})
+		})
+
+		When("upload completion request is sent", func() {
+			BeforeEach(func() {
+				rqBody := common.UploadPayload{UploadID: uploadID}
+				rqBody.GroupName = groupName
+				jsonBody, _ := json.Marshal(rqBody)
+				req, _ = http.NewRequest("POST", "/protected/group/file/upload/completion", bytes.NewBuffer(jsonBody))
+				req.Header.Set("Content-Type", "application/json")
+			})
+
+			Context("and there are missing chunks", func() {
+				BeforeEach(func() {
+					gomock.InOrder(
+						fmDAO.EXPECT().
+							GetUploadSession(uint(userID), uploadID, groupName).
+							Return(session, nil),
+
+						fmDAO.EXPECT().
+							GetUploadChunks(uint(sessionID)).
+							Return([]models.UploadChunk{{Offset: 0, Size: 4}}, nil),
+					)
+
+					fmDAO.EXPECT().
+						AddUploadedFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Not all chunks of the file were uploaded")
+				})
+			})
+
+			Context("and all chunks are uploaded", func() {
+				BeforeEach(func() {
+					ioutil.WriteFile(path.Join(uploadDirPath, "0"), []byte("conten"), 0644)
+					ioutil.WriteFile(path.Join(uploadDirPath, "1"), []byte("ntent"), 0644)
+
+					gomock.InOrder(
+						fmDAO.EXPECT().
+							GetUploadSession(uint(userID), uploadID, groupName).
+							Return(session, nil),
+
+						fmDAO.EXPECT().
+							GetUploadChunks(uint(sessionID)).
+							Return([]models.UploadChunk{{Number: 0, Offset: 0, Size: 6}, {Number: 1, Offset: 5, Size: 5}}, nil),
+
+						fmDAO.EXPECT().
+							AddUploadedFileInfo(uint(userID), session, gomock.Any(), groupName, gomock.Any()).
+							Return(uint(fileID), true, nil),
+
+						fmDAO.EXPECT().
+							RemoveUploadSession(uint(sessionID)).
+							Return(nil),
+					)
+				})
+
+				AfterEach(func() {
+					os.RemoveAll(path.Join(groupsDir, "blobs"))
+				})
+
+				It("joins the chunks and removes them", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+
+					content, err := ioutil.ReadFile(contentPath("contentent"))
+					Expect(err).To(BeNil())
+					Expect(content).To(Equal([]byte("contentent")))
+					_, err = os.Stat(uploadDirPath)
+					Expect(os.IsNotExist(err)).To(BeTrue())
+				})
+			})
+
+			Context("and a chunk cannot be read", func() {
+				var controller *gomock.Controller
+
+				BeforeEach(func() {
+					controller = gomock.NewController(GinkgoT())
+					blobStore := storage_mocks.NewMockBlobStore(controller)
+					blob := storage_mocks.NewMockBlob(controller)
+					router = setupRouterFmEndpoint(rest.NewFileManagementEndpointImpl(uamDAO, fmDAO, blobStore, permissions, shareSigner), userID)
+
+					gomock.InOrder(
+						fmDAO.EXPECT().
+							GetUploadSession(uint(userID), uploadID, groupName).
+							Return(session, nil),
+
+						fmDAO.EXPECT().
+							GetUploadChunks(uint(sessionID)).
+							Return([]models.UploadChunk{{Number: 0, Offset: 0, Size: fileSize}}, nil),
+
+						blobStore.EXPECT().
+							Get(storage.UploadKeyPrefix(groupName, uploadID)+"0").
+							Return(blob, nil),
+
+						blob.EXPECT().
+							Seek(int64(0), io.SeekStart).
+							Return(int64(0), nil),
+
+						blob.EXPECT().
+							Read(gomock.Any()).
+							Return(0, fmt.Errorf("test-error")),
+
+						blob.EXPECT().
+							Close().
+							Return(nil),
+					)
+
+					fmDAO.EXPECT().
+						AddUploadedFileInfo(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("closes the chunk and returns internal server error", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
+					controller.Finish()
+				})
+			})
+		})
+	})
+
+	Context("CreateShareLink", func() {
+		var group models.Group
+
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("POST", "/protected/group/file/share", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		BeforeEach(func() {
+			group = models.Group{ID: groupID, Name: groupName}
+		})
+
+		When("the expiry time is too long", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					AuthorizeFileAccess(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","file_id":3,"expires_in_hours":1000}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The share link cannot be valid for more than 720 hours")
+			})
+		})
+
+		When("the file belongs to another group", func() {
+			It("returns not found", func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+						Return(group, models.RoleViewer, nil),
+
+					fmDAO.EXPECT().
+						GetFileInfo(uint(userID), uint(fileID), groupName).
+						Return(models.FileInfo{ID: fileID, GroupID: groupID + 1}, nil),
+				)
+
+				fmDAO.EXPECT().
+					CreateShareLink(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","file_id":3}`)
+				assertErrorResponse(recorder, http.StatusNotFound, "File does not exist")
+			})
+		})
+
+		When("the file exists in the group", func() {
+			It("returns the token of the link", func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+						Return(group, models.RoleViewer, nil),
+
+					fmDAO.EXPECT().
+						GetFileInfo(uint(userID), uint(fileID), groupName).
+						Return(models.FileInfo{ID: fileID, GroupID: groupID}, nil),
+
+					fmDAO.EXPECT().
+						CreateShareLink(uint(userID), uint(fileID), gomock.Any(), uint(5), auditEventMatcher{action: models.AuditShareLinkCreated, actorID: userID}).
+						Return(uint(7), nil),
+
+					shareSigner.EXPECT().
+						GenerateShareToken(uint(7), gomock.Any()).
+						Return("token", nil),
+				)
+
+				sendRequest(`{"group_name":"groupName","file_id":3,"max_uses":5}`)
+				Expect(recorder.Code).To(Equal(http.StatusCreated))
+				body := common.ShareLinkResponse{}
+				json.Unmarshal([]byte(recorder.Body.String()), &body)
+				Expect(body.ID).To(Equal(uint(7)))
+				Expect(body.Token).To(Equal("token"))
+				Expect(body.Path).To(Equal("/v1/public/share/token"))
+				Expect(body.ExpiresAt).To(BeTemporally("~", time.Now().Add(24*time.Hour), time.Minute))
+			})
+		})
+	})
+
+	Context("RetrieveShareLinks", func() {
+		It("returns the active links of the file", func() {
+			gomock.InOrder(
+				permissions.EXPECT().
+					AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+					Return(models.Group{ID: groupID}, models.RoleViewer, nil),
+
+				fmDAO.EXPECT().
+					GetFileInfo(uint(userID), uint(fileID), groupName).
+					Return(models.FileInfo{ID: fileID, GroupID: groupID}, nil),
+
+				fmDAO.EXPECT().
+					GetActiveShareLinks(uint(fileID)).
+					Return([]models.ShareLink{{ID: 7, FileID: fileID, CreatorID: userID, Uses: 1, MaxUses: 5}}, nil),
+			)
+
+			req, _ = http.NewRequest("GET", fmt.Sprintf("/protected/group/file/shares?group_name=%s&file_id=%d", groupName, fileID), nil)
+			router.ServeHTTP(recorder, req)
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Shares []common.ShareLinkInfo `json:"shares"`
+			}
+			json.Unmarshal([]byte(recorder.Body.String()), &body)
+			Expect(body.Shares).To(HaveLen(1))
+			Expect(body.Shares[0].ID).To(Equal(uint(7)))
+			Expect(body.Shares[0].Uses).To(Equal(uint(1)))
+			Expect(body.Shares[0].MaxUses).To(Equal(uint(5)))
+		})
+	})
+
+	Context("RevokeShareLink", func() {
+		sendRequest := func() {
+			req, _ = http.NewRequest("DELETE", "/protected/group/file/share/revocation", strings.NewReader(`{"group_name":"groupName","share_id":7}`))
+			router.ServeHTTP(recorder, req)
+		}
+
+		expectLinkLookup := func(role string, creatorID uint) {
+			gomock.InOrder(
+				permissions.EXPECT().
+					AuthorizeFileAccess(uint(userID), groupName, permission.ViewGroup).
+					Return(models.Group{ID: groupID}, role, nil),
+
+				fmDAO.EXPECT().
+					GetShareLink(uint(7)).
+					Return(models.ShareLink{ID: 7, FileID: fileID, CreatorID: creatorID}, nil),
+
+				fmDAO.EXPECT().
+					GetFileInfo(uint(userID), uint(fileID), groupName).
+					Return(models.FileInfo{ID: fileID, GroupID: groupID}, nil),
+			)
+		}
+
+		When("the link was created by another member", func() {
+			Context("and the user isnt allowed to manage the files", func() {
+				It("returns bad request", func() {
+					expectLinkLookup(models.RoleContributor, userID+1)
+					fmDAO.EXPECT().
+						RevokeShareLink(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					sendRequest()
+					assertErrorResponse(recorder, http.StatusBadRequest, "doesnt allow you to revoke the share links of others")
+				})
+			})
+
+			Context("and the user is allowed to manage the files", func() {
+				It("revokes the link", func() {
+					expectLinkLookup(models.RoleAdmin, userID+1)
+					fmDAO.EXPECT().
+						RevokeShareLink(uint(7), gomock.Any()).
+						Return(nil)
+
+					sendRequest()
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+
+		When("the link was created by the user", func() {
+			It("revokes the link", func() {
+				expectLinkLookup(models.RoleViewer, userID)
+				fmDAO.EXPECT().
+					RevokeShareLink(uint(7), auditEventMatcher{action: models.AuditShareLinkRevoked, actorID: userID}).
+					Return(nil)
+
+				sendRequest()
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("DownloadSharedFile", func() {
+		const content = "shared content"
+		sharedFile := models.FileInfo{ID: fileID, Name: fileName, GroupID: groupID, ETag: "test-etag"}
+
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/public/share/token", nil)
+		})
+
+		AfterEach(func() {
+			controller.Finish()
+		})
+
+		When("the token is invalid", func() {
+			It("returns bad request", func() {
+				shareSigner.EXPECT().
+					ValidateShareToken("token").
+					Return(uint(0), myerr.NewClientError("Invalid or expired share link"))
+
+				fmDAO.EXPECT().
+					GetSharedFile(gomock.Any()).
+					Times(0)
+
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid or expired share link")
+			})
+		})
+
+		When("the link is used up", func() {
+			It("returns not found", func() {
+				shareSigner.EXPECT().
+					ValidateShareToken("token").
+					Return(uint(7), nil)
+
+				fmDAO.EXPECT().
+					GetSharedFile(uint(7)).
+					Return(models.FileInfo{}, "", myerr.NewItemNotFoundError("The share link is revoked, expired or used up"))
+
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusNotFound, "The share link is revoked, expired or used up")
+			})
+		})
+
+		When("the content of the file cannot be opened", func() {
+			It("returns an error without counting a use", func() {
+				shareSigner.EXPECT().
+					ValidateShareToken("token").
+					Return(uint(7), nil)
+
+				fmDAO.EXPECT().
+					GetSharedFile(uint(7)).
+					Return(sharedFile, groupName, nil)
+
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		When("the link is active", func() {
+			BeforeEach(func() {
+				os.Mkdir(path.Join(groupsDir, groupName), 0777)
+				ioutil.WriteFile(outputFilePath, []byte(content), 0644)
+
+				shareSigner.EXPECT().
+					ValidateShareToken("token").
+					Return(uint(7), nil)
+
+				fmDAO.EXPECT().
+					GetSharedFile(uint(7)).
+					Return(sharedFile, groupName, nil)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(path.Join(groupsDir, groupName))
+			})
+
+			It("returns the file and counts the use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), auditEventMatcher{action: models.AuditFileDownloaded}).
+					Return(nil)
+
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(Equal(content))
+				Expect(recorder.Header().Get("Content-Disposition")).To(Equal("attachment; filename=test"))
+			})
+
+			It("returns not found, if the last use is taken concurrently", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), gomock.Any()).
+					Return(myerr.NewItemNotFoundError("The share link is revoked, expired or used up"))
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusNotFound, "The share link is revoked, expired or used up")
+			})
+
+			It("doesnt count a part from the first byte as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req.Header.Set("Range", "bytes=0-5")
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+				Expect(recorder.Body.String()).To(Equal("shared"))
+			})
+
+			It("counts a suffix range of the whole file as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), gomock.Any()).
+					Return(nil)
+
+				req.Header.Set("Range", fmt.Sprintf("bytes=-%d", len(content)))
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+				Expect(recorder.Body.String()).To(Equal(content))
+			})
+
+			It("counts ranges, which together cover the whole file, as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), gomock.Any()).
+					Return(nil)
+
+				req.Header.Set("Range", "bytes=0-0,1-")
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+			})
+
+			It("counts overlapping ranges, which are answered with the whole file, as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), gomock.Any()).
+					Return(nil)
+
+				req.Header.Set("Range", "bytes=0-9,0-9")
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(Equal(content))
+			})
+
+			It("doesnt count ranges, which leave out a part of the file, as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req.Header.Set("Range", "bytes=0-2,4-")
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+			})
+
+			It("doesnt count a resumed download as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req.Header.Set("Range", "bytes=7-")
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusPartialContent))
+				Expect(recorder.Body.String()).To(Equal("content"))
+			})
+
+			It("counts a resumed download of a changed file as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(uint(7), gomock.Any()).
+					Return(nil)
+
+				req.Header.Set("Range", "bytes=7-")
+				req.Header.Set("If-Range", `"old-etag"`)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(Equal(content))
+			})
+
+			It("doesnt count a not modified file as a use", func() {
+				fmDAO.EXPECT().
+					UseShareLink(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req.Header.Set("If-None-Match", `"test-etag"`)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusNotModified))
+			})
+		})
+	})
+
+	Context("SearchFiles", func() {
+		uploader := username
+
+		When("the limit is too big", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search?limit=501", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The limit should be between 1 and 500")
+			})
+		})
+
+		When("the sort field is unknown", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search?sort=owner", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The files cannot be sorted by [owner]")
+			})
+		})
+
+		When("the order is invalid", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search?sort=name&order=up", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid order [up]")
+			})
+		})
+
+		When("a size limit is invalid", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search?min_size=-1", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid size [-1]")
+			})
+		})
+
+		When("a time limit is invalid", func() {
+			It("returns bad request error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search?since=yesterday", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid time [yesterday]")
+			})
+		})
+
+		When("the search fails", func() {
+			BeforeEach(func() {
+				fmDAO.EXPECT().
+					SearchFiles(uint(userID), dao.FileSearchFilter{}, dao.ListOptions{Limit: 50}).
+					Return(nil, "", myerr.NewServerError("test-error"))
+			})
+
+			It("returns internal server error response", func() {
+				req, _ = http.NewRequest("GET", "/protected/files/search", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "")
+			})
+		})
+
+		When("there are more files than a page", func() {
+			since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			minSize, maxSize := int64(10), int64(1000)
+
+			BeforeEach(func() {
+				filter := dao.FileSearchFilter{
+					Group:    groupName,
+					Uploader: uploader,
+					Since:    &since,
+					MinSize:  &minSize,
+					MaxSize:  &maxSize,
+					Tags:     []string{"finance", "2021"},
+				}
+				options := dao.ListOptions{
+					Limit:      2,
+					Cursor:     "cursor",
+					SortBy:     dao.FileSortSize,
+					Descending: true,
+					Query:      "annual report",
+				}
+
+				fmDAO.EXPECT().
+					SearchFiles(uint(userID), filter, options).
+					Return([]dao.FileSearchResult{
+						{ID: fileID, Name: "annual-report.pdf", GroupID: groupID, GroupName: groupName, OwnerID: userID, Uploader: &uploader, Version: 2, Size: 500, Tags: []string{"2021", "finance"}},
+						{ID: fileID + 1, Name: "report-annual.pdf", GroupID: groupID, GroupName: groupName, OwnerID: userID, Version: 1, Size: 100, Tags: []string{"2021", "finance"}},
+					}, "next-cursor", nil)
+			})
+
+			It("returns the page and the cursor of the next one", func() {
+				query := "q=annual+report&group_name=groupName&uploader=username&since=2021-01-01T00:00:00Z&min_size=10&max_size=1000&tags=Finance,2021&sort=size&order=desc&limit=2&cursor=cursor"
+				req, _ = http.NewRequest("GET", "/protected/files/search?"+query, nil)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				var body common.FileSearchResponse
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.NextCursor).To(Equal("next-cursor"))
+				Expect(body.Files).To(HaveLen(2))
+				Expect(body.Files[0].ID).To(Equal(uint(fileID)))
+				Expect(body.Files[0].Uploader).To(Equal(&uploader))
+				Expect(body.Files[0].Size).To(Equal(int64(500)))
+				Expect(body.Files[0].Tags).To(Equal([]string{"2021", "finance"}))
+				Expect(body.Files[1].Uploader).To(BeNil())
+			})
+		})
+	})
 })
@@ -4,45 +4,114 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/lockout"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/notifier"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/oidc"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/totp"
 	val "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/validator"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	//passwordResetExpiration - the period, in which a password reset token can be used
+	passwordResetExpiration = 30 * time.Minute
+	//loginChallengeExpiration - the period, in which the second factor of a login has to be provided
+	loginChallengeExpiration = 5 * time.Minute
+	//externalLoginExpiration - the period, in which the user has to authenticate to the external identity provider
+	externalLoginExpiration = 10 * time.Minute
+	//totpIssuer - the name, under which the accounts are shown in the authenticator apps
+	totpIssuer        = "UShare"
+	recoveryCodeCount = 10
+	//defaultAuditPageSize - the number of audit events on a page, if none is specified
+	defaultAuditPageSize = 50
+	//maxAuditPageSize - the biggest number of audit events on a page
+	maxAuditPageSize = 500
+	//defaultListLimit - the number of items on a page of a listing, if none is specified
+	defaultListLimit = 50
+	//maxListLimit - the biggest number of items on a page of a listing
+	maxListLimit = 500
+)
+
 //UamEndpoint - rest endpoint for configuration of the user access management
 type UamEndpoint interface {
 	CreateUser(*gin.Context)
 	DeleteUser(*gin.Context)
 	Login(*gin.Context)
+	LoginTwoFactor(*gin.Context)
+	StartExternalLogin(*gin.Context)
+	ExternalLogin(*gin.Context)
+	ExternalLoginCallback(*gin.Context)
+	RefreshToken(*gin.Context)
+	Logout(*gin.Context)
+	ChangePassword(*gin.Context)
+	RequestPasswordReset(*gin.Context)
+	ResetPassword(*gin.Context)
+	EnrollTwoFactor(*gin.Context)
+	ActivateTwoFactor(*gin.Context)
+	DisableTwoFactor(*gin.Context)
+	GetJWKS(*gin.Context)
+	CreateAccessToken(*gin.Context)
+	GetAccessTokens(*gin.Context)
+	RevokeAccessToken(*gin.Context)
 
 	CreateGroup(*gin.Context)
-	AddMember(*gin.Context)
+	InviteMember(*gin.Context)
+	RevokeInvitation(*gin.Context)
+	GetInvitations(*gin.Context)
+	AcceptInvitation(*gin.Context)
+	DeclineInvitation(*gin.Context)
 	RevokeMembership(*gin.Context)
 	DeleteGroup(*gin.Context)
+	GetDeletedGroups(*gin.Context)
+	RestoreGroup(*gin.Context)
+	GetGroupInfo(*gin.Context)
+	UpdateGroupQuota(*gin.Context)
+	UpdateGroupTwoFactor(*gin.Context)
+	ChangeMemberRole(*gin.Context)
+	TransferOwnership(*gin.Context)
+	GetGroupAuditEvents(*gin.Context)
+	GetAllGroupsInfo(*gin.Context)
+	GetAllUsersInfo(*gin.Context)
+	GetAllUsersInGroup(*gin.Context)
 }
 
 //UamEndpointImpl - implementation of UamEndpoint
 type UamEndpointImpl struct {
-	uamDAO     dao.UamDAO
-	jwtCreator auth.JwtCreator
-	validator  val.Validator
-	groupsDir  string
+	uamDAO      dao.UamDAO
+	jwtCreator  auth.JwtCreator
+	validator   val.Validator
+	blobStore   storage.BlobStore
+	permissions permission.Service
+	lockout     lockout.Service
+	notifier    notifier.Notifier
+	//identityProvider - the external identity provider, nil if the login through it isnt configured
+	identityProvider oidc.Provider
 }
 
 //NewUamEndPointImpl - function for creation an instance of UamEndpointImpl
-func NewUamEndPointImpl(uamDAO dao.UamDAO, creator auth.JwtCreator, validator val.Validator, groupsDir string) *UamEndpointImpl {
+func NewUamEndPointImpl(uamDAO dao.UamDAO, creator auth.JwtCreator, validator val.Validator, blobStore storage.BlobStore, permissions permission.Service, lockout lockout.Service, notifier notifier.Notifier, identityProvider oidc.Provider) *UamEndpointImpl {
 	return &UamEndpointImpl{
-		uamDAO:     uamDAO,
-		jwtCreator: creator,
-		validator:  validator,
-		groupsDir:  groupsDir,
+		uamDAO:           uamDAO,
+		jwtCreator:       creator,
+		validator:        validator,
+		blobStore:        blobStore,
+		permissions:      permissions,
+		lockout:          lockout,
+		notifier:         notifier,
+		identityProvider: identityProvider,
 	}
 }
 
@@ -53,56 +122,1271 @@ func NewUamEndPointImpl(uamDAO dao.UamDAO, creator auth.JwtCreator, validator va
 func (i *UamEndpointImpl) CreateUser(c *gin.Context) {
 	var rq common.RequestWithCredentials
 
-	//Decide what exactly to return as response -> custom message + 400 or?
-	if err := c.ShouldBindJSON(&rq); err != nil {
-		log.Println("Cannot unmarshall this shit")
+	//Decide what exactly to return as response -> custom message + 400 or?
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		log.Println("Cannot unmarshall this shit")
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if err := validateRegistration(i.validator, rq); err != nil {
+		log.Println(err)
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rq.Password), bcrypt.DefaultCost)
+	if err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem encryption of password during the registration.")
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	log.Printf("Creating the user with username %s and password %s\n", rq.Username, rq.Password)
+
+	err = i.uamDAO.CreateUser(rq.Username, string(hashedPassword))
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem crearing the user in the db.")
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.BasicResponse{
+		Status: http.StatusCreated,
+	})
+}
+
+//DeleteUser - handler for user deletion request
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 200 if the user was successfully deleted, the user is only deactivated and purged after the retention period
+func (i *UamEndpointImpl) DeleteUser(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+	}
+
+	if err = i.uamDAO.DeactivateUser(uint(userID)); err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem with deletion of user.")
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with deleting user"))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//Login - handler for user login request
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 429 if the logins of the user or from his ip address are locked, because of too many failed attempts
+//returns 201 if the login was successfull
+func (i *UamEndpointImpl) Login(c *gin.Context) {
+	var request common.RequestWithCredentials
+	if err := c.ShouldBindJSON(&request); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := i.lockout.Check(request.Username, ip); err != nil {
+		if _, ok := err.(*myerr.LockedError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lockout check in the login logic.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	user, err := i.uamDAO.GetUser(request.Username)
+	if err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); ok {
+			i.rejectLogin(c, 0, request.Username, ip)
+		} else {
+			err = myerr.NewServerErrorWrap(err, "Problem with Login.")
+			common.SendErrorResponse(c, err)
+		}
+		return
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password))
+	if err != nil {
+		i.rejectLogin(c, user.ID, request.Username, ip)
+		return
+	}
+
+	i.completeLogin(c, user)
+}
+
+//completeLogin - starts a session for the authenticated user
+//if the user has two-factor authentication, only a challenge for the second factor is sent
+func (i *UamEndpointImpl) completeLogin(c *gin.Context, user models.User) {
+	twoFactor, err := i.uamDAO.GetTwoFactor(user.ID)
+	if _, ok := err.(*myerr.ItemNotFoundError); err != nil && !ok {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the lookup of the two-factor authentication in the login logic."))
+		return
+	} else if err == nil && twoFactor.Enabled {
+		i.sendLoginChallenge(c, user.ID)
+		return
+	}
+
+	i.startSession(c, user)
+}
+
+//sendLoginChallenge - sends the challenge, which is exchanged for a session together with the second factor
+//the failed logins arent reset yet, the login succeeds only after the second factor
+func (i *UamEndpointImpl) sendLoginChallenge(c *gin.Context, userID uint) {
+	challenge, challengeHash, err := auth.GenerateLoginChallenge()
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.uamDAO.CreateLoginChallenge(userID, challengeHash, time.Now().Add(loginChallengeExpiration)); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the creation of the login challenge."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.LoginResponse{
+		Status:            http.StatusOK,
+		TwoFactorRequired: true,
+		Challenge:         challenge,
+	})
+}
+
+//startSession - completes the login of the user - a new session is created and its tokens are sent
+func (i *UamEndpointImpl) startSession(c *gin.Context, user models.User) {
+	if err := i.lockout.RecordSuccess(user.Username); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the reset of the failed logins in the login logic."))
+		return
+	}
+
+	refreshToken, err := i.jwtCreator.GenerateRefreshToken()
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with generating refresh token in the login logic."))
+		return
+	}
+
+	sessionID, err := i.uamDAO.CreateSession(user.ID, refreshToken.Hash, refreshToken.ExpiresAt)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with creating the session in the login logic."))
+		return
+	}
+
+	signedToken, err := i.jwtCreator.GenerateToken(user.ID, sessionID)
+	if err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem with generating Jwt token in the login logic.")
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.LoginResponse{
+		Status:       http.StatusCreated,
+		Token:        signedToken,
+		RefreshToken: refreshToken.Token,
+	})
+}
+
+//LoginTwoFactor - handler for the second step of the login of a user with two-factor authentication
+//the challenge from the first step is exchanged for the tokens together with a totp code or a recovery code
+//the challenge can be used only once, a wrong code requires a new login
+//returns 500, if error occurrs due to system failure
+//returns 400 if the challenge or the code is invalid
+//returns 429 if the logins of the user or from his ip address are locked, because of too many failed attempts
+//returns 201 if the login was successfull
+func (i *UamEndpointImpl) LoginTwoFactor(c *gin.Context) {
+	var rq common.TwoFactorLoginPayload
+	if err := c.ShouldBindJSON(&rq); err != nil || rq.Challenge == "" || rq.Code == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	challenge, err := i.uamDAO.UseLoginChallenge(auth.HashToken(rq.Challenge))
+	if err != nil {
+		if _, ok := err.(*myerr.ClientError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lookup of the login challenge.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	user, err := i.uamDAO.GetUserByID(challenge.UserID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the user in the login logic."))
+		return
+	}
+
+	ip := c.ClientIP()
+	if err = i.lockout.Check(user.Username, ip); err != nil {
+		if _, ok := err.(*myerr.LockedError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lockout check in the login logic.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	twoFactor, err := i.uamDAO.GetTwoFactor(user.ID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the lookup of the two-factor authentication in the login logic."))
+		return
+	}
+
+	if err = i.verifySecondFactor(twoFactor, rq.Code); err != nil {
+		if _, ok := err.(*myerr.ClientError); ok {
+			i.rejectLogin(c, user.ID, user.Username, ip)
+		} else {
+			common.SendErrorResponse(c, err)
+		}
+		return
+	}
+
+	i.startSession(c, user)
+}
+
+//rejectLogin - records the failed login and sends the response
+//the response is distinct, if the failure locked the logins
+func (i *UamEndpointImpl) rejectLogin(c *gin.Context, userID uint, username string, ip string) {
+	err := i.lockout.RecordFailure(userID, username, ip)
+	if err == nil {
+		err = myerr.NewClientError("Invalid credentials")
+	} else if _, ok := err.(*myerr.LockedError); !ok {
+		err = myerr.NewServerErrorWrap(err, "Problem with the recording of the failed login.")
+	}
+	common.SendErrorResponse(c, err)
+}
+
+//StartExternalLogin - handler for the start of a login through the external identity provider (OpenID Connect)
+//the state, the nonce and the PKCE code verifier of the login are kept on the server
+//without a redirect uri in the request, the configured one is used
+//returns 500, if error occurrs due to system failure
+//returns 400 if the redirect uri is invalid
+//returns 404 if the login through an identity provider isnt configured
+//returns 201 with the url, where the user authenticates to the identity provider
+func (i *UamEndpointImpl) StartExternalLogin(c *gin.Context) {
+	if i.identityProvider == nil {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("The login through an identity provider isnt configured"))
+		return
+	}
+
+	var rq common.ExternalLoginStartPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	redirectURI := rq.RedirectURI
+	if redirectURI == "" {
+		redirectURI = i.identityProvider.RedirectURL()
+	}
+	if parsedURI, err := url.Parse(redirectURI); err != nil || (parsedURI.Scheme != "http" && parsedURI.Scheme != "https") || parsedURI.Host == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid redirect uri"))
+		return
+	}
+
+	var values [3]string
+	for idx := range values {
+		value, err := oidc.GenerateRandomValue()
+		if err != nil {
+			common.SendErrorResponse(c, err)
+			return
+		}
+		values[idx] = value
+	}
+	state, nonce, codeVerifier := values[0], values[1], values[2]
+
+	authorizationURL, err := i.identityProvider.AuthorizationURL(redirectURI, state, nonce, codeVerifier)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the discovery of the identity provider."))
+		return
+	}
+
+	err = i.uamDAO.CreateExternalLogin(models.ExternalLogin{
+		StateHash:    auth.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		RedirectURI:  redirectURI,
+		ExpiresAt:    time.Now().Add(externalLoginExpiration),
+	})
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the creation of the external login."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.ExternalLoginStartResponse{
+		Status:           http.StatusCreated,
+		AuthorizationURL: authorizationURL,
+	})
+}
+
+//ExternalLogin - handler for the completion of a login through the external identity provider
+//the state and the authorization code are the ones, which the identity provider sent to the redirect uri
+//the external identity is mapped to a user, a new user is provisioned for an unknown identity
+//returns 500, if error occurrs due to system failure
+//returns 400 if the state is invalid or the identity provider rejects the login
+//returns 404 if the login through an identity provider isnt configured
+//returns 429 if the logins of the user or from his ip address are locked, because of too many failed attempts
+//returns 201 if the login was successfull (200 with a challenge, if the user has two-factor authentication)
+func (i *UamEndpointImpl) ExternalLogin(c *gin.Context) {
+	var rq common.ExternalLoginPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	i.finishExternalLogin(c, rq.State, rq.Code)
+}
+
+//ExternalLoginCallback - handler, which can be used as the redirect uri of the external identity provider
+//it completes the login with the state and the authorization code from the query, like ExternalLogin
+func (i *UamEndpointImpl) ExternalLoginCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("The identity provider rejected the login. %s %s", providerErr, c.Query("error_description"))))
+		return
+	}
+
+	i.finishExternalLogin(c, c.Query("state"), c.Query("code"))
+}
+
+func (i *UamEndpointImpl) finishExternalLogin(c *gin.Context, state, code string) {
+	if i.identityProvider == nil {
+		common.SendErrorResponse(c, myerr.NewItemNotFoundError("The login through an identity provider isnt configured"))
+		return
+	} else if state == "" || code == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Missing state or authorization code"))
+		return
+	}
+
+	login, err := i.uamDAO.UseExternalLogin(auth.HashToken(state))
+	if err != nil {
+		if _, ok := err.(*myerr.ClientError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lookup of the external login.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	identity, err := i.identityProvider.Exchange(code, login.RedirectURI, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		if _, ok := err.(*myerr.ClientError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the exchange of the authorization code.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	user, err := i.uamDAO.GetOrCreateExternalUser(identity.Issuer, identity.Subject, identity.Username())
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the mapping of the external identity to a user."))
+		return
+	}
+
+	if err = i.lockout.Check(user.Username, c.ClientIP()); err != nil {
+		if _, ok := err.(*myerr.LockedError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lockout check in the login logic.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	i.completeLogin(c, user)
+}
+
+//RefreshToken - handler for the renewal of the access token of a session
+//the refresh token is rotated - the used one is replaced by a new one, which is sent together with the access token
+//returns 500, if error occurrs due to system failure
+//returns 400 if the refresh token is invalid, already used or its session is revoked or expired
+//returns 201 if a new access token was issued
+func (i *UamEndpointImpl) RefreshToken(c *gin.Context) {
+	var rq common.RefreshTokenPayload
+	if err := c.ShouldBindJSON(&rq); err != nil || rq.RefreshToken == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	refreshToken, err := i.jwtCreator.GenerateRefreshToken()
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with generating refresh token."))
+		return
+	}
+
+	session, err := i.uamDAO.RotateSession(auth.HashToken(rq.RefreshToken), refreshToken.Hash, refreshToken.ExpiresAt)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the rotation of the refresh token."))
+		return
+	}
+
+	signedToken, err := i.jwtCreator.GenerateToken(session.UserID, session.ID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with generating Jwt token."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.LoginResponse{
+		Status:       http.StatusCreated,
+		Token:        signedToken,
+		RefreshToken: refreshToken.Token,
+	})
+}
+
+//Logout - handler for user logout request
+//the session of the access token is revoked, so neither the access token, nor the refresh token can be used anymore
+//returns 500, if error occurrs due to system failure
+//returns 200 if the session was successfully revoked
+func (i *UamEndpointImpl) Logout(c *gin.Context) {
+	if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The access tokens dont have a session. Revoke the access token instead"))
+		return
+	}
+
+	sessionID, err := common.GetSessionIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.uamDAO.RevokeSession(sessionID); err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the revocation of the session.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//ChangePassword - handler for the change of the password of the logged in user
+//all sessions and personal access tokens of the user are revoked, so he has to login again with the new password
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or the old password doesnt match
+//returns 200 if the password was successfully changed
+func (i *UamEndpointImpl) ChangePassword(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The password can be changed only after login"))
+		return
+	}
+
+	var rq common.PasswordChangePayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if err = i.validator.ValidatePassword(rq.NewPassword); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with the password"))
+		return
+	}
+
+	user, err := i.uamDAO.GetUserByID(userID)
+	if err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with fetching the user.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(rq.OldPassword)); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid credentials"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rq.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem encryption of the new password."))
+		return
+	}
+
+	if err = i.uamDAO.ChangePassword(userID, string(hashedPassword)); err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the change of the password.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//RequestPasswordReset - handler for the request of a one-time token for the reset of a forgotten password
+//the token is delivered through the notifier, the response doesnt reveal if the user exists
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 202 if the request was accepted
+func (i *UamEndpointImpl) RequestPasswordReset(c *gin.Context) {
+	var rq common.PasswordResetRequestPayload
+	if err := c.ShouldBindJSON(&rq); err != nil || rq.Username == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	user, err := i.uamDAO.GetUser(rq.Username)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok || (err == nil && user.ID == 0) {
+		log.Printf("Password reset requested for non-existing user [%s]\n", rq.Username)
+		c.JSON(http.StatusAccepted, common.BasicResponse{
+			Status: http.StatusAccepted,
+		})
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the user."))
+		return
+	}
+
+	token, tokenHash, err := auth.GeneratePasswordResetToken()
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	expiresAt := time.Now().Add(passwordResetExpiration)
+	if err = i.uamDAO.CreatePasswordReset(user.ID, tokenHash, expiresAt); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the creation of the password reset."))
+		return
+	}
+
+	if err = i.notifier.NotifyPasswordReset(user.Username, token, expiresAt); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with sending the password reset token."))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, common.BasicResponse{
+		Status: http.StatusAccepted,
+	})
+}
+
+//ResetPassword - handler for setting a new password with a password reset token
+//the token can be used only once, all sessions and personal access tokens of the user are revoked
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or the token is invalid or expired
+//returns 200 if the password was successfully reset
+func (i *UamEndpointImpl) ResetPassword(c *gin.Context) {
+	var rq common.PasswordResetPayload
+	if err := c.ShouldBindJSON(&rq); err != nil || rq.Token == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if err := i.validator.ValidatePassword(rq.NewPassword); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Problem with the password"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rq.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem encryption of the new password."))
+		return
+	}
+
+	err = i.uamDAO.ResetPassword(auth.HashToken(rq.Token), string(hashedPassword))
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the reset of the password."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//EnrollTwoFactor - handler for the enrollment of totp two-factor authentication
+//the enrollment stays pending until it is activated with a code, generated by the authenticator app
+//returns 500, if error occurrs due to system failure
+//returns 400 if the two-factor authentication is already enabled
+//returns 201 with the secret, its provisioning uri and the recovery codes, which are returned only once
+func (i *UamEndpointImpl) EnrollTwoFactor(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The two-factor authentication can be managed only after login"))
+		return
+	}
+
+	user, err := i.uamDAO.GetUserByID(userID)
+	if err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with fetching the user.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	recoveryCodes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, auth.HashToken(code))
+	}
+
+	err = i.uamDAO.CreateTwoFactor(userID, secret, recoveryCodeHashes)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the enrollment of the two-factor authentication."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.TwoFactorEnrollmentResponse{
+		Status:          http.StatusCreated,
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer, user.Username, secret),
+		RecoveryCodes:   recoveryCodes,
+	})
+}
+
+//ActivateTwoFactor - handler for the activation of the pending two-factor enrollment with a totp code
+//from then on the logins of the user require a second factor
+//returns 500, if error occurrs due to system failure
+//returns 400 if the code is invalid or there is no pending enrollment
+//returns 200 if the two-factor authentication was enabled
+func (i *UamEndpointImpl) ActivateTwoFactor(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The two-factor authentication can be managed only after login"))
+		return
+	}
+
+	var rq common.TwoFactorCodePayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	twoFactor, err := i.uamDAO.GetTwoFactor(userID)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok || (err == nil && twoFactor.Enabled) {
+		common.SendErrorResponse(c, myerr.NewClientError("There is no pending two-factor enrollment"))
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the lookup of the two-factor authentication."))
+		return
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, rq.Code, time.Now())
+	if !ok {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid code"))
+		return
+	}
+
+	err = i.uamDAO.EnableTwoFactor(userID, step)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with enabling the two-factor authentication."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//DisableTwoFactor - handler for disabling the two-factor authentication, which is confirmed with a totp code or a recovery code
+//returns 500, if error occurrs due to system failure
+//returns 400 if the code is invalid
+//returns 404 if the two-factor authentication isnt enabled
+//returns 200 if the two-factor authentication was disabled
+func (i *UamEndpointImpl) DisableTwoFactor(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The two-factor authentication can be managed only after login"))
+		return
+	}
+
+	var rq common.TwoFactorCodePayload
+	if err = c.ShouldBindJSON(&rq); err != nil || rq.Code == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	twoFactor, err := i.uamDAO.GetTwoFactor(userID)
+	if err == nil && !twoFactor.Enabled {
+		err = myerr.NewItemNotFoundError("Two-factor authentication isnt enabled")
+	}
+	if err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the lookup of the two-factor authentication.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.verifySecondFactor(twoFactor, rq.Code); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if err = i.uamDAO.DeleteTwoFactor(userID); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with disabling the two-factor authentication."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//verifySecondFactor - checks the totp code or consumes the recovery code of an enabled two-factor authentication
+//returns client error if the code is invalid or already used
+func (i *UamEndpointImpl) verifySecondFactor(twoFactor models.TwoFactor, code string) error {
+	var err error
+	if totp.IsCode(code) {
+		step, ok := totp.Validate(twoFactor.Secret, code, time.Now())
+		if !ok {
+			return myerr.NewClientError("Invalid code")
+		}
+		err = i.uamDAO.UseTwoFactorStep(twoFactor.UserID, step)
+	} else {
+		err = i.uamDAO.UseRecoveryCode(twoFactor.UserID, auth.HashToken(totp.NormalizeRecoveryCode(code)))
+	}
+
+	if _, ok := err.(*myerr.ClientError); err != nil && !ok {
+		return myerr.NewServerErrorWrap(err, "Problem with the verification of the code.")
+	}
+	return err
+}
+
+//GetJWKS - handler for the retrieval of the public keys, which verify the access tokens
+//the keys are returned in the JSON Web Key Set format, so that other services can verify the tokens
+//returns 200 with the keys, which are currently accepted
+func (i *UamEndpointImpl) GetJWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, i.jwtCreator.GetJWKS())
+}
+
+//CreateAccessToken - handler for creation of a personal access token, used by automated clients instead of a login
+//the access tokens cannot create other access tokens, so that the scopes and the expiry of the tokens cannot be extended
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 201 with the token, which is returned only once
+func (i *UamEndpointImpl) CreateAccessToken(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if common.IsAccessTokenRequest(c) {
+		common.SendErrorResponse(c, myerr.NewClientError("The access tokens can be created only after login"))
+		return
+	}
+
+	var rq common.AccessTokenPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	scopes, err := validateAccessToken(rq)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	token := models.AccessToken{
+		UserID: userID,
+		Name:   rq.Name,
+		Scopes: scopes,
+	}
+
+	if rq.GroupName != "" {
+		group, _, err := i.permissions.Authorize(userID, rq.GroupName, permission.ViewGroup)
+		if err != nil {
+			common.SendErrorResponse(c, err)
+			return
+		}
+		token.GroupID = &group.ID
+	}
+
+	if rq.ExpiresIn > 0 {
+		expiry := time.Now().Add(time.Duration(rq.ExpiresIn) * time.Hour)
+		token.ExpiresAt = &expiry
+	}
+
+	accessToken, tokenHash, err := auth.GenerateAccessToken()
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+	token.TokenHash = tokenHash
+
+	tokenID, err := i.uamDAO.CreateAccessToken(token)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the creation of the access token."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.AccessTokenResponse{
+		Status:    http.StatusCreated,
+		ID:        tokenID,
+		Token:     accessToken,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+//GetAccessTokens - handler for retrieval of the personal access tokens of the user
+//returns 500, if error occurrs due to system failure
+//returns 200 with the details about the tokens, the tokens themselves arent stored
+func (i *UamEndpointImpl) GetAccessTokens(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	tokens, err := i.uamDAO.GetAccessTokens(userID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the access tokens."))
+		return
+	}
+
+	tokensInfo := make([]common.AccessTokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		scopes := make([]string, 0)
+		if token.Scopes != "" {
+			scopes = strings.Split(token.Scopes, ",")
+		}
+
+		tokensInfo = append(tokensInfo, common.AccessTokenInfo{
+			ID:         token.ID,
+			Name:       token.Name,
+			Scopes:     scopes,
+			GroupName:  token.GroupName,
+			CreatedAt:  token.CreatedAt,
+			ExpiresAt:  token.ExpiresAt,
+			LastUsedAt: token.LastUsedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, common.AccessTokensResponse{
+		Status: http.StatusOK,
+		Tokens: tokensInfo,
+	})
+}
+
+//RevokeAccessToken - handler for revocation of a personal access token of the user
+//returns 500, if error occurrs due to system failure
+//returns 404 if the user doesnt have such token
+//returns 200 if the token was revoked
+func (i *UamEndpointImpl) RevokeAccessToken(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.AccessTokenRevocationPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if err = i.uamDAO.DeleteAccessToken(userID, rq.TokenID); err != nil {
+		if _, ok := err.(*myerr.ItemNotFoundError); !ok {
+			err = myerr.NewServerErrorWrap(err, "Problem with the revocation of the access token.")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//CreateGroup - handler for group creation request
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 201 if the group was successfully created
+func (i *UamEndpointImpl) CreateGroup(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+	}
+
+	var rq common.GroupPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	//TODO should rename this function - maybe? or create specific function for the group name
+	if err = i.validator.ValidateUsername(rq.GroupName); err != nil {
+		err = myerr.NewClientErrorWrap(err, "Problem with the group name")
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//the resources of a deleted group with the same name might not be erased yet
+	if keys, err := i.blobStore.List(rq.GroupName + "/"); err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the lookup of group resources"))
+		return
+	} else if len(keys) > 0 {
+		common.SendErrorResponse(c, myerr.NewClientError("Problem with creation of group. Reason: Group already exists"))
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditGroupCreated, fmt.Sprintf("Created group [%s]", rq.GroupName))
+	err = i.uamDAO.CreateGroup(userID, rq.GroupName, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with creation of group."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.BasicResponse{
+		Status: http.StatusCreated,
+	})
+}
+
+//InviteMember - handler for invitation creation request
+//the user becomes a member of the group, after accepting the invitation
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 201 if the user was successfully invited to the group
+func (i *UamEndpointImpl) InviteMember(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.InvitationPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageMembers); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var expiresAt *time.Time
+	if rq.ExpiresIn > 0 {
+		expiry := time.Now().Add(time.Duration(rq.ExpiresIn) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	event := newAuditEvent(c, userID, models.AuditMemberInvited, fmt.Sprintf("Invited [%s]", rq.Username))
+	err = i.uamDAO.CreateInvitation(userID, rq.Username, rq.GroupName, expiresAt, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem with creation of invitation.")
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.BasicResponse{
+		Status: http.StatusCreated,
+	})
+}
+
+//RevokeInvitation - handler for revocation of a pending invitation
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or the user isnt allowed to manage the members
+//returns 200 if the invitation was revoked
+func (i *UamEndpointImpl) RevokeInvitation(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.GroupMembershipPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageMembers); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditInvitationRevoked, fmt.Sprintf("Revoked the invitation of [%s]", rq.Username))
+	err = i.uamDAO.RevokeInvitation(rq.Username, rq.GroupName, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with revocation of invitation."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//GetInvitations - handler for fetching the pending invitations of the user
+//returns 500, if error occurrs due to system failure
+//returns 200 otherwise
+func (i *UamEndpointImpl) GetInvitations(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	invitations, err := i.uamDAO.GetInvitations(userID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the invitations."))
+		return
+	}
+
+	invitationsInfo := make([]common.InvitationInfo, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitationsInfo = append(invitationsInfo, common.InvitationInfo{
+			GroupName: invitation.GroupName,
+			Inviter:   invitation.Inviter,
+			InvitedAt: invitation.CreatedAt,
+			ExpiresAt: invitation.ExpiresAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, common.InvitationsResponse{
+		Status:      http.StatusOK,
+		Invitations: invitationsInfo,
+	})
+}
+
+//AcceptInvitation - handler for accepting an invitation, the user becomes a member of the group
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or there isnt a valid invitation
+//returns 201 if the membership was created
+func (i *UamEndpointImpl) AcceptInvitation(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.GroupPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditInvitationAccepted, "Joined the group")
+	err = i.uamDAO.AcceptInvitation(userID, rq.GroupName, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with accepting the invitation."))
+		return
+	}
+
+	c.JSON(http.StatusCreated, common.BasicResponse{
+		Status: http.StatusCreated,
+	})
+}
+
+//DeclineInvitation - handler for declining an invitation
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or there isnt such invitation
+//returns 200 if the invitation was declined
+func (i *UamEndpointImpl) DeclineInvitation(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.GroupPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditInvitationDeclined, "Declined the invitation")
+	err = i.uamDAO.DeclineInvitation(userID, rq.GroupName, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with declining the invitation."))
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//RevokeMembership - handler for membership deletion request
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 200 if the user was successfully removed from the group
+func (i *UamEndpointImpl) RevokeMembership(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.GroupMembershipPayload
+	if err := c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	if _, err = i.permissions.AuthorizeMemberChange(userID, rq.GroupName, rq.Username); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//a member, who leaves the group, is both the actor and the target user of the event
+	event := newAuditEvent(c, userID, models.AuditMemberRemoved, fmt.Sprintf("Removed [%s] from the group", rq.Username))
+	err = i.uamDAO.RemoveUserFromGroup(rq.Username, rq.GroupName, event)
+
+	if err != nil {
+		if _, ok := err.(*myerr.ServerError); ok {
+			err = myerr.NewServerErrorWrap(err, "Couldnt remove membership")
+		}
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
+
+//DeleteGroup - handler for group deletion request, the group is moved to the trash of its owner
+//the group is erased, when the retention period of the trash expires
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid
+//returns 20- if the group was successfully deleted
+func (i *UamEndpointImpl) DeleteGroup(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	var rq common.GroupPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
 		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
 		return
 	}
 
-	if err := validateRegistration(i.validator, rq); err != nil {
-		log.Println(err)
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageGroup); err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rq.Password), bcrypt.DefaultCost)
-	if err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem encryption of password during the registration.")
+	event := newAuditEvent(c, userID, models.AuditGroupDeleted, fmt.Sprintf("Deleted group [%s]", rq.GroupName))
+	err = i.uamDAO.DeactivateGroup(rq.GroupName, event)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, err)
+		return
+	} else if err != nil {
+		err = myerr.NewServerErrorWrap(err, "Problem with deletion of group.")
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	log.Printf("Creating the user with username %s and password %s\n", rq.Username, rq.Password)
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
+	})
+}
 
-	err = i.uamDAO.CreateUser(rq.Username, string(hashedPassword))
-	if _, ok := err.(*myerr.ClientError); ok {
+//GetDeletedGroups - handler for fetching the groups in the trash of the user, starting from the latest deleted one
+//returns 500, if error occurrs due to system failure
+//returns 200 otherwise
+func (i *UamEndpointImpl) GetDeletedGroups(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
-	} else if err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem crearing the user in the db.")
-		common.SendErrorResponse(c, err)
+	}
+
+	groups, err := i.uamDAO.GetDeactivatedGroups(userID)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the deleted groups."))
 		return
 	}
 
-	c.JSON(http.StatusCreated, common.BasicResponse{
-		Status: http.StatusCreated,
+	groupsInfo := make([]common.DeletedGroupInfo, 0, len(groups))
+	for _, group := range groups {
+		groupsInfo = append(groupsInfo, common.DeletedGroupInfo{
+			GroupInfo: common.GroupInfo{
+				ID:      group.ID,
+				Name:    group.Name,
+				OwnerID: group.OwnerID,
+			},
+			DeletedAt: group.DeactivatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": http.StatusOK,
+		"groups": groupsInfo,
 	})
 }
 
-//DeleteUser - handler for user deletion request
+//RestoreGroup - handler for restoring a group from the trash of its owner, together with its members and files
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 200 if the user was successfully deleted
-func (i *UamEndpointImpl) DeleteUser(c *gin.Context) {
+//returns 400 if the user input was invalid or the user isnt the owner of the group
+//returns 404 if the group doesnt exist
+//returns 200 if the group was successfully restored
+func (i *UamEndpointImpl) RestoreGroup(c *gin.Context) {
 	userID, err := common.GetIDFromContext(c)
 	if err != nil {
 		common.SendErrorResponse(c, err)
+		return
 	}
 
-	if err = i.uamDAO.DeleteUser(uint(userID)); err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem with deletion of user.")
-		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with deleting user"))
+	var rq common.GroupPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditGroupRecovered, fmt.Sprintf("Restored group [%s] from the trash", rq.GroupName))
+	if err = i.uamDAO.RestoreGroup(userID, rq.GroupName, event); err != nil {
+		common.SendErrorResponse(c, err)
 		return
 	}
 
@@ -111,151 +1395,188 @@ func (i *UamEndpointImpl) DeleteUser(c *gin.Context) {
 	})
 }
 
-//Login - handler for user login request
+//GetGroupInfo - handler for fetching info about a group, including the usage of its quota
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 201 if the login was successfull
-func (i *UamEndpointImpl) Login(c *gin.Context) {
-	var request common.RequestWithCredentials
-	if err := c.ShouldBindJSON(&request); err != nil {
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
+//returns 400 if the user input was invalid or the user isnt a member of the group
+//returns 200 otherwise
+func (i *UamEndpointImpl) GetGroupInfo(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
 		return
 	}
 
-	user, err := i.uamDAO.GetUser(request.Username)
-	if err != nil {
-		if _, ok := err.(*myerr.ItemNotFoundError); ok {
-			common.SendErrorResponse(c, myerr.NewClientError("Invalid credentials"))
-		} else {
-			err = myerr.NewServerErrorWrap(err, "Problem with Login.")
-			common.SendErrorResponse(c, err)
-		}
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password))
+	group, role, err := i.permissions.Authorize(userID, groupName, permission.ViewGroup)
 	if err != nil {
-		common.SendErrorResponse(c, myerr.NewClientError("Invalid credentials"))
+		common.SendErrorResponse(c, err)
 		return
 	}
 
-	signedToken, err := i.jwtCreator.GenerateToken(user.ID)
+	usage, err := i.uamDAO.GetGroupUsage(group.ID)
 	if err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem with generating Jwt token in the login logic.")
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, common.LoginResponse{
-		Status: http.StatusCreated,
-		Token:  signedToken,
+	c.JSON(http.StatusOK, common.GroupDetailsResponse{
+		Status: http.StatusOK,
+		GroupInfo: common.GroupInfo{
+			ID:      group.ID,
+			Name:    group.Name,
+			OwnerID: group.OwnerID,
+		},
+		Quota:            group.Quota,
+		MaxFileSize:      group.MaxFileSize,
+		Usage:            usage,
+		Role:             role,
+		RequireTwoFactor: group.RequireTwoFactor,
 	})
 }
 
-//CreateGroup - handler for group creation request
+//UpdateGroupQuota - handler for changing the quota and the maximum file size of a group
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 201 if the group was successfully created
-func (i *UamEndpointImpl) CreateGroup(c *gin.Context) {
+//returns 400 if the user input was invalid or the user isnt the group owner
+//returns 200 if the limits were changed
+func (i *UamEndpointImpl) UpdateGroupQuota(c *gin.Context) {
 	userID, err := common.GetIDFromContext(c)
 	if err != nil {
 		common.SendErrorResponse(c, err)
+		return
 	}
 
-	var rq common.GroupPayload
-	if err := c.ShouldBindJSON(&rq); err != nil {
+	var rq common.GroupQuotaPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
 		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
 		return
 	}
 
-	//TODO should rename this function - maybe? or create specific function for the group name
-	if err = i.validator.ValidateUsername(rq.GroupName); err != nil {
-		err = myerr.NewClientErrorWrap(err, "Problem with the group name")
-		common.SendErrorResponse(c, err)
+	if rq.Quota == nil && rq.MaxFileSize == nil {
+		common.SendErrorResponse(c, myerr.NewClientError("Neither quota nor max file size is specified"))
+		return
+	} else if (rq.Quota != nil && *rq.Quota <= 0) || (rq.MaxFileSize != nil && *rq.MaxFileSize <= 0) {
+		common.SendErrorResponse(c, myerr.NewClientError("The limits of the group should be positive"))
 		return
 	}
 
-	groupDir := path.Join(i.groupsDir, rq.GroupName)
-	fmt.Println(groupDir)
-	if _, err := os.Stat(groupDir); !os.IsNotExist(err) {
-		common.SendErrorResponse(c, myerr.NewClientError("Problem with creation of group. Reason: Group already exists"))
-		return
-	} else if err = os.Mkdir(groupDir, 0755); err != nil {
-		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with creation of directory"))
+	group, _, err := i.permissions.Authorize(userID, rq.GroupName, permission.ManageGroup)
+	if err != nil {
+		common.SendErrorResponse(c, err)
 		return
 	}
 
-	err = i.uamDAO.CreateGroup(userID, rq.GroupName)
+	quota, maxFileSize := group.Quota, group.MaxFileSize
+	if rq.Quota != nil {
+		quota = *rq.Quota
+	}
+	if rq.MaxFileSize != nil {
+		maxFileSize = *rq.MaxFileSize
+	}
+
+	event := newAuditEvent(c, userID, models.AuditGroupLimitsChanged, fmt.Sprintf("Changed the quota from %d to %d bytes and the max file size from %d to %d bytes", group.Quota, quota, group.MaxFileSize, maxFileSize))
+	err = i.uamDAO.UpdateGroupLimits(rq.GroupName, quota, maxFileSize, event)
 	if _, ok := err.(*myerr.ClientError); ok {
 		common.SendErrorResponse(c, err)
 		return
 	} else if err != nil {
-		os.RemoveAll(groupDir)
-		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with creation of group."))
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with changing the quota of the group."))
 		return
 	}
 
-	c.JSON(http.StatusCreated, common.BasicResponse{
-		Status: http.StatusCreated,
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
 	})
 }
 
-//AddMember - handler for membership creation request
+//UpdateGroupTwoFactor - handler for changing if the members of a group need two-factor authentication to access its files
+//the owner has to enable two-factor authentication himself, before requiring it from the members
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 201 if the user was successfully added to the group
-func (i *UamEndpointImpl) AddMember(c *gin.Context) {
+//returns 400 if the user input was invalid or the user isnt the group owner
+//returns 200 if the requirement was changed
+func (i *UamEndpointImpl) UpdateGroupTwoFactor(c *gin.Context) {
 	userID, err := common.GetIDFromContext(c)
 	if err != nil {
 		common.SendErrorResponse(c, err)
+		return
 	}
 
-	var rq common.GroupMembershipPayload
-	if err := c.ShouldBindJSON(&rq); err != nil {
+	var rq common.GroupTwoFactorPayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
 		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
 		return
 	}
 
-	err = i.uamDAO.AddUserToGroup(userID, rq.Username, rq.GroupName)
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageGroup); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	if rq.Required {
+		twoFactor, err := i.uamDAO.GetTwoFactor(userID)
+		if _, ok := err.(*myerr.ItemNotFoundError); ok || (err == nil && !twoFactor.Enabled) {
+			common.SendErrorResponse(c, myerr.NewClientError("Enable two-factor authentication, before requiring it from the members"))
+			return
+		} else if err != nil {
+			common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with the lookup of the two-factor authentication."))
+			return
+		}
+	}
+
+	event := newAuditEvent(c, userID, models.AuditGroupTwoFactorChanged, fmt.Sprintf("Set the two-factor requirement to %t", rq.Required))
+	err = i.uamDAO.UpdateGroupTwoFactor(rq.GroupName, rq.Required, event)
 	if _, ok := err.(*myerr.ClientError); ok {
 		common.SendErrorResponse(c, err)
 		return
 	} else if err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem with creation of group.")
-		common.SendErrorResponse(c, err)
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with changing the two-factor requirement of the group."))
 		return
 	}
 
-	c.JSON(http.StatusCreated, common.BasicResponse{
-		Status: http.StatusCreated,
+	c.JSON(http.StatusOK, common.BasicResponse{
+		Status: http.StatusOK,
 	})
 }
 
-//RevokeMembership - handler for membership deletion request
+//ChangeMemberRole - handler for changing the role of a member in a group
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 200 if the user was successfully removed from the group
-func (i *UamEndpointImpl) RevokeMembership(c *gin.Context) {
+//returns 400 if the user input was invalid or the user isnt allowed to change roles
+//returns 200 if the role was changed
+func (i *UamEndpointImpl) ChangeMemberRole(c *gin.Context) {
 	userID, err := common.GetIDFromContext(c)
 	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	var rq common.GroupMembershipPayload
-	if err := c.ShouldBindJSON(&rq); err != nil {
+	var rq common.GroupRolePayload
+	if err = c.ShouldBindJSON(&rq); err != nil {
 		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
 		return
 	}
 
-	err = i.uamDAO.RemoveUserFromGroup(userID, rq.Username, rq.GroupName)
+	if !permission.IsAssignableRole(rq.Role) {
+		common.SendErrorResponse(c, myerr.NewClientError("Invalid role. The role should be one of admin, contributor and viewer"))
+		return
+	}
+
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageRoles); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
 
-	if err != nil {
-		if _, ok := err.(*myerr.ServerError); ok {
-			err = myerr.NewServerErrorWrap(err, "Couldnt remove membership")
-		}
+	event := newAuditEvent(c, userID, models.AuditMemberRoleChanged, fmt.Sprintf("Changed the role of [%s] to %s", rq.Username, rq.Role))
+	err = i.uamDAO.UpdateMemberRole(rq.Username, rq.GroupName, rq.Role, event)
+	if _, ok := err.(*myerr.ClientError); ok {
 		common.SendErrorResponse(c, err)
 		return
+	} else if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with changing the role of the member."))
+		return
 	}
 
 	c.JSON(http.StatusOK, common.BasicResponse{
@@ -263,30 +1584,36 @@ func (i *UamEndpointImpl) RevokeMembership(c *gin.Context) {
 	})
 }
 
-//DeleteGroup - handler for group deletion request
+//TransferOwnership - handler for transferring the ownership of a group to another member
+//the former owner becomes an admin of the group
 //returns 500, if error occurrs due to system failure
-//returns 400 if the user input was invalid
-//returns 20- if the group was successfully deleted
-func (i *UamEndpointImpl) DeleteGroup(c *gin.Context) {
+//returns 400 if the user input was invalid or the user isnt the owner of the group
+//returns 200 if the ownership was transferred
+func (i *UamEndpointImpl) TransferOwnership(c *gin.Context) {
 	userID, err := common.GetIDFromContext(c)
 	if err != nil {
 		common.SendErrorResponse(c, err)
 		return
 	}
 
-	var rq common.GroupPayload
+	var rq common.GroupMembershipPayload
 	if err = c.ShouldBindJSON(&rq); err != nil {
 		common.SendErrorResponse(c, myerr.NewClientError("Invalid json body"))
 		return
 	}
 
-	err = i.uamDAO.DeactivateGroup(userID, rq.GroupName)
+	if _, _, err = i.permissions.Authorize(userID, rq.GroupName, permission.ManageGroup); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	event := newAuditEvent(c, userID, models.AuditGroupOwnershipTransferred, fmt.Sprintf("Transferred the ownership to [%s]", rq.Username))
+	err = i.uamDAO.TransferGroupOwnership(rq.GroupName, rq.Username, event)
 	if _, ok := err.(*myerr.ClientError); ok {
 		common.SendErrorResponse(c, err)
 		return
 	} else if err != nil {
-		err = myerr.NewServerErrorWrap(err, "Problem with deletion of group.")
-		common.SendErrorResponse(c, err)
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with transferring the ownership of the group."))
 		return
 	}
 
@@ -295,13 +1622,106 @@ func (i *UamEndpointImpl) DeleteGroup(c *gin.Context) {
 	})
 }
 
-//GetAllGroupsInfo - handler for fetching info about every active group
+//GetGroupAuditEvents - handler for fetching a page of the audit trail of a group, starting from the latest event
+//the events can be filtered by action, by the username of the actor and by time (RFC 3339) with the since and until parameters
+//returns 500, if error occurrs due to system failure
+//returns 400 if the user input was invalid or the user isnt the group owner
+//returns 200 otherwise
+func (i *UamEndpointImpl) GetGroupAuditEvents(c *gin.Context) {
+	userID, err := common.GetIDFromContext(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groupName := c.Query("group_name")
+	if groupName == "" {
+		common.SendErrorResponse(c, myerr.NewClientError("Groupname isnt specified"))
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		common.SendErrorResponse(c, myerr.NewClientError("The page should be a positive number"))
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultAuditPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxAuditPageSize {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("The page size should be between 1 and %d", maxAuditPageSize)))
+		return
+	}
+
+	filter := dao.AuditFilter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+	}
+	if filter.Since, err = parseQueryTime(c.Query("since")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	} else if filter.Until, err = parseQueryTime(c.Query("until")); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	group, _, err := i.permissions.Authorize(userID, groupName, permission.ViewAuditLog)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	//one more event is fetched, to find out if there is a next page
+	events, err := i.uamDAO.GetAuditEvents(group.ID, filter, (page-1)*pageSize, pageSize+1)
+	if err != nil {
+		common.SendErrorResponse(c, myerr.NewServerErrorWrap(err, "Problem with fetching the audit events."))
+		return
+	}
+
+	more := len(events) > pageSize
+	if more {
+		events = events[:pageSize]
+	}
+
+	eventsInfo := make([]common.AuditEventInfo, 0, len(events))
+	for _, event := range events {
+		eventsInfo = append(eventsInfo, common.AuditEventInfo{
+			ID:         event.ID,
+			CreatedAt:  event.CreatedAt,
+			Action:     event.Action,
+			Actor:      event.Actor,
+			TargetUser: event.TargetUser,
+			FileID:     event.FileID,
+			Details:    event.Details,
+			IP:         event.IP,
+		})
+	}
+
+	c.JSON(http.StatusOK, common.AuditEventsResponse{
+		Status:   http.StatusOK,
+		Page:     page,
+		PageSize: pageSize,
+		More:     more,
+		Events:   eventsInfo,
+	})
+}
+
+//GetAllGroupsInfo - handler for fetching info about every active group, a page at a time
+//the groups can be filtered by name and sorted by name, created_at or id
 //returns 500, if error occurrs due to system failure
 //returns 400 if the user input was invalid
 //returns 200 otherwise
 func (i *UamEndpointImpl) GetAllGroupsInfo(c *gin.Context) {
-	groups, err := i.uamDAO.GetAllGroups()
-	if _, ok := err.(*myerr.ServerError); ok {
+	options, err := parseListOptions(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	groups, cursor, err := i.uamDAO.GetAllGroups(options)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Cannot retrieve the groups"))
+		return
+	} else if err != nil {
 		err = myerr.NewServerErrorWrap(err, "Problem with fetching all groups.")
 		common.SendErrorResponse(c, err)
 		return
@@ -317,18 +1737,29 @@ func (i *UamEndpointImpl) GetAllGroupsInfo(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": http.StatusOK,
-		"groups": groupsInfo,
+		"status":      http.StatusOK,
+		"groups":      groupsInfo,
+		"next_cursor": cursor,
 	})
 }
 
-//GetAllUsersInfo - handler for fetching info about every user
+//GetAllUsersInfo - handler for fetching info about every user, a page at a time
+//the users can be filtered by username and sorted by username, created_at or id
 //returns 500, if error occurrs due to system failure
 //returns 400 if the user input was invalid
 //returns 200 otherwise
 func (i *UamEndpointImpl) GetAllUsersInfo(c *gin.Context) {
-	users, err := i.uamDAO.GetAllUsers()
-	if _, ok := err.(*myerr.ServerError); ok {
+	options, err := parseListOptions(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	users, cursor, err := i.uamDAO.GetAllUsers(options)
+	if _, ok := err.(*myerr.ClientError); ok {
+		common.SendErrorResponse(c, myerr.NewClientErrorWrap(err, "Cannot retrieve the users"))
+		return
+	} else if err != nil {
 		err = myerr.NewServerErrorWrap(err, "Problem with fetching all users.")
 		common.SendErrorResponse(c, err)
 		return
@@ -343,12 +1774,14 @@ func (i *UamEndpointImpl) GetAllUsersInfo(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": http.StatusOK,
-		"users":  usersInfo,
+		"status":      http.StatusOK,
+		"users":       usersInfo,
+		"next_cursor": cursor,
 	})
 }
 
-//GetAllUsersInGroup - handler for fetching info about every user in a specific group
+//GetAllUsersInGroup - handler for fetching info about every user in a specific group, a page at a time
+//the members can be filtered by username and role and sorted by username, joined_at or id
 //returns 500, if error occurrs due to system failure
 //returns 400 if the user input was invalid
 //returns 200 otherwise
@@ -365,7 +1798,24 @@ func (i *UamEndpointImpl) GetAllUsersInGroup(c *gin.Context) {
 		return
 	}
 
-	users, err := i.uamDAO.GetAllUsersInGroup(userID, groupName)
+	options, err := parseListOptions(c)
+	if err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	options.Role = c.Query("role")
+	if options.Role != "" && !permission.IsAssignableRole(options.Role) && options.Role != models.RoleOwner {
+		common.SendErrorResponse(c, myerr.NewClientError(fmt.Sprintf("Invalid role [%s]", options.Role)))
+		return
+	}
+
+	if _, _, err = i.permissions.Authorize(userID, groupName, permission.ViewGroup); err != nil {
+		common.SendErrorResponse(c, err)
+		return
+	}
+
+	users, cursor, err := i.uamDAO.GetAllUsersInGroup(userID, groupName, options)
 	if _, ok := err.(*myerr.ClientError); ok {
 		err = myerr.NewClientErrorWrap(err, "Cannot retrieve the group users")
 		common.SendErrorResponse(c, err)
@@ -385,8 +1835,9 @@ func (i *UamEndpointImpl) GetAllUsersInGroup(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": http.StatusOK,
-		"users":  usersInfo,
+		"status":      http.StatusOK,
+		"users":       usersInfo,
+		"next_cursor": cursor,
 	})
 }
 
@@ -400,3 +1851,72 @@ func validateRegistration(validator val.Validator, rq common.RequestWithCredenti
 	}
 	return nil
 }
+
+//validateAccessToken - validates the name and the scopes of a new access token
+//returns the scopes without duplicates, in the format, in which they are stored
+func validateAccessToken(rq common.AccessTokenPayload) (string, error) {
+	if len(rq.Name) == 0 || len(rq.Name) > 64 {
+		return "", myerr.NewClientError("The name of the access token should be between 1 and 64 symbols")
+	}
+
+	scopes := make([]string, 0, len(rq.Scopes))
+	unique := make(map[string]bool)
+	for _, scope := range rq.Scopes {
+		if scope != models.ScopeRead && scope != models.ScopeUpload {
+			return "", myerr.NewClientError(fmt.Sprintf("Unknown scope [%s]", scope))
+		} else if !unique[scope] {
+			unique[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+//newAuditEvent - creates an audit event for a change, made by the user through the request
+//the affected group, user and file are filled in, when the change is saved
+func newAuditEvent(c *gin.Context, actorID uint, action string, details string) *models.AuditEvent {
+	return &models.AuditEvent{
+		ActorID: &actorID,
+		Action:  action,
+		Details: details,
+		IP:      c.ClientIP(),
+	}
+}
+
+//parseListOptions - parses the pagination, the sorting and the name filter of a listing from the query parameters
+//the sort field is checked by the listing itself
+func parseListOptions(c *gin.Context) (dao.ListOptions, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || limit < 1 || limit > maxListLimit {
+		return dao.ListOptions{}, myerr.NewClientError(fmt.Sprintf("The limit should be between 1 and %d", maxListLimit))
+	}
+
+	options := dao.ListOptions{
+		Limit:  limit,
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort"),
+		Query:  c.Query("q"),
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		options.Descending = true
+	default:
+		return dao.ListOptions{}, myerr.NewClientError(fmt.Sprintf("Invalid order [%s]. The order should be asc or desc", order))
+	}
+	return options, nil
+}
+
+//parseQueryTime - parses a time limit from a query parameter in RFC 3339 format, the empty limit is nil
+func parseQueryTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, myerr.NewClientError(fmt.Sprintf("Invalid time [%s]. The time should be in RFC 3339 format", value))
+	}
+	return &parsed, nil
+}
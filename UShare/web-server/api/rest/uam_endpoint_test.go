@@ -9,13 +9,24 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/rest"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth/auth_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/lockout/lockout_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/notifier/notifier_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/oidc"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/oidc/oidc_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission/permission_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/totp"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/validator/validator_mocks"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -24,28 +35,84 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	loginSessionID = 3
+	accessTokenID  = 4
+)
+
 func setupRouter(uamRest rest.UamEndpoint, userID uint) *gin.Engine {
 	r := gin.Default()
 
+	r.GET("/.well-known/jwks.json", uamRest.GetJWKS)
+
 	public := r.Group("/public")
 	{
 		public.POST("/user/registration", uamRest.CreateUser)
 		public.POST("/user/login", uamRest.Login)
+		public.POST("/user/login/2fa", uamRest.LoginTwoFactor)
+		public.POST("/user/oidc/authorization", uamRest.StartExternalLogin)
+		public.POST("/user/oidc/login", uamRest.ExternalLogin)
+		public.GET("/user/oidc/callback", uamRest.ExternalLoginCallback)
+		public.POST("/user/token/refresh", uamRest.RefreshToken)
+		public.POST("/user/password/reset/request", uamRest.RequestPasswordReset)
+		public.POST("/user/password/reset", uamRest.ResetPassword)
 	}
 	protected := r.Group("/protected").Use(func(c *gin.Context) {
 		c.Set("userID", userID)
+		c.Set("sessionID", uint(loginSessionID))
 		c.Next()
 	})
 	{
+		protected.POST("/user/logout", uamRest.Logout)
+		protected.PUT("/user/password", uamRest.ChangePassword)
+		protected.POST("/user/2fa/enrollment", uamRest.EnrollTwoFactor)
+		protected.POST("/user/2fa/activation", uamRest.ActivateTwoFactor)
+		protected.DELETE("/user/2fa", uamRest.DisableTwoFactor)
 		protected.DELETE("/user/deletion", uamRest.DeleteUser)
 		protected.DELETE("/group/deletion", uamRest.DeleteGroup)
+		protected.GET("/groups/trash", uamRest.GetDeletedGroups)
+		protected.POST("/group/restoration", uamRest.RestoreGroup)
 		protected.POST("/group/creation", uamRest.CreateGroup)
 		protected.POST("/group/membership/revocation", uamRest.RevokeMembership)
-		protected.POST("/group/membership/invitation", uamRest.AddMember)
+		protected.POST("/group/membership/invitation", uamRest.InviteMember)
+		protected.DELETE("/group/invitation/revocation", uamRest.RevokeInvitation)
+		protected.GET("/invitations", uamRest.GetInvitations)
+		protected.POST("/invitation/acceptance", uamRest.AcceptInvitation)
+		protected.DELETE("/invitation/rejection", uamRest.DeclineInvitation)
+		protected.GET("/group/info", uamRest.GetGroupInfo)
+		protected.PUT("/group/quota", uamRest.UpdateGroupQuota)
+		protected.PUT("/group/2fa", uamRest.UpdateGroupTwoFactor)
+		protected.PUT("/group/member/role", uamRest.ChangeMemberRole)
+		protected.PUT("/group/ownership", uamRest.TransferOwnership)
+		protected.GET("/group/audit", uamRest.GetGroupAuditEvents)
+		protected.GET("/groups", uamRest.GetAllGroupsInfo)
+		protected.GET("/users", uamRest.GetAllUsersInfo)
+		protected.GET("/group/users", uamRest.GetAllUsersInGroup)
+		protected.POST("/user/token", uamRest.CreateAccessToken)
+		protected.GET("/user/tokens", uamRest.GetAccessTokens)
+		protected.DELETE("/user/token/revocation", uamRest.RevokeAccessToken)
+	}
+
+	automated := r.Group("/automated").Use(func(c *gin.Context) {
+		c.Set("userID", userID)
+		c.Set("accessTokenID", uint(accessTokenID))
+		c.Next()
+	})
+	{
+		automated.POST("/user/logout", uamRest.Logout)
+		automated.POST("/user/token", uamRest.CreateAccessToken)
+		automated.PUT("/user/password", uamRest.ChangePassword)
+		automated.POST("/user/2fa/enrollment", uamRest.EnrollTwoFactor)
 	}
 	return r
 }
 
+func generateCode(secret string, step int64) string {
+	code, err := totp.GenerateCode(secret, step)
+	Expect(err).NotTo(HaveOccurred())
+	return code
+}
+
 func assertErrorResponse(recorder *httptest.ResponseRecorder, expStatusCode int, expMessage string) {
 	Expect(recorder.Code).To(Equal(expStatusCode))
 	body := common.ErrorResponse{}
@@ -56,12 +123,16 @@ func assertErrorResponse(recorder *httptest.ResponseRecorder, expStatusCode int,
 
 var _ = Describe("UamEndpoint", func() {
 	var (
-		router     *gin.Engine
-		recorder   *httptest.ResponseRecorder
-		jwtCreator *auth_mocks.MockJwtCreator
-		uamDAO     *dao_mocks.MockUamDAO
-		validator  *validator_mocks.MockValidator
-		req        *http.Request
+		router      *gin.Engine
+		recorder    *httptest.ResponseRecorder
+		jwtCreator  *auth_mocks.MockJwtCreator
+		uamDAO      *dao_mocks.MockUamDAO
+		validator   *validator_mocks.MockValidator
+		permissions *permission_mocks.MockService
+		lockouts    *lockout_mocks.MockService
+		notifier    *notifier_mocks.MockNotifier
+		provider    *oidc_mocks.MockProvider
+		req         *http.Request
 	)
 
 	const (
@@ -77,7 +148,11 @@ var _ = Describe("UamEndpoint", func() {
 		uamDAO = dao_mocks.NewMockUamDAO(controller)
 		jwtCreator = auth_mocks.NewMockJwtCreator(controller)
 		validator = validator_mocks.NewMockValidator(controller)
-		uamRest := rest.NewUamEndPointImpl(uamDAO, jwtCreator, validator, groupsDir)
+		permissions = permission_mocks.NewMockService(controller)
+		lockouts = lockout_mocks.NewMockService(controller)
+		notifier = notifier_mocks.NewMockNotifier(controller)
+		provider = oidc_mocks.NewMockProvider(controller)
+		uamRest := rest.NewUamEndPointImpl(uamDAO, jwtCreator, validator, storage.NewLocalBlobStore(groupsDir), permissions, lockouts, notifier, provider)
 
 		router = setupRouter(uamRest, userID)
 		recorder = httptest.NewRecorder()
@@ -271,7 +346,7 @@ var _ = Describe("UamEndpoint", func() {
 						Times(0)
 
 					jwtCreator.EXPECT().
-						GenerateToken(gomock.Any()).
+						GenerateToken(gomock.Any(), gomock.Any()).
 						Times(0)
 
 					req, _ = http.NewRequest("POST", "/public/user/login", strings.NewReader("test"))
@@ -284,10 +359,50 @@ var _ = Describe("UamEndpoint", func() {
 			})
 
 			Context("with json body", func() {
+				var checkErr error
+
 				BeforeEach(func() {
 					jsonBody, _ := json.Marshal(*reqBody)
 					req, _ = http.NewRequest("POST", "/public/user/login", bytes.NewBuffer(jsonBody))
 					req.Header.Set("Content-Type", "application/json")
+
+					checkErr = nil
+					lockouts.EXPECT().
+						Check(username, gomock.Any()).
+						DoAndReturn(func(string, string) error {
+							return checkErr
+						})
+				})
+
+				Context("and the logins are locked", func() {
+					BeforeEach(func() {
+						checkErr = myerr.NewLockedError("Too many failed login attempts", 90*time.Second)
+
+						uamDAO.EXPECT().
+							GetUser(gomock.Any()).
+							Times(0)
+					})
+
+					It("returns too many requests error response", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusTooManyRequests, "Too many failed login attempts")
+						Expect(recorder.Header().Get("Retry-After")).To(Equal("90"))
+					})
+				})
+
+				Context("and the lockout check fails", func() {
+					BeforeEach(func() {
+						checkErr = myerr.NewServerError("test-error")
+
+						uamDAO.EXPECT().
+							GetUser(gomock.Any()).
+							Times(0)
+					})
+
+					It("returns internal server error response", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+					})
 				})
 
 				Context("and request to check if user exist fail", func() {
@@ -297,7 +412,7 @@ var _ = Describe("UamEndpoint", func() {
 							Return(models.User{}, myerr.NewServerError("test-error"))
 
 						jwtCreator.EXPECT().
-							GenerateToken(gomock.Any()).
+							GenerateToken(gomock.Any(), gomock.Any()).
 							Times(0)
 					})
 
@@ -315,8 +430,12 @@ var _ = Describe("UamEndpoint", func() {
 									GetUser(username).
 									Return(models.User{}, myerr.NewItemNotFoundError("test-error"))
 
+								lockouts.EXPECT().
+									RecordFailure(uint(0), username, gomock.Any()).
+									Return(nil)
+
 								jwtCreator.EXPECT().
-									GenerateToken(gomock.Any()).
+									GenerateToken(gomock.Any(), gomock.Any()).
 									Times(0)
 							})
 
@@ -331,16 +450,51 @@ var _ = Describe("UamEndpoint", func() {
 
 								uamDAO.EXPECT().
 									GetUser(username).
-									Return(models.User{Username: username, Password: string(encryptedPass)}, nil)
+									Return(models.User{ID: 1, Username: username, Password: string(encryptedPass)}, nil)
 
 								jwtCreator.EXPECT().
-									GenerateToken(gomock.Any()).
+									GenerateToken(gomock.Any(), gomock.Any()).
 									Times(0)
 							})
 
-							It("returns bad request error response", func() {
-								router.ServeHTTP(recorder, req)
-								assertErrorResponse(recorder, http.StatusBadRequest, "Invalid credentials")
+							Context("and the failure doesnt lock the logins", func() {
+								BeforeEach(func() {
+									lockouts.EXPECT().
+										RecordFailure(uint(1), username, gomock.Any()).
+										Return(nil)
+								})
+
+								It("returns bad request error response", func() {
+									router.ServeHTTP(recorder, req)
+									assertErrorResponse(recorder, http.StatusBadRequest, "Invalid credentials")
+								})
+							})
+
+							Context("and the failure locks the logins", func() {
+								BeforeEach(func() {
+									lockouts.EXPECT().
+										RecordFailure(uint(1), username, gomock.Any()).
+										Return(myerr.NewLockedError("Too many failed login attempts", time.Minute))
+								})
+
+								It("returns too many requests error response", func() {
+									router.ServeHTTP(recorder, req)
+									assertErrorResponse(recorder, http.StatusTooManyRequests, "Too many failed login attempts")
+									Expect(recorder.Header().Get("Retry-After")).To(Equal("60"))
+								})
+							})
+
+							Context("and the recording of the failure fails", func() {
+								BeforeEach(func() {
+									lockouts.EXPECT().
+										RecordFailure(uint(1), username, gomock.Any()).
+										Return(myerr.NewServerError("test-error"))
+								})
+
+								It("returns internal server error response", func() {
+									router.ServeHTTP(recorder, req)
+									assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+								})
 							})
 						})
 
@@ -357,19 +511,26 @@ var _ = Describe("UamEndpoint", func() {
 								Password: string(encryptedPass),
 							}
 							user.ID = 1
+
+							uamDAO.EXPECT().
+								GetTwoFactor(user.ID).
+								Return(models.TwoFactor{}, myerr.NewItemNotFoundError("test-error"))
+
+							lockouts.EXPECT().
+								RecordSuccess(username).
+								Return(nil)
 						})
 
-						Context("and token generation fails", func() {
+						Context("and refresh token generation fails", func() {
 							BeforeEach(func() {
-
 								gomock.InOrder(
 									uamDAO.EXPECT().
 										GetUser(user.Username).
 										Return(user, nil),
 
 									jwtCreator.EXPECT().
-										GenerateToken(user.ID).
-										Return("", errors.New("test-error")),
+										GenerateRefreshToken().
+										Return(auth.RefreshToken{}, errors.New("test-error")),
 								)
 							})
 
@@ -379,29 +540,81 @@ var _ = Describe("UamEndpoint", func() {
 							})
 						})
 
-						Context("and token generation succeeds", func() {
-							const token = "token"
+						Context("and refresh token generation succeeds", func() {
+							const sessionID = 5
+							var refreshToken auth.RefreshToken
 
 							BeforeEach(func() {
+								refreshToken = auth.RefreshToken{
+									Token:     "refresh-token",
+									Hash:      "refresh-token-hash",
+									ExpiresAt: time.Now().Add(time.Hour),
+								}
+
 								gomock.InOrder(
 									uamDAO.EXPECT().
 										GetUser(user.Username).
 										Return(user, nil),
 
 									jwtCreator.EXPECT().
-										GenerateToken(user.ID).
-										Return(token, nil),
+										GenerateRefreshToken().
+										Return(refreshToken, nil),
 								)
 							})
 
-							It("returns successful response", func() {
-								router.ServeHTTP(recorder, req)
+							Context("and session creation fails", func() {
+								BeforeEach(func() {
+									uamDAO.EXPECT().
+										CreateSession(user.ID, refreshToken.Hash, refreshToken.ExpiresAt).
+										Return(uint(0), myerr.NewServerError("test-error"))
+								})
+
+								It("returns internal server error response", func() {
+									router.ServeHTTP(recorder, req)
+									assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+								})
+							})
 
-								Expect(recorder.Code).To(Equal(http.StatusCreated))
-								body := common.LoginResponse{}
-								json.Unmarshal([]byte(recorder.Body.String()), &body)
-								Expect(body.Status).To(Equal(http.StatusCreated))
-								Expect(body.Token).To(Equal(token))
+							Context("and session creation succeeds", func() {
+								BeforeEach(func() {
+									uamDAO.EXPECT().
+										CreateSession(user.ID, refreshToken.Hash, refreshToken.ExpiresAt).
+										Return(uint(sessionID), nil)
+								})
+
+								Context("and token generation fails", func() {
+									BeforeEach(func() {
+										jwtCreator.EXPECT().
+											GenerateToken(user.ID, uint(sessionID)).
+											Return("", errors.New("test-error"))
+									})
+
+									It("returns internal server error response", func() {
+										router.ServeHTTP(recorder, req)
+										assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+									})
+								})
+
+								Context("and token generation succeeds", func() {
+									const token = "token"
+
+									BeforeEach(func() {
+										jwtCreator.EXPECT().
+											GenerateToken(user.ID, uint(sessionID)).
+											Return(token, nil)
+									})
+
+									It("returns successful response", func() {
+										router.ServeHTTP(recorder, req)
+
+										Expect(recorder.Code).To(Equal(http.StatusCreated))
+										body := common.LoginResponse{}
+										json.Unmarshal([]byte(recorder.Body.String()), &body)
+										Expect(body.Status).To(Equal(http.StatusCreated))
+										Expect(body.Token).To(Equal(token))
+										Expect(body.RefreshToken).To(Equal(refreshToken.Token))
+									})
+								})
 							})
 						})
 					})
@@ -410,269 +623,708 @@ var _ = Describe("UamEndpoint", func() {
 		})
 	})
 
-	Context("DeleteUser", func() {
-		When("login request is sent and authentication passes", func() {
+	Context("Login with two-factor authentication", func() {
+		var user models.User
+
+		BeforeEach(func() {
+			encryptedPass, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			user = models.User{
+				Username: username,
+				Password: string(encryptedPass),
+			}
+			user.ID = userID
+
+			jsonBody, _ := json.Marshal(common.RequestWithCredentials{Username: username, Password: password})
+			req, _ = http.NewRequest("POST", "/public/user/login", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			lockouts.EXPECT().
+				Check(username, gomock.Any()).
+				Return(nil)
+			uamDAO.EXPECT().
+				GetUser(username).
+				Return(user, nil)
+			uamDAO.EXPECT().
+				GetTwoFactor(user.ID).
+				Return(models.TwoFactor{UserID: user.ID, Enabled: true}, nil)
+			lockouts.EXPECT().
+				RecordSuccess(gomock.Any()).
+				Times(0)
+			uamDAO.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+		})
 
+		Context("and the creation of the challenge fails", func() {
 			BeforeEach(func() {
-				req, _ = http.NewRequest("DELETE", "/protected/user/deletion", nil)
-				req.Header.Set("Authorization", "Bearer sometoken")
+				uamDAO.EXPECT().
+					CreateLoginChallenge(user.ID, gomock.Any(), gomock.Any()).
+					Return(myerr.NewServerError("test-error"))
 			})
 
-			Context("operation of deleting user from db fails", func() {
-				BeforeEach(func() {
-					uamDAO.EXPECT().
-						DeleteUser(uint(userID)).
-						Return(myerr.NewServerError("test-error"))
-				})
-
-				It("returns internal server error response", func() {
-					router.ServeHTTP(recorder, req)
-					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
-				})
+			It("returns internal server error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
 			})
+		})
 
-			Context("operation of deleting user from db succeeds", func() {
-				Context("and user doesnt exist", func() {
-					BeforeEach(func() {
-						uamDAO.EXPECT().
-							DeleteUser(uint(userID)).
-							Return(myerr.NewItemNotFoundError("test-error"))
-					})
+		Context("and the creation of the challenge succeeds", func() {
+			var challengeHash string
 
-					It("returns internal server error response", func() {
-						router.ServeHTTP(recorder, req)
-						assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					CreateLoginChallenge(user.ID, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ uint, hash string, _ time.Time) error {
+						challengeHash = hash
+						return nil
 					})
-				})
+			})
 
-				Context("and user exists", func() {
-					BeforeEach(func() {
-						uamDAO.EXPECT().
-							DeleteUser(uint(userID)).
-							Return(nil)
-					})
+			It("returns only a challenge", func() {
+				router.ServeHTTP(recorder, req)
 
-					It("returns success response", func() {
-						router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				body := common.LoginResponse{}
+				json.Unmarshal([]byte(recorder.Body.String()), &body)
+				Expect(body.TwoFactorRequired).To(BeTrue())
+				Expect(body.Token).To(BeEmpty())
+				Expect(body.RefreshToken).To(BeEmpty())
+				Expect(auth.HashToken(body.Challenge)).To(Equal(challengeHash))
+			})
+		})
+	})
 
-						Expect(recorder.Code).To(Equal(http.StatusOK))
-						body := common.BasicResponse{}
-						json.Unmarshal([]byte(recorder.Body.String()), &body)
-						Expect(body.Status).To(Equal(http.StatusOK))
-					})
-				})
+	Context("LoginTwoFactor", func() {
+		const challenge = "challenge"
+		var (
+			secret string
+			user   models.User
+		)
+
+		sendRequest := func(code string) {
+			jsonBody, _ := json.Marshal(common.TwoFactorLoginPayload{Challenge: challenge, Code: code})
+			req, _ = http.NewRequest("POST", "/public/user/login/2fa", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+		}
+
+		BeforeEach(func() {
+			secret, _ = totp.GenerateSecret()
+			user = models.User{Username: username}
+			user.ID = userID
+		})
 
+		Context("with missing code", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					UseLoginChallenge(gomock.Any()).
+					Times(0)
+
+				sendRequest("")
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
 			})
 		})
-	})
 
-	Context("CreateGroup", func() {
-		When("login request is sent and authentication passes", func() {
-			Context("with non-json body", func() {
+		Context("and the challenge is invalid", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					UseLoginChallenge(auth.HashToken(challenge)).
+					Return(models.LoginChallenge{}, myerr.NewClientError("Invalid or expired login challenge. Please login again"))
 
-				BeforeEach(func() {
-					validator.EXPECT().
-						ValidateUsername(username).
-						Times(0)
+				sendRequest("123456")
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid or expired login challenge")
+			})
+		})
 
+		Context("and the challenge is valid", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
 					uamDAO.EXPECT().
-						CreateGroup(gomock.Any(), gomock.Any()).
-						Times(0)
+						UseLoginChallenge(auth.HashToken(challenge)).
+						Return(models.LoginChallenge{UserID: user.ID}, nil),
+					uamDAO.EXPECT().
+						GetUserByID(user.ID).
+						Return(user, nil),
+					lockouts.EXPECT().
+						Check(username, gomock.Any()).
+						Return(nil),
+					uamDAO.EXPECT().
+						GetTwoFactor(user.ID).
+						Return(models.TwoFactor{UserID: user.ID, Secret: secret, Enabled: true}, nil),
+				)
+			})
 
-					req, _ = http.NewRequest("POST", "/protected/group/creation", strings.NewReader("test"))
-				})
+			Context("and the code is wrong", func() {
+				It("records the failure and returns bad request", func() {
+					lockouts.EXPECT().
+						RecordFailure(user.ID, username, gomock.Any()).
+						Return(nil)
 
-				It("returns bad request", func() {
-					router.ServeHTTP(recorder, req)
-					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+					code := generateCode(secret, totp.Step(time.Now())+5)
+					sendRequest(code)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid credentials")
 				})
 			})
 
-			Context("with json body", func() {
-				var rqBody common.GroupPayload
-
-				BeforeEach(func() {
-					rqBody = common.GroupPayload{GroupName: groupName}
-					jsonBody, _ := json.Marshal(&rqBody)
-					req, _ = http.NewRequest("POST", "/protected/group/creation", bytes.NewBuffer(jsonBody))
-					req.Header.Set("Authorization", "Bearer sometoken")
+			Context("and the recovery code is wrong", func() {
+				It("records the failure and returns bad request", func() {
+					gomock.InOrder(
+						uamDAO.EXPECT().
+							UseRecoveryCode(user.ID, auth.HashToken("abcde-fghij")).
+							Return(myerr.NewClientError("Invalid code")),
+						lockouts.EXPECT().
+							RecordFailure(user.ID, username, gomock.Any()).
+							Return(nil),
+					)
+
+					sendRequest(" ABCDE-FGHIJ ")
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid credentials")
 				})
+			})
 
-				Context("and group name fails the validation", func() {
-					BeforeEach(func() {
-						validator.EXPECT().
-							ValidateUsername(rqBody.GroupName).
-							Return(myerr.NewClientError("test-error"))
+			Context("and the code is correct", func() {
+				const (
+					sessionID = 5
+					token     = "token"
+				)
 
+				It("returns the tokens", func() {
+					refreshToken := auth.RefreshToken{Token: "refresh-token", Hash: "refresh-token-hash", ExpiresAt: time.Now().Add(time.Hour)}
+					step := totp.Step(time.Now())
+
+					gomock.InOrder(
 						uamDAO.EXPECT().
-							CreateGroup(gomock.Any(), gomock.Any()).
-							Times(0)
-					})
+							UseTwoFactorStep(user.ID, step).
+							Return(nil),
+						lockouts.EXPECT().
+							RecordSuccess(username).
+							Return(nil),
+						jwtCreator.EXPECT().
+							GenerateRefreshToken().
+							Return(refreshToken, nil),
+						uamDAO.EXPECT().
+							CreateSession(user.ID, refreshToken.Hash, refreshToken.ExpiresAt).
+							Return(uint(sessionID), nil),
+						jwtCreator.EXPECT().
+							GenerateToken(user.ID, uint(sessionID)).
+							Return(token, nil),
+					)
 
-					It("return bad request", func() {
-						router.ServeHTTP(recorder, req)
-						assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
-					})
+					sendRequest(generateCode(secret, step))
 
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+					body := common.LoginResponse{}
+					json.Unmarshal([]byte(recorder.Body.String()), &body)
+					Expect(body.Token).To(Equal(token))
+					Expect(body.RefreshToken).To(Equal(refreshToken.Token))
 				})
+			})
+		})
+	})
 
-				Context("and group name passes the validation", func() {
-					AfterEach(func() {
-						os.RemoveAll(path.Join(groupsDir, groupName))
-					})
+	Context("EnrollTwoFactor", func() {
+		Context("with an access token", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					CreateTwoFactor(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
 
-					Context("and operation of creation group from db fails", func() {
-						Context("because connection to db fails", func() {
-							BeforeEach(func() {
-								gomock.InOrder(
-									validator.EXPECT().
-										ValidateUsername(rqBody.GroupName).
-										Return(nil),
-									uamDAO.EXPECT().
-										CreateGroup(uint(userID), rqBody.GroupName).
-										Return(myerr.NewServerError("test-error")),
-								)
-							})
+				req, _ = http.NewRequest("POST", "/automated/user/2fa/enrollment", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The two-factor authentication can be managed only after login")
+			})
+		})
 
-							It("returns internal server error response", func() {
-								router.ServeHTTP(recorder, req)
-								assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
-							})
-						})
+		Context("with a login session", func() {
+			BeforeEach(func() {
+				user := models.User{Username: username}
+				user.ID = userID
+				uamDAO.EXPECT().
+					GetUserByID(uint(userID)).
+					Return(user, nil)
 
-						Context("because group already exists", func() {
-							BeforeEach(func() {
-								gomock.InOrder(
-									validator.EXPECT().
-										ValidateUsername(rqBody.GroupName).
-										Return(nil),
-									uamDAO.EXPECT().
-										CreateGroup(uint(userID), rqBody.GroupName).
-										Return(myerr.NewClientError("test-error")),
-								)
-							})
+				req, _ = http.NewRequest("POST", "/protected/user/2fa/enrollment", nil)
+			})
 
-							It("returns bad request response", func() {
-								router.ServeHTTP(recorder, req)
-								assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
-							})
-						})
+			Context("and the two-factor authentication is already enabled", func() {
+				It("returns bad request", func() {
+					uamDAO.EXPECT().
+						CreateTwoFactor(uint(userID), gomock.Any(), gomock.Any()).
+						Return(myerr.NewClientError("Two-factor authentication is already enabled"))
 
-					})
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Two-factor authentication is already enabled")
+				})
+			})
 
-					Context("and operation of creating group from db suceeeds", func() {
-						BeforeEach(func() {
-							gomock.InOrder(
-								validator.EXPECT().
-									ValidateUsername(rqBody.GroupName).
-									Return(nil),
-								uamDAO.EXPECT().
-									CreateGroup(uint(userID), rqBody.GroupName).
-									Return(nil),
-							)
+			Context("and the enrollment succeeds", func() {
+				It("returns the secret and the recovery codes, storing only their hashes", func() {
+					var (
+						storedSecret string
+						storedHashes []string
+					)
+					uamDAO.EXPECT().
+						CreateTwoFactor(uint(userID), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ uint, secret string, hashes []string) error {
+							storedSecret = secret
+							storedHashes = hashes
+							return nil
 						})
 
-						It("returns Created", func() {
-							router.ServeHTTP(recorder, req)
+					router.ServeHTTP(recorder, req)
 
-							Expect(recorder.Code).To(Equal(http.StatusCreated))
-							body := common.BasicResponse{}
-							json.Unmarshal([]byte(recorder.Body.String()), &body)
-							Expect(body.Status).To(Equal(http.StatusCreated))
-						})
-					})
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+					body := common.TwoFactorEnrollmentResponse{}
+					json.Unmarshal([]byte(recorder.Body.String()), &body)
+					Expect(body.Secret).To(Equal(storedSecret))
+					Expect(body.ProvisioningURI).To(ContainSubstring("secret=" + storedSecret))
+					Expect(body.RecoveryCodes).To(HaveLen(len(storedHashes)))
+					for idx, code := range body.RecoveryCodes {
+						Expect(auth.HashToken(code)).To(Equal(storedHashes[idx]))
+					}
 				})
 			})
 		})
 	})
 
-	Context("AddMember", func() {
-		When("request a user to be added to group is sent and authentication passes", func() {
-			Context("with non-json body", func() {
+	Context("ActivateTwoFactor", func() {
+		var secret string
 
-				BeforeEach(func() {
-					uamDAO.EXPECT().
-						AddUserToGroup(uint(userID), username, groupName).
-						Times(0)
+		BeforeEach(func() {
+			secret, _ = totp.GenerateSecret()
+		})
 
-					req, _ = http.NewRequest("POST", "/protected/group/membership/invitation", strings.NewReader("test"))
-				})
+		sendRequest := func(code string) {
+			jsonBody, _ := json.Marshal(common.TwoFactorCodePayload{Code: code})
+			req, _ = http.NewRequest("POST", "/protected/user/2fa/activation", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+		}
+
+		Context("and there is no pending enrollment", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetTwoFactor(uint(userID)).
+					Return(models.TwoFactor{}, myerr.NewItemNotFoundError("test-error"))
+
+				sendRequest("123456")
+				assertErrorResponse(recorder, http.StatusBadRequest, "There is no pending two-factor enrollment")
+			})
+		})
 
-				It("returns bad request", func() {
-					router.ServeHTTP(recorder, req)
-					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
-				})
+		Context("and there is a pending enrollment", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetTwoFactor(uint(userID)).
+					Return(models.TwoFactor{UserID: userID, Secret: secret}, nil)
 			})
 
-			Context("with json body", func() {
-				var rqBody common.GroupMembershipPayload
+			It("returns bad request with a wrong code", func() {
+				uamDAO.EXPECT().
+					EnableTwoFactor(gomock.Any(), gomock.Any()).
+					Times(0)
 
-				BeforeEach(func() {
-					rqBody = common.GroupMembershipPayload{Username: username}
-					rqBody.GroupName = groupName
-					jsonBody, _ := json.Marshal(&rqBody)
-					req, _ = http.NewRequest("POST", "/protected/group/membership/invitation", bytes.NewBuffer(jsonBody))
-					req.Header.Set("Authorization", "Bearer sometoken")
+				sendRequest(generateCode(secret, totp.Step(time.Now())+5))
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid code")
+			})
+
+			It("enables the two-factor authentication with a correct code", func() {
+				step := totp.Step(time.Now())
+				uamDAO.EXPECT().
+					EnableTwoFactor(uint(userID), step).
+					Return(nil)
+
+				sendRequest(generateCode(secret, step))
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("DisableTwoFactor", func() {
+		const recoveryCode = "abcde-fghij"
+
+		BeforeEach(func() {
+			jsonBody, _ := json.Marshal(common.TwoFactorCodePayload{Code: recoveryCode})
+			req, _ = http.NewRequest("DELETE", "/protected/user/2fa", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+		})
+
+		Context("and the two-factor authentication isnt enabled", func() {
+			It("returns not found", func() {
+				uamDAO.EXPECT().
+					GetTwoFactor(uint(userID)).
+					Return(models.TwoFactor{UserID: userID}, nil)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusNotFound, "Two-factor authentication isnt enabled")
+			})
+		})
+
+		Context("and the two-factor authentication is enabled", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetTwoFactor(uint(userID)).
+					Return(models.TwoFactor{UserID: userID, Enabled: true}, nil)
+			})
+
+			It("returns bad request with an invalid recovery code", func() {
+				gomock.InOrder(
+					uamDAO.EXPECT().
+						UseRecoveryCode(uint(userID), auth.HashToken(recoveryCode)).
+						Return(myerr.NewClientError("Invalid code")),
+					uamDAO.EXPECT().
+						DeleteTwoFactor(gomock.Any()).
+						Times(0),
+				)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid code")
+			})
+
+			It("disables the two-factor authentication with a valid recovery code", func() {
+				gomock.InOrder(
+					uamDAO.EXPECT().
+						UseRecoveryCode(uint(userID), auth.HashToken(recoveryCode)).
+						Return(nil),
+					uamDAO.EXPECT().
+						DeleteTwoFactor(uint(userID)).
+						Return(nil),
+				)
+
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("UpdateGroupTwoFactor", func() {
+		sendRequest := func(required bool) {
+			jsonBody, _ := json.Marshal(common.GroupTwoFactorPayload{GroupPayload: common.GroupPayload{GroupName: groupName}, Required: required})
+			req, _ = http.NewRequest("PUT", "/protected/group/2fa", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+		}
+
+		Context("and the user isnt the owner", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageGroup).
+					Return(models.Group{}, "", myerr.NewClientError("Only the owner can manage the group"))
+				uamDAO.EXPECT().
+					UpdateGroupTwoFactor(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(true)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Only the owner can manage the group")
+			})
+		})
+
+		Context("and the user is the owner", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageGroup).
+					Return(models.Group{Name: groupName}, models.RoleOwner, nil)
+			})
+
+			It("doesnt require the two-factor authentication if the owner hasnt enabled it", func() {
+				gomock.InOrder(
+					uamDAO.EXPECT().
+						GetTwoFactor(uint(userID)).
+						Return(models.TwoFactor{}, myerr.NewItemNotFoundError("test-error")),
+					uamDAO.EXPECT().
+						UpdateGroupTwoFactor(gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0),
+				)
+
+				sendRequest(true)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Enable two-factor authentication, before requiring it from the members")
+			})
+
+			It("requires the two-factor authentication", func() {
+				gomock.InOrder(
+					uamDAO.EXPECT().
+						GetTwoFactor(uint(userID)).
+						Return(models.TwoFactor{Enabled: true}, nil),
+					uamDAO.EXPECT().
+						UpdateGroupTwoFactor(groupName, true, gomock.Any()).
+						Return(nil),
+				)
+
+				sendRequest(true)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+
+			It("drops the requirement without checking the owner", func() {
+				gomock.InOrder(
+					uamDAO.EXPECT().
+						GetTwoFactor(gomock.Any()).
+						Times(0),
+					uamDAO.EXPECT().
+						UpdateGroupTwoFactor(groupName, false, gomock.Any()).
+						Return(nil),
+				)
+
+				sendRequest(false)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("StartExternalLogin", func() {
+		const redirectURI = "http://127.0.0.1:9999/callback"
+
+		sendRequest := func(payload common.ExternalLoginStartPayload) {
+			jsonBody, _ := json.Marshal(payload)
+			req, _ = http.NewRequest("POST", "/public/user/oidc/authorization", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(recorder, req)
+		}
+
+		Context("and the identity provider isnt configured", func() {
+			It("returns not found", func() {
+				uamRest := rest.NewUamEndPointImpl(uamDAO, jwtCreator, validator, storage.NewLocalBlobStore(groupsDir), permissions, lockouts, notifier, nil)
+				router = setupRouter(uamRest, userID)
+
+				sendRequest(common.ExternalLoginStartPayload{RedirectURI: redirectURI})
+				assertErrorResponse(recorder, http.StatusNotFound, "The login through an identity provider isnt configured")
+			})
+		})
+
+		Context("and the redirect uri is invalid", func() {
+			It("returns bad request", func() {
+				provider.EXPECT().
+					RedirectURL().
+					Return("")
+
+				sendRequest(common.ExternalLoginStartPayload{})
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid redirect uri")
+			})
+		})
+
+		Context("and the discovery of the identity provider fails", func() {
+			It("returns internal server error", func() {
+				provider.EXPECT().
+					AuthorizationURL(redirectURI, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return("", myerr.NewServerError("test-error"))
+				uamDAO.EXPECT().
+					CreateExternalLogin(gomock.Any()).
+					Times(0)
+
+				sendRequest(common.ExternalLoginStartPayload{RedirectURI: redirectURI})
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+
+		Context("and the login is started", func() {
+			It("returns the authorization url and keeps only the hash of the state", func() {
+				var state, nonce, codeVerifier string
+				gomock.InOrder(
+					provider.EXPECT().
+						AuthorizationURL(redirectURI, gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_, s, n, v string) (string, error) {
+							state, nonce, codeVerifier = s, n, v
+							return "https://idp/authorize?state=" + s, nil
+						}),
+					uamDAO.EXPECT().
+						CreateExternalLogin(gomock.Any()).
+						DoAndReturn(func(login models.ExternalLogin) error {
+							Expect(login.StateHash).To(Equal(auth.HashToken(state)))
+							Expect(login.Nonce).To(Equal(nonce))
+							Expect(login.CodeVerifier).To(Equal(codeVerifier))
+							Expect(login.RedirectURI).To(Equal(redirectURI))
+							Expect(login.ExpiresAt).To(BeTemporally(">", time.Now()))
+							return nil
+						}),
+				)
+
+				sendRequest(common.ExternalLoginStartPayload{RedirectURI: redirectURI})
+
+				Expect(recorder.Code).To(Equal(http.StatusCreated))
+				body := common.ExternalLoginStartResponse{}
+				json.Unmarshal([]byte(recorder.Body.String()), &body)
+				Expect(body.AuthorizationURL).To(Equal("https://idp/authorize?state=" + state))
+				Expect(state).NotTo(Equal(nonce))
+				Expect(codeVerifier).NotTo(Equal(state))
+			})
+		})
+	})
+
+	Context("ExternalLogin", func() {
+		const (
+			state = "state"
+			code  = "code"
+		)
+
+		var (
+			login    models.ExternalLogin
+			identity oidc.Identity
+		)
+
+		BeforeEach(func() {
+			login = models.ExternalLogin{
+				Nonce:        "nonce",
+				CodeVerifier: "code-verifier",
+				RedirectURI:  "http://127.0.0.1:9999/callback",
+			}
+			identity = oidc.Identity{
+				Issuer:            "https://idp.example.com",
+				Subject:           "subject",
+				PreferredUsername: username,
+			}
+
+			jsonBody, _ := json.Marshal(common.ExternalLoginPayload{State: state, Code: code})
+			req, _ = http.NewRequest("POST", "/public/user/oidc/login", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+		})
+
+		Context("and the state is invalid", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					UseExternalLogin(auth.HashToken(state)).
+					Return(models.ExternalLogin{}, myerr.NewClientError("Invalid or expired login state. Please login again"))
+				provider.EXPECT().
+					Exchange(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid or expired login state")
+			})
+		})
+
+		Context("and the state is valid", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					UseExternalLogin(auth.HashToken(state)).
+					Return(login, nil)
+			})
+
+			Context("and the identity provider rejects the code", func() {
+				It("returns bad request", func() {
+					gomock.InOrder(
+						provider.EXPECT().
+							Exchange(code, login.RedirectURI, login.CodeVerifier, login.Nonce).
+							Return(oidc.Identity{}, myerr.NewClientError("The identity provider rejected the login")),
+						uamDAO.EXPECT().
+							GetOrCreateExternalUser(gomock.Any(), gomock.Any(), gomock.Any()).
+							Times(0),
+					)
+
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "The identity provider rejected the login")
 				})
+			})
 
-				Context("and membership creation fails", func() {
-					Context("and request fails due to problem with the server", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								AddUserToGroup(uint(userID), username, groupName).
-								Return(myerr.NewServerError("some-error"))
-						})
+			Context("and the identity provider accepts the code", func() {
+				const (
+					sessionID = 5
+					token     = "token"
+				)
 
-						It("returns internal server error", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
-						})
-					})
+				var user models.User
 
-					Context("and username or group doesnt exist", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								AddUserToGroup(uint(userID), username, groupName).
-								Return(myerr.NewClientError("some-error"))
-						})
+				BeforeEach(func() {
+					user = models.User{Username: username}
+					user.ID = userID
 
-						It("returns bad request", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
-						})
-					})
+					gomock.InOrder(
+						provider.EXPECT().
+							Exchange(code, login.RedirectURI, login.CodeVerifier, login.Nonce).
+							Return(identity, nil),
+						uamDAO.EXPECT().
+							GetOrCreateExternalUser(identity.Issuer, identity.Subject, identity.Username()).
+							Return(user, nil),
+						lockouts.EXPECT().
+							Check(username, gomock.Any()).
+							Return(nil),
+					)
 				})
 
-				Context("and membership creation succeeds", func() {
-					BeforeEach(func() {
+				It("sends only a challenge to a user with two-factor authentication", func() {
+					gomock.InOrder(
 						uamDAO.EXPECT().
-							AddUserToGroup(uint(userID), username, groupName).
-							Return(nil)
-					})
+							GetTwoFactor(user.ID).
+							Return(models.TwoFactor{UserID: user.ID, Enabled: true}, nil),
+						uamDAO.EXPECT().
+							CreateLoginChallenge(user.ID, gomock.Any(), gomock.Any()).
+							Return(nil),
+					)
 
-					It("returns created", func() {
-						router.ServeHTTP(recorder, req)
+					router.ServeHTTP(recorder, req)
 
-						Expect(recorder.Code).To(Equal(http.StatusCreated))
-						body := common.BasicResponse{}
-						json.Unmarshal([]byte(recorder.Body.String()), &body)
-						Expect(body.Status).To(Equal(http.StatusCreated))
-					})
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					body := common.LoginResponse{}
+					json.Unmarshal([]byte(recorder.Body.String()), &body)
+					Expect(body.TwoFactorRequired).To(BeTrue())
+					Expect(body.Token).To(BeEmpty())
+				})
+
+				It("returns the tokens of a new session", func() {
+					refreshToken := auth.RefreshToken{Token: "refresh-token", Hash: "refresh-token-hash", ExpiresAt: time.Now().Add(time.Hour)}
+					gomock.InOrder(
+						uamDAO.EXPECT().
+							GetTwoFactor(user.ID).
+							Return(models.TwoFactor{}, myerr.NewItemNotFoundError("test-error")),
+						lockouts.EXPECT().
+							RecordSuccess(username).
+							Return(nil),
+						jwtCreator.EXPECT().
+							GenerateRefreshToken().
+							Return(refreshToken, nil),
+						uamDAO.EXPECT().
+							CreateSession(user.ID, refreshToken.Hash, refreshToken.ExpiresAt).
+							Return(uint(sessionID), nil),
+						jwtCreator.EXPECT().
+							GenerateToken(user.ID, uint(sessionID)).
+							Return(token, nil),
+					)
+
+					router.ServeHTTP(recorder, req)
+
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+					body := common.LoginResponse{}
+					json.Unmarshal([]byte(recorder.Body.String()), &body)
+					Expect(body.Token).To(Equal(token))
+					Expect(body.RefreshToken).To(Equal(refreshToken.Token))
 				})
 			})
 		})
 	})
 
-	Context("RevokeMembership", func() {
-		When("request a user to be added to group is sent and authentication passes", func() {
-			Context("with non-json body", func() {
+	Context("ExternalLoginCallback", func() {
+		It("returns bad request, if the identity provider sends an error", func() {
+			uamDAO.EXPECT().
+				UseExternalLogin(gomock.Any()).
+				Times(0)
 
-				BeforeEach(func() {
-					uamDAO.EXPECT().
-						RemoveUserFromGroup(uint(userID), username, groupName).
-						Times(0)
+			req, _ = http.NewRequest("GET", "/public/user/oidc/callback?error=access_denied&state=state", nil)
+			router.ServeHTTP(recorder, req)
+			assertErrorResponse(recorder, http.StatusBadRequest, "access_denied")
+		})
 
-					req, _ = http.NewRequest("POST", "/protected/group/membership/revocation", strings.NewReader("test"))
+		It("returns bad request without authorization code", func() {
+			req, _ = http.NewRequest("GET", "/public/user/oidc/callback?state=state", nil)
+			router.ServeHTTP(recorder, req)
+			assertErrorResponse(recorder, http.StatusBadRequest, "Missing state or authorization code")
+		})
+
+		It("consumes the state from the query", func() {
+			uamDAO.EXPECT().
+				UseExternalLogin(auth.HashToken("state")).
+				Return(models.ExternalLogin{}, myerr.NewClientError("Invalid or expired login state. Please login again"))
+
+			req, _ = http.NewRequest("GET", "/public/user/oidc/callback?state=state&code=code", nil)
+			router.ServeHTTP(recorder, req)
+			assertErrorResponse(recorder, http.StatusBadRequest, "Invalid or expired login state")
+		})
+	})
+
+	Context("RefreshToken", func() {
+		When("refresh request is sent", func() {
+			const refreshTokenVal = "refresh-token"
+
+			Context("with non-json body", func() {
+				BeforeEach(func() {
+					req, _ = http.NewRequest("POST", "/public/user/token/refresh", strings.NewReader("test"))
 				})
 
 				It("returns bad request", func() {
@@ -682,136 +1334,1839 @@ var _ = Describe("UamEndpoint", func() {
 			})
 
 			Context("with json body", func() {
-				var rqBody common.GroupMembershipPayload
+				var newRefreshToken auth.RefreshToken
 
 				BeforeEach(func() {
-					rqBody = common.GroupMembershipPayload{Username: username}
-					rqBody.GroupName = groupName
-					jsonBody, _ := json.Marshal(&rqBody)
-					req, _ = http.NewRequest("POST", "/protected/group/membership/revocation", bytes.NewBuffer(jsonBody))
-					req.Header.Set("Authorization", "Bearer sometoken")
-				})
+					jsonBody, _ := json.Marshal(common.RefreshTokenPayload{RefreshToken: refreshTokenVal})
+					req, _ = http.NewRequest("POST", "/public/user/token/refresh", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Content-Type", "application/json")
 
-				Context("and membership deletion fails", func() {
-					Context("and request fails due to problem with the server", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								RemoveUserFromGroup(uint(userID), username, groupName).
-								Return(myerr.NewServerError("some-error"))
-						})
+					newRefreshToken = auth.RefreshToken{
+						Token:     "new-refresh-token",
+						Hash:      "new-refresh-token-hash",
+						ExpiresAt: time.Now().Add(time.Hour),
+					}
+					jwtCreator.EXPECT().
+						GenerateRefreshToken().
+						Return(newRefreshToken, nil)
+				})
 
-						It("returns internal server error", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
-						})
+				Context("and the rotation of the session fails", func() {
+					BeforeEach(func() {
+						uamDAO.EXPECT().
+							RotateSession(auth.HashToken(refreshTokenVal), newRefreshToken.Hash, newRefreshToken.ExpiresAt).
+							Return(models.Session{}, myerr.NewClientError("The session is revoked. Please login again"))
 					})
 
-					Context("and username or group doesnt exist", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								RemoveUserFromGroup(uint(userID), username, groupName).
-								Return(myerr.NewClientError("some-error"))
-						})
-
-						It("returns bad request", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
-						})
+					It("returns bad request", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "The session is revoked. Please login again")
 					})
 				})
 
-				Context("and membership deletion succeeds", func() {
+				Context("and the rotation of the session succeeds", func() {
+					const (
+						sessionID = 5
+						token     = "token"
+					)
+
 					BeforeEach(func() {
-						uamDAO.EXPECT().
-							RemoveUserFromGroup(uint(userID), username, groupName).
-							Return(nil)
+						gomock.InOrder(
+							uamDAO.EXPECT().
+								RotateSession(auth.HashToken(refreshTokenVal), newRefreshToken.Hash, newRefreshToken.ExpiresAt).
+								Return(models.Session{ID: sessionID, UserID: userID}, nil),
+							jwtCreator.EXPECT().
+								GenerateToken(uint(userID), uint(sessionID)).
+								Return(token, nil),
+						)
 					})
 
-					It("returns created", func() {
+					It("returns the new tokens", func() {
 						router.ServeHTTP(recorder, req)
 
-						Expect(recorder.Code).To(Equal(http.StatusOK))
-						body := common.BasicResponse{}
+						Expect(recorder.Code).To(Equal(http.StatusCreated))
+						body := common.LoginResponse{}
 						json.Unmarshal([]byte(recorder.Body.String()), &body)
-						Expect(body.Status).To(Equal(http.StatusOK))
+						Expect(body.Token).To(Equal(token))
+						Expect(body.RefreshToken).To(Equal(newRefreshToken.Token))
 					})
 				})
 			})
 		})
 	})
 
-	Context("DeleteGroup", func() {
-		When("request a user to be added to group is sent and authentication passes", func() {
-			Context("with non-json body", func() {
+	Context("Logout", func() {
+		When("logout request is sent", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("POST", "/protected/user/logout", nil)
+			})
 
+			Context("and the revocation of the session fails", func() {
 				BeforeEach(func() {
 					uamDAO.EXPECT().
-						DeactivateGroup(uint(userID), groupName).
-						Times(0)
-
-					req, _ = http.NewRequest("DELETE", "/protected/group/deletion", strings.NewReader("test"))
+						RevokeSession(uint(loginSessionID)).
+						Return(myerr.NewServerError("test-error"))
 				})
 
-				It("returns bad request", func() {
+				It("returns internal server error response", func() {
 					router.ServeHTTP(recorder, req)
-					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
 				})
 			})
 
-			Context("with json body", func() {
-				var rqBody common.GroupPayload
-
+			Context("and the revocation of the session succeeds", func() {
 				BeforeEach(func() {
-					rqBody = common.GroupPayload{GroupName: groupName}
-					jsonBody, _ := json.Marshal(&rqBody)
-					req, _ = http.NewRequest("DELETE", "/protected/group/deletion", bytes.NewBuffer(jsonBody))
-					req.Header.Set("Authorization", "Bearer sometoken")
+					uamDAO.EXPECT().
+						RevokeSession(uint(loginSessionID)).
+						Return(nil)
 				})
 
-				Context("and membership deletion fails", func() {
-					Context("and request fails due to problem with the server", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								DeactivateGroup(uint(userID), groupName).
-								Return(myerr.NewServerError("some-error"))
-						})
+				It("returns successful response", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
 
-						It("returns internal server error", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
-						})
-					})
+	Context("Logout with an access token", func() {
+		It("returns bad request", func() {
+			req, _ = http.NewRequest("POST", "/automated/user/logout", nil)
+			uamDAO.EXPECT().
+				RevokeSession(gomock.Any()).
+				Times(0)
 
-					Context("and username or group doesnt exist or user doesnt have required permissions", func() {
-						BeforeEach(func() {
-							uamDAO.EXPECT().
-								DeactivateGroup(uint(userID), groupName).
-								Return(myerr.NewClientError("some-error"))
-						})
+			router.ServeHTTP(recorder, req)
+			assertErrorResponse(recorder, http.StatusBadRequest, "The access tokens dont have a session")
+		})
+	})
 
-						It("returns bad request", func() {
-							router.ServeHTTP(recorder, req)
-							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
-						})
-					})
-				})
+	Context("ChangePassword", func() {
+		const newPassword = "new-password"
+		var rqBody common.PasswordChangePayload
 
-				Context("and membership deletion succeeds", func() {
-					BeforeEach(func() {
-						uamDAO.EXPECT().
-							DeactivateGroup(uint(userID), groupName).
-							Return(nil)
-					})
+		BeforeEach(func() {
+			rqBody = common.PasswordChangePayload{
+				OldPassword: password,
+				NewPassword: newPassword,
+			}
+		})
 
-					It("returns created", func() {
-						router.ServeHTTP(recorder, req)
+		JustBeforeEach(func() {
+			jsonBody, _ := json.Marshal(&rqBody)
+			req, _ = http.NewRequest("PUT", "/protected/user/password", bytes.NewBuffer(jsonBody))
+		})
 
-						Expect(recorder.Code).To(Equal(http.StatusOK))
-						body := common.BasicResponse{}
-						json.Unmarshal([]byte(recorder.Body.String()), &body)
-						Expect(body.Status).To(Equal(http.StatusOK))
-					})
-				})
+		When("the request is authorized with an access token", func() {
+			JustBeforeEach(func() {
+				jsonBody, _ := json.Marshal(&rqBody)
+				req, _ = http.NewRequest("PUT", "/automated/user/password", bytes.NewBuffer(jsonBody))
+			})
+
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					ChangePassword(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The password can be changed only after login")
+			})
+		})
+
+		When("the new password is invalid", func() {
+			BeforeEach(func() {
+				validator.EXPECT().
+					ValidatePassword(newPassword).
+					Return(errors.New("too short"))
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Problem with the password")
+			})
+		})
+
+		When("the new password is valid", func() {
+			var hashedPassword []byte
+
+			BeforeEach(func() {
+				hashedPassword, _ = bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				gomock.InOrder(
+					validator.EXPECT().
+						ValidatePassword(newPassword).
+						Return(nil),
+					uamDAO.EXPECT().
+						GetUserByID(uint(userID)).
+						Return(models.User{ID: userID, Username: username, Password: string(hashedPassword)}, nil),
+				)
+			})
+
+			Context("and the old password doesnt match", func() {
+				BeforeEach(func() {
+					rqBody.OldPassword = "wrong-password"
+				})
+
+				It("returns bad request", func() {
+					uamDAO.EXPECT().
+						ChangePassword(gomock.Any(), gomock.Any()).
+						Times(0)
+
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid credentials")
+				})
+			})
+
+			Context("and the change of the password fails", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						ChangePassword(uint(userID), gomock.Any()).
+						Return(myerr.NewServerError("test-error"))
+				})
+
+				It("returns internal server error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("and the change of the password succeeds", func() {
+				var storedPassword string
+
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						ChangePassword(uint(userID), gomock.Any()).
+						DoAndReturn(func(_ uint, password string) error {
+							storedPassword = password
+							return nil
+						})
+				})
+
+				It("stores the hash of the new password", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(newPassword))).To(Succeed())
+				})
+			})
+		})
+	})
+
+	Context("RequestPasswordReset", func() {
+		JustBeforeEach(func() {
+			jsonBody, _ := json.Marshal(common.PasswordResetRequestPayload{Username: username})
+			req, _ = http.NewRequest("POST", "/public/user/password/reset/request", bytes.NewBuffer(jsonBody))
+		})
+
+		When("the user doesnt exist", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetUser(username).
+					Return(models.User{}, nil)
+			})
+
+			It("accepts the request without sending a token", func() {
+				uamDAO.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				notifier.EXPECT().
+					NotifyPasswordReset(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusAccepted))
+			})
+		})
+
+		When("the user exists", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetUser(username).
+					Return(models.User{ID: userID, Username: username}, nil)
+			})
+
+			Context("and the creation of the reset fails", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						CreatePasswordReset(uint(userID), gomock.Any(), gomock.Any()).
+						Return(myerr.NewServerError("test-error"))
+				})
+
+				It("returns internal server error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("and the creation of the reset succeeds", func() {
+				var (
+					storedHash string
+					sentToken  string
+				)
+
+				BeforeEach(func() {
+					gomock.InOrder(
+						uamDAO.EXPECT().
+							CreatePasswordReset(uint(userID), gomock.Any(), gomock.Any()).
+							DoAndReturn(func(_ uint, tokenHash string, _ time.Time) error {
+								storedHash = tokenHash
+								return nil
+							}),
+						notifier.EXPECT().
+							NotifyPasswordReset(username, gomock.Any(), gomock.Any()).
+							DoAndReturn(func(_ string, token string, _ time.Time) error {
+								sentToken = token
+								return nil
+							}),
+					)
+				})
+
+				It("sends the token, whose hash is stored", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusAccepted))
+					Expect(sentToken).NotTo(BeEmpty())
+					Expect(storedHash).To(Equal(auth.HashToken(sentToken)))
+				})
+			})
+		})
+	})
+
+	Context("ResetPassword", func() {
+		const (
+			resetToken  = "reset-token"
+			newPassword = "new-password"
+		)
+
+		JustBeforeEach(func() {
+			jsonBody, _ := json.Marshal(common.PasswordResetPayload{Token: resetToken, NewPassword: newPassword})
+			req, _ = http.NewRequest("POST", "/public/user/password/reset", bytes.NewBuffer(jsonBody))
+		})
+
+		When("the new password is invalid", func() {
+			BeforeEach(func() {
+				validator.EXPECT().
+					ValidatePassword(newPassword).
+					Return(errors.New("too short"))
+			})
+
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					ResetPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Problem with the password")
+			})
+		})
+
+		When("the new password is valid", func() {
+			BeforeEach(func() {
+				validator.EXPECT().
+					ValidatePassword(newPassword).
+					Return(nil)
+			})
+
+			Context("and the token is invalid or expired", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						ResetPassword(auth.HashToken(resetToken), gomock.Any()).
+						Return(myerr.NewClientError("Invalid or expired reset token"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid or expired reset token")
+				})
+			})
+
+			Context("and the token is valid", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						ResetPassword(auth.HashToken(resetToken), gomock.Any()).
+						Return(nil)
+				})
+
+				It("returns successful response", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("CreateAccessToken", func() {
+		const tokenName = "ci"
+		var rqBody common.AccessTokenPayload
+
+		BeforeEach(func() {
+			rqBody = common.AccessTokenPayload{
+				Name:   tokenName,
+				Scopes: []string{models.ScopeUpload, models.ScopeUpload},
+			}
+		})
+
+		JustBeforeEach(func() {
+			jsonBody, _ := json.Marshal(&rqBody)
+			req, _ = http.NewRequest("POST", "/protected/user/token", bytes.NewBuffer(jsonBody))
+		})
+
+		When("the request is authorized with an access token", func() {
+			JustBeforeEach(func() {
+				jsonBody, _ := json.Marshal(&rqBody)
+				req, _ = http.NewRequest("POST", "/automated/user/token", bytes.NewBuffer(jsonBody))
+			})
+
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					CreateAccessToken(gomock.Any()).
+					Times(0)
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The access tokens can be created only after login")
+			})
+		})
+
+		When("the scope is unknown", func() {
+			BeforeEach(func() {
+				rqBody.Scopes = []string{"delete"}
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Unknown scope [delete]")
+			})
+		})
+
+		When("the name is missing", func() {
+			BeforeEach(func() {
+				rqBody.Name = ""
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The name of the access token should be between 1 and 64 symbols")
+			})
+		})
+
+		When("the token is restricted to a group", func() {
+			BeforeEach(func() {
+				rqBody.GroupName = groupName
+				rqBody.ExpiresIn = 24
+			})
+
+			Context("and the user isnt a member of the group", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ViewGroup).
+						Return(models.Group{}, "", myerr.NewClientError("You arent a member of the group"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "You arent a member of the group")
+				})
+			})
+
+			Context("and the user is a member of the group", func() {
+				var token models.AccessToken
+
+				BeforeEach(func() {
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ViewGroup).
+						Return(models.Group{ID: 5, Name: groupName}, models.RoleContributor, nil)
+
+					uamDAO.EXPECT().
+						CreateAccessToken(gomock.Any()).
+						DoAndReturn(func(t models.AccessToken) (uint, error) {
+							token = t
+							return accessTokenID, nil
+						})
+				})
+
+				It("stores the hash of the token and returns the token", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusCreated))
+
+					var response common.AccessTokenResponse
+					json.Unmarshal(recorder.Body.Bytes(), &response)
+					Expect(response.ID).To(Equal(uint(accessTokenID)))
+					Expect(response.Token).To(HavePrefix(auth.AccessTokenPrefix))
+					Expect(response.ExpiresAt).NotTo(BeNil())
+
+					Expect(token.UserID).To(Equal(uint(userID)))
+					Expect(token.Name).To(Equal(tokenName))
+					Expect(token.TokenHash).To(Equal(auth.HashToken(response.Token)))
+					Expect(token.Scopes).To(Equal(models.ScopeUpload))
+					Expect(*token.GroupID).To(Equal(uint(5)))
+					Expect(*token.ExpiresAt).To(BeTemporally("~", time.Now().Add(24*time.Hour), time.Minute))
+				})
+			})
+		})
+
+		When("the user already has a token with the same name", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					CreateAccessToken(gomock.Any()).
+					Return(uint(0), myerr.NewClientError("Access token with name [ci] already exists"))
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "already exists")
+			})
+		})
+
+		When("the creation of the token fails", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					CreateAccessToken(gomock.Any()).
+					Return(uint(0), myerr.NewServerError("some-error"))
+			})
+
+			It("returns internal server error", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+	})
+
+	Context("GetAccessTokens", func() {
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/protected/user/tokens", nil)
+		})
+
+		When("the retrieval of the tokens fails", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetAccessTokens(uint(userID)).
+					Return(nil, myerr.NewServerError("some-error"))
+			})
+
+			It("returns internal server error", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+
+		When("the retrieval of the tokens succeeds", func() {
+			BeforeEach(func() {
+				group := groupName
+				uamDAO.EXPECT().
+					GetAccessTokens(uint(userID)).
+					Return([]dao.AccessTokenDetails{
+						{ID: 4, Name: "ci", Scopes: "read,upload", GroupName: &group},
+						{ID: 5, Name: "admin"},
+					}, nil)
+			})
+
+			It("returns the tokens", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				var response common.AccessTokensResponse
+				json.Unmarshal(recorder.Body.Bytes(), &response)
+				Expect(response.Tokens).To(HaveLen(2))
+				Expect(response.Tokens[0].Scopes).To(Equal([]string{models.ScopeRead, models.ScopeUpload}))
+				Expect(*response.Tokens[0].GroupName).To(Equal(groupName))
+				Expect(response.Tokens[1].Scopes).To(BeEmpty())
+				Expect(response.Tokens[1].GroupName).To(BeNil())
+			})
+		})
+	})
+
+	Context("RevokeAccessToken", func() {
+		BeforeEach(func() {
+			jsonBody, _ := json.Marshal(&common.AccessTokenRevocationPayload{TokenID: accessTokenID})
+			req, _ = http.NewRequest("DELETE", "/protected/user/token/revocation", bytes.NewBuffer(jsonBody))
+		})
+
+		When("the user doesnt have such token", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					DeleteAccessToken(uint(userID), uint(accessTokenID)).
+					Return(myerr.NewItemNotFoundError("Access token not found"))
+			})
+
+			It("returns not found", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusNotFound, "Access token not found")
+			})
+		})
+
+		When("the token is revoked", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					DeleteAccessToken(uint(userID), uint(accessTokenID)).
+					Return(nil)
+			})
+
+			It("returns successful response", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("GetJWKS", func() {
+		When("jwks request is sent", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", "/.well-known/jwks.json", nil)
+				jwtCreator.EXPECT().
+					GetJWKS().
+					Return(auth.JSONWebKeySet{Keys: []auth.JSONWebKey{{KeyType: "OKP", KeyID: "kid", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519", X: "x"}}})
+			})
+
+			It("returns the public keys", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(MatchJSON(`{"keys":[{"kty":"OKP","kid":"kid","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"x"}]}`))
+			})
+		})
+	})
+
+	Context("DeleteUser", func() {
+		When("login request is sent and authentication passes", func() {
+
+			BeforeEach(func() {
+				req, _ = http.NewRequest("DELETE", "/protected/user/deletion", nil)
+				req.Header.Set("Authorization", "Bearer sometoken")
+			})
+
+			Context("operation of deactivating user in db fails", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						DeactivateUser(uint(userID)).
+						Return(myerr.NewServerError("test-error"))
+				})
+
+				It("returns internal server error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("operation of deactivating user in db succeeds", func() {
+				Context("and user doesnt exist", func() {
+					BeforeEach(func() {
+						uamDAO.EXPECT().
+							DeactivateUser(uint(userID)).
+							Return(myerr.NewItemNotFoundError("test-error"))
+					})
+
+					It("returns internal server error response", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+					})
+				})
+
+				Context("and user exists", func() {
+					BeforeEach(func() {
+						uamDAO.EXPECT().
+							DeactivateUser(uint(userID)).
+							Return(nil)
+					})
+
+					It("returns success response", func() {
+						router.ServeHTTP(recorder, req)
+
+						Expect(recorder.Code).To(Equal(http.StatusOK))
+						body := common.BasicResponse{}
+						json.Unmarshal([]byte(recorder.Body.String()), &body)
+						Expect(body.Status).To(Equal(http.StatusOK))
+					})
+				})
+
+			})
+		})
+	})
+
+	Context("CreateGroup", func() {
+		When("login request is sent and authentication passes", func() {
+			Context("with non-json body", func() {
+
+				BeforeEach(func() {
+					validator.EXPECT().
+						ValidateUsername(username).
+						Times(0)
+
+					uamDAO.EXPECT().
+						CreateGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+
+					req, _ = http.NewRequest("POST", "/protected/group/creation", strings.NewReader("test"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+				})
+			})
+
+			Context("with json body", func() {
+				var rqBody common.GroupPayload
+
+				BeforeEach(func() {
+					rqBody = common.GroupPayload{GroupName: groupName}
+					jsonBody, _ := json.Marshal(&rqBody)
+					req, _ = http.NewRequest("POST", "/protected/group/creation", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Authorization", "Bearer sometoken")
+				})
+
+				Context("and group name fails the validation", func() {
+					BeforeEach(func() {
+						validator.EXPECT().
+							ValidateUsername(rqBody.GroupName).
+							Return(myerr.NewClientError("test-error"))
+
+						uamDAO.EXPECT().
+							CreateGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+							Times(0)
+					})
+
+					It("return bad request", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+					})
+
+				})
+
+				Context("and group name passes the validation", func() {
+					AfterEach(func() {
+						os.RemoveAll(path.Join(groupsDir, groupName))
+					})
+
+					Context("and resources of a deleted group with the same name are not erased yet", func() {
+						BeforeEach(func() {
+							os.MkdirAll(path.Join(groupsDir, groupName), 0777)
+							os.Create(path.Join(groupsDir, groupName, "1"))
+
+							gomock.InOrder(
+								validator.EXPECT().
+									ValidateUsername(rqBody.GroupName).
+									Return(nil),
+								uamDAO.EXPECT().
+									CreateGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+									Times(0),
+							)
+						})
+
+						It("returns bad request response", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusBadRequest, "Problem with creation of group. Reason: Group already exists")
+						})
+					})
+
+					Context("and operation of creation group from db fails", func() {
+						Context("because connection to db fails", func() {
+							BeforeEach(func() {
+								gomock.InOrder(
+									validator.EXPECT().
+										ValidateUsername(rqBody.GroupName).
+										Return(nil),
+									uamDAO.EXPECT().
+										CreateGroup(uint(userID), rqBody.GroupName, gomock.Any()).
+										Return(myerr.NewServerError("test-error")),
+								)
+							})
+
+							It("returns internal server error response", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+							})
+						})
+
+						Context("because group already exists", func() {
+							BeforeEach(func() {
+								gomock.InOrder(
+									validator.EXPECT().
+										ValidateUsername(rqBody.GroupName).
+										Return(nil),
+									uamDAO.EXPECT().
+										CreateGroup(uint(userID), rqBody.GroupName, gomock.Any()).
+										Return(myerr.NewClientError("test-error")),
+								)
+							})
+
+							It("returns bad request response", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusBadRequest, "test-error")
+							})
+						})
+
+					})
+
+					Context("and operation of creating group from db suceeeds", func() {
+						BeforeEach(func() {
+							gomock.InOrder(
+								validator.EXPECT().
+									ValidateUsername(rqBody.GroupName).
+									Return(nil),
+								uamDAO.EXPECT().
+									CreateGroup(uint(userID), rqBody.GroupName, auditEventMatcher{action: models.AuditGroupCreated, actorID: userID}).
+									Return(nil),
+							)
+						})
+
+						It("returns Created", func() {
+							router.ServeHTTP(recorder, req)
+
+							Expect(recorder.Code).To(Equal(http.StatusCreated))
+							body := common.BasicResponse{}
+							json.Unmarshal([]byte(recorder.Body.String()), &body)
+							Expect(body.Status).To(Equal(http.StatusCreated))
+						})
+					})
+				})
+			})
+		})
+	})
+
+	Context("InviteMember", func() {
+		When("request a user to be invited to group is sent and authentication passes", func() {
+			Context("with non-json body", func() {
+
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						CreateInvitation(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+
+					req, _ = http.NewRequest("POST", "/protected/group/membership/invitation", strings.NewReader("test"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+				})
+			})
+
+			Context("with json body", func() {
+				var rqBody common.InvitationPayload
+
+				BeforeEach(func() {
+					rqBody = common.InvitationPayload{}
+					rqBody.Username = username
+					rqBody.GroupName = groupName
+					jsonBody, _ := json.Marshal(&rqBody)
+					req, _ = http.NewRequest("POST", "/protected/group/membership/invitation", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Authorization", "Bearer sometoken")
+				})
+
+				Context("and the user isnt allowed to manage the members", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							Authorize(uint(userID), groupName, permission.ManageMembers).
+							Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+						uamDAO.EXPECT().
+							CreateInvitation(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+							Times(0)
+					})
+
+					It("returns bad request", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+					})
+				})
+
+				Context("and invitation creation fails", func() {
+					Context("and request fails due to problem with the server", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								Authorize(uint(userID), groupName, permission.ManageMembers).
+								Return(models.Group{}, models.RoleAdmin, nil)
+
+							uamDAO.EXPECT().
+								CreateInvitation(uint(userID), username, groupName, nil, gomock.Any()).
+								Return(myerr.NewServerError("some-error"))
+						})
+
+						It("returns internal server error", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+						})
+					})
+
+					Context("and username or group doesnt exist", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								Authorize(uint(userID), groupName, permission.ManageMembers).
+								Return(models.Group{}, models.RoleAdmin, nil)
+
+							uamDAO.EXPECT().
+								CreateInvitation(uint(userID), username, groupName, nil, gomock.Any()).
+								Return(myerr.NewClientError("some-error"))
+						})
+
+						It("returns bad request", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+						})
+					})
+				})
+
+				Context("and the invitation has an expiry time", func() {
+					BeforeEach(func() {
+						rqBody.ExpiresIn = 24
+						jsonBody, _ := json.Marshal(&rqBody)
+						req, _ = http.NewRequest("POST", "/protected/group/membership/invitation", bytes.NewBuffer(jsonBody))
+
+						permissions.EXPECT().
+							Authorize(uint(userID), groupName, permission.ManageMembers).
+							Return(models.Group{}, models.RoleAdmin, nil)
+
+						uamDAO.EXPECT().
+							CreateInvitation(uint(userID), username, groupName, gomock.Not(gomock.Nil()), gomock.Any()).
+							Return(nil)
+					})
+
+					It("returns created", func() {
+						router.ServeHTTP(recorder, req)
+						Expect(recorder.Code).To(Equal(http.StatusCreated))
+					})
+				})
+
+				Context("and invitation creation succeeds", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							Authorize(uint(userID), groupName, permission.ManageMembers).
+							Return(models.Group{}, models.RoleAdmin, nil)
+
+						uamDAO.EXPECT().
+							CreateInvitation(uint(userID), username, groupName, nil, auditEventMatcher{action: models.AuditMemberInvited, actorID: userID}).
+							Return(nil)
+					})
+
+					It("returns created", func() {
+						router.ServeHTTP(recorder, req)
+
+						Expect(recorder.Code).To(Equal(http.StatusCreated))
+						body := common.BasicResponse{}
+						json.Unmarshal([]byte(recorder.Body.String()), &body)
+						Expect(body.Status).To(Equal(http.StatusCreated))
+					})
+				})
+			})
+		})
+	})
+
+	Context("RevokeMembership", func() {
+		When("request a user to be added to group is sent and authentication passes", func() {
+			Context("with non-json body", func() {
+
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						RemoveUserFromGroup(username, groupName, gomock.Any()).
+						Times(0)
+
+					req, _ = http.NewRequest("POST", "/protected/group/membership/revocation", strings.NewReader("test"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+				})
+			})
+
+			Context("with json body", func() {
+				var rqBody common.GroupMembershipPayload
+
+				BeforeEach(func() {
+					rqBody = common.GroupMembershipPayload{Username: username}
+					rqBody.GroupName = groupName
+					jsonBody, _ := json.Marshal(&rqBody)
+					req, _ = http.NewRequest("POST", "/protected/group/membership/revocation", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Authorization", "Bearer sometoken")
+				})
+
+				Context("and the user isnt allowed to remove the member", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							AuthorizeMemberChange(uint(userID), groupName, username).
+							Return(models.Group{}, myerr.NewClientError("some-error"))
+
+						uamDAO.EXPECT().
+							RemoveUserFromGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+							Times(0)
+					})
+
+					It("returns bad request", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+					})
+				})
+
+				Context("and membership deletion fails", func() {
+					Context("and request fails due to problem with the server", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								AuthorizeMemberChange(uint(userID), groupName, username).
+								Return(models.Group{}, nil)
+
+							uamDAO.EXPECT().
+								RemoveUserFromGroup(username, groupName, gomock.Any()).
+								Return(myerr.NewServerError("some-error"))
+						})
+
+						It("returns internal server error", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+						})
+					})
+
+					Context("and username or group doesnt exist", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								AuthorizeMemberChange(uint(userID), groupName, username).
+								Return(models.Group{}, nil)
+
+							uamDAO.EXPECT().
+								RemoveUserFromGroup(username, groupName, gomock.Any()).
+								Return(myerr.NewClientError("some-error"))
+						})
+
+						It("returns bad request", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+						})
+					})
+				})
+
+				Context("and membership deletion succeeds", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							AuthorizeMemberChange(uint(userID), groupName, username).
+							Return(models.Group{}, nil)
+
+						uamDAO.EXPECT().
+							RemoveUserFromGroup(username, groupName, auditEventMatcher{action: models.AuditMemberRemoved, actorID: userID}).
+							Return(nil)
+					})
+
+					It("returns created", func() {
+						router.ServeHTTP(recorder, req)
+
+						Expect(recorder.Code).To(Equal(http.StatusOK))
+						body := common.BasicResponse{}
+						json.Unmarshal([]byte(recorder.Body.String()), &body)
+						Expect(body.Status).To(Equal(http.StatusOK))
+					})
+				})
+			})
+		})
+	})
+
+	Context("DeleteGroup", func() {
+		When("request a user to be added to group is sent and authentication passes", func() {
+			Context("with non-json body", func() {
+
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						DeactivateGroup(groupName, gomock.Any()).
+						Times(0)
+
+					req, _ = http.NewRequest("DELETE", "/protected/group/deletion", strings.NewReader("test"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+				})
+			})
+
+			Context("with json body", func() {
+				var rqBody common.GroupPayload
+
+				BeforeEach(func() {
+					rqBody = common.GroupPayload{GroupName: groupName}
+					jsonBody, _ := json.Marshal(&rqBody)
+					req, _ = http.NewRequest("DELETE", "/protected/group/deletion", bytes.NewBuffer(jsonBody))
+					req.Header.Set("Authorization", "Bearer sometoken")
+				})
+
+				Context("and the user isnt allowed to delete the group", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							Authorize(uint(userID), groupName, permission.ManageGroup).
+							Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+						uamDAO.EXPECT().
+							DeactivateGroup(gomock.Any(), gomock.Any()).
+							Times(0)
+					})
+
+					It("returns bad request", func() {
+						router.ServeHTTP(recorder, req)
+						assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+					})
+				})
+
+				Context("and membership deletion fails", func() {
+					Context("and request fails due to problem with the server", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								Authorize(uint(userID), groupName, permission.ManageGroup).
+								Return(models.Group{}, models.RoleOwner, nil)
+
+							uamDAO.EXPECT().
+								DeactivateGroup(groupName, gomock.Any()).
+								Return(myerr.NewServerError("some-error"))
+						})
+
+						It("returns internal server error", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+						})
+					})
+
+					Context("and username or group doesnt exist or user doesnt have required permissions", func() {
+						BeforeEach(func() {
+							permissions.EXPECT().
+								Authorize(uint(userID), groupName, permission.ManageGroup).
+								Return(models.Group{}, models.RoleOwner, nil)
+
+							uamDAO.EXPECT().
+								DeactivateGroup(groupName, gomock.Any()).
+								Return(myerr.NewClientError("some-error"))
+						})
+
+						It("returns bad request", func() {
+							router.ServeHTTP(recorder, req)
+							assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+						})
+					})
+				})
+
+				Context("and membership deletion succeeds", func() {
+					BeforeEach(func() {
+						permissions.EXPECT().
+							Authorize(uint(userID), groupName, permission.ManageGroup).
+							Return(models.Group{}, models.RoleOwner, nil)
+
+						uamDAO.EXPECT().
+							DeactivateGroup(groupName, auditEventMatcher{action: models.AuditGroupDeleted, actorID: userID}).
+							Return(nil)
+					})
+
+					It("returns created", func() {
+						router.ServeHTTP(recorder, req)
+
+						Expect(recorder.Code).To(Equal(http.StatusOK))
+						body := common.BasicResponse{}
+						json.Unmarshal([]byte(recorder.Body.String()), &body)
+						Expect(body.Status).To(Equal(http.StatusOK))
+					})
+				})
+			})
+		})
+	})
+
+	Context("GetDeletedGroups", func() {
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/protected/groups/trash", nil)
+		})
+
+		When("the deleted groups cannot be fetched", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetDeactivatedGroups(uint(userID)).
+					Return(nil, myerr.NewServerError("some-error"))
+			})
+
+			It("returns internal server error", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+
+		When("the deleted groups are fetched", func() {
+			deletedAt := time.Now()
+
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					GetDeactivatedGroups(uint(userID)).
+					Return([]models.Group{{ID: 1, Name: groupName, OwnerID: userID, DeactivatedAt: &deletedAt}}, nil)
+			})
+
+			It("returns the groups in the trash of the user", func() {
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				body := struct {
+					Groups []common.DeletedGroupInfo `json:"groups"`
+				}{}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Groups).To(HaveLen(1))
+				Expect(body.Groups[0].Name).To(Equal(groupName))
+				Expect(body.Groups[0].DeletedAt).NotTo(BeNil())
+				Expect(*body.Groups[0].DeletedAt).To(BeTemporally("~", deletedAt, time.Second))
+			})
+		})
+	})
+
+	Context("RestoreGroup", func() {
+		When("request with non-json body is sent", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					RestoreGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req, _ = http.NewRequest("POST", "/protected/group/restoration", strings.NewReader("test"))
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+			})
+		})
+
+		When("request with json body is sent", func() {
+			BeforeEach(func() {
+				jsonBody, _ := json.Marshal(common.GroupPayload{GroupName: groupName})
+				req, _ = http.NewRequest("POST", "/protected/group/restoration", bytes.NewBuffer(jsonBody))
+			})
+
+			Context("and the user isnt the owner of the group", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						RestoreGroup(uint(userID), groupName, gomock.Any()).
+						Return(myerr.NewClientError("some-error"))
+				})
+
+				It("returns bad request", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+				})
+			})
+
+			Context("and the group is restored", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						RestoreGroup(uint(userID), groupName, auditEventMatcher{action: models.AuditGroupRecovered, actorID: userID}).
+						Return(nil)
+				})
+
+				It("returns ok", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("GetGroupInfo", func() {
+		var group models.Group
+
+		BeforeEach(func() {
+			group = models.Group{ID: 2, Name: groupName, OwnerID: userID, Quota: 1000, MaxFileSize: 100}
+			req, _ = http.NewRequest("GET", "/protected/group/info?group_name="+groupName, nil)
+		})
+
+		When("the user isnt a member of the group", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ViewGroup).
+					Return(models.Group{}, "", myerr.NewClientError("You arent a member of the group"))
+
+				uamDAO.EXPECT().
+					GetGroupUsage(gomock.Any()).
+					Times(0)
+			})
+
+			It("returns bad request", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "You arent a member of the group")
+			})
+		})
+
+		When("the user is a member of the group", func() {
+			BeforeEach(func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ViewGroup).
+						Return(group, models.RoleViewer, nil),
+
+					uamDAO.EXPECT().
+						GetGroupUsage(group.ID).
+						Return(int64(250), nil),
+				)
+			})
+
+			It("returns the usage of the quota", func() {
+				router.ServeHTTP(recorder, req)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				body := common.GroupDetailsResponse{}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Name).To(Equal(groupName))
+				Expect(body.Quota).To(Equal(int64(1000)))
+				Expect(body.MaxFileSize).To(Equal(int64(100)))
+				Expect(body.Usage).To(Equal(int64(250)))
+				Expect(body.Role).To(Equal(models.RoleViewer))
+			})
+		})
+	})
+
+	Context("UpdateGroupQuota", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("PUT", "/protected/group/quota", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("no limit is specified", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					UpdateGroupLimits(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Neither quota nor max file size is specified")
+			})
+		})
+
+		When("a negative limit is specified", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					UpdateGroupLimits(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","quota":-1}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The limits of the group should be positive")
+			})
+		})
+
+		When("only the quota is specified", func() {
+			Context("and the user isnt the group owner", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ManageGroup).
+						Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+					uamDAO.EXPECT().
+						UpdateGroupLimits(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+						Times(0)
+				})
+
+				It("returns bad request", func() {
+					sendRequest(`{"group_name":"groupName","quota":2000}`)
+					assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+				})
+			})
+
+			Context("and the user is the group owner", func() {
+				BeforeEach(func() {
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ManageGroup).
+						Return(models.Group{Name: groupName, Quota: 1000, MaxFileSize: 100}, models.RoleOwner, nil)
+
+					uamDAO.EXPECT().
+						UpdateGroupLimits(groupName, int64(2000), int64(100), gomock.Any()).
+						Return(nil)
+				})
+
+				It("keeps the maximum file size", func() {
+					sendRequest(`{"group_name":"groupName","quota":2000}`)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("ChangeMemberRole", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("PUT", "/protected/group/member/role", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("the role is invalid", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","username":"username","role":"owner"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid role")
+			})
+		})
+
+		When("the user isnt allowed to change roles", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageRoles).
+					Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+				uamDAO.EXPECT().
+					UpdateMemberRole(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","username":"username","role":"admin"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+			})
+		})
+
+		When("the user is allowed to change roles", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageRoles).
+					Return(models.Group{Name: groupName}, models.RoleOwner, nil)
+			})
+
+			Context("and the change fails", func() {
+				It("returns internal server error", func() {
+					uamDAO.EXPECT().
+						UpdateMemberRole(username, groupName, models.RoleAdmin, gomock.Any()).
+						Return(myerr.NewServerError("some-error"))
+
+					sendRequest(`{"group_name":"groupName","username":"username","role":"admin"}`)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("and the change succeeds", func() {
+				It("returns ok", func() {
+					uamDAO.EXPECT().
+						UpdateMemberRole(username, groupName, models.RoleAdmin, auditEventMatcher{action: models.AuditMemberRoleChanged, actorID: userID}).
+						Return(nil)
+
+					sendRequest(`{"group_name":"groupName","username":"username","role":"admin"}`)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("TransferOwnership", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("PUT", "/protected/group/ownership", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("the user isnt the owner of the group", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageGroup).
+					Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+				uamDAO.EXPECT().
+					TransferGroupOwnership(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","username":"username"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+			})
+		})
+
+		When("the user is the owner of the group", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageGroup).
+					Return(models.Group{Name: groupName}, models.RoleOwner, nil)
+			})
+
+			Context("and the new owner isnt a member", func() {
+				It("returns bad request", func() {
+					uamDAO.EXPECT().
+						TransferGroupOwnership(groupName, username, gomock.Any()).
+						Return(myerr.NewClientError("The new owner should be a member of the group"))
+
+					sendRequest(`{"group_name":"groupName","username":"username"}`)
+					assertErrorResponse(recorder, http.StatusBadRequest, "The new owner should be a member of the group")
+				})
+			})
+
+			Context("and the transfer fails", func() {
+				It("returns internal server error", func() {
+					uamDAO.EXPECT().
+						TransferGroupOwnership(groupName, username, gomock.Any()).
+						Return(myerr.NewServerError("some-error"))
+
+					sendRequest(`{"group_name":"groupName","username":"username"}`)
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("and the transfer succeeds", func() {
+				It("returns ok", func() {
+					uamDAO.EXPECT().
+						TransferGroupOwnership(groupName, username, auditEventMatcher{action: models.AuditGroupOwnershipTransferred, actorID: userID}).
+						Return(nil)
+
+					sendRequest(`{"group_name":"groupName","username":"username"}`)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("GetGroupAuditEvents", func() {
+		var group models.Group
+
+		BeforeEach(func() {
+			group = models.Group{ID: 2, Name: groupName, OwnerID: userID}
+		})
+
+		sendRequest := func(query string) {
+			req, _ = http.NewRequest("GET", "/protected/group/audit?group_name="+groupName+query, nil)
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("the page size is too big", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAuditEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest("&page_size=100000")
+				assertErrorResponse(recorder, http.StatusBadRequest, "The page size should be between 1 and 500")
+			})
+		})
+
+		When("the time filter isnt in RFC 3339 format", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAuditEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest("&since=yesterday")
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid time [yesterday]")
+			})
+		})
+
+		When("the user isnt the owner of the group", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ViewAuditLog).
+					Return(models.Group{}, "", myerr.NewClientError("Your role (admin) doesnt allow you to view the audit log"))
+
+				uamDAO.EXPECT().
+					GetAuditEvents(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest("")
+				assertErrorResponse(recorder, http.StatusBadRequest, "Your role (admin) doesnt allow you to view the audit log")
+			})
+		})
+
+		When("the user is the owner of the group", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ViewAuditLog).
+					Return(group, models.RoleOwner, nil)
+			})
+
+			Context("and the events cannot be fetched", func() {
+				It("returns internal server error", func() {
+					uamDAO.EXPECT().
+						GetAuditEvents(group.ID, gomock.Any(), gomock.Any(), gomock.Any()).
+						Return(nil, myerr.NewServerError("some-error"))
+
+					sendRequest("")
+					assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+				})
+			})
+
+			Context("and there are more events than the page size", func() {
+				It("returns the page and reports the next one", func() {
+					since := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+					actor := username
+					filter := dao.AuditFilter{Action: models.AuditFileDeleted, Actor: username, Since: &since}
+
+					uamDAO.EXPECT().
+						GetAuditEvents(group.ID, filter, 2, 3).
+						Return([]dao.AuditEventDetails{
+							{ID: 3, Action: models.AuditFileDeleted, Actor: &actor},
+							{ID: 2, Action: models.AuditFileDeleted, Actor: &actor},
+							{ID: 1, Action: models.AuditFileDeleted, Actor: &actor},
+						}, nil)
+
+					sendRequest("&action=file.deleted&actor=username&since=2021-01-01T00:00:00Z&page=2&page_size=2")
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					body := common.AuditEventsResponse{}
+					json.Unmarshal(recorder.Body.Bytes(), &body)
+					Expect(body.Page).To(Equal(2))
+					Expect(body.More).To(BeTrue())
+					Expect(body.Events).To(HaveLen(2))
+					Expect(body.Events[0].ID).To(Equal(uint(3)))
+					Expect(*body.Events[0].Actor).To(Equal(username))
+				})
+			})
+
+			Context("and the last page is requested", func() {
+				It("returns the events with the default page size", func() {
+					uamDAO.EXPECT().
+						GetAuditEvents(group.ID, dao.AuditFilter{}, 0, 51).
+						Return([]dao.AuditEventDetails{{ID: 1, Action: models.AuditGroupCreated}}, nil)
+
+					sendRequest("")
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+
+					body := common.AuditEventsResponse{}
+					json.Unmarshal(recorder.Body.Bytes(), &body)
+					Expect(body.PageSize).To(Equal(50))
+					Expect(body.More).To(BeFalse())
+					Expect(body.Events).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	Context("RevokeInvitation", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("DELETE", "/protected/group/invitation/revocation", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("the user isnt allowed to manage the members", func() {
+			It("returns bad request", func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageMembers).
+					Return(models.Group{}, "", myerr.NewClientError("some-error"))
+
+				uamDAO.EXPECT().
+					RevokeInvitation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest(`{"group_name":"groupName","username":"username"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "some-error")
+			})
+		})
+
+		When("the user is allowed to manage the members", func() {
+			BeforeEach(func() {
+				permissions.EXPECT().
+					Authorize(uint(userID), groupName, permission.ManageMembers).
+					Return(models.Group{Name: groupName}, models.RoleOwner, nil)
+			})
+
+			Context("and there isnt such invitation", func() {
+				It("returns bad request", func() {
+					uamDAO.EXPECT().
+						RevokeInvitation(username, groupName, gomock.Any()).
+						Return(myerr.NewClientError("Invitation not found"))
+
+					sendRequest(`{"group_name":"groupName","username":"username"}`)
+					assertErrorResponse(recorder, http.StatusBadRequest, "Invitation not found")
+				})
+			})
+
+			Context("and the invitation is revoked", func() {
+				It("returns ok", func() {
+					uamDAO.EXPECT().
+						RevokeInvitation(username, groupName, gomock.Any()).
+						Return(nil)
+
+					sendRequest(`{"group_name":"groupName","username":"username"}`)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+				})
+			})
+		})
+	})
+
+	Context("GetInvitations", func() {
+		BeforeEach(func() {
+			req, _ = http.NewRequest("GET", "/protected/invitations", nil)
+		})
+
+		When("fetching the invitations fails", func() {
+			It("returns internal server error", func() {
+				uamDAO.EXPECT().
+					GetInvitations(uint(userID)).
+					Return(nil, myerr.NewServerError("some-error"))
+
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+
+		When("fetching the invitations succeeds", func() {
+			It("returns the invitations", func() {
+				uamDAO.EXPECT().
+					GetInvitations(uint(userID)).
+					Return([]dao.InvitationDetails{{ID: 1, GroupName: groupName, Inviter: "inviter"}}, nil)
+
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				body := common.InvitationsResponse{}
+				json.Unmarshal([]byte(recorder.Body.String()), &body)
+				Expect(body.Invitations).To(HaveLen(1))
+				Expect(body.Invitations[0].GroupName).To(Equal(groupName))
+				Expect(body.Invitations[0].Inviter).To(Equal("inviter"))
+				Expect(body.Invitations[0].ExpiresAt).To(BeNil())
+			})
+		})
+	})
+
+	Context("AcceptInvitation", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("POST", "/protected/invitation/acceptance", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("the body isnt json", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					AcceptInvitation(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				sendRequest("test")
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid json body")
+			})
+		})
+
+		When("the invitation has expired", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					AcceptInvitation(uint(userID), groupName, gomock.Any()).
+					Return(myerr.NewClientError("The invitation has expired"))
+
+				sendRequest(`{"group_name":"groupName"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The invitation has expired")
+			})
+		})
+
+		When("accepting the invitation fails", func() {
+			It("returns internal server error", func() {
+				uamDAO.EXPECT().
+					AcceptInvitation(uint(userID), groupName, gomock.Any()).
+					Return(myerr.NewServerError("some-error"))
+
+				sendRequest(`{"group_name":"groupName"}`)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server, please try again later")
+			})
+		})
+
+		When("the invitation is accepted", func() {
+			It("returns created", func() {
+				uamDAO.EXPECT().
+					AcceptInvitation(uint(userID), groupName, auditEventMatcher{action: models.AuditInvitationAccepted, actorID: userID}).
+					Return(nil)
+
+				sendRequest(`{"group_name":"groupName"}`)
+				Expect(recorder.Code).To(Equal(http.StatusCreated))
+			})
+		})
+	})
+
+	Context("DeclineInvitation", func() {
+		sendRequest := func(body string) {
+			req, _ = http.NewRequest("DELETE", "/protected/invitation/rejection", strings.NewReader(body))
+			router.ServeHTTP(recorder, req)
+		}
+
+		When("there isnt such invitation", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					DeclineInvitation(uint(userID), groupName, gomock.Any()).
+					Return(myerr.NewClientError("Invitation not found"))
+
+				sendRequest(`{"group_name":"groupName"}`)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invitation not found")
+			})
+		})
+
+		When("the invitation is declined", func() {
+			It("returns ok", func() {
+				uamDAO.EXPECT().
+					DeclineInvitation(uint(userID), groupName, gomock.Any()).
+					Return(nil)
+
+				sendRequest(`{"group_name":"groupName"}`)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+	})
+
+	Context("Listings", func() {
+		When("the limit is too big", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAllGroups(gomock.Any()).
+					Times(0)
+
+				req, _ = http.NewRequest("GET", "/protected/groups?limit=501", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The limit should be between 1 and 500")
+			})
+		})
+
+		When("the order is invalid", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAllUsers(gomock.Any()).
+					Times(0)
+
+				req, _ = http.NewRequest("GET", "/protected/users?order=random", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid order [random]")
+			})
+		})
+
+		When("the listing rejects the options", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAllUsers(dao.ListOptions{Limit: 50, SortBy: "password"}).
+					Return(nil, "", myerr.NewClientError("The items cannot be sorted by [password]"))
+
+				req, _ = http.NewRequest("GET", "/protected/users?sort=password", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "The items cannot be sorted by [password]")
+			})
+		})
+
+		When("the groups are listed", func() {
+			It("returns the page and the next cursor", func() {
+				uamDAO.EXPECT().
+					GetAllGroups(dao.ListOptions{Limit: 1, Cursor: "cursor", SortBy: "created_at", Descending: true, Query: "grp"}).
+					Return([]models.Group{{ID: 2, Name: groupName, OwnerID: userID}}, "next", nil)
+
+				req, _ = http.NewRequest("GET", "/protected/groups?limit=1&cursor=cursor&sort=created_at&order=desc&q=grp", nil)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				var body struct {
+					Groups     []common.GroupInfo `json:"groups"`
+					NextCursor string             `json:"next_cursor"`
+				}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Groups).To(HaveLen(1))
+				Expect(body.Groups[0].Name).To(Equal(groupName))
+				Expect(body.NextCursor).To(Equal("next"))
+			})
+		})
+
+		When("the members are filtered by an unknown role", func() {
+			It("returns bad request", func() {
+				uamDAO.EXPECT().
+					GetAllUsersInGroup(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+
+				req, _ = http.NewRequest("GET", "/protected/group/users?group_name="+groupName+"&role=guest", nil)
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusBadRequest, "Invalid role [guest]")
+			})
+		})
+
+		When("the members are listed", func() {
+			It("returns the last page without a cursor", func() {
+				gomock.InOrder(
+					permissions.EXPECT().
+						Authorize(uint(userID), groupName, permission.ViewGroup).
+						Return(models.Group{ID: 2, Name: groupName}, models.RoleViewer, nil),
+
+					uamDAO.EXPECT().
+						GetAllUsersInGroup(uint(userID), groupName, dao.ListOptions{Limit: 50, Role: models.RoleAdmin}).
+						Return([]models.User{{ID: userID, Username: username}}, "", nil),
+				)
+
+				req, _ = http.NewRequest("GET", "/protected/group/users?group_name="+groupName+"&role=admin", nil)
+				router.ServeHTTP(recorder, req)
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+
+				var body struct {
+					Users      []common.UserInfo `json:"users"`
+					NextCursor string            `json:"next_cursor"`
+				}
+				json.Unmarshal(recorder.Body.Bytes(), &body)
+				Expect(body.Users).To(HaveLen(1))
+				Expect(body.NextCursor).To(BeEmpty())
 			})
 		})
 	})
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dbconn"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/migrations"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/pkg/errors"
+)
+
+const migrateUsage = "Usage: server migrate up [-to=<version>] | down [-steps=<count>] | status"
+
+//runMigrateCommand - applies, reverts or shows the schema migrations of the database, configured by the env variables
+func runMigrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	dbConn, err := dbconn.GetDBConnFromEnv()
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Couldnt create a connection to the database")
+	}
+	migrator := migrations.NewMigrator(dbConn, migrations.All())
+
+	switch args[0] {
+	case "up":
+		fs := flag.NewFlagSet("up", flag.ExitOnError)
+		target := fs.Uint("to", 0, "version to migrate to, all pending migrations are applied by default")
+		fs.Parse(args[1:])
+
+		applied, err := migrator.Up(*target)
+		for _, migration := range applied {
+			fmt.Printf("Applied migration [%d] %s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("The database schema is up to date")
+		}
+		return err
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "count of the latest migrations to revert")
+		fs.Parse(args[1:])
+
+		reverted, err := migrator.Down(*steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted migration [%d] %s\n", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printMigrationStatuses(statuses)
+		return nil
+	default:
+		return errors.Errorf("Unknown migrate command [%s]. %s", args[0], migrateUsage)
+	}
+}
+
+func printMigrationStatuses(statuses []migrations.MigrationStatus) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS")
+	for _, status := range statuses {
+		state := "pending"
+		if !status.Known {
+			state = "unknown, applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		} else if status.AppliedAt != nil {
+			state = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Name, state)
+	}
+	writer.Flush()
+}
@@ -16,8 +16,15 @@ import (
 	cronJob "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/cron"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dbconn"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/migrations"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/lockout"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/middleware"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/notifier"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/oidc"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
 	val "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
@@ -25,9 +32,10 @@ import (
 )
 
 const (
-	hostParamName     = "HOST"
-	portParamName     = "PORT"
-	groupDirParamName = "GROUP_DIR"
+	hostParamName           = "HOST"
+	portParamName           = "PORT"
+	groupDirParamName       = "GROUP_DIR"
+	storageBackendParamName = "STORAGE_BACKEND"
 )
 
 type ServerConfig struct {
@@ -38,17 +46,35 @@ type ServerConfig struct {
 var groupDirPath string
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	serverCfg, err := getServerConfig()
 	if err != nil {
 		log.Fatalf("Proble with the server config. Reason %s", err)
 	}
 
-	if err = createGroupsDir(); err != nil {
+	blobStore, err := createBlobStore()
+	if err != nil {
 		log.Fatal(err)
 	}
 
-	httpServer := createHttpServer(serverCfg.Host, serverCfg.Port)
-	asyncJob := createCronJob()
+	migrateDatabase()
+
+	jwtCreator, err := auth.NewJwtCreatorImpl()
+	if err != nil {
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a new Jwt Creator"))
+	}
+
+	httpServer := createHttpServer(serverCfg.Host, serverCfg.Port, blobStore, jwtCreator)
+	asyncJob, err := createCronJob(blobStore, jwtCreator)
+	if err != nil {
+		log.Fatal(err)
+	}
 	asyncJob.Start()
 	defer asyncJob.Stop()
 
@@ -89,8 +115,22 @@ func getServerConfig() (ServerConfig, error) {
 	}, nil
 }
 
+func createBlobStore() (storage.BlobStore, error) {
+	switch backend := os.Getenv(storageBackendParamName); backend {
+	case "", "local":
+		if err := createGroupsDir(); err != nil {
+			return nil, err
+		}
+		return storage.NewLocalBlobStore(groupDirPath), nil
+	case "s3":
+		return storage.NewS3BlobStore()
+	default:
+		return nil, errors.Errorf("The env variable %s has unknown storage backend [%s]", storageBackendParamName, backend)
+	}
+}
+
 func createGroupsDir() error {
-	currDir := os.Getenv("GROUP_DIR")
+	currDir := os.Getenv(groupDirParamName)
 	if currDir == "" {
 		return errors.New("Please set GROUP_DIR is not set")
 	}
@@ -107,45 +147,77 @@ func createGroupsDir() error {
 	return nil
 }
 
-func createUamDAO() dao.UamDAO {
-	dbConn, err := dbconn.GetDBConn(dbconn.PostgresDialectorCreator)
+//migrateDatabase - applies the pending schema migrations
+//the server refuses to start, if the database was migrated by a newer version of the server
+func migrateDatabase() {
+	dbConn, err := dbconn.GetDBConnFromEnv()
 	if err != nil {
 		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a connection to the database"))
 	}
 
-	uamDAO := dao.NewUamDAOImpl(dbConn)
-	if err = uamDAO.Migrate(); err != nil {
+	migrator := migrations.NewMigrator(dbConn, migrations.All())
+	if err = migrator.CheckSchema(); err != nil {
+		log.Fatal(err)
+	}
+
+	applied, err := migrator.Up(0)
+	if err != nil {
 		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt migrate the database schemas"))
 	}
 
-	return uamDAO
+	for _, migration := range applied {
+		log.Printf("Applied migration [%d] %s\n", migration.Version, migration.Name)
+	}
 }
 
-func createFmDAO() dao.FmDAO {
-	dbConn, err := dbconn.GetDBConn(dbconn.PostgresDialectorCreator)
+func createUamDAO() dao.UamDAO {
+	dbConn, err := dbconn.GetDBConnFromEnv()
 	if err != nil {
 		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a connection to the database"))
 	}
 
-	fmDAO := dao.NewFmDAOImpl(dbConn)
-	if err = fmDAO.Migrate(); err != nil {
-		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt migrate the database schemas"))
+	return dao.NewUamDAOImpl(dbConn)
+}
+
+func createFmDAO() dao.FmDAO {
+	dbConn, err := dbconn.GetDBConnFromEnv()
+	if err != nil {
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a connection to the database"))
 	}
 
-	return fmDAO
+	return dao.NewFmDAOImpl(dbConn)
 }
 
-func createHttpServer(host string, port int) *http.Server {
+func createHttpServer(host string, port int, blobStore storage.BlobStore, jwtCreator *auth.JwtCreatorImpl) *http.Server {
 	var router = gin.Default()
 
-	jwtCreator, err := auth.NewJwtCreatorImpl()
+	shareSigner, err := auth.NewShareTokenSignerImpl()
 	if err != nil {
-		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a new Jwt Creator"))
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create a signer of the share links"))
+	}
+
+	uamDAO := createUamDAO()
+	filter := middleware.NewAuthzFilterImpl(jwtCreator, uamDAO)
+	loginLockout, err := lockout.NewServiceImpl(uamDAO)
+	if err != nil {
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create the login lockout service"))
 	}
 
-	filter := middleware.NewAuthzFilterImpl(jwtCreator)
-	uamEndpoint := rest.NewUamEndPointImpl(createUamDAO(), jwtCreator, val.NewBasicValidator(), groupDirPath)
-	fmEndpoint := rest.NewFileManagementEndpointImpl(createUamDAO(), createFmDAO(), groupDirPath)
+	resetNotifier, err := notifier.NewNotifierFromEnv()
+	if err != nil {
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create the notifier"))
+	}
+
+	identityProvider, err := oidc.NewProviderFromEnv()
+	if err != nil {
+		log.Fatal(myerr.NewServerErrorWrap(err, "Couldnt create the identity provider"))
+	}
+
+	permissions := permission.NewServiceImpl(uamDAO)
+	uamEndpoint := rest.NewUamEndPointImpl(uamDAO, jwtCreator, val.NewBasicValidator(), blobStore, permissions, loginLockout, resetNotifier, identityProvider)
+	fmEndpoint := rest.NewFileManagementEndpointImpl(uamDAO, createFmDAO(), blobStore, permissions, shareSigner)
+
+	router.GET("/.well-known/jwks.json", uamEndpoint.GetJWKS)
 
 	v1 := router.Group("/v1")
 	{
@@ -154,22 +226,73 @@ func createHttpServer(host string, port int) *http.Server {
 			public.GET("/healthcheck", rest.CheckHealth)
 			public.POST("/user/registration", uamEndpoint.CreateUser)
 			public.POST("/user/login", uamEndpoint.Login)
+			public.POST("/user/login/2fa", uamEndpoint.LoginTwoFactor)
+			public.POST("/user/oidc/authorization", uamEndpoint.StartExternalLogin)
+			public.POST("/user/oidc/login", uamEndpoint.ExternalLogin)
+			public.GET("/user/oidc/callback", uamEndpoint.ExternalLoginCallback)
+			public.POST("/user/token/refresh", uamEndpoint.RefreshToken)
+			public.POST("/user/password/reset/request", uamEndpoint.RequestPasswordReset)
+			public.POST("/user/password/reset", uamEndpoint.ResetPassword)
+			public.GET("/share/:token", fmEndpoint.DownloadSharedFile)
 		}
 
 		protected := v1.Group("/protected").Use(filter.Authz)
 		{
+			protected.POST("/user/logout", uamEndpoint.Logout)
+			protected.PUT("/user/password", uamEndpoint.ChangePassword)
+			protected.POST("/user/2fa/enrollment", uamEndpoint.EnrollTwoFactor)
+			protected.POST("/user/2fa/activation", uamEndpoint.ActivateTwoFactor)
+			protected.DELETE("/user/2fa", uamEndpoint.DisableTwoFactor)
+			protected.POST("/user/token", uamEndpoint.CreateAccessToken)
+			protected.GET("/user/tokens", uamEndpoint.GetAccessTokens)
+			protected.DELETE("/user/token/revocation", uamEndpoint.RevokeAccessToken)
 			protected.DELETE("/group/membership/revocation", uamEndpoint.RevokeMembership)
 			protected.POST("/group/creation", uamEndpoint.CreateGroup)
-			protected.POST("/group/invitation", uamEndpoint.AddMember)
+			protected.POST("/group/invitation", uamEndpoint.InviteMember)
+			protected.DELETE("/group/invitation/revocation", uamEndpoint.RevokeInvitation)
+			protected.GET("/invitations", uamEndpoint.GetInvitations)
+			protected.POST("/invitation/acceptance", uamEndpoint.AcceptInvitation)
+			protected.DELETE("/invitation/rejection", uamEndpoint.DeclineInvitation)
 			protected.DELETE("/group/user/deletion", uamEndpoint.DeleteUser)
 			protected.DELETE("/group/deletion", uamEndpoint.DeleteGroup)
-			protected.POST("/group/file/upload", fmEndpoint.UploadFile)
-			protected.GET("/group/file/download", fmEndpoint.DownloadFile)
+			protected.GET("/groups/trash", uamEndpoint.GetDeletedGroups)
+			protected.POST("/group/restoration", uamEndpoint.RestoreGroup)
+			protected.PUT("/group/quota", uamEndpoint.UpdateGroupQuota)
+			protected.PUT("/group/2fa", uamEndpoint.UpdateGroupTwoFactor)
+			protected.PUT("/group/member/role", uamEndpoint.ChangeMemberRole)
+			protected.PUT("/group/ownership", uamEndpoint.TransferOwnership)
+			protected.GET("/group/audit", uamEndpoint.GetGroupAuditEvents)
 			protected.DELETE("/group/file/deletion", fmEndpoint.DeleteFile)
-			protected.GET("/group/files", fmEndpoint.RetrieveAllFilesInfo)
-			protected.GET("/groups", uamEndpoint.GetAllGroupsInfo)
+			protected.PUT("/group/file/tags", fmEndpoint.SetFileTags)
+			protected.POST("/group/file/version/restoration", fmEndpoint.RestoreFileVersion)
+			protected.GET("/group/trash", fmEndpoint.RetrieveTrashedFiles)
+			protected.POST("/group/trash/restoration", fmEndpoint.RestoreTrashedFile)
+			protected.POST("/group/file/share", fmEndpoint.CreateShareLink)
+			protected.GET("/group/file/shares", fmEndpoint.RetrieveShareLinks)
+			protected.DELETE("/group/file/share/revocation", fmEndpoint.RevokeShareLink)
 			protected.GET("/users", uamEndpoint.GetAllUsersInfo)
-			protected.GET("/group/users", uamEndpoint.GetAllUsersInGroup)
+		}
+
+		//the personal access tokens with the read scope can view the groups and download their files
+		readable := v1.Group("/protected").Use(filter.AuthzScope(models.ScopeRead))
+		{
+			readable.GET("/groups", uamEndpoint.GetAllGroupsInfo)
+			readable.GET("/group/info", uamEndpoint.GetGroupInfo)
+			readable.GET("/group/users", uamEndpoint.GetAllUsersInGroup)
+			readable.GET("/group/files", fmEndpoint.RetrieveAllFilesInfo)
+			readable.GET("/group/file/versions", fmEndpoint.RetrieveFileVersions)
+			readable.GET("/group/file/download", fmEndpoint.DownloadFile)
+			readable.GET("/files/search", fmEndpoint.SearchFiles)
+		}
+
+		//the personal access tokens with the upload scope can upload files
+		uploadable := v1.Group("/protected").Use(filter.AuthzScope(models.ScopeUpload))
+		{
+			uploadable.POST("/group/file/upload", fmEndpoint.UploadFile)
+			uploadable.POST("/group/file/upload/session", fmEndpoint.StartUpload)
+			uploadable.PUT("/group/file/upload/chunk", fmEndpoint.UploadChunk)
+			uploadable.GET("/group/file/upload/status", fmEndpoint.GetUploadStatus)
+			uploadable.POST("/group/file/upload/completion", fmEndpoint.CompleteUpload)
 		}
 	}
 
@@ -181,9 +304,35 @@ func createHttpServer(host string, port int) *http.Server {
 	return httpServer
 }
 
-func createCronJob() *cron.Cron {
-	groupDeleter := cronJob.NewGroupEraserJobImpl(createUamDAO(), groupDirPath)
+func createCronJob(blobStore storage.BlobStore, jwtCreator *auth.JwtCreatorImpl) (*cron.Cron, error) {
+	groupDeleter, err := cronJob.NewGroupEraserJobFromEnv(createUamDAO(), blobStore)
+	if err != nil {
+		return nil, err
+	}
+
+	trashPurger, err := cronJob.NewTrashPurgerJobFromEnv(createFmDAO(), blobStore)
+	if err != nil {
+		return nil, err
+	}
+
+	userPurger, err := cronJob.NewUserPurgerJobFromEnv(createUamDAO())
+	if err != nil {
+		return nil, err
+	}
+
+	uploadCleaner := cronJob.NewUploadCleanerJobImpl(createFmDAO(), blobStore)
+
 	asyncJob := cron.New()
 	asyncJob.AddFunc("@every 1m", groupDeleter.DeleteGroups)
-	return asyncJob
+	asyncJob.AddFunc("@every 1h", trashPurger.PurgeTrash)
+	asyncJob.AddFunc("@every 1h", userPurger.PurgeUsers)
+	asyncJob.AddFunc("@every 1h", uploadCleaner.EraseExpiredUploads)
+	if jwtCreator.Keys != nil {
+		asyncJob.AddFunc("@every 1m", func() {
+			if err := jwtCreator.Keys.Rotate(); err != nil {
+				log.Printf("Problem with the rotation of the signing keys. Reason %s", err)
+			}
+		})
+	}
+	return asyncJob, nil
 }
@@ -8,6 +8,8 @@ require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gin-gonic/gin v1.6.3
 	github.com/golang/mock v1.4.4
+	github.com/jackc/pgconn v1.8.0
+	github.com/mattn/go-sqlite3 v1.14.5
 	github.com/nxadm/tail v1.4.6 // indirect
 	github.com/onsi/ginkgo v1.14.2
 	github.com/onsi/gomega v1.10.4
@@ -17,5 +19,6 @@ require (
 	golang.org/x/lint v0.0.0-20201208152925-83fdc39ff7b5 // indirect
 	golang.org/x/tools/gopls v0.7.1 // indirect
 	gorm.io/driver/postgres v1.0.6
+	gorm.io/driver/sqlite v1.1.4
 	gorm.io/gorm v1.20.9
 )
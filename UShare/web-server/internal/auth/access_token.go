@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+const (
+	//AccessTokenPrefix - prefix of the personal access tokens, which distinguishes them from the JWT tokens
+	AccessTokenPrefix = "ushare_pat_"
+	accessTokenSize   = 32
+)
+
+//GenerateAccessToken - generates a random personal access token
+//returns the token, which is shown to the user only once, and its hash, under which it is stored
+func GenerateAccessToken() (string, string, error) {
+	randomBytes := make([]byte, accessTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", "", myerr.NewServerErrorWrap(err, "Problem with the generation of the access token")
+	}
+
+	token := AccessTokenPrefix + hex.EncodeToString(randomBytes)
+	return token, HashToken(token), nil
+}
+
+//IsAccessToken - checks if the token from the Authorization header is a personal access token
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
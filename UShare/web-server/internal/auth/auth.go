@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"strconv"
 	"time"
@@ -13,28 +17,63 @@ const (
 	secretKey     = "SECRET"
 	issuerKey     = "ISSUER"
 	expirationKey = "EXPIRATION"
+	//accessExpirationKey - the access tokens are short-lived, they are renewed with the refresh tokens
+	accessExpirationKey = "ACCESS_EXPIRATION"
+
+	defaultAccessExpirationMinutes = 15
+	refreshTokenSize               = 32
 )
 
 //go:generate mockgen --source=auth.go --destination auth_mocks/auth.go --package auth_mocks
 
 //JwtCreator - a wrapper of jwt library
 type JwtCreator interface {
-	GenerateToken(uint) (string, error)
+	GenerateToken(uint, uint) (string, error)
+	GenerateRefreshToken() (RefreshToken, error)
 	ValidateToken(string) (*JwtClaim, error)
+	GetJWKS() JSONWebKeySet
 }
 
 //JwtCreatorImpl - implementation of JwtCreator
+//the access tokens expire after AccessExpirationMinutes, the sessions (refresh tokens) after ExpirationHours
+//the tokens are signed with the rotated Keys, if they are configured, otherwise with the Secret
 type JwtCreatorImpl struct {
-	Secret          string
-	Issuer          string
-	ExpirationHours int64
+	Secret                  string
+	Keys                    *KeySet
+	Issuer                  string
+	ExpirationHours         int64
+	AccessExpirationMinutes int64
+}
+
+//RefreshToken - opaque token, used for the renewal of the access tokens of a session
+//only its hash is stored on the server
+type RefreshToken struct {
+	Token     string
+	Hash      string
+	ExpiresAt time.Time
 }
 
 //NewJwtCreatorImpl - creates an instance of JwtCreatorImpl
+//the secret is required only by the HS256 algorithm, the other algorithms use the keys in the key directory
 func NewJwtCreatorImpl() (*JwtCreatorImpl, error) {
-	secret := os.Getenv(secretKey)
-	if len(secret) == 0 {
-		return nil, myerr.NewServerError("Missing value for \"secret\" jwt config")
+	var (
+		secret string
+		keys   *KeySet
+		err    error
+	)
+
+	switch algorithm := os.Getenv(algorithmKey); algorithm {
+	case "", AlgorithmHS256:
+		secret = os.Getenv(secretKey)
+		if len(secret) == 0 {
+			return nil, myerr.NewServerError("Missing value for \"secret\" jwt config")
+		}
+	case AlgorithmRS256, AlgorithmEdDSA:
+		if keys, err = newKeySetFromEnv(algorithm); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, myerr.NewServerError(fmt.Sprintf("Unsupported value [%s] for \"algorithm\" jwt config", algorithm))
 	}
 
 	issuer := os.Getenv(issuerKey)
@@ -51,49 +90,117 @@ func NewJwtCreatorImpl() (*JwtCreatorImpl, error) {
 		return nil, myerr.NewServerErrorWrap(err, "Wrong typeof value for \"expiration\" jwt config")
 	}
 
+	accessExpirationMinutes := int64(defaultAccessExpirationMinutes)
+	if accessExpirationStr := os.Getenv(accessExpirationKey); len(accessExpirationStr) != 0 {
+		accessExpirationMinutes, err = strconv.ParseInt(accessExpirationStr, 10, 64)
+		if err != nil || accessExpirationMinutes <= 0 {
+			return nil, myerr.NewServerError("Wrong value for \"accessExpiration\" jwt config")
+		}
+	}
+
 	return &JwtCreatorImpl{
-		Secret:          secret,
-		Issuer:          issuer,
-		ExpirationHours: int64(expirationHours),
+		Secret:                  secret,
+		Keys:                    keys,
+		Issuer:                  issuer,
+		ExpirationHours:         int64(expirationHours),
+		AccessExpirationMinutes: accessExpirationMinutes,
 	}, nil
 }
 
 // JwtClaim adds email as a claim to the token
+//the token is valid only as long as its session isnt revoked
 type JwtClaim struct {
-	UserID uint
+	UserID    uint
+	SessionID uint `json:"sid"`
 	jwt.StandardClaims
 }
 
-//GenerateToken - generates a token, encrypting the userID in it
+//GenerateToken - generates an access token, encrypting the userID and the id of his session in it
 //returns the token and error
-func (j *JwtCreatorImpl) GenerateToken(userID uint) (string, error) {
+func (j *JwtCreatorImpl) GenerateToken(userID uint, sessionID uint) (string, error) {
 	claims := &JwtClaim{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(j.ExpirationHours)).Unix(),
+			ExpiresAt: time.Now().Local().Add(time.Minute * time.Duration(j.AccessExpirationMinutes)).Unix(),
 			Issuer:    j.Issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.Secret))
+	if j.Keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(j.Secret))
+	}
+
+	key := j.Keys.SigningKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+//GenerateRefreshToken - generates a random refresh token for a new or rotated session
+func (j *JwtCreatorImpl) GenerateRefreshToken() (RefreshToken, error) {
+	randomBytes := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return RefreshToken{}, myerr.NewServerErrorWrap(err, "Problem with the generation of the refresh token")
+	}
+
+	token := hex.EncodeToString(randomBytes)
+	return RefreshToken{
+		Token:     token,
+		Hash:      HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour * time.Duration(j.ExpirationHours)),
+	}, nil
+}
+
+//HashToken - returns the hash of a refresh token or a personal access token, under which it is stored
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
 
 //ValidateToken - validates a given JWT token
-//returns the encrypted data in the token and error if the token is invalid
+//returns the encrypted data in the token and error if the token is invalid or is a share link token
 func (j *JwtCreatorImpl) ValidateToken(signedToken string) (*JwtClaim, error) {
-	token, err := jwt.ParseWithClaims(
-		signedToken,
-		&JwtClaim{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(j.Secret), nil
-		},
-	)
+	token, err := jwt.ParseWithClaims(signedToken, &JwtClaim{}, j.getVerificationKey)
 
 	if err != nil {
 		return nil, myerr.NewClientError("Invalid Token. Please login again.")
 	}
 
 	claims, _ := token.Claims.(*JwtClaim)
+	//the share tokens are signed with the same secret, if no dedicated one is configured
+	if claims.Audience == shareAudience {
+		return nil, myerr.NewClientError("Invalid Token. Please login again.")
+	}
 	return claims, nil
 }
+
+//getVerificationKey - returns the key, which verifies the signature of the token
+//the algorithm of the token must match the one of the key, otherwise the token could be forged with the public key
+func (j *JwtCreatorImpl) getVerificationKey(token *jwt.Token) (interface{}, error) {
+	if j.Keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method [%s]", token.Method.Alg())
+		}
+		return []byte(j.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.Keys.VerificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key [%s]", kid)
+	} else if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method [%s]", token.Method.Alg())
+	}
+	return key.Private.Public(), nil
+}
+
+//GetJWKS - returns the public keys, which verify the tokens
+//the set is empty, if the tokens are signed with the secret
+func (j *JwtCreatorImpl) GetJWKS() JSONWebKeySet {
+	if j.Keys == nil {
+		return JSONWebKeySet{Keys: []JSONWebKey{}}
+	}
+	return j.Keys.JWKS()
+}
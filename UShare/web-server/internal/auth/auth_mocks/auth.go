@@ -34,18 +34,33 @@ func (m *MockJwtCreator) EXPECT() *MockJwtCreatorMockRecorder {
 }
 
 // GenerateToken mocks base method
-func (m *MockJwtCreator) GenerateToken(arg0 uint) (string, error) {
+func (m *MockJwtCreator) GenerateToken(arg0, arg1 uint) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", arg0)
+	ret := m.ctrl.Call(m, "GenerateToken", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken
-func (mr *MockJwtCreatorMockRecorder) GenerateToken(arg0 interface{}) *gomock.Call {
+func (mr *MockJwtCreatorMockRecorder) GenerateToken(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJwtCreator)(nil).GenerateToken), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJwtCreator)(nil).GenerateToken), arg0, arg1)
+}
+
+// GenerateRefreshToken mocks base method
+func (m *MockJwtCreator) GenerateRefreshToken() (auth.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateRefreshToken")
+	ret0, _ := ret[0].(auth.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateRefreshToken indicates an expected call of GenerateRefreshToken
+func (mr *MockJwtCreatorMockRecorder) GenerateRefreshToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRefreshToken", reflect.TypeOf((*MockJwtCreator)(nil).GenerateRefreshToken))
 }
 
 // ValidateToken mocks base method
@@ -62,3 +77,17 @@ func (mr *MockJwtCreatorMockRecorder) ValidateToken(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockJwtCreator)(nil).ValidateToken), arg0)
 }
+
+// GetJWKS mocks base method
+func (m *MockJwtCreator) GetJWKS() auth.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJWKS")
+	ret0, _ := ret[0].(auth.JSONWebKeySet)
+	return ret0
+}
+
+// GetJWKS indicates an expected call of GetJWKS
+func (mr *MockJwtCreatorMockRecorder) GetJWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJWKS", reflect.TypeOf((*MockJwtCreator)(nil).GetJWKS))
+}
@@ -20,6 +20,9 @@ var _ = Describe("Auth module", func() {
 		issuerVal     = "issuer"
 		expirationKey = "EXPIRATION"
 		expirationVal = 24
+
+		accessExpirationKey = "ACCESS_EXPIRATION"
+		accessExpirationVal = 5
 	)
 
 	BeforeEach(func() {
@@ -99,16 +102,42 @@ var _ = Describe("Auth module", func() {
 								os.Unsetenv(expirationKey)
 							})
 
-							It("succeeds", func() {
+							It("succeeds with the default access token expiration", func() {
 								actualResult, err := auth.NewJwtCreatorImpl()
 								Expect(err).NotTo(HaveOccurred())
 								expectedResult := &auth.JwtCreatorImpl{
-									Secret:          secretVal,
-									Issuer:          issuerVal,
-									ExpirationHours: expirationVal,
+									Secret:                  secretVal,
+									Issuer:                  issuerVal,
+									ExpirationHours:         expirationVal,
+									AccessExpirationMinutes: 15,
 								}
 								Expect(actualResult).To(Equal(expectedResult))
 							})
+
+							Context("and access expiration variable is in illegal format", func() {
+								BeforeEach(func() {
+									os.Setenv(accessExpirationKey, "-1")
+								})
+
+								It("returns error", func() {
+									_, err := auth.NewJwtCreatorImpl()
+									Expect(err).To(HaveOccurred())
+									_, ok := err.(*myerr.ServerError)
+									Expect(ok).To(Equal(true))
+								})
+							})
+
+							Context("and access expiration variable is in legal format", func() {
+								BeforeEach(func() {
+									os.Setenv(accessExpirationKey, strconv.Itoa(accessExpirationVal))
+								})
+
+								It("succeeds", func() {
+									actualResult, err := auth.NewJwtCreatorImpl()
+									Expect(err).NotTo(HaveOccurred())
+									Expect(actualResult.AccessExpirationMinutes).To(Equal(int64(accessExpirationVal)))
+								})
+							})
 						})
 					})
 				})
@@ -119,35 +148,57 @@ var _ = Describe("Auth module", func() {
 		var jwtCreator auth.JwtCreator
 		BeforeEach(func() {
 			jwtCreator = &auth.JwtCreatorImpl{
-				Secret:          secretVal,
-				Issuer:          issuerVal,
-				ExpirationHours: expirationVal,
+				Secret:                  secretVal,
+				Issuer:                  issuerVal,
+				ExpirationHours:         expirationVal,
+				AccessExpirationMinutes: accessExpirationVal,
 			}
 		})
 
 		Context("GenerateToken", func() {
 			When("creating a token", func() {
 				It("succeeds", func() {
-					_, err := jwtCreator.GenerateToken(1)
+					_, err := jwtCreator.GenerateToken(1, 1)
 					Expect(err).NotTo(HaveOccurred())
 				})
 			})
 		})
 
+		Context("GenerateRefreshToken", func() {
+			When("creating a refresh token", func() {
+				It("returns a random token together with its hash", func() {
+					first, err := jwtCreator.GenerateRefreshToken()
+					Expect(err).NotTo(HaveOccurred())
+					second, err := jwtCreator.GenerateRefreshToken()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(first.Token).NotTo(Equal(second.Token))
+					Expect(first.Hash).To(Equal(auth.HashToken(first.Token)))
+					Expect(first.Hash).NotTo(Equal(first.Token))
+					Expect(first.ExpiresAt).To(BeTemporally("~", time.Now().Add(expirationVal*time.Hour), time.Minute))
+				})
+			})
+		})
+
 		Context("ValidateToken", func() {
 			var token string
-			const userID = 1
+			const (
+				userID    = 1
+				sessionID = 2
+			)
 
 			When("validating legal token", func() {
 				BeforeEach(func() {
-					token, _ = jwtCreator.GenerateToken(userID)
+					token, _ = jwtCreator.GenerateToken(userID, sessionID)
 				})
 
 				It("classifies the token as legal", func() {
 					claims, err := jwtCreator.ValidateToken(token)
 					Expect(err).NotTo(HaveOccurred())
 					Expect(claims.UserID).To(Equal(uint(userID)))
+					Expect(claims.SessionID).To(Equal(uint(sessionID)))
 					Expect(claims.Issuer).To(Equal(issuerVal))
+					Expect(claims.ExpiresAt).To(BeNumerically("~", time.Now().Add(accessExpirationVal*time.Minute).Unix(), 60))
 				})
 			})
 
@@ -155,12 +206,13 @@ var _ = Describe("Auth module", func() {
 
 				BeforeEach(func() {
 					jwtCreator = &auth.JwtCreatorImpl{
-						Secret:          secretVal,
-						Issuer:          issuerVal,
-						ExpirationHours: 0,
+						Secret:                  secretVal,
+						Issuer:                  issuerVal,
+						ExpirationHours:         expirationVal,
+						AccessExpirationMinutes: 0,
 					}
 
-					token, _ = jwtCreator.GenerateToken(userID)
+					token, _ = jwtCreator.GenerateToken(userID, sessionID)
 				})
 
 				It("returns error", func() {
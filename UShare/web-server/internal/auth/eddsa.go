@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/ed25519"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+//SigningMethodEdDSA - signing method for Ed25519 keys, which the jwt library doesnt support out of the box
+//it expects ed25519.PrivateKey for signing and ed25519.PublicKey for verification
+type SigningMethodEdDSA struct{}
+
+//SigningMethodEd25519 - the instance of SigningMethodEdDSA, registered in the jwt library under the EdDSA algorithm
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEd25519.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+//Alg - returns the name of the algorithm
+func (m *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+//Verify - checks the signature of the signing string with a public key
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+//Sign - signs the signing string with a private key
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	algorithmKey   = "JWT_ALGORITHM"
+	keyDirKey      = "JWT_KEY_DIR"
+	keyRotationKey = "JWT_KEY_ROTATION"
+	keyGraceKey    = "JWT_KEY_GRACE_PERIOD"
+
+	//AlgorithmHS256 - the tokens are signed with the shared secret
+	AlgorithmHS256 = "HS256"
+	//AlgorithmRS256 - the tokens are signed with rotated RSA keys
+	AlgorithmRS256 = "RS256"
+	//AlgorithmEdDSA - the tokens are signed with rotated Ed25519 keys
+	AlgorithmEdDSA = "EdDSA"
+
+	defaultKeyRotationHours = 720
+	defaultKeyGraceHours    = 24
+
+	rsaKeySize       = 2048
+	keyIDSize        = 16
+	keyFileExtension = ".pem"
+	keyPemType       = "PRIVATE KEY"
+	createdPemHeader = "Created"
+)
+
+//SigningKey - private key for signing of tokens, identified by its kid
+type SigningKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+//JSONWebKey - public key in the JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+//JSONWebKeySet - set of public keys, which other services can use to verify the tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+//KeySet - rotated asymmetric keys, stored in a directory, so that they survive restarts and can be shared between servers
+//the newest key signs the tokens, the replaced ones are accepted for a grace period, so the issued tokens stay valid
+type KeySet struct {
+	algorithm   string
+	dir         string
+	rotation    time.Duration
+	gracePeriod time.Duration
+
+	mutex sync.RWMutex
+	keys  []SigningKey
+}
+
+//NewKeySet - creates a key set, loading the keys from the directory and creating a new key, if needed
+func NewKeySet(algorithm, dir string, rotation, gracePeriod time.Duration) (*KeySet, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, myerr.NewServerError(fmt.Sprintf("Unsupported signing algorithm [%s]", algorithm))
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Couldnt create the directory of the signing keys")
+	}
+
+	keySet := &KeySet{
+		algorithm:   algorithm,
+		dir:         dir,
+		rotation:    rotation,
+		gracePeriod: gracePeriod,
+	}
+
+	if err := keySet.Rotate(); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+func newKeySetFromEnv(algorithm string) (*KeySet, error) {
+	dir := os.Getenv(keyDirKey)
+	if len(dir) == 0 {
+		return nil, myerr.NewServerError("Missing value for \"keyDir\" jwt config")
+	}
+
+	rotationHours, err := getHoursFromEnv(keyRotationKey, defaultKeyRotationHours)
+	if err != nil {
+		return nil, err
+	}
+
+	graceHours, err := getHoursFromEnv(keyGraceKey, defaultKeyGraceHours)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeySet(algorithm, dir, time.Duration(rotationHours)*time.Hour, time.Duration(graceHours)*time.Hour)
+}
+
+func getHoursFromEnv(key string, defaultValue int64) (int64, error) {
+	valueStr := os.Getenv(key)
+	if len(valueStr) == 0 {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, myerr.NewServerError(fmt.Sprintf("Wrong value for \"%s\" jwt config", key))
+	}
+	return value, nil
+}
+
+//Rotate - reloads the keys from the directory and creates a new signing key, if the current one is too old
+//the keys, which were replaced more than a grace period ago, are deleted
+func (k *KeySet) Rotate() error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	keys, err := loadKeys(k.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if len(keys) == 0 || keys[len(keys)-1].Method.Alg() != k.algorithm || now.Sub(keys[len(keys)-1].CreatedAt) >= k.rotation {
+		key, err := generateKey(k.algorithm, now)
+		if err != nil {
+			return err
+		}
+
+		if err = saveKey(k.dir, key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	activeKeys := make([]SigningKey, 0, len(keys))
+	for index, key := range keys {
+		if k.isExpired(keys, index, now) {
+			if err = os.Remove(filepath.Join(k.dir, key.ID+keyFileExtension)); err != nil && !os.IsNotExist(err) {
+				return myerr.NewServerErrorWrap(err, "Couldnt delete an expired signing key")
+			}
+			continue
+		}
+		activeKeys = append(activeKeys, key)
+	}
+
+	k.keys = activeKeys
+	return nil
+}
+
+//SigningKey - returns the newest key, which signs the tokens
+func (k *KeySet) SigningKey() SigningKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	return k.keys[len(k.keys)-1]
+}
+
+//VerificationKey - returns the key with the given kid, if it is still accepted
+func (k *KeySet) VerificationKey(kid string) (SigningKey, bool) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	now := time.Now()
+	for index, key := range k.keys {
+		if key.ID == kid && !k.isExpired(k.keys, index, now) {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+//JWKS - returns the public keys, which are still accepted
+func (k *KeySet) JWKS() JSONWebKeySet {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	now := time.Now()
+	keySet := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(k.keys))}
+	for index, key := range k.keys {
+		if !k.isExpired(k.keys, index, now) {
+			keySet.Keys = append(keySet.Keys, toJSONWebKey(key))
+		}
+	}
+	return keySet
+}
+
+//isExpired - checks if the key was replaced by a newer one more than a grace period ago
+func (k *KeySet) isExpired(keys []SigningKey, index int, now time.Time) bool {
+	return index < len(keys)-1 && now.After(keys[index+1].CreatedAt.Add(k.gracePeriod))
+}
+
+func toJSONWebKey(key SigningKey) JSONWebKey {
+	jwk := JSONWebKey{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Method.Alg(),
+	}
+
+	switch publicKey := key.Private.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	}
+	return jwk
+}
+
+func generateKey(algorithm string, createdAt time.Time) (SigningKey, error) {
+	var (
+		privateKey crypto.Signer
+		err        error
+	)
+
+	switch algorithm {
+	case AlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case AlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return SigningKey{}, myerr.NewServerError(fmt.Sprintf("Unsupported signing algorithm [%s]", algorithm))
+	}
+
+	if err != nil {
+		return SigningKey{}, myerr.NewServerErrorWrap(err, "Couldnt generate a signing key")
+	}
+	return newSigningKey(privateKey, createdAt.UTC().Truncate(time.Second))
+}
+
+//newSigningKey - the kid of the key is derived from its public key
+func newSigningKey(privateKey crypto.Signer, createdAt time.Time) (SigningKey, error) {
+	var method jwt.SigningMethod
+	switch privateKey.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = SigningMethodEd25519
+	default:
+		return SigningKey{}, myerr.NewServerError("Unsupported type of signing key")
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return SigningKey{}, myerr.NewServerErrorWrap(err, "Couldnt encode the public key")
+	}
+	hash := sha256.Sum256(publicKey)
+
+	return SigningKey{
+		ID:        hex.EncodeToString(hash[:])[:keyIDSize],
+		Method:    method,
+		Private:   privateKey,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+//saveKey - the key is written to a temporary file first, so that the other servers never read a partial key
+func saveKey(dir string, key SigningKey) error {
+	content, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Couldnt encode the signing key")
+	}
+
+	file, err := ioutil.TempFile(dir, ".key-*")
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Couldnt create the file of the signing key")
+	}
+	defer os.Remove(file.Name())
+
+	err = pem.Encode(file, &pem.Block{
+		Type:    keyPemType,
+		Headers: map[string]string{createdPemHeader: key.CreatedAt.Format(time.RFC3339)},
+		Bytes:   content,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Couldnt write the signing key")
+	}
+
+	if err = os.Rename(file.Name(), filepath.Join(dir, key.ID+keyFileExtension)); err != nil {
+		return myerr.NewServerErrorWrap(err, "Couldnt store the signing key")
+	}
+	return nil
+}
+
+//loadKeys - returns the keys in the directory, starting from the oldest one
+func loadKeys(dir string) ([]SigningKey, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Couldnt read the directory of the signing keys")
+	}
+
+	keys := make([]SigningKey, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != keyFileExtension {
+			continue
+		}
+
+		key, err := loadKey(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func loadKey(path string) (SigningKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, myerr.NewServerErrorWrap(err, "Couldnt read a signing key")
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != keyPemType {
+		return SigningKey{}, myerr.NewServerError(fmt.Sprintf("Invalid signing key [%s]", path))
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers[createdPemHeader])
+	if err != nil {
+		return SigningKey{}, myerr.NewServerErrorWrap(err, "Invalid creation time of a signing key")
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return SigningKey{}, myerr.NewServerErrorWrap(err, "Couldnt parse a signing key")
+	}
+
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return SigningKey{}, myerr.NewServerError(fmt.Sprintf("Unsupported type of signing key [%s]", path))
+	}
+	return newSigningKey(signer, createdAt)
+}
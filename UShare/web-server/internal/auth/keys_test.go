@@ -0,0 +1,218 @@
+package auth_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var createdHeader = regexp.MustCompile(`Created: .*`)
+
+//ageKey - moves the creation time of the stored key to the past
+func ageKey(dir, kid string, age time.Duration) {
+	path := filepath.Join(dir, kid+".pem")
+	content, err := ioutil.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+
+	createdAt := time.Now().Add(-age).UTC().Format(time.RFC3339)
+	content = createdHeader.ReplaceAll(content, []byte("Created: "+createdAt))
+	Expect(ioutil.WriteFile(path, content, 0600)).To(Succeed())
+}
+
+var _ = Describe("KeySet", func() {
+	const (
+		issuerVal = "issuer"
+		userID    = 1
+		sessionID = 2
+	)
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "ushare-keys")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newJwtCreator := func(keys *auth.KeySet) *auth.JwtCreatorImpl {
+		return &auth.JwtCreatorImpl{
+			Keys:                    keys,
+			Issuer:                  issuerVal,
+			ExpirationHours:         1,
+			AccessExpirationMinutes: 5,
+		}
+	}
+
+	Context("NewJwtCreatorImpl", func() {
+		BeforeEach(func() {
+			os.Clearenv()
+			os.Setenv("ISSUER", issuerVal)
+			os.Setenv("EXPIRATION", "1")
+		})
+
+		When("the algorithm is asymmetric", func() {
+			BeforeEach(func() {
+				os.Setenv("JWT_ALGORITHM", auth.AlgorithmEdDSA)
+			})
+
+			Context("and the key directory is missing", func() {
+				It("returns error", func() {
+					_, err := auth.NewJwtCreatorImpl()
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+				})
+			})
+
+			Context("and the key directory exists", func() {
+				It("creates a signing key without the secret", func() {
+					os.Setenv("JWT_KEY_DIR", dir)
+					jwtCreator, err := auth.NewJwtCreatorImpl()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(jwtCreator.Keys).NotTo(BeNil())
+					Expect(jwtCreator.GetJWKS().Keys).To(HaveLen(1))
+				})
+			})
+		})
+
+		When("the algorithm is unsupported", func() {
+			It("returns error", func() {
+				os.Setenv("JWT_ALGORITHM", "none")
+				_, err := auth.NewJwtCreatorImpl()
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+	})
+
+	for _, algorithm := range []string{auth.AlgorithmRS256, auth.AlgorithmEdDSA} {
+		algorithm := algorithm
+
+		When("the tokens are signed with "+algorithm, func() {
+			var keys *auth.KeySet
+
+			BeforeEach(func() {
+				var err error
+				keys, err = auth.NewKeySet(algorithm, dir, time.Hour, time.Hour)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("validates the token by its kid", func() {
+				jwtCreator := newJwtCreator(keys)
+				token, err := jwtCreator.GenerateToken(userID, sessionID)
+				Expect(err).NotTo(HaveOccurred())
+
+				claims, err := jwtCreator.ValidateToken(token)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(claims.UserID).To(Equal(uint(userID)))
+				Expect(claims.SessionID).To(Equal(uint(sessionID)))
+			})
+
+			It("publishes the public key", func() {
+				jwks := keys.JWKS()
+				Expect(jwks.Keys).To(HaveLen(1))
+				Expect(jwks.Keys[0].KeyID).To(Equal(keys.SigningKey().ID))
+				Expect(jwks.Keys[0].Algorithm).To(Equal(algorithm))
+				Expect(jwks.Keys[0].Use).To(Equal("sig"))
+			})
+
+			It("rejects tokens signed with the secret", func() {
+				hmacCreator := &auth.JwtCreatorImpl{
+					Secret:                  "secret",
+					Issuer:                  issuerVal,
+					ExpirationHours:         1,
+					AccessExpirationMinutes: 5,
+				}
+				token, err := hmacCreator.GenerateToken(userID, sessionID)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = newJwtCreator(keys).ValidateToken(token)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+	}
+
+	Context("Rotate", func() {
+		var (
+			keys     *auth.KeySet
+			oldToken string
+			oldKID   string
+		)
+
+		BeforeEach(func() {
+			var err error
+			keys, err = auth.NewKeySet(auth.AlgorithmEdDSA, dir, time.Hour, time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			oldKID = keys.SigningKey().ID
+			oldToken, err = newJwtCreator(keys).GenerateToken(userID, sessionID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("the signing key is younger than the rotation period", func() {
+			It("keeps the signing key", func() {
+				Expect(keys.Rotate()).To(Succeed())
+				Expect(keys.SigningKey().ID).To(Equal(oldKID))
+			})
+		})
+
+		When("the signing key is older than the rotation period", func() {
+			BeforeEach(func() {
+				ageKey(dir, oldKID, 2*time.Hour)
+			})
+
+			Context("and the grace period is not over", func() {
+				It("signs with a new key and accepts the tokens of the old one", func() {
+					Expect(keys.Rotate()).To(Succeed())
+					Expect(keys.SigningKey().ID).NotTo(Equal(oldKID))
+					Expect(keys.JWKS().Keys).To(HaveLen(2))
+
+					_, err := newJwtCreator(keys).ValidateToken(oldToken)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("shares the keys with other key sets in the same directory", func() {
+					Expect(keys.Rotate()).To(Succeed())
+
+					otherKeys, err := auth.NewKeySet(auth.AlgorithmEdDSA, dir, time.Hour, time.Hour)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(otherKeys.SigningKey().ID).To(Equal(keys.SigningKey().ID))
+
+					_, err = newJwtCreator(otherKeys).ValidateToken(oldToken)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("and the grace period is over", func() {
+				It("rejects the tokens of the old key and deletes it", func() {
+					keys, err := auth.NewKeySet(auth.AlgorithmEdDSA, dir, time.Hour, 0)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(keys.SigningKey().ID).NotTo(Equal(oldKID))
+					Expect(keys.JWKS().Keys).To(HaveLen(1))
+
+					_, err = newJwtCreator(keys).ValidateToken(oldToken)
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ClientError)
+					Expect(ok).To(Equal(true))
+
+					_, err = os.Stat(filepath.Join(dir, oldKID+".pem"))
+					Expect(os.IsNotExist(err)).To(Equal(true))
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+const opaqueTokenSize = 32
+
+//GeneratePasswordResetToken - generates a random one-time token for the reset of a forgotten password
+//returns the token, which is sent to the user, and its hash, under which it is stored
+func GeneratePasswordResetToken() (string, string, error) {
+	return generateOpaqueToken("password reset token")
+}
+
+//GenerateLoginChallenge - generates a random one-time token, which identifies a login, waiting for the second factor
+//returns the token, which is sent to the user, and its hash, under which it is stored
+func GenerateLoginChallenge() (string, string, error) {
+	return generateOpaqueToken("login challenge")
+}
+
+func generateOpaqueToken(purpose string) (string, string, error) {
+	randomBytes := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", "", myerr.NewServerErrorWrap(err, "Problem with the generation of the "+purpose)
+	}
+
+	token := hex.EncodeToString(randomBytes)
+	return token, HashToken(token), nil
+}
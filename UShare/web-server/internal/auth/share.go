@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"os"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	shareSecretKey = "SHARE_SECRET"
+	//shareAudience - audience of the share tokens, which keeps them from being accepted as login tokens
+	shareAudience = "share"
+)
+
+//go:generate mockgen --source=share.go --destination auth_mocks/share.go --package auth_mocks
+
+//ShareTokenSigner - signs and validates the tokens of the public share links
+type ShareTokenSigner interface {
+	GenerateShareToken(uint, time.Time) (string, error)
+	ValidateShareToken(string) (uint, error)
+}
+
+//ShareTokenSignerImpl - implementation of ShareTokenSigner
+type ShareTokenSignerImpl struct {
+	Secret string
+	Issuer string
+}
+
+//NewShareTokenSignerImpl - creates an instance of ShareTokenSignerImpl
+//the tokens are signed with a dedicated key, if one is configured, otherwise with the secret of the login tokens
+func NewShareTokenSignerImpl() (*ShareTokenSignerImpl, error) {
+	secret := os.Getenv(shareSecretKey)
+	if len(secret) == 0 {
+		secret = os.Getenv(secretKey)
+	}
+	if len(secret) == 0 {
+		return nil, myerr.NewServerError("Missing value for \"secret\" share link config")
+	}
+
+	issuer := os.Getenv(issuerKey)
+	if len(issuer) == 0 {
+		return nil, myerr.NewServerError("Missing value for \"issuer\" jwt config")
+	}
+
+	return &ShareTokenSignerImpl{
+		Secret: secret,
+		Issuer: issuer,
+	}, nil
+}
+
+//ShareClaim - claims of a share token, containing the id of the share link
+type ShareClaim struct {
+	LinkID uint
+	jwt.StandardClaims
+}
+
+//GenerateShareToken - generates a token for a share link, which is valid until the link expires
+func (s *ShareTokenSignerImpl) GenerateShareToken(linkID uint, expiresAt time.Time) (string, error) {
+	claims := &ShareClaim{
+		LinkID: linkID,
+		StandardClaims: jwt.StandardClaims{
+			Audience:  shareAudience,
+			ExpiresAt: expiresAt.Unix(),
+			Issuer:    s.Issuer,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.Secret))
+}
+
+//ValidateShareToken - validates the token of a share link
+//returns the id of the share link and error if the token is invalid or expired
+func (s *ShareTokenSignerImpl) ValidateShareToken(signedToken string) (uint, error) {
+	token, err := jwt.ParseWithClaims(
+		signedToken,
+		&ShareClaim{},
+		func(token *jwt.Token) (interface{}, error) {
+			return []byte(s.Secret), nil
+		},
+	)
+
+	if err != nil {
+		return 0, myerr.NewClientError("Invalid or expired share link")
+	}
+
+	claims, _ := token.Claims.(*ShareClaim)
+	if !claims.VerifyAudience(shareAudience, true) || claims.LinkID == 0 {
+		return 0, myerr.NewClientError("Invalid or expired share link")
+	}
+	return claims.LinkID, nil
+}
@@ -0,0 +1,121 @@
+package auth_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Share links", func() {
+
+	const (
+		secretKey      = "SECRET"
+		secretVal      = "secret"
+		shareSecretKey = "SHARE_SECRET"
+		shareSecretVal = "share-secret"
+		issuerKey      = "ISSUER"
+		issuerVal      = "issuer"
+	)
+
+	BeforeEach(func() {
+		os.Clearenv()
+	})
+
+	Context("NewShareTokenSignerImpl", func() {
+		When("no secret is configured", func() {
+			It("returns error", func() {
+				os.Setenv(issuerKey, issuerVal)
+				_, err := auth.NewShareTokenSignerImpl()
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("only the secret of the login tokens is configured", func() {
+			It("uses it", func() {
+				os.Setenv(secretKey, secretVal)
+				os.Setenv(issuerKey, issuerVal)
+				signer, err := auth.NewShareTokenSignerImpl()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(signer).To(Equal(&auth.ShareTokenSignerImpl{Secret: secretVal, Issuer: issuerVal}))
+			})
+		})
+
+		When("a dedicated secret is configured", func() {
+			It("uses the dedicated secret", func() {
+				os.Setenv(secretKey, secretVal)
+				os.Setenv(shareSecretKey, shareSecretVal)
+				os.Setenv(issuerKey, issuerVal)
+				signer, err := auth.NewShareTokenSignerImpl()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(signer).To(Equal(&auth.ShareTokenSignerImpl{Secret: shareSecretVal, Issuer: issuerVal}))
+			})
+		})
+	})
+
+	Context("ValidateShareToken", func() {
+		var signer auth.ShareTokenSigner
+		const linkID = 7
+
+		BeforeEach(func() {
+			signer = &auth.ShareTokenSignerImpl{Secret: secretVal, Issuer: issuerVal}
+		})
+
+		When("validating legal token", func() {
+			It("returns the id of the link", func() {
+				token, err := signer.GenerateShareToken(linkID, time.Now().Add(time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+
+				actualID, err := signer.ValidateShareToken(token)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualID).To(Equal(uint(linkID)))
+			})
+		})
+
+		When("validating expired token", func() {
+			It("returns error", func() {
+				token, _ := signer.GenerateShareToken(linkID, time.Now().Add(-time.Second))
+				_, err := signer.ValidateShareToken(token)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("validating token, signed with another secret", func() {
+			It("returns error", func() {
+				other := &auth.ShareTokenSignerImpl{Secret: shareSecretVal, Issuer: issuerVal}
+				token, _ := other.GenerateShareToken(linkID, time.Now().Add(time.Hour))
+				_, err := signer.ValidateShareToken(token)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("validating login token, signed with the same secret", func() {
+			It("returns error", func() {
+				jwtCreator := &auth.JwtCreatorImpl{Secret: secretVal, Issuer: issuerVal, ExpirationHours: 1, AccessExpirationMinutes: 1}
+				token, _ := jwtCreator.GenerateToken(linkID, 1)
+				_, err := signer.ValidateShareToken(token)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("ValidateToken", func() {
+		When("validating share token, signed with the same secret", func() {
+			It("returns error", func() {
+				signer := &auth.ShareTokenSignerImpl{Secret: secretVal, Issuer: issuerVal}
+				token, _ := signer.GenerateShareToken(1, time.Now().Add(time.Hour))
+
+				jwtCreator := &auth.JwtCreatorImpl{Secret: secretVal, Issuer: issuerVal, ExpirationHours: 1}
+				_, err := jwtCreator.ValidateToken(token)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -2,11 +2,11 @@ package cron
 
 import (
 	"log"
-	"os"
-	"path"
 	"sync"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
 )
 
 //GroupEraserJob - interface for group erase job
@@ -15,44 +15,70 @@ type GroupEraserJob interface {
 }
 
 //GroupEraserJobImpl - implementation of GroupEraserJob
+//the deactivated groups are kept in the trash for the retention period, before they are erased
 type GroupEraserJobImpl struct {
 	uamDAO    dao.UamDAO
-	groupsDir string
+	blobStore storage.BlobStore
+	retention time.Duration
 }
 
 //NewGroupEraserJobImpl - creates an instance of GroupEraserJobImpl
-func NewGroupEraserJobImpl(uamDAO dao.UamDAO, groupsDir string) *GroupEraserJobImpl {
+func NewGroupEraserJobImpl(uamDAO dao.UamDAO, blobStore storage.BlobStore, retention time.Duration) *GroupEraserJobImpl {
 	return &GroupEraserJobImpl{
 		uamDAO:    uamDAO,
-		groupsDir: groupsDir,
+		blobStore: blobStore,
+		retention: retention,
 	}
 }
 
-//DeleteGroups - deletes all deactivated job resources
+//NewGroupEraserJobFromEnv - creates an instance of GroupEraserJobImpl with the retention period of the trash from the env variables
+func NewGroupEraserJobFromEnv(uamDAO dao.UamDAO, blobStore storage.BlobStore) (*GroupEraserJobImpl, error) {
+	retention, err := getRetentionFromEnv(trashRetentionKey, defaultTrashRetentionHours)
+	if err != nil {
+		return nil, err
+	}
+	return NewGroupEraserJobImpl(uamDAO, blobStore, retention), nil
+}
+
+//DeleteGroups - deletes the resources of the groups, deactivated more than a retention period ago
+//the contents of their files, which no file in another group references, are erased later by the trash purger
 func (i *GroupEraserJobImpl) DeleteGroups() {
-	groupNames, err := i.uamDAO.GetDeactivatedGroupNames()
+	groupNames, err := i.uamDAO.GetDeactivatedGroupNames(time.Now().Add(-i.retention))
 	if err != nil {
 		log.Printf("Couldnt delete the resources of the groups in deleted state. Reason: %v\n", err)
 		return
 	}
 
-	deleteGroups(i.groupsDir, groupNames)
+	deleteGroups(i.blobStore, groupNames)
 
-	err = i.uamDAO.EraseDeactivatedGroups(groupNames)
-	if err != nil {
+	if err = i.uamDAO.EraseDeactivatedGroups(groupNames); err != nil {
 		log.Printf("Couldnt erase inactive groups. Reason: %v\n", err)
 	}
 }
 
-func deleteGroups(groupsDir string, groupNames []string) {
+func deleteGroups(blobStore storage.BlobStore, groupNames []string) {
 	var wg sync.WaitGroup
 	for _, name := range groupNames {
-		groupDir := path.Join(groupsDir, name)
+		prefix := name + "/"
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			os.RemoveAll(groupDir)
+			deleteBlobs(blobStore, prefix)
 		}()
 	}
 	wg.Wait()
 }
+
+func deleteBlobs(blobStore storage.BlobStore, prefix string) {
+	keys, err := blobStore.List(prefix)
+	if err != nil {
+		log.Printf("Couldnt list the resources [%s]. Reason: %v\n", prefix, err)
+		return
+	}
+
+	for _, key := range keys {
+		if err = blobStore.Delete(key); err != nil {
+			log.Printf("Couldnt delete resource [%s]. Reason: %v\n", key, err)
+		}
+	}
+}
@@ -4,16 +4,20 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/cron"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("GroupEraserJobImpl", func() {
+	const retention = 24 * time.Hour
+
 	var (
 		groupEraser cron.GroupEraserJob
 		uamDAO      *dao_mocks.MockUamDAO
@@ -24,7 +28,7 @@ var _ = Describe("GroupEraserJobImpl", func() {
 		testDir, _ = os.Getwd()
 		controller := gomock.NewController(GinkgoT())
 		uamDAO = dao_mocks.NewMockUamDAO(controller)
-		groupEraser = cron.NewGroupEraserJobImpl(uamDAO, testDir)
+		groupEraser = cron.NewGroupEraserJobImpl(uamDAO, storage.NewLocalBlobStore(testDir), retention)
 	})
 
 	When("deleting the deactivated groups", func() {
@@ -48,7 +52,7 @@ var _ = Describe("GroupEraserJobImpl", func() {
 		Context("and request to fetch deactivated group names fails", func() {
 			BeforeEach(func() {
 				uamDAO.EXPECT().
-					GetDeactivatedGroupNames().
+					GetDeactivatedGroupNames(gomock.Any()).
 					Return(nil, myerr.NewServerError("test-error"))
 
 				uamDAO.EXPECT().
@@ -75,7 +79,7 @@ var _ = Describe("GroupEraserJobImpl", func() {
 			Context("and request to erase group records in db fails", func() {
 				BeforeEach(func() {
 					uamDAO.EXPECT().
-						GetDeactivatedGroupNames().
+						GetDeactivatedGroupNames(gomock.Any()).
 						Return(groupsToDelete, nil)
 
 					uamDAO.EXPECT().
@@ -93,10 +97,15 @@ var _ = Describe("GroupEraserJobImpl", func() {
 			})
 
 			Context("and request to erase group records in db succeeds", func() {
+				var before time.Time
+
 				BeforeEach(func() {
 					uamDAO.EXPECT().
-						GetDeactivatedGroupNames().
-						Return(groupsToDelete, nil)
+						GetDeactivatedGroupNames(gomock.Any()).
+						DoAndReturn(func(deactivatedBefore time.Time) ([]string, error) {
+							before = deactivatedBefore
+							return groupsToDelete, nil
+						})
 
 					uamDAO.EXPECT().
 						EraseDeactivatedGroups(groupsToDelete).
@@ -105,6 +114,7 @@ var _ = Describe("GroupEraserJobImpl", func() {
 
 				It("should delete files from FS and group records in db", func() {
 					groupEraser.DeleteGroups()
+					Expect(before).To(BeTemporally("~", time.Now().Add(-retention), time.Second))
 
 					_, err := os.Stat(groupDirPath)
 					Expect(err).To(HaveOccurred())
@@ -113,6 +123,24 @@ var _ = Describe("GroupEraserJobImpl", func() {
 			})
 		})
 	})
+
+	Context("NewGroupEraserJobFromEnv", func() {
+		AfterEach(func() {
+			os.Unsetenv("TRASH_RETENTION")
+		})
+
+		It("rejects an invalid retention period", func() {
+			os.Setenv("TRASH_RETENTION", "week")
+			_, err := cron.NewGroupEraserJobFromEnv(uamDAO, storage.NewLocalBlobStore(testDir))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("accepts a zero retention period", func() {
+			os.Setenv("TRASH_RETENTION", "0")
+			_, err := cron.NewGroupEraserJobFromEnv(uamDAO, storage.NewLocalBlobStore(testDir))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
 })
 
 func createFile(name string) error {
@@ -0,0 +1,30 @@
+package cron
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+const (
+	trashRetentionKey = "TRASH_RETENTION"
+
+	defaultTrashRetentionHours = 720
+)
+
+//getRetentionFromEnv - returns the retention period, configured in hours in the env variable
+//the default period is returned, if the variable isnt set
+func getRetentionFromEnv(key string, defaultHours int64) (time.Duration, error) {
+	hours := defaultHours
+	if valueStr := os.Getenv(key); len(valueStr) != 0 {
+		value, err := strconv.ParseInt(valueStr, 10, 64)
+		if err != nil || value < 0 {
+			return 0, myerr.NewServerError(fmt.Sprintf("Wrong value for \"%s\" config", key))
+		}
+		hours = value
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+)
+
+//TrashPurgerJob - interface for trash purge job
+type TrashPurgerJob interface {
+	PurgeTrash()
+}
+
+//TrashPurgerJobImpl - implementation of TrashPurgerJob
+//the deleted files are kept in the trash of their groups for the retention period, before they are purged
+type TrashPurgerJobImpl struct {
+	fmDAO     dao.FmDAO
+	blobStore storage.BlobStore
+	retention time.Duration
+}
+
+//NewTrashPurgerJobImpl - creates an instance of TrashPurgerJobImpl
+func NewTrashPurgerJobImpl(fmDAO dao.FmDAO, blobStore storage.BlobStore, retention time.Duration) *TrashPurgerJobImpl {
+	return &TrashPurgerJobImpl{
+		fmDAO:     fmDAO,
+		blobStore: blobStore,
+		retention: retention,
+	}
+}
+
+//NewTrashPurgerJobFromEnv - creates an instance of TrashPurgerJobImpl with the retention period of the trash from the env variables
+func NewTrashPurgerJobFromEnv(fmDAO dao.FmDAO, blobStore storage.BlobStore) (*TrashPurgerJobImpl, error) {
+	retention, err := getRetentionFromEnv(trashRetentionKey, defaultTrashRetentionHours)
+	if err != nil {
+		return nil, err
+	}
+	return NewTrashPurgerJobImpl(fmDAO, blobStore, retention), nil
+}
+
+//PurgeTrash - erases the files, moved to the trash more than a retention period ago
+//afterwards the contents, which are no longer referenced by any file, are erased
+func (i *TrashPurgerJobImpl) PurgeTrash() {
+	defer i.eraseUnreferencedContents()
+
+	purged, err := i.fmDAO.PurgeTrashedFiles(time.Now().Add(-i.retention))
+	if err != nil {
+		log.Printf("Couldnt purge the trash. Reason: %v\n", err)
+		return
+	}
+
+	//files, uploaded before the deduplication, keep their own content under the group
+	for _, file := range purged {
+		if file.BlobID != 0 {
+			continue
+		} else if err = i.blobStore.Delete(storage.FileKey(file.GroupName, file.ID)); err != nil {
+			log.Printf("Couldnt delete the content of file [%d]. Reason: %v\n", file.ID, err)
+		}
+	}
+}
+
+//eraseUnreferencedContents - erases the contents of the blobs, released by the deleted files, the erased groups and the failed uploads
+//each blob is deleted only if it is still unreferenced, so the content of a concurrently uploaded file is kept
+func (i *TrashPurgerJobImpl) eraseUnreferencedContents() {
+	blobs, err := i.fmDAO.GetUnreferencedBlobs()
+	if err != nil {
+		log.Printf("Couldnt fetch the unreferenced contents. Reason: %v\n", err)
+		return
+	}
+
+	for _, blob := range blobs {
+		err = i.fmDAO.EraseUnreferencedBlob(blob.ID, func(blob models.Blob) error {
+			return i.blobStore.Delete(storage.ContentKey(blob.Checksum))
+		})
+		if err != nil {
+			log.Printf("Couldnt delete unreferenced content [%s]. Reason: %v\n", blob.Checksum, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package cron_test
+
+import (
+	"os"
+	"path"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/cron"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TrashPurgerJobImpl", func() {
+	const (
+		retention      = 24 * time.Hour
+		groupName      = "test-group"
+		orphanChecksum = "aa-orphan"
+		legacyFileID   = 7
+	)
+
+	var (
+		trashPurger cron.TrashPurgerJob
+		fmDAO       *dao_mocks.MockFmDAO
+		testDir     string
+	)
+
+	BeforeEach(func() {
+		testDir, _ = os.Getwd()
+		controller := gomock.NewController(GinkgoT())
+		fmDAO = dao_mocks.NewMockFmDAO(controller)
+		trashPurger = cron.NewTrashPurgerJobImpl(fmDAO, storage.NewLocalBlobStore(testDir), retention)
+
+		os.MkdirAll(path.Join(testDir, "blobs", "aa"), 0755)
+		os.MkdirAll(path.Join(testDir, groupName), 0755)
+		createFile(path.Join(testDir, storage.ContentKey(orphanChecksum)))
+		createFile(path.Join(testDir, storage.FileKey(groupName, legacyFileID)))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(path.Join(testDir, "blobs"))
+		os.RemoveAll(path.Join(testDir, groupName))
+	})
+
+	When("the request to purge the trash fails", func() {
+		BeforeEach(func() {
+			fmDAO.EXPECT().
+				PurgeTrashedFiles(gomock.Any()).
+				Return(nil, myerr.NewServerError("test-error"))
+
+			fmDAO.EXPECT().
+				GetUnreferencedBlobs().
+				Return([]models.Blob{}, nil)
+		})
+
+		It("shouldnt delete contents", func() {
+			trashPurger.PurgeTrash()
+
+			_, err := os.Stat(path.Join(testDir, storage.ContentKey(orphanChecksum)))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = os.Stat(path.Join(testDir, storage.FileKey(groupName, legacyFileID)))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("files are in the trash for longer than the retention period", func() {
+		var before time.Time
+
+		BeforeEach(func() {
+			fmDAO.EXPECT().
+				PurgeTrashedFiles(gomock.Any()).
+				DoAndReturn(func(trashedBefore time.Time) ([]dao.PurgedFile, error) {
+					before = trashedBefore
+					return []dao.PurgedFile{{ID: 1, GroupName: groupName, BlobID: 3}, {ID: legacyFileID, GroupName: groupName}}, nil
+				})
+
+			fmDAO.EXPECT().
+				GetUnreferencedBlobs().
+				Return([]models.Blob{{ID: 3, Checksum: orphanChecksum}}, nil)
+		})
+
+		Context("and the blobs are still unreferenced", func() {
+			BeforeEach(func() {
+				fmDAO.EXPECT().
+					EraseUnreferencedBlob(uint(3), gomock.Any()).
+					DoAndReturn(func(blobID uint, eraseContent func(blob models.Blob) error) error {
+						return eraseContent(models.Blob{ID: blobID, Checksum: orphanChecksum})
+					})
+			})
+
+			It("should delete the unreferenced contents and the contents of the files without a blob", func() {
+				trashPurger.PurgeTrash()
+				Expect(before).To(BeTemporally("~", time.Now().Add(-retention), time.Second))
+
+				_, err := os.Stat(path.Join(testDir, storage.ContentKey(orphanChecksum)))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				_, err = os.Stat(path.Join(testDir, storage.FileKey(groupName, legacyFileID)))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Context("and a blob is referenced again by an uploaded file", func() {
+			BeforeEach(func() {
+				fmDAO.EXPECT().
+					EraseUnreferencedBlob(uint(3), gomock.Any()).
+					Return(nil)
+			})
+
+			It("should keep its content", func() {
+				trashPurger.PurgeTrash()
+
+				_, err := os.Stat(path.Join(testDir, storage.ContentKey(orphanChecksum)))
+				Expect(err).NotTo(HaveOccurred())
+				_, err = os.Stat(path.Join(testDir, storage.FileKey(groupName, legacyFileID)))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("NewTrashPurgerJobFromEnv", func() {
+		AfterEach(func() {
+			os.Unsetenv("TRASH_RETENTION")
+		})
+
+		It("rejects an invalid retention period", func() {
+			os.Setenv("TRASH_RETENTION", "-1")
+			_, err := cron.NewTrashPurgerJobFromEnv(fmDAO, storage.NewLocalBlobStore(testDir))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"log"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+)
+
+//UploadCleanerJob - interface for the job, which erases the expired uploads
+type UploadCleanerJob interface {
+	EraseExpiredUploads()
+}
+
+//UploadCleanerJobImpl - implementation of UploadCleanerJob
+type UploadCleanerJobImpl struct {
+	fmDAO     dao.FmDAO
+	blobStore storage.BlobStore
+}
+
+//NewUploadCleanerJobImpl - creates an instance of UploadCleanerJobImpl
+func NewUploadCleanerJobImpl(fmDAO dao.FmDAO, blobStore storage.BlobStore) *UploadCleanerJobImpl {
+	return &UploadCleanerJobImpl{
+		fmDAO:     fmDAO,
+		blobStore: blobStore,
+	}
+}
+
+//EraseExpiredUploads - erases the chunks of the expired uploads, afterwards their sessions are removed
+//a session is kept, if some of its chunks couldnt be erased, so that they are erased on the next run
+func (i *UploadCleanerJobImpl) EraseExpiredUploads() {
+	uploads, err := i.fmDAO.GetExpiredUploadSessions()
+	if err != nil {
+		log.Printf("Couldnt fetch the expired uploads. Reason: %v\n", err)
+		return
+	}
+
+	for _, upload := range uploads {
+		if !i.eraseChunks(upload) {
+			continue
+		}
+
+		if err = i.fmDAO.RemoveUploadSession(upload.ID); err != nil {
+			log.Printf("Couldnt remove expired upload session [%s]. Reason: %v\n", upload.UploadID, err)
+		}
+	}
+}
+
+//eraseChunks - erases the chunks of an upload, returns if all of them were erased
+func (i *UploadCleanerJobImpl) eraseChunks(upload dao.ExpiredUpload) bool {
+	keys, err := i.blobStore.List(storage.UploadKeyPrefix(upload.GroupName, upload.UploadID))
+	if err != nil {
+		log.Printf("Couldnt list the chunks of expired upload [%s]. Reason: %v\n", upload.UploadID, err)
+		return false
+	}
+
+	erased := true
+	for _, key := range keys {
+		if err = i.blobStore.Delete(key); err != nil {
+			log.Printf("Couldnt delete chunk [%s]. Reason: %v\n", key, err)
+			erased = false
+		}
+	}
+	return erased
+}
@@ -0,0 +1,82 @@
+package cron_test
+
+import (
+	"os"
+	"path"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/cron"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UploadCleanerJobImpl", func() {
+	const (
+		groupName = "test-group"
+		uploadID  = "expired-upload"
+		sessionID = 5
+	)
+
+	var (
+		uploadCleaner cron.UploadCleanerJob
+		fmDAO         *dao_mocks.MockFmDAO
+		testDir       string
+		uploadDir     string
+	)
+
+	BeforeEach(func() {
+		testDir, _ = os.Getwd()
+		controller := gomock.NewController(GinkgoT())
+		fmDAO = dao_mocks.NewMockFmDAO(controller)
+		uploadCleaner = cron.NewUploadCleanerJobImpl(fmDAO, storage.NewLocalBlobStore(testDir))
+
+		uploadDir = path.Join(testDir, storage.UploadKeyPrefix(groupName, uploadID))
+		os.MkdirAll(uploadDir, 0755)
+		createFile(path.Join(uploadDir, "0"))
+		createFile(path.Join(uploadDir, "1"))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(path.Join(testDir, groupName))
+	})
+
+	When("the request to fetch the expired uploads fails", func() {
+		BeforeEach(func() {
+			fmDAO.EXPECT().
+				GetExpiredUploadSessions().
+				Return(nil, myerr.NewServerError("test-error"))
+
+			fmDAO.EXPECT().
+				RemoveUploadSession(gomock.Any()).
+				Times(0)
+		})
+
+		It("shouldnt delete chunks", func() {
+			uploadCleaner.EraseExpiredUploads()
+			Expect(getCountFiles(uploadDir)).To(Equal(2))
+		})
+	})
+
+	When("an upload has expired", func() {
+		BeforeEach(func() {
+			fmDAO.EXPECT().
+				GetExpiredUploadSessions().
+				Return([]dao.ExpiredUpload{{ID: sessionID, UploadID: uploadID, GroupName: groupName}}, nil)
+
+			fmDAO.EXPECT().
+				RemoveUploadSession(uint(sessionID)).
+				Return(nil)
+		})
+
+		It("should delete its chunks and its session", func() {
+			uploadCleaner.EraseExpiredUploads()
+
+			_, err := os.Stat(uploadDir)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"log"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+)
+
+const (
+	userRetentionKey = "USER_RETENTION"
+
+	defaultUserRetentionHours = 720
+)
+
+//UserPurgerJob - interface for user purge job
+type UserPurgerJob interface {
+	PurgeUsers()
+}
+
+//UserPurgerJobImpl - implementation of UserPurgerJob
+//the deactivated users are kept for the retention period, before they are purged
+type UserPurgerJobImpl struct {
+	uamDAO    dao.UamDAO
+	retention time.Duration
+}
+
+//NewUserPurgerJobImpl - creates an instance of UserPurgerJobImpl
+func NewUserPurgerJobImpl(uamDAO dao.UamDAO, retention time.Duration) *UserPurgerJobImpl {
+	return &UserPurgerJobImpl{
+		uamDAO:    uamDAO,
+		retention: retention,
+	}
+}
+
+//NewUserPurgerJobFromEnv - creates an instance of UserPurgerJobImpl with the retention period from the env variables
+func NewUserPurgerJobFromEnv(uamDAO dao.UamDAO) (*UserPurgerJobImpl, error) {
+	retention, err := getRetentionFromEnv(userRetentionKey, defaultUserRetentionHours)
+	if err != nil {
+		return nil, err
+	}
+	return NewUserPurgerJobImpl(uamDAO, retention), nil
+}
+
+//PurgeUsers - purges the users, deactivated more than a retention period ago
+//their files pass to the owners of the groups
+func (i *UserPurgerJobImpl) PurgeUsers() {
+	userIDs, err := i.uamDAO.GetDeactivatedUserIDs(time.Now().Add(-i.retention))
+	if err != nil {
+		log.Printf("Couldnt fetch the deactivated users. Reason: %v\n", err)
+		return
+	} else if len(userIDs) == 0 {
+		return
+	}
+
+	if err = i.uamDAO.PurgeDeactivatedUsers(userIDs); err != nil {
+		log.Printf("Couldnt purge the deactivated users. Reason: %v\n", err)
+	}
+}
@@ -0,0 +1,100 @@
+package cron_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/cron"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UserPurgerJobImpl", func() {
+	const retention = 24 * time.Hour
+
+	var (
+		userPurger cron.UserPurgerJob
+		uamDAO     *dao_mocks.MockUamDAO
+	)
+
+	BeforeEach(func() {
+		controller := gomock.NewController(GinkgoT())
+		uamDAO = dao_mocks.NewMockUamDAO(controller)
+		userPurger = cron.NewUserPurgerJobImpl(uamDAO, retention)
+	})
+
+	When("the request to fetch the deactivated users fails", func() {
+		BeforeEach(func() {
+			uamDAO.EXPECT().
+				GetDeactivatedUserIDs(gomock.Any()).
+				Return(nil, myerr.NewServerError("test-error"))
+
+			uamDAO.EXPECT().
+				PurgeDeactivatedUsers(gomock.Any()).
+				Times(0)
+		})
+
+		It("shouldnt purge users", func() {
+			userPurger.PurgeUsers()
+		})
+	})
+
+	When("no user is deactivated for longer than the retention period", func() {
+		BeforeEach(func() {
+			uamDAO.EXPECT().
+				GetDeactivatedUserIDs(gomock.Any()).
+				Return([]uint{}, nil)
+
+			uamDAO.EXPECT().
+				PurgeDeactivatedUsers(gomock.Any()).
+				Times(0)
+		})
+
+		It("shouldnt purge users", func() {
+			userPurger.PurgeUsers()
+		})
+	})
+
+	When("users are deactivated for longer than the retention period", func() {
+		var before time.Time
+
+		BeforeEach(func() {
+			uamDAO.EXPECT().
+				GetDeactivatedUserIDs(gomock.Any()).
+				DoAndReturn(func(deactivatedBefore time.Time) ([]uint, error) {
+					before = deactivatedBefore
+					return []uint{1, 2}, nil
+				})
+
+			uamDAO.EXPECT().
+				PurgeDeactivatedUsers([]uint{1, 2}).
+				Return(nil)
+		})
+
+		It("should purge them", func() {
+			userPurger.PurgeUsers()
+			Expect(before).To(BeTemporally("~", time.Now().Add(-retention), time.Second))
+		})
+	})
+
+	Context("NewUserPurgerJobFromEnv", func() {
+		AfterEach(func() {
+			os.Unsetenv("USER_RETENTION")
+		})
+
+		It("rejects an invalid retention period", func() {
+			os.Setenv("USER_RETENTION", "-1")
+			_, err := cron.NewUserPurgerJobFromEnv(uamDAO)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("accepts a zero retention period", func() {
+			os.Setenv("USER_RETENTION", "0")
+			_, err := cron.NewUserPurgerJobFromEnv(uamDAO)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	//pgUniqueViolation - postgres error code of a violated unique constraint
+	pgUniqueViolation = "23505"
+
+	//pgForeignKeyViolation - postgres error code of a violated foreign key
+	pgForeignKeyViolation = "23503"
+)
+
+//isUniqueViolation - checks if the error is caused by a duplicate value of a unique index
+//the count-then-insert checks are racy, so the unique indexes are the final guard against duplicates
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
+
+//isForeignKeyViolation - checks if the error is caused by a reference to a missing row
+//the row was deleted by a concurrent transaction after it was looked up
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgForeignKeyViolation
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	}
+	return false
+}
@@ -0,0 +1,612 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dbconn"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/migrations"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("DAOs on an in-memory database", func() {
+	var (
+		uamDao   *UamDAOImpl
+		fmDao    *FmDAOImpl
+		owner    models.User
+		member   models.User
+		outsider models.User
+	)
+
+	const (
+		groupName = "test-group"
+		checksum  = "checksum"
+	)
+
+	createUser := func(username string) models.User {
+		Expect(uamDao.CreateUser(username, "password")).To(Succeed())
+		user, err := uamDao.GetUser(username)
+		Expect(err).NotTo(HaveOccurred())
+		return user
+	}
+
+	BeforeEach(func() {
+		dbConn, err := dbconn.NewInMemoryDBConn()
+		Expect(err).NotTo(HaveOccurred())
+
+		uamDao = NewUamDAOImpl(dbConn)
+		fmDao = NewFmDAOImpl(dbConn)
+		_, err = migrations.NewMigrator(dbConn, migrations.All()).Up(0)
+		Expect(err).NotTo(HaveOccurred())
+
+		owner = createUser("owner")
+		member = createUser("member")
+		outsider = createUser("outsider")
+
+		Expect(uamDao.CreateGroup(owner.ID, groupName, &models.AuditEvent{Action: models.AuditGroupCreated})).To(Succeed())
+		Expect(uamDao.CreateInvitation(owner.ID, member.Username, groupName, nil, &models.AuditEvent{Action: models.AuditMemberInvited})).To(Succeed())
+		Expect(uamDao.AcceptInvitation(member.ID, groupName, &models.AuditEvent{Action: models.AuditInvitationAccepted, ActorID: &member.ID})).To(Succeed())
+	})
+
+	Context("Users and groups", func() {
+		It("rejects a duplicate username", func() {
+			err := uamDao.CreateUser(owner.Username, "password")
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("rejects a second membership of a user in a group", func() {
+			Expect(uamDao.CreateInvitation(owner.ID, outsider.Username, groupName, nil, nil)).To(Succeed())
+			Expect(uamDao.dbConn.Create(&models.Membership{GroupID: 1, UserID: outsider.ID, Role: models.RoleViewer}).Error).To(Succeed())
+
+			err := uamDao.AcceptInvitation(outsider.ID, groupName, nil)
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("lists the members of a group page by page", func() {
+			members, cursor, err := uamDao.GetAllUsersInGroup(owner.ID, groupName, ListOptions{Limit: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members).To(HaveLen(1))
+			Expect(members[0].Username).To(Equal(member.Username))
+			Expect(cursor).NotTo(BeEmpty())
+
+			members, cursor, err = uamDao.GetAllUsersInGroup(owner.ID, groupName, ListOptions{Limit: 1, Cursor: cursor})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members).To(HaveLen(1))
+			Expect(members[0].Username).To(Equal(owner.Username))
+			Expect(cursor).To(BeEmpty())
+
+			members, _, err = uamDao.GetAllUsersInGroup(owner.ID, groupName, ListOptions{Limit: 10, Role: models.RoleOwner})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(members).To(HaveLen(1))
+			Expect(members[0].Username).To(Equal(owner.Username))
+		})
+
+		It("lists the users sorted by their creation time", func() {
+			users, cursor, err := uamDao.GetAllUsers(ListOptions{Limit: 2, SortBy: "created_at", Descending: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users).To(HaveLen(2))
+			Expect(users[0].Username).To(Equal(outsider.Username))
+			Expect(users[1].Username).To(Equal(member.Username))
+
+			users, cursor, err = uamDao.GetAllUsers(ListOptions{Limit: 2, SortBy: "created_at", Descending: true, Cursor: cursor})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users).To(HaveLen(1))
+			Expect(users[0].Username).To(Equal(owner.Username))
+			Expect(cursor).To(BeEmpty())
+		})
+
+		It("records the audit trail of a group", func() {
+			group, err := uamDao.GetGroup(groupName)
+			Expect(err).NotTo(HaveOccurred())
+
+			events, err := uamDao.GetAuditEvents(group.ID, AuditFilter{Actor: member.Username}, 0, 10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Action).To(Equal(models.AuditInvitationAccepted))
+		})
+
+		It("erases a deactivated group together with its files", func() {
+			_, _, err := fmDao.AddFileInfo(member.ID, "file.txt", checksum, 10, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uamDao.DeactivateGroup(groupName, nil)).To(Succeed())
+
+			groupNames, err := uamDao.GetDeactivatedGroupNames(time.Now().Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groupNames).To(BeEmpty())
+			groupNames, err = uamDao.GetDeactivatedGroupNames(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groupNames).To(ConsistOf(groupName))
+
+			Expect(uamDao.EraseDeactivatedGroups(groupNames)).To(Succeed())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			Expect(blobs[0].Checksum).To(Equal(checksum))
+
+			deleted, err := uamDao.GetDeactivatedGroups(owner.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(BeEmpty())
+			var count int64
+			Expect(uamDao.dbConn.Model(&models.Membership{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+		})
+
+		It("restores a deleted group together with its members", func() {
+			Expect(uamDao.DeactivateGroup(groupName, nil)).To(Succeed())
+
+			groups, _, err := uamDao.GetAllGroups(ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(BeEmpty())
+			deleted, err := uamDao.GetDeactivatedGroups(owner.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(HaveLen(1))
+			Expect(deleted[0].DeactivatedAt).NotTo(BeNil())
+
+			err = uamDao.RestoreGroup(member.ID, groupName, nil)
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+
+			Expect(uamDao.RestoreGroup(owner.ID, groupName, &models.AuditEvent{Action: models.AuditGroupRecovered})).To(Succeed())
+			group, err := uamDao.GetGroup(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(group.Active).To(BeTrue())
+			Expect(group.DeactivatedAt).To(BeNil())
+			Expect(uamDao.MemberExists(member.ID, group.ID)).To(BeTrue())
+		})
+	})
+
+	Context("Files", func() {
+		var fileID uint
+
+		BeforeEach(func() {
+			var err error
+			fileID, _, err = fmDao.AddFileInfo(member.ID, "Report 2024.txt", checksum, 100, groupName, &models.AuditEvent{Action: models.AuditFileUploaded})
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = fmDao.AddFileInfo(owner.ID, "notes.txt", "other", 5, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the versions of a file", func() {
+			latestID, _, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			versions, err := fmDao.GetFileVersions(owner.ID, latestID, groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(HaveLen(2))
+
+			restored, err := fmDao.RestoreFileVersion(fileID, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored.Size).To(Equal(int64(100)))
+			Expect(restored.OwnerID).To(Equal(member.ID))
+
+			files, _, err := fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(2))
+		})
+
+		It("passes the files of a purged user to the group owner", func() {
+			Expect(uamDao.DeactivateUser(member.ID)).To(Succeed())
+
+			users, _, err := uamDao.GetAllUsers(ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users).To(HaveLen(2))
+			_, err = uamDao.GetUserByID(member.ID)
+			Expect(err).To(HaveOccurred())
+			Expect(uamDao.CreateUser(member.Username, "password")).NotTo(Succeed())
+
+			userIDs, err := uamDao.GetDeactivatedUserIDs(time.Now().Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(userIDs).To(BeEmpty())
+			userIDs, err = uamDao.GetDeactivatedUserIDs(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(userIDs).To(ConsistOf(member.ID))
+
+			Expect(uamDao.PurgeDeactivatedUsers(userIDs)).To(Succeed())
+
+			file, err := fmDao.GetFileInfo(owner.ID, fileID, groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.OwnerID).To(Equal(owner.ID))
+			Expect(uamDao.CreateUser(member.Username, "password")).To(Succeed())
+		})
+
+		Context("and a concurrent change takes the number of a new version", func() {
+			var conflicts int
+
+			BeforeEach(func() {
+				conflicts = 0
+				err := fmDao.dbConn.Callback().Create().Before("gorm:create").Register("test:take_version", func(db *gorm.DB) {
+					fileInfo, ok := db.Statement.Dest.(*models.FileInfo)
+					if !ok || conflicts == 0 {
+						return
+					}
+					conflicts--
+					db.Session(&gorm.Session{NewDB: true}).
+						Exec("INSERT INTO file_infos (name, owner_id, group_id, version, size) VALUES (?, ?, ?, ?, 0)", fileInfo.Name, fileInfo.OwnerID, fileInfo.GroupID, fileInfo.Version)
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("numbers the version again", func() {
+				conflicts = 1
+				latestID, _, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conflicts).To(BeZero())
+
+				file, err := fmDao.GetFileInfo(owner.ID, latestID, groupName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(file.Version).To(Equal(uint(2)))
+
+				conflicts = 1
+				restored, err := fmDao.RestoreFileVersion(fileID, groupName, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restored.Version).To(Equal(uint(3)))
+			})
+
+			It("reports the concurrent changes, if the version is taken every time", func() {
+				conflicts = maxVersionAttempts
+				_, _, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		Context("and a concurrent upload of the same content creates its blob", func() {
+			var conflicts int
+
+			BeforeEach(func() {
+				conflicts = 0
+				err := fmDao.dbConn.Callback().Create().Before("gorm:create").Register("test:take_blob", func(db *gorm.DB) {
+					blob, ok := db.Statement.Dest.(*models.Blob)
+					if !ok || conflicts == 0 {
+						return
+					}
+					conflicts--
+					db.Session(&gorm.Session{NewDB: true}).
+						Exec("INSERT INTO blobs (checksum, size, ref_count) VALUES (?, ?, 1)", blob.Checksum, blob.Size)
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("references the blob in the next attempt", func() {
+				conflicts = 1
+				latestID, created, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeTrue())
+				Expect(conflicts).To(BeZero())
+
+				file, err := fmDao.GetFileInfo(owner.ID, latestID, groupName)
+				Expect(err).NotTo(HaveOccurred())
+				var blob models.Blob
+				Expect(fmDao.dbConn.First(&blob, file.BlobID).Error).To(Succeed())
+				Expect(blob.Checksum).To(Equal("newer"))
+				Expect(blob.RefCount).To(Equal(uint(1)))
+			})
+
+			It("reports the concurrent changes, if the blob is created every time", func() {
+				conflicts = maxVersionAttempts
+				_, _, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		It("purges a user, who owns a deactivated group, only after the group is erased", func() {
+			const soloGroupName = "solo-group"
+			Expect(uamDao.CreateGroup(outsider.ID, soloGroupName, nil)).To(Succeed())
+			_, _, err := fmDao.AddFileInfo(outsider.ID, "solo.txt", "solo", 10, soloGroupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uamDao.DeactivateUser(outsider.ID)).To(Succeed())
+
+			Expect(uamDao.PurgeDeactivatedUsers([]uint{outsider.ID})).To(Succeed())
+			userIDs, err := uamDao.GetDeactivatedUserIDs(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(userIDs).To(ConsistOf(outsider.ID))
+
+			Expect(uamDao.EraseDeactivatedGroups([]string{soloGroupName})).To(Succeed())
+			Expect(uamDao.PurgeDeactivatedUsers([]uint{outsider.ID})).To(Succeed())
+			userIDs, err = uamDao.GetDeactivatedUserIDs(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(userIDs).To(BeEmpty())
+		})
+
+		It("doesnt count the expired uploads in the quota of the group", func() {
+			Expect(uamDao.UpdateGroupLimits(groupName, 300, 200, nil)).To(Succeed())
+
+			_, err := fmDao.CreateUploadSession(member.ID, "expired", "expired.txt", 150, groupName, time.Now().Add(-time.Minute))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fmDao.CreateUploadSession(member.ID, "pending", "pending.txt", 150, groupName, time.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fmDao.CreateUploadSession(member.ID, "rejected", "rejected.txt", 100, groupName, time.Now().Add(time.Hour))
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+
+			_, err = fmDao.GetUploadSession(member.ID, "expired", groupName)
+			_, ok = err.(*myerr.ItemNotFoundError)
+			Expect(ok).To(BeTrue())
+			_, err = fmDao.GetUploadSession(member.ID, "pending", groupName)
+			Expect(err).NotTo(HaveOccurred())
+
+			uploads, err := fmDao.GetExpiredUploadSessions()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploads).To(HaveLen(1))
+			Expect(uploads[0].UploadID).To(Equal("expired"))
+			Expect(uploads[0].GroupName).To(Equal(groupName))
+		})
+
+		It("counts the pending uploads in the quota of the group, except for the completed one", func() {
+			Expect(uamDao.UpdateGroupLimits(groupName, 400, 300, nil)).To(Succeed())
+			_, err := fmDao.CreateUploadSession(member.ID, "pending", "pending.txt", 200, groupName, time.Now().Add(time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = fmDao.AddFileInfo(member.ID, "direct.txt", "direct", 100, groupName, nil)
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+
+			session, err := fmDao.GetUploadSession(member.ID, "pending", groupName)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = fmDao.AddUploadedFileInfo(member.ID, session, "pending", groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("lists the files page by page", func() {
+			files, cursor, err := fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 1, SortBy: "size"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name).To(Equal("notes.txt"))
+
+			files, cursor, err = fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 1, SortBy: "size", Cursor: cursor})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name).To(Equal("Report 2024.txt"))
+			Expect(cursor).To(BeEmpty())
+
+			files, _, err = fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 10, Query: "REPORT"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+
+		It("finds the tagged files only in the groups of the user", func() {
+			Expect(fmDao.SetFileTags(fileID, groupName, []string{"finance", "2024"}, nil)).To(Succeed())
+
+			since := time.Now().UTC().Add(-time.Hour)
+			minSize := int64(50)
+			results, cursor, err := fmDao.SearchFiles(owner.ID, FileSearchFilter{
+				Uploader: member.Username,
+				Since:    &since,
+				MinSize:  &minSize,
+				Tags:     []string{"finance"},
+			}, ListOptions{Limit: 10, SortBy: FileSortUploader, Query: "report"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cursor).To(BeEmpty())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].ID).To(Equal(fileID))
+			Expect(results[0].GroupName).To(Equal(groupName))
+			Expect(results[0].Tags).To(ConsistOf("finance", "2024"))
+
+			results, _, err = fmDao.SearchFiles(outsider.ID, FileSearchFilter{}, ListOptions{Limit: 10, Query: "report"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(BeEmpty())
+		})
+
+		It("finds the files page by page, even if their uploader was deleted", func() {
+			Expect(fmDao.dbConn.Model(&models.FileInfo{}).Where("name = ?", "notes.txt").Update("owner_id", outsider.ID+100).Error).To(Succeed())
+
+			results, cursor, err := fmDao.SearchFiles(owner.ID, FileSearchFilter{}, ListOptions{Limit: 1, SortBy: FileSortUploader})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Name).To(Equal("notes.txt"))
+			Expect(results[0].Uploader).To(BeNil())
+			Expect(cursor).NotTo(BeEmpty())
+
+			results, cursor, err = fmDao.SearchFiles(owner.ID, FileSearchFilter{}, ListOptions{Limit: 1, SortBy: FileSortUploader, Cursor: cursor})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Name).To(Equal("Report 2024.txt"))
+			Expect(cursor).To(BeEmpty())
+
+			results, cursor, err = fmDao.SearchFiles(owner.ID, FileSearchFilter{}, ListOptions{Limit: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results[0].Name).To(Equal("notes.txt"))
+
+			_, _, err = fmDao.SearchFiles(owner.ID, FileSearchFilter{}, ListOptions{Limit: 1, SortBy: FileSortName, Cursor: cursor})
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("erases the blob of the last file, which references it", func() {
+			Expect(fmDao.RemoveFileInfo(fileID, groupName, &models.AuditEvent{Action: models.AuditFileDeleted})).To(Succeed())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			Expect(blobs[0].Checksum).To(Equal(checksum))
+
+			var erased []string
+			eraseContent := func(blob models.Blob) error {
+				erased = append(erased, blob.Checksum)
+				return nil
+			}
+			Expect(fmDao.EraseUnreferencedBlob(blobs[0].ID, eraseContent)).To(Succeed())
+			Expect(erased).To(ConsistOf(checksum))
+			blobs, err = fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(BeEmpty())
+
+			group, err := uamDao.GetGroup(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			usage, err := uamDao.GetGroupUsage(group.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal(int64(5)))
+		})
+
+		It("moves a deleted file to the trash and restores it", func() {
+			_, err := fmDao.CreateShareLink(member.ID, fileID, time.Now().Add(time.Hour), 0, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fmDao.SetFileTags(fileID, groupName, []string{"finance"}, nil)).To(Succeed())
+			Expect(fmDao.TrashFile(owner.ID, fileID, groupName, &models.AuditEvent{Action: models.AuditFileDeleted})).To(Succeed())
+
+			files, _, err := fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			_, err = fmDao.GetFileInfo(owner.ID, fileID, groupName)
+			Expect(err).To(HaveOccurred())
+			group, err := uamDao.GetGroup(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			usage, err := uamDao.GetGroupUsage(group.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(usage).To(Equal(int64(5)))
+
+			trashed, err := fmDao.GetTrashedFiles(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trashed).To(HaveLen(1))
+			Expect(trashed[0].ID).To(Equal(fileID))
+			Expect(*trashed[0].TrashedBy).To(Equal(owner.ID))
+
+			restored, err := fmDao.RestoreTrashedFile(fileID, groupName, &models.AuditEvent{Action: models.AuditFileRecovered})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored.ID).To(Equal(fileID))
+			Expect(restored.Version).To(Equal(uint(1)))
+			Expect(restored.TrashedAt).To(BeNil())
+
+			_, err = fmDao.RestoreTrashedFile(fileID, groupName, nil)
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ItemNotFoundError)
+			Expect(ok).To(BeTrue())
+
+			links, err := fmDao.GetActiveShareLinks(fileID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(links).To(BeEmpty())
+			results, _, err := fmDao.SearchFiles(owner.ID, FileSearchFilter{Tags: []string{"finance"}}, ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(1))
+		})
+
+		It("moves all versions of a deleted file to the trash and restores them together", func() {
+			latestID, _, err := fmDao.AddFileInfo(owner.ID, "Report 2024.txt", "newer", 200, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fmDao.TrashFile(owner.ID, latestID, groupName, nil)).To(Succeed())
+
+			files, _, err := fmDao.GetAllFilesInfo(owner.ID, groupName, ListOptions{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Name).To(Equal("notes.txt"))
+			_, err = fmDao.GetFileInfo(owner.ID, fileID, groupName)
+			Expect(err).To(HaveOccurred())
+
+			trashed, err := fmDao.GetTrashedFiles(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trashed).To(HaveLen(1))
+			Expect(trashed[0].ID).To(Equal(latestID))
+
+			restored, err := fmDao.RestoreTrashedFile(fileID, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored.ID).To(Equal(latestID))
+			Expect(restored.Version).To(Equal(uint(2)))
+
+			versions, err := fmDao.GetFileVersions(owner.ID, latestID, groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(HaveLen(2))
+			trashed, err = fmDao.GetTrashedFiles(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trashed).To(BeEmpty())
+		})
+
+		It("keeps the blob, which is referenced again before its content is erased", func() {
+			Expect(fmDao.RemoveFileInfo(fileID, groupName, nil)).To(Succeed())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+
+			_, created, err := fmDao.AddFileInfo(member.ID, "copy.txt", checksum, 100, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeFalse())
+
+			eraseContent := func(blob models.Blob) error {
+				Fail("the content of a referenced blob is erased")
+				return nil
+			}
+			Expect(fmDao.EraseUnreferencedBlob(blobs[0].ID, eraseContent)).To(Succeed())
+			blobs, err = fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(BeEmpty())
+		})
+
+		It("creates the blob again, after its content is erased", func() {
+			Expect(fmDao.RemoveFileInfo(fileID, groupName, nil)).To(Succeed())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fmDao.EraseUnreferencedBlob(blobs[0].ID, func(models.Blob) error { return nil })).To(Succeed())
+
+			_, created, err := fmDao.AddFileInfo(member.ID, "copy.txt", checksum, 100, groupName, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeTrue())
+		})
+
+		It("keeps the blob, if its content cannot be erased", func() {
+			Expect(fmDao.RemoveFileInfo(fileID, groupName, nil)).To(Succeed())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+
+			err = fmDao.EraseUnreferencedBlob(blobs[0].ID, func(models.Blob) error { return myerr.NewServerError("test-error") })
+			Expect(err).To(HaveOccurred())
+			blobs, err = fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+		})
+
+		It("purges the expired files from the trash", func() {
+			Expect(fmDao.SetFileTags(fileID, groupName, []string{"finance"}, nil)).To(Succeed())
+			Expect(fmDao.TrashFile(member.ID, fileID, groupName, nil)).To(Succeed())
+
+			purged, err := fmDao.PurgeTrashedFiles(time.Now().Add(-time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(BeEmpty())
+			blobs, err := fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(BeEmpty())
+
+			purged, err = fmDao.PurgeTrashedFiles(time.Now().Add(time.Second))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(ConsistOf(PurgedFile{ID: fileID, GroupName: groupName, BlobID: 1}))
+			blobs, err = fmDao.GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blobs).To(HaveLen(1))
+			Expect(blobs[0].Checksum).To(Equal(checksum))
+
+			trashed, err := fmDao.GetTrashedFiles(groupName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trashed).To(BeEmpty())
+			var count int64
+			Expect(fmDao.dbConn.Model(&models.FileTag{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+		})
+
+		It("counts the uses of a share link", func() {
+			linkID, err := fmDao.CreateShareLink(member.ID, fileID, time.Now().Add(time.Hour), 1, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			file, group, err := fmDao.GetSharedFile(linkID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.ID).To(Equal(fileID))
+			Expect(group).To(Equal(groupName))
+
+			Expect(fmDao.UseShareLink(linkID, nil)).To(Succeed())
+			Expect(fmDao.UseShareLink(linkID, nil)).NotTo(Succeed())
+
+			_, _, err = fmDao.GetSharedFile(linkID)
+			_, ok := err.(*myerr.ItemNotFoundError)
+			Expect(ok).To(BeTrue())
+		})
+	})
+})
@@ -5,9 +5,11 @@
 package dao_mocks
 
 import (
+	dao "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	models "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockFmDAO is a mock of FmDAO interface
@@ -34,18 +36,35 @@ func (m *MockFmDAO) EXPECT() *MockFmDAOMockRecorder {
 }
 
 // AddFileInfo mocks base method
-func (m *MockFmDAO) AddFileInfo(userID uint, fileName, groupName string) (uint, error) {
+func (m *MockFmDAO) AddFileInfo(userID uint, fileName, checksum string, size int64, groupName string, event *models.AuditEvent) (uint, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddFileInfo", userID, fileName, groupName)
+	ret := m.ctrl.Call(m, "AddFileInfo", userID, fileName, checksum, size, groupName, event)
 	ret0, _ := ret[0].(uint)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // AddFileInfo indicates an expected call of AddFileInfo
-func (mr *MockFmDAOMockRecorder) AddFileInfo(userID, fileName, groupName interface{}) *gomock.Call {
+func (mr *MockFmDAOMockRecorder) AddFileInfo(userID, fileName, checksum, size, groupName, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFileInfo", reflect.TypeOf((*MockFmDAO)(nil).AddFileInfo), userID, fileName, checksum, size, groupName, event)
+}
+
+// AddUploadedFileInfo mocks base method
+func (m *MockFmDAO) AddUploadedFileInfo(userID uint, session models.UploadSession, checksum, groupName string, event *models.AuditEvent) (uint, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUploadedFileInfo", userID, session, checksum, groupName, event)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddUploadedFileInfo indicates an expected call of AddUploadedFileInfo
+func (mr *MockFmDAOMockRecorder) AddUploadedFileInfo(userID, session, checksum, groupName, event interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFileInfo", reflect.TypeOf((*MockFmDAO)(nil).AddFileInfo), userID, fileName, groupName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUploadedFileInfo", reflect.TypeOf((*MockFmDAO)(nil).AddUploadedFileInfo), userID, session, checksum, groupName, event)
 }
 
 // GetFileInfo mocks base method
@@ -64,44 +83,356 @@ func (mr *MockFmDAOMockRecorder) GetFileInfo(userID, fileID, groupName interface
 }
 
 // GetAllFilesInfo mocks base method
-func (m *MockFmDAO) GetAllFilesInfo(userID uint, groupName string) ([]models.FileInfo, error) {
+func (m *MockFmDAO) GetAllFilesInfo(userID uint, groupName string, options dao.ListOptions) ([]models.FileInfo, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllFilesInfo", userID, groupName)
+	ret := m.ctrl.Call(m, "GetAllFilesInfo", userID, groupName, options)
 	ret0, _ := ret[0].([]models.FileInfo)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAllFilesInfo indicates an expected call of GetAllFilesInfo
-func (mr *MockFmDAOMockRecorder) GetAllFilesInfo(userID, groupName interface{}) *gomock.Call {
+func (mr *MockFmDAOMockRecorder) GetAllFilesInfo(userID, groupName, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFilesInfo", reflect.TypeOf((*MockFmDAO)(nil).GetAllFilesInfo), userID, groupName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllFilesInfo", reflect.TypeOf((*MockFmDAO)(nil).GetAllFilesInfo), userID, groupName, options)
 }
 
 // RemoveFileInfo mocks base method
-func (m *MockFmDAO) RemoveFileInfo(userID, fileID uint, groupName string) error {
+func (m *MockFmDAO) RemoveFileInfo(fileID uint, groupName string, event *models.AuditEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveFileInfo", userID, fileID, groupName)
+	ret := m.ctrl.Call(m, "RemoveFileInfo", fileID, groupName, event)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveFileInfo indicates an expected call of RemoveFileInfo
-func (mr *MockFmDAOMockRecorder) RemoveFileInfo(userID, fileID, groupName interface{}) *gomock.Call {
+func (mr *MockFmDAOMockRecorder) RemoveFileInfo(fileID, groupName, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFileInfo", reflect.TypeOf((*MockFmDAO)(nil).RemoveFileInfo), fileID, groupName, event)
+}
+
+// TrashFile mocks base method
+func (m *MockFmDAO) TrashFile(userID, fileID uint, groupName string, event *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashFile", userID, fileID, groupName, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashFile indicates an expected call of TrashFile
+func (mr *MockFmDAOMockRecorder) TrashFile(userID, fileID, groupName, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashFile", reflect.TypeOf((*MockFmDAO)(nil).TrashFile), userID, fileID, groupName, event)
+}
+
+// GetTrashedFiles mocks base method
+func (m *MockFmDAO) GetTrashedFiles(groupName string) ([]models.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrashedFiles", groupName)
+	ret0, _ := ret[0].([]models.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashedFiles indicates an expected call of GetTrashedFiles
+func (mr *MockFmDAOMockRecorder) GetTrashedFiles(groupName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashedFiles", reflect.TypeOf((*MockFmDAO)(nil).GetTrashedFiles), groupName)
+}
+
+// RestoreTrashedFile mocks base method
+func (m *MockFmDAO) RestoreTrashedFile(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTrashedFile", fileID, groupName, event)
+	ret0, _ := ret[0].(models.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTrashedFile indicates an expected call of RestoreTrashedFile
+func (mr *MockFmDAOMockRecorder) RestoreTrashedFile(fileID, groupName, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTrashedFile", reflect.TypeOf((*MockFmDAO)(nil).RestoreTrashedFile), fileID, groupName, event)
+}
+
+// PurgeTrashedFiles mocks base method
+func (m *MockFmDAO) PurgeTrashedFiles(before time.Time) ([]dao.PurgedFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrashedFiles", before)
+	ret0, _ := ret[0].([]dao.PurgedFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrashedFiles indicates an expected call of PurgeTrashedFiles
+func (mr *MockFmDAOMockRecorder) PurgeTrashedFiles(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrashedFiles", reflect.TypeOf((*MockFmDAO)(nil).PurgeTrashedFiles), before)
+}
+
+// GetUnreferencedBlobs mocks base method
+func (m *MockFmDAO) GetUnreferencedBlobs() ([]models.Blob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreferencedBlobs")
+	ret0, _ := ret[0].([]models.Blob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreferencedBlobs indicates an expected call of GetUnreferencedBlobs
+func (mr *MockFmDAOMockRecorder) GetUnreferencedBlobs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreferencedBlobs", reflect.TypeOf((*MockFmDAO)(nil).GetUnreferencedBlobs))
+}
+
+// EraseUnreferencedBlob mocks base method
+func (m *MockFmDAO) EraseUnreferencedBlob(blobID uint, eraseContent func(models.Blob) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EraseUnreferencedBlob", blobID, eraseContent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EraseUnreferencedBlob indicates an expected call of EraseUnreferencedBlob
+func (mr *MockFmDAOMockRecorder) EraseUnreferencedBlob(blobID, eraseContent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EraseUnreferencedBlob", reflect.TypeOf((*MockFmDAO)(nil).EraseUnreferencedBlob), blobID, eraseContent)
+}
+
+// GetFileVersions mocks base method
+func (m *MockFmDAO) GetFileVersions(userID, fileID uint, groupName string) ([]models.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileVersions", userID, fileID, groupName)
+	ret0, _ := ret[0].([]models.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileVersions indicates an expected call of GetFileVersions
+func (mr *MockFmDAOMockRecorder) GetFileVersions(userID, fileID, groupName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileVersions", reflect.TypeOf((*MockFmDAO)(nil).GetFileVersions), userID, fileID, groupName)
+}
+
+// RestoreFileVersion mocks base method
+func (m *MockFmDAO) RestoreFileVersion(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreFileVersion", fileID, groupName, event)
+	ret0, _ := ret[0].(models.FileInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreFileVersion indicates an expected call of RestoreFileVersion
+func (mr *MockFmDAOMockRecorder) RestoreFileVersion(fileID, groupName, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreFileVersion", reflect.TypeOf((*MockFmDAO)(nil).RestoreFileVersion), fileID, groupName, event)
+}
+
+// CreateUploadSession mocks base method
+func (m *MockFmDAO) CreateUploadSession(userID uint, uploadID, fileName string, size int64, groupName string, expiresAt time.Time) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUploadSession", userID, uploadID, fileName, size, groupName, expiresAt)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUploadSession indicates an expected call of CreateUploadSession
+func (mr *MockFmDAOMockRecorder) CreateUploadSession(userID, uploadID, fileName, size, groupName, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUploadSession", reflect.TypeOf((*MockFmDAO)(nil).CreateUploadSession), userID, uploadID, fileName, size, groupName, expiresAt)
+}
+
+// GetUploadSession mocks base method
+func (m *MockFmDAO) GetUploadSession(userID uint, uploadID, groupName string) (models.UploadSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUploadSession", userID, uploadID, groupName)
+	ret0, _ := ret[0].(models.UploadSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUploadSession indicates an expected call of GetUploadSession
+func (mr *MockFmDAOMockRecorder) GetUploadSession(userID, uploadID, groupName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFileInfo", reflect.TypeOf((*MockFmDAO)(nil).RemoveFileInfo), userID, fileID, groupName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUploadSession", reflect.TypeOf((*MockFmDAO)(nil).GetUploadSession), userID, uploadID, groupName)
 }
 
-// Migrate mocks base method
-func (m *MockFmDAO) Migrate() error {
+// AddUploadChunk mocks base method
+func (m *MockFmDAO) AddUploadChunk(sessionID, number uint, offset, size int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Migrate")
+	ret := m.ctrl.Call(m, "AddUploadChunk", sessionID, number, offset, size)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Migrate indicates an expected call of Migrate
-func (mr *MockFmDAOMockRecorder) Migrate() *gomock.Call {
+// AddUploadChunk indicates an expected call of AddUploadChunk
+func (mr *MockFmDAOMockRecorder) AddUploadChunk(sessionID, number, offset, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUploadChunk", reflect.TypeOf((*MockFmDAO)(nil).AddUploadChunk), sessionID, number, offset, size)
+}
+
+// GetUploadChunks mocks base method
+func (m *MockFmDAO) GetUploadChunks(sessionID uint) ([]models.UploadChunk, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUploadChunks", sessionID)
+	ret0, _ := ret[0].([]models.UploadChunk)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUploadChunks indicates an expected call of GetUploadChunks
+func (mr *MockFmDAOMockRecorder) GetUploadChunks(sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUploadChunks", reflect.TypeOf((*MockFmDAO)(nil).GetUploadChunks), sessionID)
+}
+
+// RemoveUploadSession mocks base method
+func (m *MockFmDAO) RemoveUploadSession(sessionID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUploadSession", sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveUploadSession indicates an expected call of RemoveUploadSession
+func (mr *MockFmDAOMockRecorder) RemoveUploadSession(sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUploadSession", reflect.TypeOf((*MockFmDAO)(nil).RemoveUploadSession), sessionID)
+}
+
+// GetExpiredUploadSessions mocks base method
+func (m *MockFmDAO) GetExpiredUploadSessions() ([]dao.ExpiredUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredUploadSessions")
+	ret0, _ := ret[0].([]dao.ExpiredUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredUploadSessions indicates an expected call of GetExpiredUploadSessions
+func (mr *MockFmDAOMockRecorder) GetExpiredUploadSessions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredUploadSessions", reflect.TypeOf((*MockFmDAO)(nil).GetExpiredUploadSessions))
+}
+
+// CreateShareLink mocks base method
+func (m *MockFmDAO) CreateShareLink(creatorID, fileID uint, expiresAt time.Time, maxUses uint, event *models.AuditEvent) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShareLink", creatorID, fileID, expiresAt, maxUses, event)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShareLink indicates an expected call of CreateShareLink
+func (mr *MockFmDAOMockRecorder) CreateShareLink(creatorID, fileID, expiresAt, maxUses, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShareLink", reflect.TypeOf((*MockFmDAO)(nil).CreateShareLink), creatorID, fileID, expiresAt, maxUses, event)
+}
+
+// GetShareLink mocks base method
+func (m *MockFmDAO) GetShareLink(linkID uint) (models.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetShareLink", linkID)
+	ret0, _ := ret[0].(models.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetShareLink indicates an expected call of GetShareLink
+func (mr *MockFmDAOMockRecorder) GetShareLink(linkID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetShareLink", reflect.TypeOf((*MockFmDAO)(nil).GetShareLink), linkID)
+}
+
+// GetActiveShareLinks mocks base method
+func (m *MockFmDAO) GetActiveShareLinks(fileID uint) ([]models.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveShareLinks", fileID)
+	ret0, _ := ret[0].([]models.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveShareLinks indicates an expected call of GetActiveShareLinks
+func (mr *MockFmDAOMockRecorder) GetActiveShareLinks(fileID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveShareLinks", reflect.TypeOf((*MockFmDAO)(nil).GetActiveShareLinks), fileID)
+}
+
+// RevokeShareLink mocks base method
+func (m *MockFmDAO) RevokeShareLink(linkID uint, event *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeShareLink", linkID, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeShareLink indicates an expected call of RevokeShareLink
+func (mr *MockFmDAOMockRecorder) RevokeShareLink(linkID, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeShareLink", reflect.TypeOf((*MockFmDAO)(nil).RevokeShareLink), linkID, event)
+}
+
+// GetSharedFile mocks base method
+func (m *MockFmDAO) GetSharedFile(linkID uint) (models.FileInfo, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSharedFile", linkID)
+	ret0, _ := ret[0].(models.FileInfo)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSharedFile indicates an expected call of GetSharedFile
+func (mr *MockFmDAOMockRecorder) GetSharedFile(linkID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSharedFile", reflect.TypeOf((*MockFmDAO)(nil).GetSharedFile), linkID)
+}
+
+// UseShareLink mocks base method
+func (m *MockFmDAO) UseShareLink(linkID uint, event *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseShareLink", linkID, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseShareLink indicates an expected call of UseShareLink
+func (mr *MockFmDAOMockRecorder) UseShareLink(linkID, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseShareLink", reflect.TypeOf((*MockFmDAO)(nil).UseShareLink), linkID, event)
+}
+
+// SetFileTags mocks base method
+func (m *MockFmDAO) SetFileTags(fileID uint, groupName string, tags []string, event *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFileTags", fileID, groupName, tags, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetFileTags indicates an expected call of SetFileTags
+func (mr *MockFmDAOMockRecorder) SetFileTags(fileID, groupName, tags, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFileTags", reflect.TypeOf((*MockFmDAO)(nil).SetFileTags), fileID, groupName, tags, event)
+}
+
+// SearchFiles mocks base method
+func (m *MockFmDAO) SearchFiles(userID uint, filter dao.FileSearchFilter, options dao.ListOptions) ([]dao.FileSearchResult, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchFiles", userID, filter, options)
+	ret0, _ := ret[0].([]dao.FileSearchResult)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchFiles indicates an expected call of SearchFiles
+func (mr *MockFmDAOMockRecorder) SearchFiles(userID, filter, options interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockFmDAO)(nil).Migrate))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchFiles", reflect.TypeOf((*MockFmDAO)(nil).SearchFiles), userID, filter, options)
 }
@@ -5,9 +5,11 @@
 package dao_mocks
 
 import (
+	dao "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
 	models "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockUamDAO is a mock of UamDAO interface
@@ -33,20 +35,6 @@ func (m *MockUamDAO) EXPECT() *MockUamDAOMockRecorder {
 	return m.recorder
 }
 
-// Migrate mocks base method
-func (m *MockUamDAO) Migrate() error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Migrate")
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Migrate indicates an expected call of Migrate
-func (mr *MockUamDAOMockRecorder) Migrate() *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Migrate", reflect.TypeOf((*MockUamDAO)(nil).Migrate))
-}
-
 // CreateUser mocks base method
 func (m *MockUamDAO) CreateUser(arg0, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -76,50 +64,527 @@ func (mr *MockUamDAOMockRecorder) GetUser(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUamDAO)(nil).GetUser), arg0)
 }
 
-// DeleteUser mocks base method
-func (m *MockUamDAO) DeleteUser(arg0 uint) error {
+// GetUserByID mocks base method
+func (m *MockUamDAO) GetUserByID(arg0 uint) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", arg0)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID
+func (mr *MockUamDAOMockRecorder) GetUserByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUamDAO)(nil).GetUserByID), arg0)
+}
+
+// DeactivateUser mocks base method
+func (m *MockUamDAO) DeactivateUser(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUser", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateUser indicates an expected call of DeactivateUser
+func (mr *MockUamDAOMockRecorder) DeactivateUser(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUser", reflect.TypeOf((*MockUamDAO)(nil).DeactivateUser), arg0)
+}
+
+// GetDeactivatedUserIDs mocks base method
+func (m *MockUamDAO) GetDeactivatedUserIDs(arg0 time.Time) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeactivatedUserIDs", arg0)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeactivatedUserIDs indicates an expected call of GetDeactivatedUserIDs
+func (mr *MockUamDAOMockRecorder) GetDeactivatedUserIDs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeactivatedUserIDs", reflect.TypeOf((*MockUamDAO)(nil).GetDeactivatedUserIDs), arg0)
+}
+
+// PurgeDeactivatedUsers mocks base method
+func (m *MockUamDAO) PurgeDeactivatedUsers(arg0 []uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeactivatedUsers", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeDeactivatedUsers indicates an expected call of PurgeDeactivatedUsers
+func (mr *MockUamDAOMockRecorder) PurgeDeactivatedUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeactivatedUsers", reflect.TypeOf((*MockUamDAO)(nil).PurgeDeactivatedUsers), arg0)
+}
+
+// ChangePassword mocks base method
+func (m *MockUamDAO) ChangePassword(arg0 uint, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword
+func (mr *MockUamDAOMockRecorder) ChangePassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUamDAO)(nil).ChangePassword), arg0, arg1)
+}
+
+// CreatePasswordReset mocks base method
+func (m *MockUamDAO) CreatePasswordReset(arg0 uint, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset
+func (mr *MockUamDAOMockRecorder) CreatePasswordReset(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockUamDAO)(nil).CreatePasswordReset), arg0, arg1, arg2)
+}
+
+// ResetPassword mocks base method
+func (m *MockUamDAO) ResetPassword(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword
+func (mr *MockUamDAOMockRecorder) ResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUamDAO)(nil).ResetPassword), arg0, arg1)
+}
+
+// CreateTwoFactor mocks base method
+func (m *MockUamDAO) CreateTwoFactor(arg0 uint, arg1 string, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTwoFactor indicates an expected call of CreateTwoFactor
+func (mr *MockUamDAOMockRecorder) CreateTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTwoFactor", reflect.TypeOf((*MockUamDAO)(nil).CreateTwoFactor), arg0, arg1, arg2)
+}
+
+// GetTwoFactor mocks base method
+func (m *MockUamDAO) GetTwoFactor(arg0 uint) (models.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", arg0)
+	ret0, _ := ret[0].(models.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor
+func (mr *MockUamDAOMockRecorder) GetTwoFactor(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockUamDAO)(nil).GetTwoFactor), arg0)
+}
+
+// EnableTwoFactor mocks base method
+func (m *MockUamDAO) EnableTwoFactor(arg0 uint, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor
+func (mr *MockUamDAOMockRecorder) EnableTwoFactor(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockUamDAO)(nil).EnableTwoFactor), arg0, arg1)
+}
+
+// UseTwoFactorStep mocks base method
+func (m *MockUamDAO) UseTwoFactorStep(arg0 uint, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTwoFactorStep", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTwoFactorStep indicates an expected call of UseTwoFactorStep
+func (mr *MockUamDAOMockRecorder) UseTwoFactorStep(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTwoFactorStep", reflect.TypeOf((*MockUamDAO)(nil).UseTwoFactorStep), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method
+func (m *MockUamDAO) UseRecoveryCode(arg0 uint, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode
+func (mr *MockUamDAOMockRecorder) UseRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUamDAO)(nil).UseRecoveryCode), arg0, arg1)
+}
+
+// DeleteTwoFactor mocks base method
+func (m *MockUamDAO) DeleteTwoFactor(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTwoFactor", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTwoFactor indicates an expected call of DeleteTwoFactor
+func (mr *MockUamDAOMockRecorder) DeleteTwoFactor(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTwoFactor", reflect.TypeOf((*MockUamDAO)(nil).DeleteTwoFactor), arg0)
+}
+
+// CreateLoginChallenge mocks base method
+func (m *MockUamDAO) CreateLoginChallenge(arg0 uint, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginChallenge", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginChallenge indicates an expected call of CreateLoginChallenge
+func (mr *MockUamDAOMockRecorder) CreateLoginChallenge(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginChallenge", reflect.TypeOf((*MockUamDAO)(nil).CreateLoginChallenge), arg0, arg1, arg2)
+}
+
+// UseLoginChallenge mocks base method
+func (m *MockUamDAO) UseLoginChallenge(arg0 string) (models.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseLoginChallenge", arg0)
+	ret0, _ := ret[0].(models.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseLoginChallenge indicates an expected call of UseLoginChallenge
+func (mr *MockUamDAOMockRecorder) UseLoginChallenge(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseLoginChallenge", reflect.TypeOf((*MockUamDAO)(nil).UseLoginChallenge), arg0)
+}
+
+// CreateExternalLogin mocks base method
+func (m *MockUamDAO) CreateExternalLogin(arg0 models.ExternalLogin) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExternalLogin", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateExternalLogin indicates an expected call of CreateExternalLogin
+func (mr *MockUamDAOMockRecorder) CreateExternalLogin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExternalLogin", reflect.TypeOf((*MockUamDAO)(nil).CreateExternalLogin), arg0)
+}
+
+// UseExternalLogin mocks base method
+func (m *MockUamDAO) UseExternalLogin(arg0 string) (models.ExternalLogin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseExternalLogin", arg0)
+	ret0, _ := ret[0].(models.ExternalLogin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseExternalLogin indicates an expected call of UseExternalLogin
+func (mr *MockUamDAOMockRecorder) UseExternalLogin(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseExternalLogin", reflect.TypeOf((*MockUamDAO)(nil).UseExternalLogin), arg0)
+}
+
+// GetOrCreateExternalUser mocks base method
+func (m *MockUamDAO) GetOrCreateExternalUser(arg0, arg1, arg2 string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateExternalUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateExternalUser indicates an expected call of GetOrCreateExternalUser
+func (mr *MockUamDAOMockRecorder) GetOrCreateExternalUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateExternalUser", reflect.TypeOf((*MockUamDAO)(nil).GetOrCreateExternalUser), arg0, arg1, arg2)
+}
+
+// CreateSession mocks base method
+func (m *MockUamDAO) CreateSession(arg0 uint, arg1 string, arg2 time.Time) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession
+func (mr *MockUamDAOMockRecorder) CreateSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockUamDAO)(nil).CreateSession), arg0, arg1, arg2)
+}
+
+// RotateSession mocks base method
+func (m *MockUamDAO) RotateSession(arg0, arg1 string, arg2 time.Time) (models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateSession indicates an expected call of RotateSession
+func (mr *MockUamDAOMockRecorder) RotateSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSession", reflect.TypeOf((*MockUamDAO)(nil).RotateSession), arg0, arg1, arg2)
+}
+
+// RevokeSession mocks base method
+func (m *MockUamDAO) RevokeSession(arg0 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession
+func (mr *MockUamDAOMockRecorder) RevokeSession(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockUamDAO)(nil).RevokeSession), arg0)
+}
+
+// IsSessionRevoked mocks base method
+func (m *MockUamDAO) IsSessionRevoked(arg0 uint) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSessionRevoked", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSessionRevoked indicates an expected call of IsSessionRevoked
+func (mr *MockUamDAOMockRecorder) IsSessionRevoked(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionRevoked", reflect.TypeOf((*MockUamDAO)(nil).IsSessionRevoked), arg0)
+}
+
+// CreateAccessToken mocks base method
+func (m *MockUamDAO) CreateAccessToken(arg0 models.AccessToken) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccessToken", arg0)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccessToken indicates an expected call of CreateAccessToken
+func (mr *MockUamDAOMockRecorder) CreateAccessToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccessToken", reflect.TypeOf((*MockUamDAO)(nil).CreateAccessToken), arg0)
+}
+
+// UseAccessToken mocks base method
+func (m *MockUamDAO) UseAccessToken(arg0 string) (models.AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseAccessToken", arg0)
+	ret0, _ := ret[0].(models.AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseAccessToken indicates an expected call of UseAccessToken
+func (mr *MockUamDAOMockRecorder) UseAccessToken(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseAccessToken", reflect.TypeOf((*MockUamDAO)(nil).UseAccessToken), arg0)
+}
+
+// GetAccessTokens mocks base method
+func (m *MockUamDAO) GetAccessTokens(arg0 uint) ([]dao.AccessTokenDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccessTokens", arg0)
+	ret0, _ := ret[0].([]dao.AccessTokenDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccessTokens indicates an expected call of GetAccessTokens
+func (mr *MockUamDAOMockRecorder) GetAccessTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccessTokens", reflect.TypeOf((*MockUamDAO)(nil).GetAccessTokens), arg0)
+}
+
+// DeleteAccessToken mocks base method
+func (m *MockUamDAO) DeleteAccessToken(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccessToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccessToken indicates an expected call of DeleteAccessToken
+func (mr *MockUamDAOMockRecorder) DeleteAccessToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccessToken", reflect.TypeOf((*MockUamDAO)(nil).DeleteAccessToken), arg0, arg1)
+}
+
+// GetLoginLockout mocks base method
+func (m *MockUamDAO) GetLoginLockout(arg0 []string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLockout", arg0)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLockout indicates an expected call of GetLoginLockout
+func (mr *MockUamDAOMockRecorder) GetLoginLockout(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockout", reflect.TypeOf((*MockUamDAO)(nil).GetLoginLockout), arg0)
+}
+
+// RecordLoginFailure mocks base method
+func (m *MockUamDAO) RecordLoginFailure(arg0 string, arg1 time.Time) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure
+func (mr *MockUamDAOMockRecorder) RecordLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockUamDAO)(nil).RecordLoginFailure), arg0, arg1)
+}
+
+// LockLogin mocks base method
+func (m *MockUamDAO) LockLogin(arg0 string, arg1 time.Time, arg2 *models.AuditEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", arg0)
+	ret := m.ctrl.Call(m, "LockLogin", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteUser indicates an expected call of DeleteUser
-func (mr *MockUamDAOMockRecorder) DeleteUser(arg0 interface{}) *gomock.Call {
+// LockLogin indicates an expected call of LockLogin
+func (mr *MockUamDAOMockRecorder) LockLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUamDAO)(nil).DeleteUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockUamDAO)(nil).LockLogin), arg0, arg1, arg2)
+}
+
+// ResetLoginFailures mocks base method
+func (m *MockUamDAO) ResetLoginFailures(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures
+func (mr *MockUamDAOMockRecorder) ResetLoginFailures(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockUamDAO)(nil).ResetLoginFailures), arg0)
 }
 
 // CreateGroup mocks base method
-func (m *MockUamDAO) CreateGroup(arg0 uint, arg1 string) error {
+func (m *MockUamDAO) CreateGroup(arg0 uint, arg1 string, arg2 *models.AuditEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateGroup", arg0, arg1)
+	ret := m.ctrl.Call(m, "CreateGroup", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateGroup indicates an expected call of CreateGroup
-func (mr *MockUamDAOMockRecorder) CreateGroup(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockUamDAOMockRecorder) CreateGroup(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockUamDAO)(nil).CreateGroup), arg0, arg1, arg2)
+}
+
+// CreateInvitation mocks base method
+func (m *MockUamDAO) CreateInvitation(arg0 uint, arg1, arg2 string, arg3 *time.Time, arg4 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvitation", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateInvitation indicates an expected call of CreateInvitation
+func (mr *MockUamDAOMockRecorder) CreateInvitation(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvitation", reflect.TypeOf((*MockUamDAO)(nil).CreateInvitation), arg0, arg1, arg2, arg3, arg4)
+}
+
+// GetInvitations mocks base method
+func (m *MockUamDAO) GetInvitations(arg0 uint) ([]dao.InvitationDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitations", arg0)
+	ret0, _ := ret[0].([]dao.InvitationDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitations indicates an expected call of GetInvitations
+func (mr *MockUamDAOMockRecorder) GetInvitations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitations", reflect.TypeOf((*MockUamDAO)(nil).GetInvitations), arg0)
+}
+
+// AcceptInvitation mocks base method
+func (m *MockUamDAO) AcceptInvitation(arg0 uint, arg1 string, arg2 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation
+func (mr *MockUamDAOMockRecorder) AcceptInvitation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockUamDAO)(nil).AcceptInvitation), arg0, arg1, arg2)
+}
+
+// DeclineInvitation mocks base method
+func (m *MockUamDAO) DeclineInvitation(arg0 uint, arg1 string, arg2 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineInvitation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeclineInvitation indicates an expected call of DeclineInvitation
+func (mr *MockUamDAOMockRecorder) DeclineInvitation(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroup", reflect.TypeOf((*MockUamDAO)(nil).CreateGroup), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineInvitation", reflect.TypeOf((*MockUamDAO)(nil).DeclineInvitation), arg0, arg1, arg2)
 }
 
-// AddUserToGroup mocks base method
-func (m *MockUamDAO) AddUserToGroup(arg0 uint, arg1, arg2 string) error {
+// RevokeInvitation mocks base method
+func (m *MockUamDAO) RevokeInvitation(arg0, arg1 string, arg2 *models.AuditEvent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUserToGroup", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "RevokeInvitation", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AddUserToGroup indicates an expected call of AddUserToGroup
-func (mr *MockUamDAOMockRecorder) AddUserToGroup(arg0, arg1, arg2 interface{}) *gomock.Call {
+// RevokeInvitation indicates an expected call of RevokeInvitation
+func (mr *MockUamDAOMockRecorder) RevokeInvitation(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUserToGroup", reflect.TypeOf((*MockUamDAO)(nil).AddUserToGroup), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvitation", reflect.TypeOf((*MockUamDAO)(nil).RevokeInvitation), arg0, arg1, arg2)
 }
 
 // RemoveUserFromGroup mocks base method
-func (m *MockUamDAO) RemoveUserFromGroup(arg0 uint, arg1, arg2 string) error {
+func (m *MockUamDAO) RemoveUserFromGroup(arg0, arg1 string, arg2 *models.AuditEvent) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "RemoveUserFromGroup", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -147,8 +612,51 @@ func (mr *MockUamDAOMockRecorder) MemberExists(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemberExists", reflect.TypeOf((*MockUamDAO)(nil).MemberExists), arg0, arg1)
 }
 
+// GetMembership mocks base method
+func (m *MockUamDAO) GetMembership(arg0, arg1 uint) (models.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembership", arg0, arg1)
+	ret0, _ := ret[0].(models.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembership indicates an expected call of GetMembership
+func (mr *MockUamDAOMockRecorder) GetMembership(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembership", reflect.TypeOf((*MockUamDAO)(nil).GetMembership), arg0, arg1)
+}
+
+// UpdateMemberRole mocks base method
+func (m *MockUamDAO) UpdateMemberRole(arg0, arg1, arg2 string, arg3 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMemberRole", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMemberRole indicates an expected call of UpdateMemberRole
+func (mr *MockUamDAOMockRecorder) UpdateMemberRole(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMemberRole", reflect.TypeOf((*MockUamDAO)(nil).UpdateMemberRole), arg0, arg1, arg2, arg3)
+}
+
+// TransferGroupOwnership mocks base method
+func (m *MockUamDAO) TransferGroupOwnership(arg0, arg1 string, arg2 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferGroupOwnership", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferGroupOwnership indicates an expected call of TransferGroupOwnership
+func (mr *MockUamDAOMockRecorder) TransferGroupOwnership(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferGroupOwnership", reflect.TypeOf((*MockUamDAO)(nil).TransferGroupOwnership), arg0, arg1, arg2)
+}
+
 // DeactivateGroup mocks base method
-func (m *MockUamDAO) DeactivateGroup(arg0 uint, arg1 string) error {
+func (m *MockUamDAO) DeactivateGroup(arg0 string, arg1 *models.AuditEvent) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeactivateGroup", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -176,19 +684,48 @@ func (mr *MockUamDAOMockRecorder) GetGroup(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockUamDAO)(nil).GetGroup), arg0)
 }
 
+// GetDeactivatedGroups mocks base method
+func (m *MockUamDAO) GetDeactivatedGroups(arg0 uint) ([]models.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeactivatedGroups", arg0)
+	ret0, _ := ret[0].([]models.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeactivatedGroups indicates an expected call of GetDeactivatedGroups
+func (mr *MockUamDAOMockRecorder) GetDeactivatedGroups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeactivatedGroups", reflect.TypeOf((*MockUamDAO)(nil).GetDeactivatedGroups), arg0)
+}
+
+// RestoreGroup mocks base method
+func (m *MockUamDAO) RestoreGroup(arg0 uint, arg1 string, arg2 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreGroup", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreGroup indicates an expected call of RestoreGroup
+func (mr *MockUamDAOMockRecorder) RestoreGroup(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreGroup", reflect.TypeOf((*MockUamDAO)(nil).RestoreGroup), arg0, arg1, arg2)
+}
+
 // GetDeactivatedGroupNames mocks base method
-func (m *MockUamDAO) GetDeactivatedGroupNames() ([]string, error) {
+func (m *MockUamDAO) GetDeactivatedGroupNames(arg0 time.Time) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDeactivatedGroupNames")
+	ret := m.ctrl.Call(m, "GetDeactivatedGroupNames", arg0)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDeactivatedGroupNames indicates an expected call of GetDeactivatedGroupNames
-func (mr *MockUamDAOMockRecorder) GetDeactivatedGroupNames() *gomock.Call {
+func (mr *MockUamDAOMockRecorder) GetDeactivatedGroupNames(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeactivatedGroupNames", reflect.TypeOf((*MockUamDAO)(nil).GetDeactivatedGroupNames))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeactivatedGroupNames", reflect.TypeOf((*MockUamDAO)(nil).GetDeactivatedGroupNames), arg0)
 }
 
 // EraseDeactivatedGroups mocks base method
@@ -206,46 +743,121 @@ func (mr *MockUamDAOMockRecorder) EraseDeactivatedGroups(arg0 interface{}) *gomo
 }
 
 // GetAllGroups mocks base method
-func (m *MockUamDAO) GetAllGroups() ([]models.Group, error) {
+func (m *MockUamDAO) GetAllGroups(arg0 dao.ListOptions) ([]models.Group, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllGroups")
+	ret := m.ctrl.Call(m, "GetAllGroups", arg0)
 	ret0, _ := ret[0].([]models.Group)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAllGroups indicates an expected call of GetAllGroups
-func (mr *MockUamDAOMockRecorder) GetAllGroups() *gomock.Call {
+func (mr *MockUamDAOMockRecorder) GetAllGroups(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGroups", reflect.TypeOf((*MockUamDAO)(nil).GetAllGroups))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGroups", reflect.TypeOf((*MockUamDAO)(nil).GetAllGroups), arg0)
 }
 
 // GetAllUsers mocks base method
-func (m *MockUamDAO) GetAllUsers() ([]models.User, error) {
+func (m *MockUamDAO) GetAllUsers(arg0 dao.ListOptions) ([]models.User, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUsers")
+	ret := m.ctrl.Call(m, "GetAllUsers", arg0)
 	ret0, _ := ret[0].([]models.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAllUsers indicates an expected call of GetAllUsers
-func (mr *MockUamDAOMockRecorder) GetAllUsers() *gomock.Call {
+func (mr *MockUamDAOMockRecorder) GetAllUsers(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*MockUamDAO)(nil).GetAllUsers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*MockUamDAO)(nil).GetAllUsers), arg0)
 }
 
 // GetAllUsersInGroup mocks base method
-func (m *MockUamDAO) GetAllUsersInGroup(arg0 uint, arg1 string) ([]models.User, error) {
+func (m *MockUamDAO) GetAllUsersInGroup(arg0 uint, arg1 string, arg2 dao.ListOptions) ([]models.User, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUsersInGroup", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetAllUsersInGroup", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllUsersInGroup indicates an expected call of GetAllUsersInGroup
+func (mr *MockUamDAOMockRecorder) GetAllUsersInGroup(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsersInGroup", reflect.TypeOf((*MockUamDAO)(nil).GetAllUsersInGroup), arg0, arg1, arg2)
+}
+
+// GetGroupUsage mocks base method
+func (m *MockUamDAO) GetGroupUsage(arg0 uint) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupUsage", arg0)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllUsersInGroup indicates an expected call of GetAllUsersInGroup
-func (mr *MockUamDAOMockRecorder) GetAllUsersInGroup(arg0, arg1 interface{}) *gomock.Call {
+// GetGroupUsage indicates an expected call of GetGroupUsage
+func (mr *MockUamDAOMockRecorder) GetGroupUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupUsage", reflect.TypeOf((*MockUamDAO)(nil).GetGroupUsage), arg0)
+}
+
+// UpdateGroupLimits mocks base method
+func (m *MockUamDAO) UpdateGroupLimits(arg0 string, arg1, arg2 int64, arg3 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroupLimits", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroupLimits indicates an expected call of UpdateGroupLimits
+func (mr *MockUamDAOMockRecorder) UpdateGroupLimits(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroupLimits", reflect.TypeOf((*MockUamDAO)(nil).UpdateGroupLimits), arg0, arg1, arg2, arg3)
+}
+
+// UpdateGroupTwoFactor mocks base method
+func (m *MockUamDAO) UpdateGroupTwoFactor(arg0 string, arg1 bool, arg2 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroupTwoFactor", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroupTwoFactor indicates an expected call of UpdateGroupTwoFactor
+func (mr *MockUamDAOMockRecorder) UpdateGroupTwoFactor(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroupTwoFactor", reflect.TypeOf((*MockUamDAO)(nil).UpdateGroupTwoFactor), arg0, arg1, arg2)
+}
+
+// CreateAuditEvent mocks base method
+func (m *MockUamDAO) CreateAuditEvent(arg0 *models.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent
+func (mr *MockUamDAOMockRecorder) CreateAuditEvent(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockUamDAO)(nil).CreateAuditEvent), arg0)
+}
+
+// GetAuditEvents mocks base method
+func (m *MockUamDAO) GetAuditEvents(arg0 uint, arg1 dao.AuditFilter, arg2, arg3 int) ([]dao.AuditEventDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]dao.AuditEventDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEvents indicates an expected call of GetAuditEvents
+func (mr *MockUamDAOMockRecorder) GetAuditEvents(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsersInGroup", reflect.TypeOf((*MockUamDAO)(nil).GetAllUsersInGroup), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEvents", reflect.TypeOf((*MockUamDAO)(nil).GetAuditEvents), arg0, arg1, arg2, arg3)
 }
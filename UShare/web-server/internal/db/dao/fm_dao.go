@@ -3,6 +3,8 @@ package dao
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
@@ -13,11 +15,103 @@ import (
 
 //FmDAO - interface, used for file management
 type FmDAO interface {
-	AddFileInfo(userID uint, fileName string, groupName string) (uint, error)
+	AddFileInfo(userID uint, fileName string, checksum string, size int64, groupName string, event *models.AuditEvent) (uint, bool, error)
+	AddUploadedFileInfo(userID uint, session models.UploadSession, checksum string, groupName string, event *models.AuditEvent) (uint, bool, error)
 	GetFileInfo(userID uint, fileID uint, groupName string) (models.FileInfo, error)
-	GetAllFilesInfo(userID uint, groupName string) ([]models.FileInfo, error)
-	RemoveFileInfo(userID uint, fileID uint, groupName string) error
-	Migrate() error
+	GetAllFilesInfo(userID uint, groupName string, options ListOptions) ([]models.FileInfo, string, error)
+	RemoveFileInfo(fileID uint, groupName string, event *models.AuditEvent) error
+	TrashFile(userID uint, fileID uint, groupName string, event *models.AuditEvent) error
+	GetTrashedFiles(groupName string) ([]models.FileInfo, error)
+	RestoreTrashedFile(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error)
+	PurgeTrashedFiles(before time.Time) ([]PurgedFile, error)
+	GetUnreferencedBlobs() ([]models.Blob, error)
+	EraseUnreferencedBlob(blobID uint, eraseContent func(blob models.Blob) error) error
+	GetFileVersions(userID uint, fileID uint, groupName string) ([]models.FileInfo, error)
+	RestoreFileVersion(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error)
+	CreateUploadSession(userID uint, uploadID string, fileName string, size int64, groupName string, expiresAt time.Time) (uint, error)
+	GetUploadSession(userID uint, uploadID string, groupName string) (models.UploadSession, error)
+	AddUploadChunk(sessionID uint, number uint, offset int64, size int64) error
+	GetUploadChunks(sessionID uint) ([]models.UploadChunk, error)
+	RemoveUploadSession(sessionID uint) error
+	GetExpiredUploadSessions() ([]ExpiredUpload, error)
+	CreateShareLink(creatorID uint, fileID uint, expiresAt time.Time, maxUses uint, event *models.AuditEvent) (uint, error)
+	GetShareLink(linkID uint) (models.ShareLink, error)
+	GetActiveShareLinks(fileID uint) ([]models.ShareLink, error)
+	RevokeShareLink(linkID uint, event *models.AuditEvent) error
+	GetSharedFile(linkID uint) (models.FileInfo, string, error)
+	UseShareLink(linkID uint, event *models.AuditEvent) error
+	SetFileTags(fileID uint, groupName string, tags []string, event *models.AuditEvent) error
+	SearchFiles(userID uint, filter FileSearchFilter, options ListOptions) ([]FileSearchResult, string, error)
+}
+
+//maxVersionAttempts - how many times a new file version is numbered, before the concurrent changes of the file are reported
+const maxVersionAttempts = 3
+
+//errVersionTaken - the number of a new file version was taken by a concurrent transaction
+var errVersionTaken = errors.New("the file version is already taken")
+
+//errBlobTaken - the blob of a new file version was created by a concurrent transaction
+var errBlobTaken = errors.New("the blob is already created")
+
+const (
+	//FileSortName - sorting of the found files by their name
+	FileSortName = "name"
+	//FileSortSize - sorting of the found files by their size
+	FileSortSize = "size"
+	//FileSortUploadedAt - sorting of the found files by the time of the upload of their latest version
+	FileSortUploadedAt = "uploaded_at"
+	//FileSortUploader - sorting of the found files by the username of the uploader of their latest version
+	FileSortUploader = "uploader"
+	//FileSortGroup - sorting of the found files by the name of their group
+	FileSortGroup = "group"
+)
+
+//FileSearchFilter - conditions, which the found files should satisfy, the empty ones are ignored
+//every tag should match, the name is matched by the query of the listing options
+type FileSearchFilter struct {
+	Group    string
+	Uploader string
+	Since    *time.Time
+	Until    *time.Time
+	MinSize  *int64
+	MaxSize  *int64
+	Tags     []string
+}
+
+//FileSearchResult - latest version of a found file together with the name of its group, the username of its uploader and its tags
+//the uploader is missing, if the user was deleted
+type FileSearchResult struct {
+	ID        uint
+	Name      string
+	GroupID   uint
+	GroupName string
+	OwnerID   uint
+	Uploader  *string
+	CreatedAt time.Time
+	Version   uint
+	Size      int64
+	ETag      string
+	Tags      []string `gorm:"-"`
+}
+
+//PurgedFile - file, erased from the trash, together with the name of its group
+type PurgedFile struct {
+	ID        uint
+	GroupName string
+	BlobID    uint
+}
+
+//ExpiredUpload - upload session, which has expired, together with the name of its group
+type ExpiredUpload struct {
+	ID        uint
+	UploadID  string
+	GroupName string
+}
+
+//IsFileSortField - checks if the found files can be sorted by the field
+func IsFileSortField(field string) bool {
+	_, ok := fileSearchListing.fields[field]
+	return ok
 }
 
 //FmDAOImpl - implementation of FmDAO
@@ -32,22 +126,34 @@ func NewFmDAOImpl(dbConn *gorm.DB) *FmDAOImpl {
 	}
 }
 
-//Migrate - updates the models in the db
-func (i *FmDAOImpl) Migrate() error {
-	return i.dbConn.AutoMigrate(models.FileInfo{})
+//AddFileInfo - saves metadate for a newly added file (just like in linux with inodes)
+//the file references the blob with the given sha256 checksum, which is created if it doesnt exist yet
+//the audit event, if given, is stored in the same transaction, just like for the other changes of the files
+//returns the id of the file and if the blob was created, in which case its content has to be stored
+func (i *FmDAOImpl) AddFileInfo(userID uint, fileName string, checksum string, size int64, groupName string, event *models.AuditEvent) (uint, bool, error) {
+	return i.addFileInfo(userID, fileName, checksum, size, groupName, 0, event)
+}
+
+//AddUploadedFileInfo - saves metadata for the file of a completed chunked upload, just like AddFileInfo
+//the space, reserved by the upload session, isnt counted again in the quota of the group
+func (i *FmDAOImpl) AddUploadedFileInfo(userID uint, session models.UploadSession, checksum string, groupName string, event *models.AuditEvent) (uint, bool, error) {
+	return i.addFileInfo(userID, session.FileName, checksum, session.Size, groupName, session.ID, event)
 }
 
-//AddFileInfo - saves metadate for a newly added file (just like in linux with inodes)
-func (i *FmDAOImpl) AddFileInfo(userID uint, fileName string, groupName string) (uint, error) {
+//addFileInfo - saves metadata for a new file, the reservation of the upload session with the given id (0 if none) is left out of the quota
+func (i *FmDAOImpl) addFileInfo(userID uint, fileName string, checksum string, size int64, groupName string, uploadSessionID uint, event *models.AuditEvent) (uint, bool, error) {
 	var (
-		fileID uint
-		err    error
+		fileID  uint
+		created bool
+		err     error
 	)
-	err = i.dbConn.Transaction(func(tx *gorm.DB) error {
+	err = i.versionTransaction(func(tx *gorm.DB) error {
 
 		group, err := getGroupWithConn(tx, groupName)
 		if err != nil {
 			return err
+		} else if !group.Active {
+			return myerr.NewClientError("The group is currently being deleted")
 		}
 
 		var count int64
@@ -62,23 +168,47 @@ func (i *FmDAOImpl) AddFileInfo(userID uint, fileName string, groupName string)
 			return myerr.NewClientError("Cannot upload a file in a group you aren't part of")
 		}
 
+		if err = checkGroupLimitsWithConn(tx, group, size, uploadSessionID); err != nil {
+			return err
+		}
+
+		version, err := getLatestVersionWithConn(tx, group.ID, fileName)
+		if err != nil {
+			return err
+		}
+
+		var blob models.Blob
+		if blob, created, err = acquireBlobWithConn(tx, checksum, size); err != nil {
+			return err
+		}
+
 		fileInfo := models.FileInfo{
 			Name:    fileName,
 			OwnerID: userID,
 			GroupID: group.ID,
+			ETag:    checksum,
+			Version: version + 1,
+			BlobID:  blob.ID,
+			Size:    size,
 		}
 
-		if result = tx.Create(&fileInfo); result.Error != nil {
+		if result = tx.Create(&fileInfo); isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError(fmt.Sprintf("Group [%s] no longer exists", groupName))
+		} else if isUniqueViolation(result.Error) {
+			return errVersionTaken
+		} else if result.Error != nil {
 			return myerr.NewServerError(fmt.Sprintf("Cannot save file info in the db for group [%s]", groupName))
 		}
 		fileID = fileInfo.ID
-		return nil
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
 	})
-	return fileID, err
+	return fileID, created, err
 }
 
-//RemoveFileInfo - removes the file matadata from the db
-func (i *FmDAOImpl) RemoveFileInfo(userID uint, fileID uint, groupName string) error {
+//RemoveFileInfo - removes the file matadata and its share links from the db, without moving the file to the trash
+//the tags of the file are removed together with its last version
+//the blob of the file is kept without references, until its content is erased
+func (i *FmDAOImpl) RemoveFileInfo(fileID uint, groupName string, event *models.AuditEvent) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
 		group, err := getGroupWithConn(tx, groupName)
 		if err != nil {
@@ -88,10 +218,12 @@ func (i *FmDAOImpl) RemoveFileInfo(userID uint, fileID uint, groupName string) e
 		fileInfo, err := getFileInfoWithConn(tx, fileID)
 		if err != nil {
 			return err
+		} else if fileInfo.GroupID != group.ID {
+			return myerr.NewItemNotFoundError("File does not exist")
 		}
 
-		if group.OwnerID != userID && fileInfo.OwnerID != userID {
-			return myerr.NewClientError("Only the onwer of the file or the group owner can remove files from the group")
+		if result := tx.Where("file_id = ?", fileInfo.ID).Delete(&models.ShareLink{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the share links of the file")
 		}
 
 		if result := tx.Delete(&fileInfo); result.Error != nil {
@@ -99,8 +231,23 @@ func (i *FmDAOImpl) RemoveFileInfo(userID uint, fileID uint, groupName string) e
 		} else if result.RowsAffected == 0 {
 			return myerr.NewClientError("File info not found")
 		}
-		fileID = fileInfo.ID
-		return nil
+
+		version, err := getLatestVersionWithConn(tx, group.ID, fileInfo.Name)
+		if err != nil {
+			return err
+		} else if version == 0 {
+			result := tx.Where("group_id = ?", group.ID).
+				Where("file_name = ?", fileInfo.Name).
+				Delete(&models.FileTag{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the tags of the file")
+			}
+		}
+
+		if err = releaseBlobsWithConn(tx, []uint{fileInfo.BlobID}); err != nil {
+			return err
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
 	})
 }
 
@@ -127,8 +274,10 @@ func (i *FmDAOImpl) GetFileInfo(userID uint, fileID uint, groupName string) (mod
 
 }
 
-//GetAllFilesInfo - returns information about all files, given a praticular group
-func (i *FmDAOImpl) GetAllFilesInfo(userID uint, groupName string) ([]models.FileInfo, error) {
+//GetAllFilesInfo - returns a page of the latest versions of all files, given a praticular group
+//the query is matched against the file names
+//returns the files and the cursor of the next page, which is empty on the last page
+func (i *FmDAOImpl) GetAllFilesInfo(userID uint, groupName string, options ListOptions) ([]models.FileInfo, string, error) {
 	var count int64
 	result := i.dbConn.Table("memberships").Joins("inner join groups on memberships.group_id = groups.id").
 		Where("groups.name = ?", groupName).
@@ -136,27 +285,859 @@ func (i *FmDAOImpl) GetAllFilesInfo(userID uint, groupName string) ([]models.Fil
 		Count(&count)
 
 	if result.Error != nil {
-		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with checking if user is a member of the group.")
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with checking if user is a member of the group.")
 	} else if count == 0 {
-		return nil, myerr.NewClientError("You arent a member of the group.")
+		return nil, "", myerr.NewClientError("You arent a member of the group.")
+	}
+
+	query := i.dbConn.Table("file_infos").Joins("inner join groups on file_infos.group_id = groups.id").
+		Where("groups.name = ?", groupName).
+		Where("file_infos.trashed_at IS NULL").
+		Where("file_infos.version = (?)", i.dbConn.Table("file_infos AS versions").
+			Select("max(versions.version)").
+			Where("versions.group_id = file_infos.group_id").
+			Where("versions.name = file_infos.name").
+			Where("versions.trashed_at IS NULL")).
+		Select("file_infos.*")
+	if options.Query != "" {
+		query = query.Where("lower(file_infos.name) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(strings.ToLower(options.Query))+"%")
+	}
+
+	query, sortBy, err := fileListing.paginate(query, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fileInfos := make([]models.FileInfo, 0)
+	if result = query.Find(&fileInfos); result.Error != nil {
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with fetching all files from a specific group")
 	}
 
+	if len(fileInfos) <= options.Limit {
+		return fileInfos, "", nil
+	}
+
+	fileInfos = fileInfos[:options.Limit]
+	last := fileInfos[len(fileInfos)-1]
+	keys := map[string]interface{}{"name": last.Name, "size": last.Size, "uploaded_at": last.CreatedAt, "id": last.ID}
+	return fileInfos, nextCursor(options, sortBy, keys[sortBy], last.ID), nil
+}
+
+//GetFileVersions - returns all versions of a file, starting from the latest one
+func (i *FmDAOImpl) GetFileVersions(userID uint, fileID uint, groupName string) ([]models.FileInfo, error) {
 	var fileInfos []models.FileInfo
-	result = i.dbConn.Table("file_infos").Joins("inner join groups on file_infos.group_id = groups.id").
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		result := tx.Table("memberships").
+			Where("user_id = ?", userID).
+			Where("group_id = ?", group.ID).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with checking if user is a member of the group.")
+		} else if count == 0 {
+			return myerr.NewClientError("You arent a member of the group.")
+		}
+
+		fileInfo, err := getFileInfoWithConn(tx, fileID)
+		if err != nil {
+			return err
+		} else if fileInfo.GroupID != group.ID {
+			return myerr.NewItemNotFoundError("File does not exist")
+		}
+
+		result = tx.Where("group_id = ?", group.ID).
+			Where("name = ?", fileInfo.Name).
+			Where("trashed_at IS NULL").
+			Order("version desc").
+			Find(&fileInfos)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with fetching the versions of the file")
+		}
+		return nil
+	})
+	return fileInfos, err
+}
+
+//RestoreFileVersion - makes an older version of a file the latest one, by saving it as a new version
+//the new version keeps the owner of the restored one, the user, restoring it, is recorded only in the audit log
+//returns the metadata of the new version
+func (i *FmDAOImpl) RestoreFileVersion(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error) {
+	var restored models.FileInfo
+	err := i.versionTransaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if !group.Active {
+			return myerr.NewClientError("The group is currently being deleted")
+		}
+
+		fileInfo, err := getFileInfoWithConn(tx, fileID)
+		if err != nil {
+			return err
+		} else if fileInfo.GroupID != group.ID {
+			return myerr.NewItemNotFoundError("File does not exist")
+		}
+
+		version, err := getLatestVersionWithConn(tx, group.ID, fileInfo.Name)
+		if err != nil {
+			return err
+		} else if version == fileInfo.Version {
+			return myerr.NewClientError("The file version is already the latest one")
+		}
+
+		if err = checkGroupLimitsWithConn(tx, group, fileInfo.Size, 0); err != nil {
+			return err
+		}
+
+		restored = models.FileInfo{
+			Name:    fileInfo.Name,
+			OwnerID: fileInfo.OwnerID,
+			GroupID: group.ID,
+			ETag:    fileInfo.ETag,
+			Version: version + 1,
+			BlobID:  fileInfo.BlobID,
+			Size:    fileInfo.Size,
+		}
+
+		if result := tx.Create(&restored); isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError(fmt.Sprintf("Group [%s] no longer exists", groupName))
+		} else if isUniqueViolation(result.Error) {
+			return errVersionTaken
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with saving the restored file version")
+		}
+
+		if restored.BlobID != 0 {
+			result := tx.Model(&models.Blob{}).
+				Where("id = ?", restored.BlobID).
+				Update("ref_count", gorm.Expr("ref_count + 1"))
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with referencing the content of the file version")
+			}
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, restored.ID)
+	})
+	return restored, err
+}
+
+//TrashFile - moves a file with all of its versions to the trash of its group, where it is kept until the retention period expires
+//the share links of the versions are deleted, their contents and the tags are kept, so that the file can be restored
+func (i *FmDAOImpl) TrashFile(userID uint, fileID uint, groupName string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		}
+
+		fileInfo, err := getFileInfoWithConn(tx, fileID)
+		if err != nil {
+			return err
+		} else if fileInfo.GroupID != group.ID {
+			return myerr.NewItemNotFoundError("File does not exist")
+		}
+
+		//the older versions are trashed too, otherwise the previous version would take the place of the deleted file
+		versionIDs := tx.Table("file_infos").
+			Select("id").
+			Where("group_id = ?", group.ID).
+			Where("name = ?", fileInfo.Name).
+			Where("trashed_at IS NULL")
+		if result := tx.Where("file_id IN (?)", versionIDs).Delete(&models.ShareLink{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the share links of the file")
+		}
+
+		//the condition guards against concurrent deletions of the file, all versions get the same time,
+		//so that they are restored together
+		result := tx.Model(&models.FileInfo{}).
+			Where("group_id = ?", group.ID).
+			Where("name = ?", fileInfo.Name).
+			Where("trashed_at IS NULL").
+			Updates(map[string]interface{}{"trashed_at": time.Now(), "trashed_by": userID})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with moving the file to the trash")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewItemNotFoundError("File does not exist")
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
+	})
+}
+
+//GetTrashedFiles - returns the latest versions of the files in the trash of a group, starting from the latest deleted one
+func (i *FmDAOImpl) GetTrashedFiles(groupName string) ([]models.FileInfo, error) {
+	fileInfos := make([]models.FileInfo, 0)
+	result := i.dbConn.Table("file_infos").
+		Joins("inner join groups on file_infos.group_id = groups.id").
 		Where("groups.name = ?", groupName).
+		Where("file_infos.trashed_at IS NOT NULL").
+		Where("file_infos.version = (?)", i.dbConn.Table("file_infos AS versions").
+			Select("max(versions.version)").
+			Where("versions.group_id = file_infos.group_id").
+			Where("versions.name = file_infos.name").
+			Where("versions.trashed_at = file_infos.trashed_at")).
+		Select("file_infos.*").
+		Order("file_infos.trashed_at desc").
+		Order("file_infos.id desc").
 		Find(&fileInfos)
+
 	if result.Error != nil {
-		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching all files from a specific group")
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the files in the trash of the group")
 	}
-
 	return fileInfos, nil
 }
 
+//RestoreTrashedFile - moves a file with the versions, which were deleted together with it, from the trash back to its group
+//the versions keep their ids and numbers, but they count against the limits of the group again
+//returns the metadata of the latest restored version
+func (i *FmDAOImpl) RestoreTrashedFile(fileID uint, groupName string, event *models.AuditEvent) (models.FileInfo, error) {
+	var fileInfo models.FileInfo
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if !group.Active {
+			return myerr.NewClientError("The group is currently being deleted")
+		}
+
+		result := tx.Where("id = ?", fileID).
+			Where("group_id = ?", group.ID).
+			Where("trashed_at IS NOT NULL").
+			Take(&fileInfo)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewItemNotFoundError("File is not in the trash")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the file in the trash")
+		}
+
+		//the time is compared in the db, so that it isnt changed by the conversions of the driver
+		versions := tx.Model(&models.FileInfo{}).
+			Where("group_id = ?", group.ID).
+			Where("name = ?", fileInfo.Name).
+			Where("trashed_at = (?)", tx.Table("file_infos").Select("trashed_at").Where("id = ?", fileInfo.ID))
+
+		var restored []models.FileInfo
+		if result = versions.Session(&gorm.Session{}).Order("version desc").Find(&restored); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the deleted versions of the file")
+		}
+
+		var size int64
+		for _, version := range restored {
+			size += version.Size
+		}
+		if err = checkGroupLimitsWithConn(tx, group, size, 0); err != nil {
+			return err
+		}
+
+		result = versions.Session(&gorm.Session{}).Updates(map[string]interface{}{"trashed_at": nil, "trashed_by": nil})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with restoring the file from the trash")
+		}
+		fileInfo = restored[0]
+		fileInfo.TrashedAt, fileInfo.TrashedBy = nil, nil
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
+	})
+	return fileInfo, err
+}
+
+//PurgeTrashedFiles - erases the files, which were moved to the trash before the given time
+//returns the erased files, their blobs are kept without references, until their content is erased
+func (i *FmDAOImpl) PurgeTrashedFiles(before time.Time) ([]PurgedFile, error) {
+	var purged []PurgedFile
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		//sqlite compares the times as text, so the time is converted to the time zone of the stored times
+		purged = make([]PurgedFile, 0)
+		result := tx.Table("file_infos").
+			Select("file_infos.id, groups.name AS group_name, coalesce(file_infos.blob_id, 0) AS blob_id").
+			Joins("inner join groups on groups.id = file_infos.group_id").
+			Where("file_infos.trashed_at < ?", before.Local()).
+			Scan(&purged)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with finding the files, whose retention in the trash expired")
+		} else if len(purged) == 0 {
+			return nil
+		}
+
+		fileIDs := make([]uint, 0, len(purged))
+		blobIDs := make([]uint, 0, len(purged))
+		for _, file := range purged {
+			fileIDs = append(fileIDs, file.ID)
+			blobIDs = append(blobIDs, file.BlobID)
+		}
+
+		if result = tx.Where("id IN ?", fileIDs).Delete(&models.FileInfo{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the files in the trash")
+		}
+
+		//the tags are deleted together with the last version of the file
+		result = tx.Where("NOT EXISTS (?)", tx.Table("file_infos").
+			Select("1").
+			Where("file_infos.group_id = file_tags.group_id").
+			Where("file_infos.name = file_tags.file_name")).
+			Delete(&models.FileTag{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the tags of the erased files")
+		}
+
+		return releaseBlobsWithConn(tx, blobIDs)
+	})
+	return purged, err
+}
+
+//GetUnreferencedBlobs - retrieves the blobs, which are no longer referenced by any file
+func (i *FmDAOImpl) GetUnreferencedBlobs() ([]models.Blob, error) {
+	blobs := make([]models.Blob, 0)
+	if result := i.dbConn.Where("ref_count = ?", 0).Find(&blobs); result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with finding the unreferenced contents")
+	}
+	return blobs, nil
+}
+
+//EraseUnreferencedBlob - deletes the blob, if it is still not referenced by any file, and erases its content
+//the content is erased before the deletion is committed, so a concurrent upload of the same content
+//either references the blob before it is deleted, or waits for the deletion and creates the blob again
+func (i *FmDAOImpl) EraseUnreferencedBlob(blobID uint, eraseContent func(blob models.Blob) error) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var blob models.Blob
+		result := tx.Where("id = ?", blobID).Take(&blob)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the content")
+		}
+
+		result = tx.Where("id = ?", blobID).Where("ref_count = ?", 0).Delete(&models.Blob{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the unreferenced content")
+		} else if result.RowsAffected == 0 {
+			return nil
+		}
+		return eraseContent(blob)
+	})
+}
+
+//CreateUploadSession - registers a new chunked upload of a file in a particular group, which can be completed until it expires
+//the file should fit in the limits of the group, the size of the pending uploads in the group is reserved in its quota,
+//the expired uploads arent counted
+func (i *FmDAOImpl) CreateUploadSession(userID uint, uploadID string, fileName string, size int64, groupName string, expiresAt time.Time) (uint, error) {
+	var sessionID uint
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if !group.Active {
+			return myerr.NewClientError("The group is currently being deleted")
+		}
+
+		var count int64
+		result := tx.Table("memberships").
+			Where("user_id = ?", userID).
+			Where("group_id = ?", group.ID).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Couldnt check if membership exists")
+		} else if count == 0 {
+			return myerr.NewClientError("Cannot upload a file in a group you aren't part of")
+		}
+
+		//the limits are checked again, when the upload is completed
+		if err = checkGroupLimitsWithConn(tx, group, size, 0); err != nil {
+			return err
+		}
+
+		session := models.UploadSession{
+			UploadID:  uploadID,
+			FileName:  fileName,
+			Size:      size,
+			OwnerID:   userID,
+			GroupID:   group.ID,
+			ExpiresAt: expiresAt,
+		}
+
+		if result = tx.Create(&session); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, fmt.Sprintf("Cannot save upload session in the db for group [%s]", groupName))
+		}
+		sessionID = session.ID
+		return nil
+	})
+	return sessionID, err
+}
+
+//GetUploadSession - fetches an upload session, started by the user for a particular group
+//the expired sessions arent returned
+func (i *FmDAOImpl) GetUploadSession(userID uint, uploadID string, groupName string) (models.UploadSession, error) {
+	var session models.UploadSession
+
+	result := i.dbConn.Table("upload_sessions").
+		Joins("inner join groups on upload_sessions.group_id = groups.id").
+		Where("groups.name = ?", groupName).
+		Where("upload_sessions.upload_id = ?", uploadID).
+		Where("upload_sessions.owner_id = ?", userID).
+		Where("upload_sessions.expires_at > ?", time.Now()).
+		Select("upload_sessions.*").
+		Take(&session)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return session, myerr.NewItemNotFoundError("Upload session does not exist or has expired")
+	} else if result.Error != nil {
+		return session, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the upload session")
+	}
+
+	return session, nil
+}
+
+//AddUploadChunk - records that a chunk of an upload session was received
+//if the chunk was already received, its previous record is replaced
+func (i *FmDAOImpl) AddUploadChunk(sessionID uint, number uint, offset int64, size int64) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("upload_session_id = ?", sessionID).
+			Where("number = ?", number).
+			Delete(&models.UploadChunk{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the removal of previous chunk record")
+		}
+
+		chunk := models.UploadChunk{
+			UploadSessionID: sessionID,
+			Number:          number,
+			Offset:          offset,
+			Size:            size,
+		}
+
+		if result = tx.Create(&chunk); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with saving the chunk record")
+		}
+		return nil
+	})
+}
+
+//GetUploadChunks - returns all received chunks of an upload session, ordered by their offset
+func (i *FmDAOImpl) GetUploadChunks(sessionID uint) ([]models.UploadChunk, error) {
+	var chunks []models.UploadChunk
+	result := i.dbConn.Where("upload_session_id = ?", sessionID).
+		Order("chunk_offset").
+		Find(&chunks)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the chunks of the upload session")
+	}
+	return chunks, nil
+}
+
+//RemoveUploadSession - removes an upload session and the records of its chunks
+func (i *FmDAOImpl) RemoveUploadSession(sessionID uint) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("upload_session_id = ?", sessionID).Delete(&models.UploadChunk{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the upload chunks")
+		}
+
+		if result = tx.Delete(&models.UploadSession{}, sessionID); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the upload session")
+		}
+		return nil
+	})
+}
+
+//GetExpiredUploadSessions - retrieves the upload sessions, which have expired, together with the names of their groups
+func (i *FmDAOImpl) GetExpiredUploadSessions() ([]ExpiredUpload, error) {
+	uploads := make([]ExpiredUpload, 0)
+	result := i.dbConn.Table("upload_sessions").
+		Joins("inner join groups on upload_sessions.group_id = groups.id").
+		Where("upload_sessions.expires_at <= ?", time.Now()).
+		Select("upload_sessions.id, upload_sessions.upload_id, groups.name AS group_name").
+		Scan(&uploads)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the expired upload sessions")
+	}
+	return uploads, nil
+}
+
+//CreateShareLink - creates a public link to a file, which expires at the given time
+//zero max uses means that the number of uses isnt limited
+//returns the id of the link
+func (i *FmDAOImpl) CreateShareLink(creatorID uint, fileID uint, expiresAt time.Time, maxUses uint, event *models.AuditEvent) (uint, error) {
+	link := models.ShareLink{
+		FileID:    fileID,
+		CreatorID: creatorID,
+		ExpiresAt: expiresAt,
+		MaxUses:   maxUses,
+	}
+
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		fileInfo, err := getFileInfoWithConn(tx, fileID)
+		if err != nil {
+			return err
+		}
+
+		if result := tx.Create(&link); isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError("The file no longer exists")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with saving the share link")
+		}
+		return createAuditEventWithConn(tx, event, fileInfo.GroupID, 0, fileInfo.ID)
+	})
+	return link.ID, err
+}
+
+//GetShareLink - fetches a share link, no matter if it is still active
+func (i *FmDAOImpl) GetShareLink(linkID uint) (models.ShareLink, error) {
+	var link models.ShareLink
+	result := i.dbConn.Where("id = ?", linkID).Take(&link)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return link, myerr.NewItemNotFoundError("Share link not found")
+	} else if result.Error != nil {
+		return link, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the share link")
+	}
+	return link, nil
+}
+
+//GetActiveShareLinks - returns the links to a file, which arent revoked, expired or used up
+func (i *FmDAOImpl) GetActiveShareLinks(fileID uint) ([]models.ShareLink, error) {
+	links := make([]models.ShareLink, 0)
+	result := activeShareLinks(i.dbConn).
+		Where("file_id = ?", fileID).
+		Order("created_at").
+		Find(&links)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the share links of the file")
+	}
+	return links, nil
+}
+
+//RevokeShareLink - makes a share link unusable
+func (i *FmDAOImpl) RevokeShareLink(linkID uint, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var link models.ShareLink
+		result := tx.Where("id = ?", linkID).Take(&link)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewItemNotFoundError("Share link not found")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the share link")
+		}
+
+		if result = tx.Model(&link).Update("revoked", true); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the share link")
+		}
+
+		fileInfo, err := getFileInfoWithConn(tx, link.FileID)
+		if err != nil {
+			return err
+		}
+		return createAuditEventWithConn(tx, event, fileInfo.GroupID, 0, fileInfo.ID)
+	})
+}
+
+//GetSharedFile - fetches the file, an active share link gives access to, and the name of its group
+//the use of the link isnt counted
+func (i *FmDAOImpl) GetSharedFile(linkID uint) (models.FileInfo, string, error) {
+	fileInfo, group, err := getSharedFileWithConn(i.dbConn, linkID)
+	return fileInfo, group.Name, err
+}
+
+//UseShareLink - counts a use of an active share link
+func (i *FmDAOImpl) UseShareLink(linkID uint, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		fileInfo, group, err := getSharedFileWithConn(tx, linkID)
+		if err != nil {
+			return err
+		}
+
+		//the condition guards against concurrent uses of the last allowed use
+		result := activeShareLinks(tx.Model(&models.ShareLink{})).
+			Where("id = ?", linkID).
+			Update("uses", gorm.Expr("uses + 1"))
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with counting the use of the share link")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewItemNotFoundError("The share link is revoked, expired or used up")
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
+	})
+}
+
+//SetFileTags - replaces the tags of a file, they are shared by all versions of the file
+func (i *FmDAOImpl) SetFileTags(fileID uint, groupName string, tags []string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		}
+
+		fileInfo, err := getFileInfoWithConn(tx, fileID)
+		if err != nil {
+			return err
+		} else if fileInfo.GroupID != group.ID {
+			return myerr.NewItemNotFoundError("File does not exist")
+		}
+
+		result := tx.Where("group_id = ?", group.ID).
+			Where("file_name = ?", fileInfo.Name).
+			Delete(&models.FileTag{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the previous tags of the file")
+		}
+
+		if len(tags) > 0 {
+			fileTags := make([]models.FileTag, 0, len(tags))
+			for _, tag := range tags {
+				fileTags = append(fileTags, models.FileTag{GroupID: group.ID, FileName: fileInfo.Name, Name: tag})
+			}
+			if result = tx.Create(&fileTags); isForeignKeyViolation(result.Error) {
+				return myerr.NewClientError(fmt.Sprintf("Group [%s] no longer exists", groupName))
+			} else if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with saving the tags of the file")
+			}
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, fileInfo.ID)
+	})
+}
+
+//SearchFiles - finds a page of the latest versions of the files, which satisfy the filter, in the groups of the user
+//every word of the query should be contained in the file name, the newest files are found first, if no sorting is given
+//the groups, which are being deleted or require two-factor authentication, which the user hasnt enabled, are skipped
+func (i *FmDAOImpl) SearchFiles(userID uint, filter FileSearchFilter, options ListOptions) ([]FileSearchResult, string, error) {
+	if options.SortBy == "" {
+		options.SortBy, options.Descending = FileSortUploadedAt, true
+	}
+
+	query := i.dbConn.Table("file_infos").
+		Select("file_infos.id, file_infos.name, file_infos.group_id, groups.name AS group_name, file_infos.owner_id, users.username AS uploader, file_infos.created_at, file_infos.version, file_infos.size, file_infos.e_tag").
+		Joins("inner join groups on groups.id = file_infos.group_id").
+		Joins("inner join memberships on memberships.group_id = file_infos.group_id").
+		Joins("left join users on users.id = file_infos.owner_id").
+		Where("memberships.user_id = ?", userID).
+		Where("groups.active = ?", true).
+		Where("(groups.require_two_factor = ? OR EXISTS (?))", false, i.dbConn.Table("two_factors").
+			Select("1").
+			Where("two_factors.user_id = ?", userID).
+			Where("two_factors.enabled = ?", true)).
+		Where("file_infos.trashed_at IS NULL").
+		Where("file_infos.version = (?)", i.dbConn.Table("file_infos AS versions").
+			Select("max(versions.version)").
+			Where("versions.group_id = file_infos.group_id").
+			Where("versions.name = file_infos.name").
+			Where("versions.trashed_at IS NULL"))
+
+	for _, word := range strings.Fields(strings.ToLower(options.Query)) {
+		query = query.Where("lower(file_infos.name) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(word)+"%")
+	}
+	if filter.Group != "" {
+		query = query.Where("groups.name = ?", filter.Group)
+	}
+	if filter.Uploader != "" {
+		query = query.Where("users.username = ?", filter.Uploader)
+	}
+	//sqlite compares the times as text, so they are converted to the time zone of the stored times
+	if filter.Since != nil {
+		query = query.Where("file_infos.created_at >= ?", filter.Since.Local())
+	}
+	if filter.Until != nil {
+		query = query.Where("file_infos.created_at < ?", filter.Until.Local())
+	}
+	if filter.MinSize != nil {
+		query = query.Where("file_infos.size >= ?", *filter.MinSize)
+	}
+	if filter.MaxSize != nil {
+		query = query.Where("file_infos.size <= ?", *filter.MaxSize)
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where("EXISTS (?)", i.dbConn.Table("file_tags").
+			Select("1").
+			Where("file_tags.group_id = file_infos.group_id").
+			Where("file_tags.file_name = file_infos.name").
+			Where("file_tags.name = ?", tag))
+	}
+
+	query, sortBy, err := fileSearchListing.paginate(query, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	files := make([]FileSearchResult, 0)
+	if result := query.Scan(&files); result.Error != nil {
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with the search of files")
+	} else if len(files) == 0 {
+		return files, "", nil
+	}
+
+	cursor := ""
+	if len(files) > options.Limit {
+		files = files[:options.Limit]
+		last := files[len(files)-1]
+		uploader := ""
+		if last.Uploader != nil {
+			uploader = *last.Uploader
+		}
+		keys := map[string]interface{}{
+			FileSortName:       last.Name,
+			FileSortSize:       last.Size,
+			FileSortUploadedAt: last.CreatedAt,
+			FileSortUploader:   uploader,
+			FileSortGroup:      last.GroupName,
+		}
+		cursor = nextCursor(options, sortBy, keys[sortBy], last.ID)
+	}
+
+	groupIDs := make([]uint, 0, len(files))
+	fileNames := make([]string, 0, len(files))
+	for _, file := range files {
+		groupIDs = append(groupIDs, file.GroupID)
+		fileNames = append(fileNames, file.Name)
+	}
+
+	var fileTags []models.FileTag
+	result := i.dbConn.Where("group_id IN ?", groupIDs).
+		Where("file_name IN ?", fileNames).
+		Order("name").
+		Find(&fileTags)
+	if result.Error != nil {
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with fetching the tags of the found files")
+	}
+
+	for index := range files {
+		files[index].Tags = make([]string, 0)
+		for _, fileTag := range fileTags {
+			if fileTag.GroupID == files[index].GroupID && fileTag.FileName == files[index].Name {
+				files[index].Tags = append(files[index].Tags, fileTag.Name)
+			}
+		}
+	}
+	return files, cursor, nil
+}
+
+//escapeLikePattern - escapes the wildcards of a LIKE pattern, so that they match only themselves
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+//activeShareLinks - narrows a query to the share links, which arent revoked, expired or used up
+func activeShareLinks(query *gorm.DB) *gorm.DB {
+	return query.Where("revoked = ?", false).
+		Where("expires_at > ?", time.Now()).
+		Where("max_uses = 0 OR uses < max_uses")
+}
+
+//getSharedFileWithConn - fetches the file of an active share link and its active group
+func getSharedFileWithConn(dbConn *gorm.DB, linkID uint) (models.FileInfo, models.Group, error) {
+	var link models.ShareLink
+	result := activeShareLinks(dbConn).Where("id = ?", linkID).Take(&link)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.FileInfo{}, models.Group{}, myerr.NewItemNotFoundError("The share link is revoked, expired or used up")
+	} else if result.Error != nil {
+		return models.FileInfo{}, models.Group{}, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the share link")
+	}
+
+	fileInfo, err := getFileInfoWithConn(dbConn, link.FileID)
+	if err != nil {
+		return models.FileInfo{}, models.Group{}, err
+	}
+
+	var group models.Group
+	result = dbConn.Where("id = ?", fileInfo.GroupID).Where("active = ?", true).Take(&group)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.FileInfo{}, models.Group{}, myerr.NewItemNotFoundError("File does not exist")
+	} else if result.Error != nil {
+		return models.FileInfo{}, models.Group{}, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the group of the file")
+	}
+	return fileInfo, group, nil
+}
+
+//CheckGroupLimits - checks if a file with the given size can be added to a group, whose files already take usage bytes
+func CheckGroupLimits(group models.Group, usage int64, size int64) error {
+	if size > group.MaxFileSize {
+		return myerr.NewClientError(fmt.Sprintf("The file exceeds the maximum file size of the group (%d bytes)", group.MaxFileSize))
+	} else if usage+size > group.Quota {
+		return myerr.NewClientError(fmt.Sprintf("The file exceeds the quota of the group (%d of %d bytes are used)", usage, group.Quota))
+	}
+	return nil
+}
+
+//checkGroupLimitsWithConn - checks if a file with the given size can be added to the group
+//the space, reserved by the pending chunked uploads, is counted as used, except for the upload session with the given id (0 if none)
+//the group record is locked till the end of the transaction, so that concurrent uploads cannot exceed the quota together
+func checkGroupLimitsWithConn(dbConn *gorm.DB, group models.Group, size int64, uploadSessionID uint) error {
+	if result := dbConn.Model(&group).Update("updated_at", time.Now()); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with locking the group")
+	}
+
+	usage, err := getGroupUsageWithConn(dbConn, group.ID)
+	if err != nil {
+		return err
+	}
+
+	var pending int64
+	row := dbConn.Table("upload_sessions").
+		Where("group_id = ?", group.ID).
+		Where("id <> ?", uploadSessionID).
+		Where("expires_at > ?", time.Now()).
+		Select("coalesce(sum(size), 0)").
+		Row()
+	if err = row.Scan(&pending); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with calculating the size of the pending uploads of the group")
+	}
+	return CheckGroupLimits(group, usage+pending, size)
+}
+
+//getGroupUsageWithConn - returns the size of the files of the group, the files in the trash arent counted
+func getGroupUsageWithConn(dbConn *gorm.DB, groupID uint) (int64, error) {
+	var usage int64
+	result := dbConn.Table("file_infos").
+		Where("group_id = ?", groupID).
+		Where("trashed_at IS NULL").
+		Select("coalesce(sum(size), 0)").
+		Row()
+
+	if err := result.Scan(&usage); err != nil {
+		return 0, myerr.NewServerErrorWrap(err, "Problem with calculating the used space of the group")
+	}
+	return usage, nil
+}
+
+//versionTransaction - runs a transaction, which adds a new version of a file
+//the transaction is run again, if a concurrent one has taken the number of the version or created its blob in the meantime
+func (i *FmDAOImpl) versionTransaction(fc func(tx *gorm.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		err := i.dbConn.Transaction(fc)
+		if err != errVersionTaken && err != errBlobTaken {
+			return err
+		} else if attempt == maxVersionAttempts {
+			return myerr.NewClientError("The file is being changed concurrently, please try again")
+		}
+	}
+}
+
+//getLatestVersionWithConn - returns the latest version of a file, zero if the file has no versions
+//the versions in the trash are counted, so that they keep their numbers, when they are restored
+func getLatestVersionWithConn(dbConn *gorm.DB, groupID uint, fileName string) (uint, error) {
+	var version uint
+	result := dbConn.Table("file_infos").
+		Where("group_id = ?", groupID).
+		Where("name = ?", fileName).
+		Select("coalesce(max(version), 0)").
+		Row()
+
+	if err := result.Scan(&version); err != nil {
+		return 0, myerr.NewServerErrorWrap(err, "Problem with the lookup of the latest file version")
+	}
+	return version, nil
+}
+
 func getFileInfoWithConn(dbConn *gorm.DB, fileID uint) (models.FileInfo, error) {
 	var fileInfo models.FileInfo
 
 	result := dbConn.Table("file_infos").
 		Where("id = ?", fileID).
+		Where("trashed_at IS NULL").
 		Take(&fileInfo)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -167,3 +1148,58 @@ func getFileInfoWithConn(dbConn *gorm.DB, fileID uint) (models.FileInfo, error)
 
 	return fileInfo, nil
 }
+
+//acquireBlobWithConn - adds a reference to the blob with the given checksum
+//the blob is created, if it doesnt exist yet, and then its content has to be stored, even if the storage still has it
+//because the content of a deleted blob may be erased at any time
+//it is called in versionTransaction, which runs it again, if a concurrent upload of the same content creates the blob first
+func acquireBlobWithConn(dbConn *gorm.DB, checksum string, size int64) (models.Blob, bool, error) {
+	var blob models.Blob
+
+	result := dbConn.Model(&models.Blob{}).
+		Where("checksum = ?", checksum).
+		Update("ref_count", gorm.Expr("ref_count + 1"))
+	if result.Error != nil {
+		return blob, false, myerr.NewServerErrorWrap(result.Error, "Problem with referencing the content of the file")
+	} else if result.RowsAffected == 0 {
+		blob = models.Blob{
+			Checksum: checksum,
+			Size:     size,
+			RefCount: 1,
+		}
+
+		//the transaction is aborted by the violation, so the reference is added, when it is run again
+		if result = dbConn.Create(&blob); isUniqueViolation(result.Error) {
+			return blob, false, errBlobTaken
+		} else if result.Error != nil {
+			return blob, false, myerr.NewServerErrorWrap(result.Error, "Problem with saving the content info of the file")
+		}
+		return blob, true, nil
+	}
+
+	if result = dbConn.Where("checksum = ?", checksum).Take(&blob); result.Error != nil {
+		return blob, false, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the content of the file")
+	}
+	return blob, false, nil
+}
+
+//releaseBlobsWithConn - removes one reference to each of the blobs (an id can be present more than once)
+//the blobs, which are no longer referenced, are kept with zero references, until EraseUnreferencedBlob erases their content
+func releaseBlobsWithConn(dbConn *gorm.DB, blobIDs []uint) error {
+	references := make(map[uint]uint)
+	for _, blobID := range blobIDs {
+		if blobID != 0 {
+			references[blobID]++
+		}
+	}
+
+	for blobID, count := range references {
+		result := dbConn.Model(&models.Blob{}).
+			Where("id = ?", blobID).
+			Update("ref_count", gorm.Expr("ref_count - ?", count))
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with releasing the content of the file")
+		}
+	}
+	return nil
+}
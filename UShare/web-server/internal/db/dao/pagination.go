@@ -0,0 +1,198 @@
+package dao
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"gorm.io/gorm"
+)
+
+//ListOptions - pagination, sorting and filtering of a listing
+//the cursor is the next cursor of the previous page, the empty one starts from the beginning
+//the query is matched against the name of the listed items (case insensitive), the role is used only for the members of a group
+type ListOptions struct {
+	Limit      int
+	Cursor     string
+	SortBy     string
+	Descending bool
+	Query      string
+	Role       string
+}
+
+//sortKind - type of the values of a sort field, needed to restore them from a cursor
+type sortKind int
+
+const (
+	sortString sortKind = iota
+	sortInt
+	sortTime
+)
+
+//sortField - column, by which a listing can be sorted
+type sortField struct {
+	column string
+	kind   sortKind
+}
+
+//listing - the sort fields of a listing, the default one and the unique column, which breaks the ties
+type listing struct {
+	fields      map[string]sortField
+	defaultSort string
+	idColumn    string
+}
+
+//listCursor - position after the last item of a page, the sort field and direction are kept,
+//so that a cursor cannot be used with a different sorting
+type listCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d"`
+	Key        string `json:"k"`
+	ID         uint   `json:"i"`
+}
+
+var (
+	groupListing = listing{
+		fields: map[string]sortField{
+			"name":       {column: "groups.name", kind: sortString},
+			"created_at": {column: "groups.created_at", kind: sortTime},
+			"id":         {column: "groups.id", kind: sortInt},
+		},
+		defaultSort: "name",
+		idColumn:    "groups.id",
+	}
+
+	userListing = listing{
+		fields: map[string]sortField{
+			"username":   {column: "users.username", kind: sortString},
+			"created_at": {column: "users.created_at", kind: sortTime},
+			"id":         {column: "users.id", kind: sortInt},
+		},
+		defaultSort: "username",
+		idColumn:    "users.id",
+	}
+
+	memberListing = listing{
+		fields: map[string]sortField{
+			"username":  {column: "users.username", kind: sortString},
+			"joined_at": {column: "memberships.created_at", kind: sortTime},
+			"id":        {column: "users.id", kind: sortInt},
+		},
+		defaultSort: "username",
+		idColumn:    "users.id",
+	}
+
+	fileListing = listing{
+		fields: map[string]sortField{
+			"name":        {column: "file_infos.name", kind: sortString},
+			"size":        {column: "file_infos.size", kind: sortInt},
+			"uploaded_at": {column: "file_infos.created_at", kind: sortTime},
+			"id":          {column: "file_infos.id", kind: sortInt},
+		},
+		defaultSort: "name",
+		idColumn:    "file_infos.id",
+	}
+
+	fileSearchListing = listing{
+		fields: map[string]sortField{
+			FileSortName:       {column: "file_infos.name", kind: sortString},
+			FileSortSize:       {column: "file_infos.size", kind: sortInt},
+			FileSortUploadedAt: {column: "file_infos.created_at", kind: sortTime},
+			//the uploaders, who were deleted, are sorted as an empty username, so that they can be compared with a cursor
+			FileSortUploader: {column: "coalesce(users.username, '')", kind: sortString},
+			FileSortGroup:    {column: "groups.name", kind: sortString},
+		},
+		defaultSort: FileSortUploadedAt,
+		idColumn:    "file_infos.id",
+	}
+)
+
+//paginate - narrows the query to a page of the listing, one more item than the limit is fetched,
+//to find out if there is a next page
+//returns the sort field, which is used
+func (l listing) paginate(query *gorm.DB, options ListOptions) (*gorm.DB, string, error) {
+	sortBy := options.SortBy
+	if sortBy == "" {
+		sortBy = l.defaultSort
+	}
+
+	field, ok := l.fields[sortBy]
+	if !ok {
+		return nil, "", myerr.NewClientError(fmt.Sprintf("The items cannot be sorted by [%s]", sortBy))
+	}
+
+	operator, direction := ">", "asc"
+	if options.Descending {
+		operator, direction = "<", "desc"
+	}
+
+	if options.Cursor != "" {
+		cursor, err := decodeCursor(options.Cursor)
+		if err != nil {
+			return nil, "", err
+		} else if cursor.SortBy != sortBy || cursor.Descending != options.Descending {
+			return nil, "", myerr.NewClientError("The cursor belongs to a listing with a different sorting")
+		}
+
+		key, err := parseCursorKey(field.kind, cursor.Key)
+		if err != nil {
+			return nil, "", err
+		}
+
+		condition := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", field.column, operator, l.idColumn)
+		query = query.Where(condition, key, key, cursor.ID)
+	}
+
+	query = query.Order(fmt.Sprintf("%s %s", field.column, direction)).
+		Order(fmt.Sprintf("%s %s", l.idColumn, direction)).
+		Limit(options.Limit + 1)
+	return query, sortBy, nil
+}
+
+//nextCursor - returns the cursor after the last item of a page, the key of the item is the value of its sort field
+func nextCursor(options ListOptions, sortBy string, key interface{}, id uint) string {
+	cursor := listCursor{SortBy: sortBy, Descending: options.Descending, ID: id}
+	switch value := key.(type) {
+	case time.Time:
+		cursor.Key = value.UTC().Format(time.RFC3339Nano)
+	default:
+		cursor.Key = fmt.Sprint(value)
+	}
+
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, myerr.NewClientError("Invalid cursor")
+	} else if err = json.Unmarshal(decoded, &cursor); err != nil {
+		return cursor, myerr.NewClientError("Invalid cursor")
+	}
+	return cursor, nil
+}
+
+func parseCursorKey(kind sortKind, key string) (interface{}, error) {
+	switch kind {
+	case sortInt:
+		value, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, myerr.NewClientError("Invalid cursor")
+		}
+		return value, nil
+	case sortTime:
+		value, err := time.Parse(time.RFC3339Nano, key)
+		if err != nil {
+			return nil, myerr.NewClientError("Invalid cursor")
+		}
+		//sqlite compares the times as text, so the key must be in the same time zone as the stored times
+		return value.Local(), nil
+	default:
+		return key, nil
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
@@ -14,21 +16,104 @@ import (
 
 //UamDAO - interface for working with the Database in regards to the User Access Management
 type UamDAO interface {
-	Migrate() error
 	CreateUser(string, string) error
 	GetUser(string) (models.User, error)
-	DeleteUser(uint) error
-	CreateGroup(uint, string) error
-	AddUserToGroup(uint, string, string) error
-	RemoveUserFromGroup(uint, string, string) error
+	GetUserByID(uint) (models.User, error)
+	DeactivateUser(uint) error
+	GetDeactivatedUserIDs(time.Time) ([]uint, error)
+	PurgeDeactivatedUsers([]uint) error
+	ChangePassword(uint, string) error
+	CreatePasswordReset(uint, string, time.Time) error
+	ResetPassword(string, string) error
+	CreateTwoFactor(uint, string, []string) error
+	GetTwoFactor(uint) (models.TwoFactor, error)
+	EnableTwoFactor(uint, int64) error
+	UseTwoFactorStep(uint, int64) error
+	UseRecoveryCode(uint, string) error
+	DeleteTwoFactor(uint) error
+	CreateLoginChallenge(uint, string, time.Time) error
+	UseLoginChallenge(string) (models.LoginChallenge, error)
+	CreateExternalLogin(models.ExternalLogin) error
+	UseExternalLogin(string) (models.ExternalLogin, error)
+	GetOrCreateExternalUser(string, string, string) (models.User, error)
+	CreateSession(uint, string, time.Time) (uint, error)
+	RotateSession(string, string, time.Time) (models.Session, error)
+	RevokeSession(uint) error
+	IsSessionRevoked(uint) (bool, error)
+	CreateAccessToken(models.AccessToken) (uint, error)
+	UseAccessToken(string) (models.AccessToken, error)
+	GetAccessTokens(uint) ([]AccessTokenDetails, error)
+	DeleteAccessToken(uint, uint) error
+	GetLoginLockout([]string) (time.Time, error)
+	RecordLoginFailure(string, time.Time) (uint, error)
+	LockLogin(string, time.Time, *models.AuditEvent) error
+	ResetLoginFailures(string) error
+	CreateGroup(uint, string, *models.AuditEvent) error
+	CreateInvitation(uint, string, string, *time.Time, *models.AuditEvent) error
+	GetInvitations(uint) ([]InvitationDetails, error)
+	AcceptInvitation(uint, string, *models.AuditEvent) error
+	DeclineInvitation(uint, string, *models.AuditEvent) error
+	RevokeInvitation(string, string, *models.AuditEvent) error
+	RemoveUserFromGroup(string, string, *models.AuditEvent) error
 	MemberExists(uint, uint) (bool, error)
-	DeactivateGroup(uint, string) error
+	GetMembership(uint, uint) (models.Membership, error)
+	UpdateMemberRole(string, string, string, *models.AuditEvent) error
+	TransferGroupOwnership(string, string, *models.AuditEvent) error
+	DeactivateGroup(string, *models.AuditEvent) error
 	GetGroup(string) (models.Group, error)
-	GetDeactivatedGroupNames() ([]string, error)
+	GetDeactivatedGroups(uint) ([]models.Group, error)
+	RestoreGroup(uint, string, *models.AuditEvent) error
+	GetDeactivatedGroupNames(time.Time) ([]string, error)
 	EraseDeactivatedGroups([]string) error
-	GetAllGroups() ([]models.Group, error)
-	GetAllUsers() ([]models.User, error)
-	GetAllUsersInGroup(uint, string) ([]models.User, error)
+	GetAllGroups(ListOptions) ([]models.Group, string, error)
+	GetAllUsers(ListOptions) ([]models.User, string, error)
+	GetAllUsersInGroup(uint, string, ListOptions) ([]models.User, string, error)
+	GetGroupUsage(uint) (int64, error)
+	UpdateGroupLimits(string, int64, int64, *models.AuditEvent) error
+	UpdateGroupTwoFactor(string, bool, *models.AuditEvent) error
+	CreateAuditEvent(*models.AuditEvent) error
+	GetAuditEvents(uint, AuditFilter, int, int) ([]AuditEventDetails, error)
+}
+
+//InvitationDetails - pending invitation together with the name of its group and the username of the inviter
+type InvitationDetails struct {
+	ID        uint
+	GroupName string
+	Inviter   string
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+}
+
+//AccessTokenDetails - personal access token together with the name of the group, to which it is restricted
+type AccessTokenDetails struct {
+	ID         uint
+	Name       string
+	Scopes     string
+	GroupName  *string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+//AuditFilter - conditions, which the listed audit events should satisfy, the empty ones are ignored
+type AuditFilter struct {
+	Action string
+	Actor  string
+	Since  *time.Time
+	Until  *time.Time
+}
+
+//AuditEventDetails - audit event together with the usernames of the actor and the user, whom it concerns
+//the usernames are missing, if the event has no actor or target user or they were deleted
+type AuditEventDetails struct {
+	ID         uint
+	CreatedAt  time.Time
+	Action     string
+	Actor      *string
+	TargetUser *string
+	FileID     *uint
+	Details    string
+	IP         string
 }
 
 //UamDAOImpl - implementation of UamDAO
@@ -41,11 +126,6 @@ func NewUamDAOImpl(dbConn *gorm.DB) *UamDAOImpl {
 	return &UamDAOImpl{dbConn: dbConn}
 }
 
-//Migrate - function which updates the models(table structure) in db
-func (i *UamDAOImpl) Migrate() error {
-	return i.dbConn.AutoMigrate(models.User{}, models.Group{}, models.Membership{})
-}
-
 //CreateUser - creates a new user in the database, given username and password (encrypted)
 func (i *UamDAOImpl) CreateUser(username string, password string) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
@@ -61,10 +141,13 @@ func (i *UamDAOImpl) CreateUser(username string, password string) error {
 		user := models.User{
 			Username: username,
 			Password: password,
+			Active:   true,
 		}
 
 		log.Printf("Creating user with username [%s]", username)
-		if result := tx.Create(&user); result.Error != nil {
+		if result := tx.Create(&user); isUniqueViolation(result.Error) {
+			return myerr.NewClientError("A user with the same username exists")
+		} else if result.Error != nil {
 			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of new user")
 		}
 		log.Printf("User with username [%s] created", username)
@@ -73,25 +156,456 @@ func (i *UamDAOImpl) CreateUser(username string, password string) error {
 	})
 }
 
-//DeleteUser - deletes user given an id of the user
-func (i *UamDAOImpl) DeleteUser(userID uint) error {
-	var count int64
-	result := i.dbConn.Table("users").Where("id = ?", userID).Count(&count)
+//DeactivateUser - deactivates user given an id of the user, the user is purged after the retention period
+//the ownership of every active group of the user passes to the member with the highest role (the oldest membership wins a tie)
+//the groups without other members are deactivated and later erased together with their files
+//the memberships, the credentials and the share links of the user are revoked immediately
+func (i *UamDAOImpl) DeactivateUser(userID uint) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		result := tx.Table("users").
+			Where("id = ?", userID).
+			Where("active = ?", true).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup if user exists")
+		} else if count == 0 {
+			return myerr.NewItemNotFoundError("User with that id does not exist")
+		}
+
+		var ownedGroups []models.Group
+		result = tx.Where("owner_id = ?", userID).Where("active = ?", true).Find(&ownedGroups)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the groups owned by the user")
+		}
+
+		for _, group := range ownedGroups {
+			var successor models.Membership
+			result = tx.Where("group_id = ?", group.ID).
+				Where("user_id <> ?", userID).
+				Order(fmt.Sprintf("CASE role WHEN '%s' THEN 0 WHEN '%s' THEN 1 ELSE 2 END", models.RoleAdmin, models.RoleContributor)).
+				Order("created_at").
+				Take(&successor)
+
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				log.Printf("Group [%s] has no other members. Change status of group to non active\n", group.Name)
+				result = tx.Model(&group).Updates(map[string]interface{}{"active": false, "deactivated_at": time.Now()})
+				if result.Error != nil {
+					return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the group in db")
+				}
+				continue
+			} else if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the new group owner")
+			}
+
+			if err := changeGroupOwnerWithConn(tx, group, successor.UserID); err != nil {
+				return err
+			}
+		}
+
+		log.Printf("Revolking memberships of user with id [%d]\n", userID)
+		if result = tx.Where("user_id = ?", userID).Delete(&models.Membership{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the memberships of the user")
+		}
+
+		if result = tx.Where("user_id = ?", userID).Delete(&models.Invitation{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the invitations of the user")
+		}
+
+		//the access tokens of the user become invalid together with his sessions
+		if result = tx.Where("user_id = ?", userID).Delete(&models.Session{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the sessions of the user")
+		}
+
+		if result = tx.Where("user_id = ?", userID).Delete(&models.AccessToken{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the access tokens of the user")
+		}
+
+		if result = tx.Where("user_id = ?", userID).Delete(&models.PasswordReset{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the password resets of the user")
+		}
+
+		if err := deleteTwoFactorWithConn(tx, userID); err != nil {
+			return err
+		}
+
+		if result = tx.Where("user_id = ?", userID).Delete(&models.LoginChallenge{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the login challenges of the user")
+		}
+
+		if result = tx.Where("user_id = ?", userID).Delete(&models.ExternalIdentity{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the external identities of the user")
+		}
+
+		result = tx.Model(&models.ShareLink{}).
+			Where("creator_id = ?", userID).
+			Where("revoked = ?", false).
+			Update("revoked", true)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the share links of the user")
+		}
+
+		log.Printf("Deactivating user with id [%d]\n", userID)
+		result = tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{"active": false, "deactivated_at": time.Now()})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deactivation of the user in db")
+		}
+		log.Printf("User with id [%d] is deactivated\n", userID)
+
+		return nil
+	})
+}
+
+//GetDeactivatedUserIDs - retrieves the ids of the users, deactivated before the given time, which are still not purged
+func (i *UamDAOImpl) GetDeactivatedUserIDs(before time.Time) ([]uint, error) {
+	userIDs := make([]uint, 0)
+	result := i.dbConn.Table("users").
+		Where("active = ?", false).
+		Where("deactivated_at < ?", before.Local()).
+		Pluck("id", &userIDs)
 
 	if result.Error != nil {
-		return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup if user exists")
-	} else if count == 0 {
-		return myerr.NewItemNotFoundError("User with that id does not exist")
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with finding the users, who should be purged")
 	}
+	return userIDs, nil
+}
 
-	log.Printf("Deleting user with id [%d]\n", userID)
-	if result = i.dbConn.Delete(&models.User{}, userID); result.Error != nil {
-		return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the user from db")
+//PurgeDeactivatedUsers - deletes pernamently the deactivated users, the active ones are skipped
+//the files, uploaded by them, pass to the owners of their groups, so that every file has an existing uploader
+//the users, who still own deactivated groups, are skipped until their groups are erased, so that no group is left without an owner
+func (i *UamDAOImpl) PurgeDeactivatedUsers(userIDs []uint) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		deactivatedIDs := make([]uint, 0, len(userIDs))
+		result := tx.Table("users").
+			Where("id IN (?)", userIDs).
+			Where("active = ?", false).
+			Where("id NOT IN (?)", tx.Table("groups").Select("owner_id")).
+			Pluck("id", &deactivatedIDs)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the deactivated users")
+		} else if len(deactivatedIDs) == 0 {
+			return nil
+		}
+
+		log.Printf("Reassigning the files of the users with ids %v to the owners of their groups\n", deactivatedIDs)
+		result = tx.Model(&models.FileInfo{}).
+			Where("owner_id IN (?)", deactivatedIDs).
+			Update("owner_id", tx.Table("groups").Select("owner_id").Where("groups.id = file_infos.group_id"))
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the reassignment of the files of the deactivated users")
+		}
+
+		if result = tx.Where("id IN (?)", deactivatedIDs).Delete(&models.User{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the deactivated users")
+		}
+		log.Printf("Users with ids %v are purged\n", deactivatedIDs)
+		return nil
+	})
+}
+
+//ChangePassword - replaces the password hash of a user and revokes all of his sessions and access tokens
+func (i *UamDAOImpl) ChangePassword(userID uint, password string) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		return changePasswordWithConn(tx, userID, password)
+	})
+}
+
+//CreatePasswordReset - creates a one-time password reset of a user, given the hash of its token
+//the previous resets of the user are deleted, only the newest token can be used
+func (i *UamDAOImpl) CreatePasswordReset(userID uint, tokenHash string, expiresAt time.Time) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("user_id = ?", userID).Delete(&models.PasswordReset{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the previous password resets")
+		}
+
+		reset := models.PasswordReset{
+			UserID:    userID,
+			TokenHash: tokenHash,
+			ExpiresAt: expiresAt,
+		}
+		if result := tx.Create(&reset); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the password reset")
+		}
+		return nil
+	})
+}
+
+//ResetPassword - replaces the password hash of the user, who owns the reset token, and revokes all of his sessions and access tokens
+//the token can be used only once
+func (i *UamDAOImpl) ResetPassword(tokenHash string, password string) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var reset models.PasswordReset
+		result := tx.Where("token_hash = ?", tokenHash).
+			Where("expires_at > ?", time.Now()).
+			Take(&reset)
+
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewClientError("Invalid or expired reset token")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the password reset")
+		}
+
+		if result = tx.Where("user_id = ?", reset.UserID).Delete(&models.PasswordReset{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the password resets")
+		}
+
+		return changePasswordWithConn(tx, reset.UserID, password)
+	})
+}
+
+//CreateTwoFactor - creates a pending totp enrollment of a user together with the hashes of its recovery codes
+//a previous pending enrollment is replaced, an enabled one has to be disabled first
+func (i *UamDAOImpl) CreateTwoFactor(userID uint, secret string, recoveryCodeHashes []string) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		result := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ?", userID).
+			Where("enabled = ?", true).
+			Count(&count)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the two-factor authentication")
+		} else if count > 0 {
+			return myerr.NewClientError("Two-factor authentication is already enabled")
+		}
+
+		if err := deleteTwoFactorWithConn(tx, userID); err != nil {
+			return err
+		}
+
+		twoFactor := models.TwoFactor{
+			UserID: userID,
+			Secret: secret,
+		}
+		if result = tx.Create(&twoFactor); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the two-factor authentication")
+		}
+
+		recoveryCodes := make([]models.RecoveryCode, 0, len(recoveryCodeHashes))
+		for _, codeHash := range recoveryCodeHashes {
+			recoveryCodes = append(recoveryCodes, models.RecoveryCode{UserID: userID, CodeHash: codeHash})
+		}
+		if len(recoveryCodes) > 0 {
+			if result = tx.Create(&recoveryCodes); result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the recovery codes")
+			}
+		}
+		return nil
+	})
+}
+
+//GetTwoFactor - fetches the totp enrollment of a user, pending or enabled
+func (i *UamDAOImpl) GetTwoFactor(userID uint) (models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+
+	result := i.dbConn.Where("user_id = ?", userID).Take(&twoFactor)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return twoFactor, myerr.NewItemNotFoundError("Two-factor authentication isnt enrolled")
+	} else if result.Error != nil {
+		return twoFactor, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the two-factor authentication")
 	}
-	log.Printf("User with id [%d] is deleted\n", userID)
+	return twoFactor, nil
+}
+
+//EnableTwoFactor - enables the pending totp enrollment of a user, after it was confirmed with the code of the given period
+func (i *UamDAOImpl) EnableTwoFactor(userID uint, step int64) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ?", userID).
+			Where("enabled = ?", false).
+			Updates(map[string]interface{}{
+				"enabled":        true,
+				"last_used_step": step,
+			})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with enabling the two-factor authentication")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("There is no pending two-factor enrollment")
+		}
+		return nil
+	})
+}
+
+//UseTwoFactorStep - records the use of the totp code of the given period
+//the codes of the same or earlier periods cannot be used again
+func (i *UamDAOImpl) UseTwoFactorStep(userID uint, step int64) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TwoFactor{}).
+			Where("user_id = ?", userID).
+			Where("enabled = ?", true).
+			Where("last_used_step < ?", step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the recording of the used code")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("The code was already used")
+		}
+		return nil
+	})
+}
+
+//UseRecoveryCode - consumes a recovery code of a user, given its hash
+func (i *UamDAOImpl) UseRecoveryCode(userID uint, codeHash string) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", userID).
+			Where("code_hash = ?", codeHash).
+			Delete(&models.RecoveryCode{})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the use of the recovery code")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("Invalid code")
+		}
+		return nil
+	})
+}
+
+//DeleteTwoFactor - disables the two-factor authentication of a user and deletes his recovery codes
+func (i *UamDAOImpl) DeleteTwoFactor(userID uint) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		return deleteTwoFactorWithConn(tx, userID)
+	})
+}
+
+//CreateLoginChallenge - creates a pending login of a user with two-factor authentication, given the hash of its token
+//the previous challenges of the user are deleted
+func (i *UamDAOImpl) CreateLoginChallenge(userID uint, tokenHash string, expiresAt time.Time) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("user_id = ?", userID).Delete(&models.LoginChallenge{}); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the previous login challenges")
+		}
 
+		challenge := models.LoginChallenge{
+			UserID:    userID,
+			TokenHash: tokenHash,
+			ExpiresAt: expiresAt,
+		}
+		if result := tx.Create(&challenge); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the login challenge")
+		}
+		return nil
+	})
+}
+
+//UseLoginChallenge - consumes a login challenge, given the hash of its token
+//the challenge can be used only once, even if the code turns out to be invalid
+func (i *UamDAOImpl) UseLoginChallenge(tokenHash string) (models.LoginChallenge, error) {
+	var challenge models.LoginChallenge
+
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("token_hash = ?", tokenHash).
+			Where("expires_at > ?", time.Now()).
+			Take(&challenge)
+
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewClientError("Invalid or expired login challenge. Please login again")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the login challenge")
+		}
+
+		if result = tx.Delete(&challenge); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the login challenge")
+		}
+		return nil
+	})
+	return challenge, err
+}
+
+//CreateExternalLogin - creates a pending login through the external identity provider
+func (i *UamDAOImpl) CreateExternalLogin(login models.ExternalLogin) error {
+	if result := i.dbConn.Create(&login); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the external login")
+	}
 	return nil
+}
+
+//UseExternalLogin - consumes a pending login through the external identity provider, given the hash of its state
+//the login can be used only once, even if the exchange of the authorization code fails
+func (i *UamDAOImpl) UseExternalLogin(stateHash string) (models.ExternalLogin, error) {
+	var login models.ExternalLogin
+
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("state_hash = ?", stateHash).
+			Where("expires_at > ?", time.Now()).
+			Take(&login)
+
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewClientError("Invalid or expired login state. Please login again")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the external login")
+		}
 
+		if result = tx.Delete(&login); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the external login")
+		}
+		return nil
+	})
+	return login, err
+}
+
+//GetOrCreateExternalUser - fetches the user, linked to an external identity
+//if there is no such user, a new one is provisioned with the suggested username or, if it is taken, with a numbered suffix
+//the provisioned user has no password, until he resets it
+func (i *UamDAOImpl) GetOrCreateExternalUser(issuer, subject, username string) (models.User, error) {
+	var user models.User
+
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var identity models.ExternalIdentity
+		result := tx.Where("issuer = ?", issuer).
+			Where("subject = ?", subject).
+			Take(&identity)
+
+		if result.Error == nil {
+			if result = tx.Take(&user, identity.UserID); result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the user of the external identity")
+			}
+			return nil
+		} else if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the external identity")
+		}
+
+		var takenUsernames []string
+		result = tx.Table("users").
+			Where("username = ? OR username LIKE ?", username, username+"-%").
+			Pluck("username", &takenUsernames)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of users")
+		}
+
+		user = models.User{Username: freeUsername(username, takenUsernames), Active: true}
+		log.Printf("Provisioning user with username [%s] for an external identity", user.Username)
+		if result = tx.Create(&user); isUniqueViolation(result.Error) {
+			return myerr.NewClientError("The username is already taken. Please login again")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of new user")
+		}
+
+		identity = models.ExternalIdentity{
+			UserID:  user.ID,
+			Issuer:  issuer,
+			Subject: subject,
+		}
+		if result = tx.Create(&identity); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the external identity")
+		}
+		return nil
+	})
+	return user, err
+}
+
+//freeUsername - returns the username or, if it is taken, the username with the first free numbered suffix
+func freeUsername(username string, takenUsernames []string) string {
+	taken := make(map[string]bool, len(takenUsernames))
+	for _, takenUsername := range takenUsernames {
+		taken[takenUsername] = true
+	}
+
+	candidate := username
+	for suffix := 2; taken[candidate]; suffix++ {
+		candidate = fmt.Sprintf("%s-%d", username, suffix)
+	}
+	return candidate
 }
 
 //GetUser - fetches information about an existing user
@@ -99,8 +613,286 @@ func (i *UamDAOImpl) GetUser(username string) (models.User, error) {
 	return getUserWithConn(i.dbConn, username)
 }
 
+//GetUserByID - fetches information about an existing user, given his id
+func (i *UamDAOImpl) GetUserByID(userID uint) (models.User, error) {
+	var user models.User
+
+	result := i.dbConn.Where("active = ?", true).Take(&user, userID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return user, myerr.NewItemNotFoundError("User does not exist")
+	} else if result.Error != nil {
+		return user, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the user")
+	}
+
+	return user, nil
+}
+
+//CreateSession - creates a new login session of a user, given the hash of its refresh token
+//returns the id of the session
+func (i *UamDAOImpl) CreateSession(userID uint, tokenHash string, expiresAt time.Time) (uint, error) {
+	session := models.Session{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+
+	if result := i.dbConn.Create(&session); result.Error != nil {
+		return 0, myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the session")
+	}
+	return session.ID, nil
+}
+
+//RotateSession - replaces the refresh token of a session with a new one and extends the session
+//if an already rotated refresh token is used, the session is revoked, because the token was probably stolen
+func (i *UamDAOImpl) RotateSession(tokenHash, newTokenHash string, expiresAt time.Time) (models.Session, error) {
+	var (
+		session models.Session
+		reused  bool
+	)
+
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("token_hash = ?", tokenHash).Take(&session)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			result = tx.Model(&models.Session{}).
+				Where("previous_token_hash = ?", tokenHash).
+				Update("revoked", true)
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the session")
+			}
+			reused = result.RowsAffected > 0
+			return nil
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the session")
+		}
+
+		if session.Revoked {
+			return myerr.NewClientError("The session is revoked. Please login again")
+		} else if !session.ExpiresAt.After(time.Now()) {
+			return myerr.NewClientError("The session has expired. Please login again")
+		}
+
+		result = tx.Model(&models.Session{}).
+			Where("id = ?", session.ID).
+			Where("token_hash = ?", tokenHash).
+			Updates(map[string]interface{}{
+				"token_hash":          newTokenHash,
+				"previous_token_hash": tokenHash,
+				"expires_at":          expiresAt,
+			})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the rotation of the refresh token")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("Invalid refresh token")
+		}
+
+		session.TokenHash = newTokenHash
+		session.PreviousTokenHash = tokenHash
+		session.ExpiresAt = expiresAt
+		return nil
+	})
+
+	if err != nil {
+		return models.Session{}, err
+	} else if reused {
+		return models.Session{}, myerr.NewClientError("The refresh token was already used. The session is revoked, please login again")
+	} else if session.ID == 0 {
+		return models.Session{}, myerr.NewClientError("Invalid refresh token")
+	}
+	return session, nil
+}
+
+//RevokeSession - revokes a session, its refresh token and access tokens can no longer be used
+func (i *UamDAOImpl) RevokeSession(sessionID uint) error {
+	result := i.dbConn.Model(&models.Session{}).Where("id = ?", sessionID).Update("revoked", true)
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the session")
+	} else if result.RowsAffected == 0 {
+		return myerr.NewItemNotFoundError("Session not found")
+	}
+	return nil
+}
+
+//IsSessionRevoked - checks if the access tokens of a session are no longer valid
+//the tokens of revoked, expired and deleted sessions are not valid
+func (i *UamDAOImpl) IsSessionRevoked(sessionID uint) (bool, error) {
+	var count int64
+	result := i.dbConn.Table("sessions").
+		Where("id = ?", sessionID).
+		Where("revoked = ?", false).
+		Where("expires_at > ?", time.Now()).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the session")
+	}
+	return count == 0, nil
+}
+
+//CreateAccessToken - creates a new personal access token, the names of the tokens of a user are unique
+//returns the id of the token
+func (i *UamDAOImpl) CreateAccessToken(token models.AccessToken) (uint, error) {
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		result := tx.Table("access_tokens").
+			Where("user_id = ?", token.UserID).
+			Where("name = ?", token.Name).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup if access token exists")
+		} else if count != 0 {
+			return myerr.NewClientError(fmt.Sprintf("Access token with name [%s] already exists", token.Name))
+		}
+
+		if result = tx.Create(&token); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the access token")
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return token.ID, nil
+}
+
+//UseAccessToken - retrieves a personal access token, given the hash of the token, and records its use
+//returns ItemNotFoundError if the token doesnt exist or has expired
+func (i *UamDAOImpl) UseAccessToken(tokenHash string) (models.AccessToken, error) {
+	var token models.AccessToken
+	result := i.dbConn.Where("token_hash = ?", tokenHash).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Take(&token)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return models.AccessToken{}, myerr.NewItemNotFoundError("Access token not found")
+	} else if result.Error != nil {
+		return models.AccessToken{}, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the access token")
+	}
+
+	now := time.Now()
+	if result = i.dbConn.Model(&token).Update("last_used_at", now); result.Error != nil {
+		return models.AccessToken{}, myerr.NewServerErrorWrap(result.Error, "Problem with the update of the access token")
+	}
+	return token, nil
+}
+
+//GetAccessTokens - retrieves the personal access tokens of a user, including the expired ones
+func (i *UamDAOImpl) GetAccessTokens(userID uint) ([]AccessTokenDetails, error) {
+	tokens := make([]AccessTokenDetails, 0)
+	result := i.dbConn.Table("access_tokens").
+		Select("access_tokens.id, access_tokens.name, access_tokens.scopes, groups.name AS group_name, access_tokens.created_at, access_tokens.expires_at, access_tokens.last_used_at").
+		Joins("left join groups on groups.id = access_tokens.group_id").
+		Where("access_tokens.user_id = ?", userID).
+		Order("access_tokens.created_at").
+		Scan(&tokens)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the access tokens of the user")
+	}
+	return tokens, nil
+}
+
+//DeleteAccessToken - revokes a personal access token of a user
+func (i *UamDAOImpl) DeleteAccessToken(userID uint, tokenID uint) error {
+	result := i.dbConn.Where("user_id = ?", userID).Delete(&models.AccessToken{}, tokenID)
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the access token")
+	} else if result.RowsAffected == 0 {
+		return myerr.NewItemNotFoundError("Access token not found")
+	}
+	return nil
+}
+
+//GetLoginLockout - returns until when the logins are blocked for any of the keys
+//returns zero time if none of the keys is locked
+func (i *UamDAOImpl) GetLoginLockout(keys []string) (time.Time, error) {
+	var failures []models.LoginFailure
+	result := i.dbConn.Where("key IN ?", keys).
+		Where("locked_until > ?", time.Now()).
+		Find(&failures)
+
+	if result.Error != nil {
+		return time.Time{}, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the login lockouts")
+	}
+
+	var lockedUntil time.Time
+	for _, failure := range failures {
+		if failure.LockedUntil.After(lockedUntil) {
+			lockedUntil = *failure.LockedUntil
+		}
+	}
+	return lockedUntil, nil
+}
+
+//RecordLoginFailure - counts a failed login for the key
+//the failures before staleBefore are forgotten and the counting starts again
+//returns the number of the counted failures
+func (i *UamDAOImpl) RecordLoginFailure(key string, staleBefore time.Time) (uint, error) {
+	var failures uint
+	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var failure models.LoginFailure
+		result := tx.Where("key = ?", key).Take(&failure)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			failure = models.LoginFailure{Key: key, Failures: 1, LastFailureAt: now}
+			if result = tx.Create(&failure); result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Problem with the recording of the failed login")
+			}
+			failures = failure.Failures
+			return nil
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of the failed logins")
+		}
+
+		updates := map[string]interface{}{
+			"failures":        gorm.Expr("failures + 1"),
+			"last_failure_at": now,
+		}
+		failures = failure.Failures + 1
+		if failure.LastFailureAt.Before(staleBefore) {
+			updates["failures"] = 1
+			updates["locked_until"] = nil
+			failures = 1
+		}
+
+		if result = tx.Model(&failure).Updates(updates); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the recording of the failed login")
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return failures, nil
+}
+
+//LockLogin - blocks the logins for the key until the given time
+//the audit event, if given, is stored in the same transaction
+func (i *UamDAOImpl) LockLogin(key string, until time.Time, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LoginFailure{}).Where("key = ?", key).Update("locked_until", until)
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lockout of the logins")
+		}
+
+		return createAuditEventWithConn(tx, event, 0, 0, 0)
+	})
+}
+
+//ResetLoginFailures - forgets the failed logins for the key
+func (i *UamDAOImpl) ResetLoginFailures(key string) error {
+	if result := i.dbConn.Where("key = ?", key).Delete(&models.LoginFailure{}); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the reset of the failed logins")
+	}
+	return nil
+}
+
 //CreateGroup - creates a new group for sharing files
-func (i *UamDAOImpl) CreateGroup(userID uint, groupName string) error {
+//the audit event, if given, is stored in the same transaction, just like for the other changes of the groups
+func (i *UamDAOImpl) CreateGroup(userID uint, groupName string, event *models.AuditEvent) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
 		var count int64
 
@@ -117,7 +909,9 @@ func (i *UamDAOImpl) CreateGroup(userID uint, groupName string) error {
 		}
 
 		log.Printf("Creating group [%s] with owner [%d]\n", groupName, userID)
-		if result := tx.Create(&group); result.Error != nil {
+		if result := tx.Create(&group); isUniqueViolation(result.Error) {
+			return myerr.NewClientError("A group with the same name exists")
+		} else if result.Error != nil {
 			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of group [%s] in db")
 		}
 		log.Printf("Group with name [%s] and owner [%d] created\n", groupName, userID)
@@ -125,105 +919,324 @@ func (i *UamDAOImpl) CreateGroup(userID uint, groupName string) error {
 		membership := models.Membership{
 			UserID:  userID,
 			GroupID: group.ID,
+			Role:    models.RoleOwner,
 		}
 
 		//its usedless to check if the membership already exists, because basically the group is created in this transaction
 		log.Printf("Creating membership of user [%d] for group [%d]\n", userID, group.ID)
-		if result := tx.Create(&membership); result.Error != nil {
+		if result := tx.Create(&membership); isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError("User does not exist")
+		} else if result.Error != nil {
 			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of membership in db")
 		}
-		log.Printf("Membership of user [%d] for group [%d] is created\n", userID, group.ID)
+		log.Printf("Membership of user [%d] for group [%d] is created\n", userID, group.ID)
+
+		return createAuditEventWithConn(tx, event, group.ID, 0, 0)
+	})
+}
+
+//GetGroup - gets information about the group
+func (i *UamDAOImpl) GetGroup(groupName string) (models.Group, error) {
+	return getGroupWithConn(i.dbConn, groupName)
+}
+
+//CreateInvitation - invites a user to become a member of a specified group
+//the invitation expires at the given time, if one is given. An expired invitation to the same group is replaced
+func (i *UamDAOImpl) CreateInvitation(inviterID uint, username string, groupName string, expiresAt *time.Time, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		var (
+			count int64
+			group models.Group
+			user  models.User
+			err   error
+		)
+
+		group, err = getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if !group.Active {
+			return myerr.NewClientError("The group is currently being deleted")
+		}
+
+		user, err = getUserWithConn(tx, username)
+		if err != nil {
+			return err
+		} else if user.ID == 0 {
+			return myerr.NewClientError("User does not exist")
+		}
+
+		result := tx.Table("memberships").
+			Where("group_id = ?", group.ID).
+			Where("user_id = ?", user.ID).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of membership in db")
+		} else if count != 0 {
+			return myerr.NewClientError("The user is already a member of the group")
+		}
+
+		result = tx.Table("invitations").
+			Where("group_id = ?", group.ID).
+			Where("user_id = ?", user.ID).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+			Count(&count)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of invitation in db")
+		} else if count != 0 {
+			return myerr.NewClientError("The user is already invited to the group")
+		}
+
+		//removes the expired invitation, if there is such
+		if _, err = deleteInvitationWithConn(tx, group.ID, user.ID); err != nil {
+			return err
+		}
+
+		invitation := models.Invitation{
+			GroupID:   group.ID,
+			UserID:    user.ID,
+			InviterID: inviterID,
+			ExpiresAt: expiresAt,
+		}
+
+		log.Printf("Creating invitation for user with id [%d] in group with id [%d]", invitation.UserID, invitation.GroupID)
+		if result := tx.Create(&invitation); isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError("The user or the group no longer exists")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of new invitation in db")
+		}
+		log.Printf("Invitation for user with id [%d] in group id [%d] created", invitation.UserID, invitation.GroupID)
+
+		return createAuditEventWithConn(tx, event, group.ID, user.ID, 0)
+	})
+}
+
+//GetInvitations - retrieves the pending invitations of a user, the expired ones are skipped
+func (i *UamDAOImpl) GetInvitations(userID uint) ([]InvitationDetails, error) {
+	invitations := make([]InvitationDetails, 0)
+	result := i.dbConn.Table("invitations").
+		Select("invitations.id, groups.name AS group_name, users.username AS inviter, invitations.created_at, invitations.expires_at").
+		Joins("inner join groups on groups.id = invitations.group_id").
+		Joins("left join users on users.id = invitations.inviter_id").
+		Where("invitations.user_id = ?", userID).
+		Where("groups.active = ?", true).
+		Where("invitations.expires_at IS NULL OR invitations.expires_at > ?", time.Now()).
+		Scan(&invitations)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the invitations of the user")
+	}
+	return invitations, nil
+}
+
+//AcceptInvitation - turns the pending invitation of a user into a membership in the group
+//the new member is a contributor, the role can be changed afterwards
+func (i *UamDAOImpl) AcceptInvitation(userID uint, groupName string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
+		}
+
+		var invitation models.Invitation
+		result := tx.Where("group_id = ?", group.ID).
+			Where("user_id = ?", userID).
+			Take(&invitation)
+
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return myerr.NewClientError("Invitation not found")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of invitation in db")
+		} else if invitation.ExpiresAt != nil && invitation.ExpiresAt.Before(time.Now()) {
+			return myerr.NewClientError("The invitation has expired")
+		}
+
+		if result = tx.Delete(&invitation); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the invitation")
+		}
+
+		membership := models.Membership{
+			GroupID: group.ID,
+			UserID:  userID,
+			Role:    models.RoleContributor,
+		}
+
+		log.Printf("Creating membership for user with id [%d] in group with id [%d]", membership.UserID, membership.GroupID)
+		if result := tx.Create(&membership); isUniqueViolation(result.Error) {
+			return myerr.NewClientError("The user is already a member of the group")
+		} else if isForeignKeyViolation(result.Error) {
+			return myerr.NewClientError("Invalid group")
+		} else if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of new membership in db")
+		}
+		log.Printf("Membership for user with id [%d] in group id [%d] created", membership.UserID, membership.GroupID)
 
-		return nil
+		return createAuditEventWithConn(tx, event, group.ID, userID, 0)
 	})
 }
 
-//GetGroup - gets information about the group
-func (i *UamDAOImpl) GetGroup(groupName string) (models.Group, error) {
-	return getGroupWithConn(i.dbConn, groupName)
-}
-
-//AddUserToGroup - adds a new member to a specified group
-func (i *UamDAOImpl) AddUserToGroup(ownerID uint, username string, groupName string) error {
+//DeclineInvitation - removes the pending invitation of a user for a group
+func (i *UamDAOImpl) DeclineInvitation(userID uint, groupName string, event *models.AuditEvent) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
-		var (
-			count int64
-			group models.Group
-			user  models.User
-			err   error
-		)
-
-		group, err = getGroupWithConn(tx, groupName)
+		group, err := getGroupWithConn(tx, groupName)
 		if err != nil {
 			return err
-		} else if group.OwnerID != ownerID {
-			return myerr.NewClientError("Only the group owner can add members to the group")
-		} else if !group.Active {
-			return myerr.NewClientError("The group is currently being deleted")
+		} else if group.ID == 0 {
+			return myerr.NewClientError("Invalid group")
 		}
 
-		user, err = getUserWithConn(tx, username)
+		deleted, err := deleteInvitationWithConn(tx, group.ID, userID)
 		if err != nil {
 			return err
+		} else if !deleted {
+			return myerr.NewClientError("Invitation not found")
 		}
+		return createAuditEventWithConn(tx, event, group.ID, userID, 0)
+	})
+}
 
-		result := tx.Table("memberships").
-			Where("group_id = ?", group.ID).
-			Where("user_id = ?", user.ID).
-			Count(&count)
-
-		if result.Error != nil {
-			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of membership in db")
-		} else if count != 0 {
-			return myerr.NewClientError("The user is already a member of the group")
+//RevokeInvitation - removes the pending invitation of a user, before the user accepts it
+func (i *UamDAOImpl) RevokeInvitation(username string, groupName string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
 		}
 
-		membership := models.Membership{
-			GroupID: group.ID,
-			UserID:  user.ID,
+		user, err := getUserWithConn(tx, username)
+		if err != nil {
+			return err
 		}
 
-		log.Printf("Creating membership for user with id [%d] in group with id [%d]", membership.UserID, membership.GroupID)
-		if result := tx.Create(&membership); result.Error != nil {
-			return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of new membership in db")
+		deleted, err := deleteInvitationWithConn(tx, group.ID, user.ID)
+		if err != nil {
+			return err
+		} else if !deleted {
+			return myerr.NewClientError("Invitation not found")
 		}
-		log.Printf("Membership for user with id [%d] in group id [%d] created", membership.UserID, membership.GroupID)
-
-		return nil
+		return createAuditEventWithConn(tx, event, group.ID, user.ID, 0)
 	})
 }
 
-//DeactivateGroup - deletes all memberships and changes the status of the group to non active
-func (i *UamDAOImpl) DeactivateGroup(currUserID uint, groupName string) error {
+//DeactivateGroup - deletes all invitations and changes the status of the group to non active
+//the group is moved to the trash of its owner, its memberships and files are kept, so that it can be restored
+func (i *UamDAOImpl) DeactivateGroup(groupName string, event *models.AuditEvent) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
 		group, err := getGroupWithConn(tx, groupName)
 		if err != nil {
 			return err
-		} else if group.OwnerID != currUserID {
-			return myerr.NewClientError("Only the group owner can delete the group")
 		} else if !group.Active {
 			return myerr.NewClientError("The group is currently being deleted")
 		}
 
-		log.Printf("Revolking membership for users in group [%s]", groupName)
-		result := tx.Table("memberships").
-			Where("group_id = ?", group.ID).Delete(&models.Membership{})
+		result := tx.Where("group_id = ?", group.ID).Delete(&models.Invitation{})
 		if result.Error != nil {
-			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of memberships in db")
+			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of invitations in db")
 		}
-		log.Printf("Revolked membership for users in group [%s]", groupName)
 
 		log.Printf("Change status of group [%s] to non active\n", groupName)
-		if result = tx.Model(&group).Update("active", false); result.Error != nil {
+		result = tx.Model(&group).Updates(map[string]interface{}{"active": false, "deactivated_at": time.Now()})
+		if result.Error != nil {
 			return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the group in db")
 		}
 		log.Printf("Status of group [%s] is set to non active\n", groupName)
-		return nil
+		return createAuditEventWithConn(tx, event, group.ID, 0, 0)
+	})
+}
+
+//UpdateGroupLimits - changes the quota and the maximum file size of a group
+//the new quota can be lower than the current usage, which prevents further uploads
+func (i *UamDAOImpl) UpdateGroupLimits(groupName string, quota int64, maxFileSize int64, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
+		}
+
+		result := tx.Model(&group).Updates(map[string]interface{}{
+			"quota":         quota,
+			"max_file_size": maxFileSize,
+		})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with changing the quota of the group")
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, 0)
+	})
+}
+
+//UpdateGroupTwoFactor - changes if the members of a group need two-factor authentication to access its files
+func (i *UamDAOImpl) UpdateGroupTwoFactor(groupName string, required bool, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
+		}
+
+		if result := tx.Model(&group).Update("require_two_factor", required); result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with changing the two-factor requirement of the group")
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, 0)
 	})
 }
 
+//CreateAuditEvent - stores an audit event, which isnt a part of a change in the db (like a download of a file)
+func (i *UamDAOImpl) CreateAuditEvent(event *models.AuditEvent) error {
+	return createAuditEventWithConn(i.dbConn, event, 0, 0, 0)
+}
+
+//GetAuditEvents - retrieves a page of the audit events of a group, which satisfy the filter, starting from the latest one
+func (i *UamDAOImpl) GetAuditEvents(groupID uint, filter AuditFilter, offset int, limit int) ([]AuditEventDetails, error) {
+	query := i.dbConn.Table("audit_events").
+		Select("audit_events.id, audit_events.created_at, audit_events.action, actors.username AS actor, targets.username AS target_user, audit_events.file_id, audit_events.details, audit_events.ip").
+		Joins("left join users AS actors on actors.id = audit_events.actor_id").
+		Joins("left join users AS targets on targets.id = audit_events.user_id").
+		Where("audit_events.group_id = ?", groupID)
+
+	if filter.Action != "" {
+		query = query.Where("audit_events.action = ?", filter.Action)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actors.username = ?", filter.Actor)
+	}
+	//sqlite compares the times as text, so they are converted to the time zone of the stored times
+	if filter.Since != nil {
+		query = query.Where("audit_events.created_at >= ?", filter.Since.Local())
+	}
+	if filter.Until != nil {
+		query = query.Where("audit_events.created_at < ?", filter.Until.Local())
+	}
+
+	events := make([]AuditEventDetails, 0)
+	result := query.Order("audit_events.created_at desc").
+		Order("audit_events.id desc").
+		Offset(offset).
+		Limit(limit).
+		Scan(&events)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the audit events of the group")
+	}
+	return events, nil
+}
+
+//GetGroupUsage - returns the total size of the files in a group (in bytes)
+//every file is counted, even if its content is shared with other files
+func (i *UamDAOImpl) GetGroupUsage(groupID uint) (int64, error) {
+	return getGroupUsageWithConn(i.dbConn, groupID)
+}
+
 //RemoveUserFromGroup - removes a membership of a user to a specific group
-func (i *UamDAOImpl) RemoveUserFromGroup(currUserID uint, username string, groupName string) error {
+//the membership of the group owner cannot be removed, the owner has to transfer the ownership first
+func (i *UamDAOImpl) RemoveUserFromGroup(username string, groupName string, event *models.AuditEvent) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
 		var (
 			group models.Group
@@ -243,10 +1256,8 @@ func (i *UamDAOImpl) RemoveUserFromGroup(currUserID uint, username string, group
 			return err
 		}
 
-		if group.OwnerID != currUserID && user.ID != currUserID {
-			return myerr.NewClientError("Only the owner of the group can revoke membership of other members")
-		} else if group.OwnerID == currUserID && user.ID == currUserID {
-			return myerr.NewClientError("The owner cannot remove its own membership. Yet to be added this functionality")
+		if group.OwnerID == user.ID {
+			return myerr.NewClientError("The owner cannot leave the group. Transfer the ownership to another member first")
 		}
 
 		log.Printf("Revolking membership for user with id [%d] in group with id [%d]", user.ID, group.ID)
@@ -261,7 +1272,7 @@ func (i *UamDAOImpl) RemoveUserFromGroup(currUserID uint, username string, group
 		}
 		log.Printf("Membership for user with id [%d] in group id [%d] is revoked", user.ID, group.ID)
 
-		return nil
+		return createAuditEventWithConn(tx, event, group.ID, user.ID, 0)
 	})
 }
 
@@ -279,11 +1290,132 @@ func (i *UamDAOImpl) MemberExists(userID uint, groupID uint) (bool, error) {
 	return count != 0, nil
 }
 
-//GetDeactivatedGroupNames - retrieves names of all deactivated groups, which are still not deleted
-func (i *UamDAOImpl) GetDeactivatedGroupNames() ([]string, error) {
+//GetMembership - fetches the membership of a user for a particular group
+//returns ItemNotFoundError if the user isnt a member of the group
+func (i *UamDAOImpl) GetMembership(userID uint, groupID uint) (models.Membership, error) {
+	var membership models.Membership
+	result := i.dbConn.Where("user_id = ?", userID).
+		Where("group_id = ?", groupID).
+		Take(&membership)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return membership, myerr.NewItemNotFoundError("Membership not found")
+	} else if result.Error != nil {
+		return membership, myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of membership in db")
+	}
+	return membership, nil
+}
+
+//UpdateMemberRole - changes the role of a member in a specific group
+//the role of the group owner cannot be changed
+func (i *UamDAOImpl) UpdateMemberRole(username string, groupName string, role string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
+		}
+
+		user, err := getUserWithConn(tx, username)
+		if err != nil {
+			return err
+		} else if group.OwnerID == user.ID {
+			return myerr.NewClientError("The role of the group owner cannot be changed")
+		}
+
+		log.Printf("Changing the role of user with id [%d] in group with id [%d] to [%s]", user.ID, group.ID, role)
+		result := tx.Model(&models.Membership{}).
+			Where("user_id = ?", user.ID).
+			Where("group_id = ?", group.ID).
+			Update("role", role)
+
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with changing the role of the member")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("Membership not found")
+		}
+		log.Printf("Role of user with id [%d] in group with id [%d] is changed to [%s]", user.ID, group.ID, role)
+
+		return createAuditEventWithConn(tx, event, group.ID, user.ID, 0)
+	})
+}
+
+//TransferGroupOwnership - makes another member of the group its owner
+//the former owner stays in the group as an admin and is free to leave it afterwards
+func (i *UamDAOImpl) TransferGroupOwnership(groupName string, username string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 || !group.Active {
+			return myerr.NewClientError("Invalid group")
+		}
+
+		user, err := getUserWithConn(tx, username)
+		if err != nil {
+			return err
+		} else if group.OwnerID == user.ID {
+			return myerr.NewClientError("The user is already the owner of the group")
+		}
+
+		if err = changeGroupOwnerWithConn(tx, group, user.ID); err != nil {
+			return err
+		}
+		return createAuditEventWithConn(tx, event, group.ID, user.ID, 0)
+	})
+}
+
+//GetDeactivatedGroups - retrieves the groups in the trash of their owner, starting from the latest deleted one
+func (i *UamDAOImpl) GetDeactivatedGroups(userID uint) ([]models.Group, error) {
+	groups := make([]models.Group, 0)
+	result := i.dbConn.Where("owner_id = ?", userID).
+		Where("active = ?", false).
+		Order("deactivated_at desc").
+		Order("id desc").
+		Find(&groups)
+
+	if result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching the deleted groups")
+	}
+	return groups, nil
+}
+
+//RestoreGroup - moves a group from the trash of its owner back to the active groups, together with its members and files
+func (i *UamDAOImpl) RestoreGroup(userID uint, groupName string, event *models.AuditEvent) error {
+	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		group, err := getGroupWithConn(tx, groupName)
+		if err != nil {
+			return err
+		} else if group.ID == 0 {
+			return myerr.NewItemNotFoundError(fmt.Sprintf("Group [%s] does not exist", groupName))
+		} else if group.Active {
+			return myerr.NewClientError("The group isnt deleted")
+		} else if group.OwnerID != userID {
+			return myerr.NewClientError("Only the owner of the group can restore it")
+		}
+
+		log.Printf("Change status of group [%s] to active\n", groupName)
+		result := tx.Model(&group).
+			Where("active = ?", false).
+			Updates(map[string]interface{}{"active": true, "deactivated_at": nil})
+		if result.Error != nil {
+			return myerr.NewServerErrorWrap(result.Error, "Problem with the restoration of the group in db")
+		} else if result.RowsAffected == 0 {
+			return myerr.NewClientError("The group isnt deleted")
+		}
+		return createAuditEventWithConn(tx, event, group.ID, 0, 0)
+	})
+}
+
+//GetDeactivatedGroupNames - retrieves names of the groups, deactivated before the given time, which are still not deleted
+//the groups, deactivated before the introduction of the trash, dont have a time of deactivation and are always retrieved
+func (i *UamDAOImpl) GetDeactivatedGroupNames(before time.Time) ([]string, error) {
 	var groupNames []string
 	result := i.dbConn.Table("groups").
-		Where("active", false).Pluck("name", &groupNames)
+		Where("active = ?", false).
+		Where("deactivated_at IS NULL OR deactivated_at < ?", before.Local()).
+		Pluck("name", &groupNames)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return make([]string, 0), nil
@@ -293,46 +1425,138 @@ func (i *UamDAOImpl) GetDeactivatedGroupNames() ([]string, error) {
 	return groupNames, nil
 }
 
-//EraseDeactivatedGroups - deletes pernamently the groups, instead of just deactivate them
+//EraseDeactivatedGroups - deletes pernamently the groups, their memberships, their upload sessions and the info about their files (including the trashed ones)
+//the blobs of the files are kept without references, until their content is erased
 func (i *UamDAOImpl) EraseDeactivatedGroups(groupNames []string) error {
 	return i.dbConn.Transaction(func(tx *gorm.DB) error {
+		blobIDs := make([]uint, 0)
 		for _, groupName := range groupNames {
-			result := tx.Unscoped().Where("name = ?", groupName).Delete(&models.Group{})
+			groupIDs := tx.Table("groups").Select("id").Where("name = ?", groupName)
+
+			var groupBlobIDs []uint
+			result := tx.Table("file_infos").
+				Where("group_id IN (?)", groupIDs).
+				Where("blob_id IS NOT NULL").
+				Pluck("blob_id", &groupBlobIDs)
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt fetch the contents of the inactive groups")
+			}
+			blobIDs = append(blobIDs, groupBlobIDs...)
+
+			result = tx.Where("file_id IN (?)", tx.Table("file_infos").Select("id").Where("group_id IN (?)", groupIDs)).
+				Delete(&models.ShareLink{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the share links of the inactive groups")
+			}
+
+			result = tx.Where("group_id IN (?)", groupIDs).Delete(&models.FileTag{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the tags of the files of the inactive groups")
+			}
+
+			result = tx.Where("group_id IN (?)", groupIDs).Delete(&models.FileInfo{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the files of the inactive groups")
+			}
+
+			result = tx.Where("upload_session_id IN (?)", tx.Table("upload_sessions").Select("id").Where("group_id IN (?)", groupIDs)).
+				Delete(&models.UploadChunk{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the upload chunks of the inactive groups")
+			}
+
+			result = tx.Where("group_id IN (?)", groupIDs).Delete(&models.UploadSession{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the upload sessions of the inactive groups")
+			}
+
+			result = tx.Where("group_id IN (?)", groupIDs).Delete(&models.Membership{})
+			if result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the memberships of the inactive groups")
+			}
+
+			result = tx.Unscoped().Where("name = ?", groupName).Delete(&models.Group{})
 			if result.Error != nil {
 				return myerr.NewServerErrorWrap(result.Error, "Couldnt delete the inactive groups")
 			} else if result.RowsAffected == 0 {
 				fmt.Println("Warning. Tried to delete already deleted group")
 			}
 		}
-		return nil
+
+		return releaseBlobsWithConn(tx, blobIDs)
 	})
 }
 
-//GetAllGroups - retrieves all groups
-func (i *UamDAOImpl) GetAllGroups() ([]models.Group, error) {
-	var groups []models.Group
-	result := i.dbConn.Find(&groups)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return make([]models.Group, 0), nil
-	} else if result.Error != nil {
-		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching all groups")
+//GetAllGroups - retrieves a page of all active groups, the query is matched against the group names
+//returns the groups and the cursor of the next page, which is empty on the last page
+func (i *UamDAOImpl) GetAllGroups(options ListOptions) ([]models.Group, string, error) {
+	query := i.dbConn.Model(&models.Group{}).Where("groups.active = ?", true)
+	if options.Query != "" {
+		query = query.Where("lower(groups.name) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(strings.ToLower(options.Query))+"%")
 	}
-	return groups, nil
+
+	query, sortBy, err := groupListing.paginate(query, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	groups := make([]models.Group, 0)
+	if result := query.Find(&groups); result.Error != nil {
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with fetching all groups")
+	}
+
+	if len(groups) <= options.Limit {
+		return groups, "", nil
+	}
+
+	groups = groups[:options.Limit]
+	last := groups[len(groups)-1]
+	keys := map[string]interface{}{"name": last.Name, "created_at": last.CreatedAt, "id": last.ID}
+	return groups, nextCursor(options, sortBy, keys[sortBy], last.ID), nil
 }
 
-//GetAllUsers - retrieves all users
-func (i *UamDAOImpl) GetAllUsers() ([]models.User, error) {
-	var users []models.User
-	result := i.dbConn.Find(&users)
-	if result.Error != nil {
-		return nil, myerr.NewServerErrorWrap(result.Error, "Problem with fetching all users")
+//GetAllUsers - retrieves a page of all active users, the query is matched against the usernames
+//returns the users and the cursor of the next page, which is empty on the last page
+func (i *UamDAOImpl) GetAllUsers(options ListOptions) ([]models.User, string, error) {
+	query := i.dbConn.Model(&models.User{}).Where("users.active = ?", true)
+	if options.Query != "" {
+		query = query.Where("lower(users.username) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(strings.ToLower(options.Query))+"%")
+	}
+
+	query, sortBy, err := userListing.paginate(query, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	users := make([]models.User, 0)
+	if result := query.Find(&users); result.Error != nil {
+		return nil, "", myerr.NewServerErrorWrap(result.Error, "Problem with fetching all users")
+	}
+
+	if len(users) <= options.Limit {
+		return users, "", nil
 	}
-	return users, nil
+
+	users = users[:options.Limit]
+	last := users[len(users)-1]
+	keys := map[string]interface{}{"username": last.Username, "created_at": last.CreatedAt, "id": last.ID}
+	return users, nextCursor(options, sortBy, keys[sortBy], last.ID), nil
+}
+
+//memberDetails - user together with the time, when he joined the group, needed for the cursor of the members
+type memberDetails struct {
+	models.User
+	JoinedAt time.Time
 }
 
-//GetAllUsersInGroup - retrieves all users in a group
-func (i *UamDAOImpl) GetAllUsersInGroup(userID uint, groupName string) ([]models.User, error) {
-	var users []models.User
+//GetAllUsersInGroup - retrieves a page of the users in a group, the query is matched against the usernames
+//and the members can be filtered by their role
+//returns the users and the cursor of the next page, which is empty on the last page
+func (i *UamDAOImpl) GetAllUsersInGroup(userID uint, groupName string, options ListOptions) ([]models.User, string, error) {
+	var (
+		users  []models.User
+		cursor string
+	)
 	err := i.dbConn.Transaction(func(tx *gorm.DB) error {
 		group, errGet := getGroupWithConn(tx, groupName)
 		if _, ok := errGet.(*myerr.ItemNotFoundError); ok || !group.Active {
@@ -353,18 +1577,140 @@ func (i *UamDAOImpl) GetAllUsersInGroup(userID uint, groupName string) ([]models
 			return myerr.NewClientError("The user is not a member of the group")
 		}
 
-		log.Printf("Group id %d\n", group.ID)
+		query := tx.Table("users").
+			Select("users.*, memberships.created_at AS joined_at").
+			Joins("inner join memberships on users.id = memberships.user_id").
+			Where("memberships.group_id = ?", group.ID)
+		if options.Query != "" {
+			query = query.Where("lower(users.username) LIKE ? ESCAPE '\\'", "%"+escapeLikePattern(strings.ToLower(options.Query))+"%")
+		}
+		if options.Role != "" {
+			query = query.Where("memberships.role = ?", options.Role)
+		}
 
-		result = tx.Table("users").Joins("inner join memberships on users.id = memberships.user_id").
-			Where("memberships.group_id = ?", group.ID).Find(&users)
+		query, sortBy, err := memberListing.paginate(query, options)
+		if err != nil {
+			return err
+		}
 
-		if result.Error != nil {
+		members := make([]memberDetails, 0)
+		if result = query.Scan(&members); result.Error != nil {
 			return myerr.NewServerErrorWrap(result.Error, "Problem with the lookup of users in db")
 		}
 
+		if len(members) > options.Limit {
+			members = members[:options.Limit]
+			last := members[len(members)-1]
+			keys := map[string]interface{}{"username": last.Username, "joined_at": last.JoinedAt, "id": last.ID}
+			cursor = nextCursor(options, sortBy, keys[sortBy], last.ID)
+		}
+
+		users = make([]models.User, 0, len(members))
+		for _, member := range members {
+			users = append(users, member.User)
+		}
 		return nil
 	})
-	return users, err
+	return users, cursor, err
+}
+
+func changeGroupOwnerWithConn(tx *gorm.DB, group models.Group, newOwnerID uint) error {
+	log.Printf("Transferring the ownership of group with id [%d] from user [%d] to user [%d]", group.ID, group.OwnerID, newOwnerID)
+	result := tx.Model(&models.Membership{}).
+		Where("user_id = ?", newOwnerID).
+		Where("group_id = ?", group.ID).
+		Update("role", models.RoleOwner)
+
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with changing the role of the new owner")
+	} else if result.RowsAffected == 0 {
+		return myerr.NewClientError("The new owner should be a member of the group")
+	}
+
+	result = tx.Model(&models.Membership{}).
+		Where("user_id = ?", group.OwnerID).
+		Where("group_id = ?", group.ID).
+		Update("role", models.RoleAdmin)
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with changing the role of the former owner")
+	}
+
+	if result = tx.Model(&group).Update("owner_id", newOwnerID); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with changing the owner of the group")
+	}
+	log.Printf("Ownership of group with id [%d] is transferred to user [%d]", group.ID, newOwnerID)
+
+	return nil
+}
+
+//createAuditEventWithConn - stores the audit event, if one is given, as a part of the change, which it describes
+//the non zero ids of the group, the user and the file, affected by the change, are set to the event
+func createAuditEventWithConn(dbConn *gorm.DB, event *models.AuditEvent, groupID uint, userID uint, fileID uint) error {
+	if event == nil {
+		return nil
+	}
+
+	if groupID != 0 {
+		event.GroupID = &groupID
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	if fileID != 0 {
+		event.FileID = &fileID
+	}
+
+	if result := dbConn.Create(event); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the creation of the audit event")
+	}
+	return nil
+}
+
+func deleteInvitationWithConn(tx *gorm.DB, groupID uint, userID uint) (bool, error) {
+	log.Printf("Removing invitation for user with id [%d] in group with id [%d]", userID, groupID)
+	result := tx.Where("group_id = ?", groupID).
+		Where("user_id = ?", userID).
+		Delete(&models.Invitation{})
+
+	if result.Error != nil {
+		return false, myerr.NewServerErrorWrap(result.Error, "Problem with the deletion of the invitation")
+	}
+	return result.RowsAffected != 0, nil
+}
+
+func changePasswordWithConn(tx *gorm.DB, userID uint, password string) error {
+	result := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", password)
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the change of the password")
+	} else if result.RowsAffected == 0 {
+		return myerr.NewItemNotFoundError("User with that id does not exist")
+	}
+
+	log.Printf("Revoking the sessions of user with id [%d]\n", userID)
+	result = tx.Model(&models.Session{}).
+		Where("user_id = ?", userID).
+		Where("revoked = ?", false).
+		Update("revoked", true)
+	if result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the sessions of the user")
+	}
+
+	//the access tokens dont depend on the sessions, so a leaked password could have been used to create some of them
+	if result = tx.Where("user_id = ?", userID).Delete(&models.AccessToken{}); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with the revocation of the access tokens of the user")
+	}
+	return nil
+}
+
+func deleteTwoFactorWithConn(tx *gorm.DB, userID uint) error {
+	if result := tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the two-factor authentication")
+	}
+
+	if result := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}); result.Error != nil {
+		return myerr.NewServerErrorWrap(result.Error, "Problem with deletion of the recovery codes")
+	}
+	return nil
 }
 
 func getUserWithConn(dbConn *gorm.DB, username string) (models.User, error) {
@@ -372,6 +1718,7 @@ func getUserWithConn(dbConn *gorm.DB, username string) (models.User, error) {
 
 	result := dbConn.Table("users").
 		Where("username = ?", username).
+		Where("active = ?", true).
 		Find(&user)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
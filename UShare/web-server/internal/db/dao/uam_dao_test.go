@@ -9,6 +9,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	"github.com/jackc/pgconn"
 	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -114,7 +115,7 @@ var _ = Describe("UamDAO", func() {
 							WithArgs(username).
 							WillReturnRows(rows)
 						mock.ExpectQuery("INSERT INTO \"users\"").
-							WithArgs(Any{}, Any{}, username, password). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+							WithArgs(Any{}, Any{}, username, password, true, nil). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 							WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 						mock.ExpectCommit()
 					})
@@ -133,7 +134,7 @@ var _ = Describe("UamDAO", func() {
 							WithArgs(username).
 							WillReturnRows(rows)
 						mock.ExpectQuery("INSERT INTO \"users\"").
-							WithArgs(Any{}, Any{}, username, password). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+							WithArgs(Any{}, Any{}, username, password, true, nil). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 							WillReturnError(fmt.Errorf("some error"))
 						mock.ExpectRollback()
 					})
@@ -146,21 +147,44 @@ var _ = Describe("UamDAO", func() {
 						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
+
+				Context("and a concurrent creation takes the username", func() {
+					BeforeEach(func() {
+						mock.ExpectBegin()
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
+							WithArgs(username).
+							WillReturnRows(rows)
+						mock.ExpectQuery("INSERT INTO \"users\"").
+							WithArgs(Any{}, Any{}, username, password, true, nil).
+							WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+						mock.ExpectRollback()
+					})
+
+					It("returns client error", func() {
+						err := uamDao.CreateUser(username, password)
+						Expect(err).To(HaveOccurred())
+						_, ok := err.(*myerr.ClientError)
+						Expect(ok).To(Equal(true))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
 			})
 		})
 
 	})
 
-	Context("Delete user", func() {
+	Context("DeactivateUser", func() {
 		When("request if user exists fails", func() {
 			BeforeEach(func() {
+				mock.ExpectBegin()
 				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
-					WithArgs(userID).
+					WithArgs(userID, true).
 					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
 			})
 
 			It("propagates error", func() {
-				err := uamDao.DeleteUser(uint(userID))
+				err := uamDao.DeactivateUser(uint(userID))
 				Expect(err).To(HaveOccurred())
 				_, ok := err.(*myerr.ServerError)
 				Expect(ok).To(Equal(true))
@@ -172,14 +196,15 @@ var _ = Describe("UamDAO", func() {
 			Context("and user does not exist", func() {
 				BeforeEach(func() {
 					rows := sqlmock.NewRows([]string{"count"}).AddRow(0)
+					mock.ExpectBegin()
 					mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
-						WithArgs(userID).
+						WithArgs(userID, true).
 						WillReturnRows(rows)
-
+					mock.ExpectRollback()
 				})
 
 				It("propagates error", func() {
-					err := uamDao.DeleteUser(uint(userID))
+					err := uamDao.DeactivateUser(uint(userID))
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ItemNotFoundError)
 					Expect(ok).To(Equal(true))
@@ -191,41 +216,210 @@ var _ = Describe("UamDAO", func() {
 				var existCountRows *sqlmock.Rows
 				BeforeEach(func() {
 					existCountRows = sqlmock.NewRows([]string{"count"}).AddRow(1)
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
+						WithArgs(userID, true).
+						WillReturnRows(existCountRows)
 				})
 
-				Context("and deletion query fails", func() {
+				Context("and lookup of owned groups fails", func() {
 					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
-							WithArgs(userID).
-							WillReturnRows(existCountRows)
-						mock.ExpectExec("DELETE FROM \"users\"").
-							WithArgs(userID). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(userID, true).
 							WillReturnError(fmt.Errorf("some error"))
+						mock.ExpectRollback()
 					})
 
 					It("propagates error", func() {
-						err := uamDao.DeleteUser(userID)
+						err := uamDao.DeactivateUser(userID)
 						Expect(err).To(HaveOccurred())
 						_, ok := err.(*myerr.ServerError)
 						Expect(ok).To(Equal(true))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
 
-				Context("and deletion query is successful", func() {
+				Context("and user owns a group without other members", func() {
 					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "users"`)).
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(userID, true).
+							WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+								AddRow(groupID, groupName, userID, true))
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "memberships"`)).
+							WithArgs(groupID, userID).
+							WillReturnError(gorm.ErrRecordNotFound)
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups" SET "active"`)).
+							WithArgs(false, Any{}, Any{}, groupID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "memberships"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "sessions"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_resets"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "two_factors"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_challenges"`)).
 							WithArgs(userID).
-							WillReturnRows(existCountRows)
-						mock.ExpectExec("DELETE FROM \"users\"").
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "external_identities"`)).
 							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "share_links" SET "revoked"`)).
+							WithArgs(true, Any{}, userID, false).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "active"`)).
+							WithArgs(false, Any{}, Any{}, userID).
 							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectCommit()
 					})
 
-					It("succeeds", func() {
-						err := uamDao.DeleteUser(uint(userID))
+					It("deactivates the group", func() {
+						err := uamDao.DeactivateUser(userID)
 						Expect(err).NotTo(HaveOccurred())
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
+				Context("and user owns a group with other members", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(userID, true).
+							WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+								AddRow(groupID, groupName, userID, true))
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "memberships"`)).
+							WithArgs(groupID, userID).
+							WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}).
+								AddRow(1, groupID, userID+1, "admin"))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+							WithArgs("owner", Any{}, userID+1, groupID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+							WithArgs("admin", Any{}, userID, groupID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups" SET "owner_id"`)).
+							WithArgs(userID+1, Any{}, groupID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "memberships"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "sessions"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_resets"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "two_factors"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_challenges"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "external_identities"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "share_links" SET "revoked"`)).
+							WithArgs(true, Any{}, userID, false).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "active"`)).
+							WithArgs(false, Any{}, Any{}, userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectCommit()
+					})
+
+					It("transfers the ownership", func() {
+						err := uamDao.DeactivateUser(userID)
+						Expect(err).NotTo(HaveOccurred())
+					})
+				})
+
+				Context("and user doesnt own groups", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(userID, true).
+							WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "memberships"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 2))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "sessions"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_resets"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "two_factors"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_challenges"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "external_identities"`)).
+							WithArgs(userID).
+							WillReturnResult(sqlmock.NewResult(0, 0))
+						mock.ExpectExec(regexp.QuoteMeta(`UPDATE "share_links" SET "revoked"`)).
+							WithArgs(true, Any{}, userID, false).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+					})
+
+					Context("and deactivation query fails", func() {
+						BeforeEach(func() {
+							mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "active"`)).
+								WithArgs(false, Any{}, Any{}, userID).
+								WillReturnError(fmt.Errorf("some error"))
+							mock.ExpectRollback()
+						})
+
+						It("propagates error", func() {
+							err := uamDao.DeactivateUser(userID)
+							Expect(err).To(HaveOccurred())
+							_, ok := err.(*myerr.ServerError)
+							Expect(ok).To(Equal(true))
+							Expect(mock.ExpectationsWereMet()).To(BeNil())
+						})
+					})
+
+					Context("and deactivation query is successful", func() {
+						BeforeEach(func() {
+							mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "active"`)).
+								WithArgs(false, Any{}, Any{}, userID).
+								WillReturnResult(sqlmock.NewResult(0, 1))
+							mock.ExpectCommit()
+						})
+
+						It("succeeds", func() {
+							err := uamDao.DeactivateUser(uint(userID))
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mock.ExpectationsWereMet()).To(BeNil())
+						})
 					})
 				})
 			})
@@ -236,7 +430,7 @@ var _ = Describe("UamDAO", func() {
 		When("request to get userID fails", func() {
 			BeforeEach(func() {
 				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-					WithArgs(username).
+					WithArgs(username, true).
 					WillReturnError(fmt.Errorf("some error"))
 			})
 
@@ -253,7 +447,7 @@ var _ = Describe("UamDAO", func() {
 			Context("and user does not exist", func() {
 				BeforeEach(func() {
 					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-						WithArgs(username).
+						WithArgs(username, true).
 						WillReturnError(gorm.ErrRecordNotFound)
 				})
 
@@ -273,7 +467,7 @@ var _ = Describe("UamDAO", func() {
 					mockTime = time.Now()
 					rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "username", "password"}).AddRow(1, mockTime, mockTime, username, password)
 					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-						WithArgs(username).
+						WithArgs(username, true).
 						WillReturnRows(rows)
 				})
 
@@ -303,7 +497,7 @@ var _ = Describe("UamDAO", func() {
 			})
 
 			It("propagates error", func() {
-				err := uamDao.CreateGroup(uint(userID), groupName)
+				err := uamDao.CreateGroup(uint(userID), groupName, nil)
 				Expect(err).To(HaveOccurred())
 				_, ok := err.(*myerr.ServerError)
 				Expect(ok).To(Equal(true))
@@ -324,7 +518,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.CreateGroup(uint(userID), groupName)
+					err := uamDao.CreateGroup(uint(userID), groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ClientError)
 					Expect(ok).To(Equal(true))
@@ -346,13 +540,13 @@ var _ = Describe("UamDAO", func() {
 							WithArgs(groupName).
 							WillReturnRows(zeroCountRows)
 						mock.ExpectQuery("INSERT INTO \"groups\"").
-							WithArgs(Any{}, Any{}, groupName, userID, true). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+							WithArgs(Any{}, Any{}, groupName, userID, true, 1073741824, 104857600, false, nil). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 							WillReturnError(fmt.Errorf("some error"))
 						mock.ExpectRollback()
 					})
 
 					It("propagates error", func() {
-						err := uamDao.CreateGroup(uint(userID), groupName)
+						err := uamDao.CreateGroup(uint(userID), groupName, nil)
 						Expect(err).To(HaveOccurred())
 						_, ok := err.(*myerr.ServerError)
 						Expect(ok).To(Equal(true))
@@ -360,6 +554,27 @@ var _ = Describe("UamDAO", func() {
 					})
 				})
 
+				Context("and a concurrent creation takes the group name", func() {
+					BeforeEach(func() {
+						mock.ExpectBegin()
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "groups"`)).
+							WithArgs(groupName).
+							WillReturnRows(zeroCountRows)
+						mock.ExpectQuery("INSERT INTO \"groups\"").
+							WithArgs(Any{}, Any{}, groupName, userID, true, 1073741824, 104857600, false, nil).
+							WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+						mock.ExpectRollback()
+					})
+
+					It("returns client error", func() {
+						err := uamDao.CreateGroup(uint(userID), groupName, nil)
+						Expect(err).To(HaveOccurred())
+						_, ok := err.(*myerr.ClientError)
+						Expect(ok).To(Equal(true))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
 				Context("and group creation query is successful", func() {
 					var creationRows *sqlmock.Rows
 					var group models.Group
@@ -380,16 +595,16 @@ var _ = Describe("UamDAO", func() {
 								WithArgs(groupName).
 								WillReturnRows(zeroCountRows)
 							mock.ExpectQuery("INSERT INTO \"groups\"").
-								WithArgs(Any{}, Any{}, groupName, userID, true). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+								WithArgs(Any{}, Any{}, groupName, userID, true, 1073741824, 104857600, false, nil). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 								WillReturnRows(creationRows)
 							mock.ExpectQuery("INSERT INTO \"memberships\"").
-								WithArgs(Any{}, Any{}, group.ID, group.OwnerID). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+								WithArgs(Any{}, Any{}, group.ID, group.OwnerID, "owner"). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 								WillReturnError(fmt.Errorf("some error"))
 							mock.ExpectRollback()
 						})
 
 						It("propagates error", func() {
-							err := uamDao.CreateGroup(uint(userID), groupName)
+							err := uamDao.CreateGroup(uint(userID), groupName, nil)
 							Expect(err).To(HaveOccurred())
 							_, ok := err.(*myerr.ServerError)
 							Expect(ok).To(Equal(true))
@@ -404,16 +619,16 @@ var _ = Describe("UamDAO", func() {
 								WithArgs(groupName).
 								WillReturnRows(zeroCountRows)
 							mock.ExpectQuery("INSERT INTO \"groups\"").
-								WithArgs(Any{}, Any{}, groupName, userID, true). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+								WithArgs(Any{}, Any{}, groupName, userID, true, 1073741824, 104857600, false, nil). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 								WillReturnRows(creationRows)
 							mock.ExpectQuery("INSERT INTO \"memberships\"").
-								WithArgs(Any{}, Any{}, group.ID, group.OwnerID). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
+								WithArgs(Any{}, Any{}, group.ID, group.OwnerID, "owner"). // driver.NamedValue - {Name: Ordinal:1 Value:2020-12-28 01:22:59.344298 +0200 EET}"
 								WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 							mock.ExpectCommit()
 						})
 
 						It("succeeds", func() {
-							err := uamDao.CreateGroup(uint(userID), groupName)
+							err := uamDao.CreateGroup(uint(userID), groupName, nil)
 							Expect(err).NotTo(HaveOccurred())
 							Expect(mock.ExpectationsWereMet()).To(BeNil())
 						})
@@ -423,7 +638,7 @@ var _ = Describe("UamDAO", func() {
 		})
 	})
 
-	Context("AddUserToGroup", func() {
+	Context("CreateInvitation", func() {
 		When("get group request fails", func() {
 			Context("and there is a problem with the database", func() {
 				BeforeEach(func() {
@@ -435,7 +650,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+					err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
@@ -453,7 +668,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+					err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ItemNotFoundError)
 					Expect(ok).To(Equal(true))
@@ -475,7 +690,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+					err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ClientError)
 					Expect(ok).To(Equal(true))
@@ -484,26 +699,6 @@ var _ = Describe("UamDAO", func() {
 			})
 
 			Context("and group is active", func() {
-				Context("and you arent the owner of the group", func() {
-					BeforeEach(func() {
-						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
-							AddRow(1, time.Now(), time.Now(), groupName, userID+1, true)
-						mock.ExpectBegin()
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-							WithArgs(groupName).
-							WillReturnRows(rows)
-						mock.ExpectRollback()
-					})
-
-					It("propagates error", func() {
-						err := uamDao.AddUserToGroup(uint(userID), username, groupName)
-						Expect(err).To(HaveOccurred())
-						_, ok := err.(*myerr.ClientError)
-						Expect(ok).To(Equal(true))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
-
 				Context("and you are the owner of the group", func() {
 					var groupRow *sqlmock.Rows
 					BeforeEach(func() {
@@ -519,13 +714,13 @@ var _ = Describe("UamDAO", func() {
 									WithArgs(groupName).
 									WillReturnRows(groupRow)
 								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-									WithArgs(username).
+									WithArgs(username, true).
 									WillReturnError(fmt.Errorf("some error"))
 								mock.ExpectRollback()
 							})
 
 							It("propagates error", func() {
-								err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+								err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 								Expect(err).To(HaveOccurred())
 								_, ok := err.(*myerr.ServerError)
 								Expect(ok).To(Equal(true))
@@ -540,13 +735,13 @@ var _ = Describe("UamDAO", func() {
 									WithArgs(groupName).
 									WillReturnRows(groupRow)
 								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-									WithArgs(username).
+									WithArgs(username, true).
 									WillReturnError(gorm.ErrRecordNotFound)
 								mock.ExpectRollback()
 							})
 
 							It("propagates error", func() {
-								err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+								err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 								Expect(err).To(HaveOccurred())
 								_, ok := err.(*myerr.ItemNotFoundError)
 								Expect(ok).To(Equal(true))
@@ -571,7 +766,7 @@ var _ = Describe("UamDAO", func() {
 										WithArgs(groupName).
 										WillReturnRows(groupRow)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
+										WithArgs(username, true).
 										WillReturnRows(userRows)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
 										WithArgs(groupID, userID).
@@ -580,7 +775,7 @@ var _ = Describe("UamDAO", func() {
 								})
 
 								It("propagates error", func() {
-									err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+									err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 									Expect(err).To(HaveOccurred())
 									_, ok := err.(*myerr.ServerError)
 									Expect(ok).To(Equal(true))
@@ -594,7 +789,7 @@ var _ = Describe("UamDAO", func() {
 										WithArgs(groupName).
 										WillReturnRows(groupRow)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
+										WithArgs(username, true).
 										WillReturnRows(userRows)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
 										WithArgs(groupID, userID).
@@ -603,7 +798,7 @@ var _ = Describe("UamDAO", func() {
 								})
 
 								It("propagates error", func() {
-									err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+									err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 									Expect(err).To(HaveOccurred())
 									_, ok := err.(*myerr.ClientError)
 									Expect(ok).To(Equal(true))
@@ -613,54 +808,78 @@ var _ = Describe("UamDAO", func() {
 						})
 
 						Context("and request if membership exists is successful", func() {
-							Context("and creation of membership fails", func() {
+							BeforeEach(func() {
+								mock.ExpectBegin()
+								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+									WithArgs(groupName).
+									WillReturnRows(groupRow)
+								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+									WithArgs(username, true).
+									WillReturnRows(userRows)
+								mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
+									WithArgs(groupID, userID).
+									WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+							})
+
+							Context("and the user is already invited", func() {
 								BeforeEach(func() {
-									mock.ExpectBegin()
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-										WithArgs(groupName).
-										WillReturnRows(groupRow)
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
-										WillReturnRows(userRows)
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
-										WithArgs(groupID, userID).
-										WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-									mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
-										WithArgs(Any{}, Any{}, groupID, userID).
-										WillReturnError(fmt.Errorf("some error"))
+									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "invitations"`)).
+										WithArgs(groupID, userID, Any{}).
+										WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 									mock.ExpectRollback()
 								})
 
 								It("propagates error", func() {
-									err := uamDao.AddUserToGroup(uint(userID), username, groupName)
+									err := uamDao.CreateInvitation(userID+1, username, groupName, nil, nil)
 									Expect(err).To(HaveOccurred())
-									_, ok := err.(*myerr.ServerError)
+									_, ok := err.(*myerr.ClientError)
 									Expect(ok).To(Equal(true))
 									Expect(mock.ExpectationsWereMet()).To(BeNil())
 								})
 							})
-							Context("and creation succeeds", func() {
+
+							Context("and the user isnt invited", func() {
+								var expiresAt time.Time
 								BeforeEach(func() {
-									mock.ExpectBegin()
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-										WithArgs(groupName).
-										WillReturnRows(groupRow)
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
-										WillReturnRows(userRows)
-									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
-										WithArgs(groupID, userID).
+									expiresAt = time.Now().Add(time.Hour)
+									mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "invitations"`)).
+										WithArgs(groupID, userID, Any{}).
 										WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-									mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
-										WithArgs(Any{}, Any{}, groupID, userID).
-										WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-									mock.ExpectCommit()
+									mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+										WithArgs(groupID, userID).
+										WillReturnResult(sqlmock.NewResult(0, 0))
 								})
 
-								It("returns no error", func() {
-									err := uamDao.AddUserToGroup(uint(userID), username, groupName)
-									Expect(err).NotTo(HaveOccurred())
-									Expect(mock.ExpectationsWereMet()).To(BeNil())
+								Context("and creation of invitation fails", func() {
+									BeforeEach(func() {
+										mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "invitations"`)).
+											WithArgs(Any{}, Any{}, groupID, userID, userID+1, expiresAt).
+											WillReturnError(fmt.Errorf("some error"))
+										mock.ExpectRollback()
+									})
+
+									It("propagates error", func() {
+										err := uamDao.CreateInvitation(userID+1, username, groupName, &expiresAt, nil)
+										Expect(err).To(HaveOccurred())
+										_, ok := err.(*myerr.ServerError)
+										Expect(ok).To(Equal(true))
+										Expect(mock.ExpectationsWereMet()).To(BeNil())
+									})
+								})
+
+								Context("and creation succeeds", func() {
+									BeforeEach(func() {
+										mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "invitations"`)).
+											WithArgs(Any{}, Any{}, groupID, userID, userID+1, expiresAt).
+											WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+										mock.ExpectCommit()
+									})
+
+									It("returns no error", func() {
+										err := uamDao.CreateInvitation(userID+1, username, groupName, &expiresAt, nil)
+										Expect(err).NotTo(HaveOccurred())
+										Expect(mock.ExpectationsWereMet()).To(BeNil())
+									})
 								})
 							})
 						})
@@ -672,71 +891,306 @@ var _ = Describe("UamDAO", func() {
 
 	})
 
-	Context("RemoveUserFromGroup", func() {
+	Context("GetInvitations", func() {
+		When("the query fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT invitations.id, groups.name AS group_name`)).
+					WithArgs(userID, true, Any{}).
+					WillReturnError(fmt.Errorf("some error"))
+			})
 
-		When("get group request fails", func() {
-			Context("problem with the database", func() {
+			It("propagates error", func() {
+				_, err := uamDao.GetInvitations(userID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the query succeeds", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT invitations.id, groups.name AS group_name`)).
+					WithArgs(userID, true, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "inviter", "created_at", "expires_at"}).
+						AddRow(1, groupName, username, time.Now(), nil))
+			})
+
+			It("returns the invitations", func() {
+				invitations, err := uamDao.GetInvitations(userID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(invitations).To(HaveLen(1))
+				Expect(invitations[0].GroupName).To(Equal(groupName))
+				Expect(invitations[0].Inviter).To(Equal(username))
+				Expect(invitations[0].ExpiresAt).To(BeNil())
+			})
+		})
+	})
+
+	Context("AcceptInvitation", func() {
+		var groupRow *sqlmock.Rows
+		BeforeEach(func() {
+			groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+				AddRow(groupID, time.Now(), time.Now(), groupName, userID+1, true)
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+				WithArgs(groupName).
+				WillReturnRows(groupRow)
+		})
+
+		When("there isnt such invitation", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnError(gorm.ErrRecordNotFound)
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.AcceptInvitation(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the invitation has expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "expires_at"}).
+						AddRow(1, groupID, userID, time.Now().Add(-time.Hour)))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.AcceptInvitation(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("The invitation has expired"))
+			})
+		})
+
+		When("the invitation is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "expires_at"}).
+						AddRow(1, groupID, userID, nil))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+					WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			})
+
+			Context("and creation of membership fails", func() {
 				BeforeEach(func() {
-					mock.ExpectBegin()
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-						WithArgs(groupName).
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
+						WithArgs(Any{}, Any{}, groupID, userID, "contributor").
 						WillReturnError(fmt.Errorf("some error"))
 					mock.ExpectRollback()
 				})
 
 				It("propagates error", func() {
-					err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+					err := uamDao.AcceptInvitation(userID, groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 			})
 
-			Context("and group doesnt exist", func() {
+			Context("and the user is already a member", func() {
 				BeforeEach(func() {
-					mock.ExpectBegin()
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-						WithArgs(groupName).
-						WillReturnError(gorm.ErrRecordNotFound)
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
+						WithArgs(Any{}, Any{}, groupID, userID, "contributor").
+						WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
 					mock.ExpectRollback()
 				})
 
-				It("propagates error", func() {
-					err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+				It("returns client error", func() {
+					err := uamDao.AcceptInvitation(userID, groupName, nil)
 					Expect(err).To(HaveOccurred())
-					_, ok := err.(*myerr.ItemNotFoundError)
-					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
+					Expect(err.Error()).To(ContainSubstring("already a member"))
 				})
 			})
-		})
 
-		When("get group request succeeds", func() {
-			Context("and group is deactivated", func() {
+			Context("and the group is deleted concurrently", func() {
 				BeforeEach(func() {
-					deactivatedGroupRows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
-						AddRow(groupID, time.Now(), time.Now(), groupName, userID, false)
-					mock.ExpectBegin()
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-						WithArgs(groupName).
-						WillReturnRows(deactivatedGroupRows)
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
+						WithArgs(Any{}, Any{}, groupID, userID, "contributor").
+						WillReturnError(&pgconn.PgError{Code: pgForeignKeyViolation})
 					mock.ExpectRollback()
 				})
 
-				It("propagates error", func() {
-					err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+				It("returns client error", func() {
+					err := uamDao.AcceptInvitation(userID, groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ClientError)
 					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 			})
 
-			Context("and group is active", func() {
-				var groupRow *sqlmock.Rows
+			Context("and creation of membership succeeds", func() {
 				BeforeEach(func() {
-					groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "memberships"`)).
+						WithArgs(Any{}, Any{}, groupID, userID, "contributor").
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectCommit()
+				})
+
+				It("creates the membership", func() {
+					err := uamDao.AcceptInvitation(userID, groupName, nil)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+	})
+
+	Context("DeclineInvitation", func() {
+		BeforeEach(func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+				WithArgs(groupName).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+					AddRow(groupID, groupName, userID+1, true))
+		})
+
+		When("there isnt such invitation", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.DeclineInvitation(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the invitation exists", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("removes the invitation", func() {
+				err := uamDao.DeclineInvitation(userID, groupName, nil)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("RevokeInvitation", func() {
+		BeforeEach(func() {
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+				WithArgs(groupName).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+					AddRow(groupID, groupName, userID+1, true))
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+				WithArgs(username, true).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, username))
+		})
+
+		When("there isnt such invitation", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.RevokeInvitation(username, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the invitation exists", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "invitations"`)).
+					WithArgs(groupID, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("removes the invitation", func() {
+				err := uamDao.RevokeInvitation(username, groupName, nil)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("RemoveUserFromGroup", func() {
+
+		When("get group request fails", func() {
+			Context("problem with the database", func() {
+				BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+						WithArgs(groupName).
+						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
+				})
+
+				It("propagates error", func() {
+					err := uamDao.RemoveUserFromGroup(username, groupName, nil)
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and group doesnt exist", func() {
+				BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+						WithArgs(groupName).
+						WillReturnError(gorm.ErrRecordNotFound)
+					mock.ExpectRollback()
+				})
+
+				It("propagates error", func() {
+					err := uamDao.RemoveUserFromGroup(username, groupName, nil)
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ItemNotFoundError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		When("get group request succeeds", func() {
+			Context("and group is deactivated", func() {
+				BeforeEach(func() {
+					deactivatedGroupRows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+						AddRow(groupID, time.Now(), time.Now(), groupName, userID, false)
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+						WithArgs(groupName).
+						WillReturnRows(deactivatedGroupRows)
+					mock.ExpectRollback()
+				})
+
+				It("propagates error", func() {
+					err := uamDao.RemoveUserFromGroup(username, groupName, nil)
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ClientError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and group is active", func() {
+				var groupRow *sqlmock.Rows
+				BeforeEach(func() {
+					groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
 						AddRow(groupID, time.Now(), time.Now(), groupName, userID, true)
 				})
 
@@ -748,13 +1202,13 @@ var _ = Describe("UamDAO", func() {
 								WithArgs(groupName).
 								WillReturnRows(groupRow)
 							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-								WithArgs(username).
+								WithArgs(username, true).
 								WillReturnError(fmt.Errorf("some error"))
 							mock.ExpectRollback()
 						})
 
 						It("propagates error", func() {
-							err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+							err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 							Expect(err).To(HaveOccurred())
 							_, ok := err.(*myerr.ServerError)
 							Expect(ok).To(Equal(true))
@@ -769,13 +1223,13 @@ var _ = Describe("UamDAO", func() {
 								WithArgs(groupName).
 								WillReturnRows(groupRow)
 							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-								WithArgs(username).
+								WithArgs(username, true).
 								WillReturnError(gorm.ErrRecordNotFound)
 							mock.ExpectRollback()
 						})
 
 						It("propagates error", func() {
-							err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+							err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 							Expect(err).To(HaveOccurred())
 							_, ok := err.(*myerr.ItemNotFoundError)
 							Expect(ok).To(Equal(true))
@@ -797,27 +1251,6 @@ var _ = Describe("UamDAO", func() {
 							AddRow(targetUserID, time.Now(), time.Now(), username, password)
 					})
 
-					Context("and you arent the owner of the group or the targeted user", func() {
-						BeforeEach(func() {
-							mock.ExpectBegin()
-							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-								WithArgs(groupName).
-								WillReturnRows(groupRow)
-							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-								WithArgs(username).
-								WillReturnRows(userRows)
-							mock.ExpectRollback()
-						})
-
-						It("propagates error", func() {
-							err := uamDao.RemoveUserFromGroup(uint(userID+2), username, groupName)
-							Expect(err).To(HaveOccurred())
-							_, ok := err.(*myerr.ClientError)
-							Expect(ok).To(Equal(true))
-							Expect(mock.ExpectationsWereMet()).To(BeNil())
-						})
-					})
-
 					Context("and you are the owner of the group and target of the deletion", func() {
 
 						BeforeEach(func() {
@@ -829,13 +1262,13 @@ var _ = Describe("UamDAO", func() {
 								WithArgs(groupName).
 								WillReturnRows(groupRow)
 							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-								WithArgs(username).
+								WithArgs(username, true).
 								WillReturnRows(rows)
 							mock.ExpectRollback()
 						})
 
 						It("propagates error", func() {
-							err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+							err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 							Expect(err).To(HaveOccurred())
 							_, ok := err.(*myerr.ClientError)
 							Expect(ok).To(Equal(true))
@@ -851,7 +1284,7 @@ var _ = Describe("UamDAO", func() {
 									WithArgs(groupName).
 									WillReturnRows(groupRow)
 								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-									WithArgs(username).
+									WithArgs(username, true).
 									WillReturnRows(userRows)
 								mock.ExpectExec("DELETE FROM \"memberships\"").
 									WithArgs(targetUserID, groupID).
@@ -860,7 +1293,7 @@ var _ = Describe("UamDAO", func() {
 							})
 
 							It("propagates error", func() {
-								err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+								err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 								Expect(err).To(HaveOccurred())
 								_, ok := err.(*myerr.ServerError)
 								Expect(ok).To(Equal(true))
@@ -875,7 +1308,7 @@ var _ = Describe("UamDAO", func() {
 										WithArgs(groupName).
 										WillReturnRows(groupRow)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
+										WithArgs(username, true).
 										WillReturnRows(userRows)
 									mock.ExpectExec("DELETE FROM \"memberships\"").
 										WithArgs(targetUserID, groupID).
@@ -884,7 +1317,7 @@ var _ = Describe("UamDAO", func() {
 								})
 
 								It("propagates error", func() {
-									err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+									err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 									Expect(err).To(HaveOccurred())
 									_, ok := err.(*myerr.ClientError)
 									Expect(ok).To(Equal(true))
@@ -899,7 +1332,7 @@ var _ = Describe("UamDAO", func() {
 										WithArgs(groupName).
 										WillReturnRows(groupRow)
 									mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-										WithArgs(username).
+										WithArgs(username, true).
 										WillReturnRows(userRows)
 									mock.ExpectExec("DELETE FROM \"memberships\"").
 										WithArgs(targetUserID, groupID).
@@ -908,7 +1341,7 @@ var _ = Describe("UamDAO", func() {
 								})
 
 								It("propagates error", func() {
-									err := uamDao.RemoveUserFromGroup(uint(userID), username, groupName)
+									err := uamDao.RemoveUserFromGroup(username, groupName, nil)
 									Expect(err).NotTo(HaveOccurred())
 									Expect(mock.ExpectationsWereMet()).To(BeNil())
 								})
@@ -934,7 +1367,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.DeactivateGroup(uint(userID), groupName)
+					err := uamDao.DeactivateGroup(groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
@@ -952,7 +1385,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.DeactivateGroup(uint(userID), groupName)
+					err := uamDao.DeactivateGroup(groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ItemNotFoundError)
 					Expect(ok).To(Equal(true))
@@ -974,7 +1407,7 @@ var _ = Describe("UamDAO", func() {
 				})
 
 				It("propagates error", func() {
-					err := uamDao.DeactivateGroup(uint(userID), groupName)
+					err := uamDao.DeactivateGroup(groupName, nil)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ClientError)
 					Expect(ok).To(Equal(true))
@@ -989,39 +1422,43 @@ var _ = Describe("UamDAO", func() {
 						AddRow(groupID, time.Now(), time.Now(), groupName, userID, true)
 				})
 
-				Context("and you are not the owner of the targeted group", func() {
+				Context("and request to revoke invitations fails", func() {
 					BeforeEach(func() {
 						mock.ExpectBegin()
 						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
 							WithArgs(groupName).
 							WillReturnRows(groupRow)
+						mock.ExpectExec("DELETE FROM \"invitations\"").
+							WithArgs(groupID).
+							WillReturnError(fmt.Errorf("some error"))
 						mock.ExpectRollback()
 					})
-
 					It("propagates error", func() {
-						err := uamDao.DeactivateGroup(uint(userID+2), groupName)
+						err := uamDao.DeactivateGroup(groupName, nil)
 						Expect(err).To(HaveOccurred())
-						_, ok := err.(*myerr.ClientError)
+						_, ok := err.(*myerr.ServerError)
 						Expect(ok).To(Equal(true))
 						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
 
-				Context("and you are the owner of the targeted group", func() {
-
-					Context("and request to revoke memberships fail", func() {
+				Context("and request to revoke invitations succeeds", func() {
+					Context("and request to delete group fails", func() {
 						BeforeEach(func() {
 							mock.ExpectBegin()
 							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
 								WithArgs(groupName).
 								WillReturnRows(groupRow)
-							mock.ExpectExec("DELETE FROM \"memberships\"").
+							mock.ExpectExec("DELETE FROM \"invitations\"").
 								WithArgs(groupID).
+								WillReturnResult(sqlmock.NewResult(0, 0))
+							mock.ExpectExec("UPDATE \"groups\"").
+								WithArgs(false, Any{}, Any{}, groupID).
 								WillReturnError(fmt.Errorf("some error"))
 							mock.ExpectRollback()
 						})
 						It("propagates error", func() {
-							err := uamDao.DeactivateGroup(uint(userID), groupName)
+							err := uamDao.DeactivateGroup(groupName, nil)
 							Expect(err).To(HaveOccurred())
 							_, ok := err.(*myerr.ServerError)
 							Expect(ok).To(Equal(true))
@@ -1029,178 +1466,463 @@ var _ = Describe("UamDAO", func() {
 						})
 					})
 
-					Context("and request to revoke memberships succeeds", func() {
-						Context("and request to delete group fails", func() {
-							BeforeEach(func() {
-								mock.ExpectBegin()
-								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-									WithArgs(groupName).
-									WillReturnRows(groupRow)
-								mock.ExpectExec("DELETE FROM \"memberships\"").
-									WithArgs(groupID).
-									WillReturnResult(sqlmock.NewResult(0, 1))
-								mock.ExpectExec("UPDATE \"groups\"").
-									WithArgs(false, Any{}, groupID).
-									WillReturnError(fmt.Errorf("some error"))
-								mock.ExpectRollback()
-							})
-							It("propagates error", func() {
-								err := uamDao.DeactivateGroup(uint(userID), groupName)
-								Expect(err).To(HaveOccurred())
-								_, ok := err.(*myerr.ServerError)
-								Expect(ok).To(Equal(true))
-								Expect(mock.ExpectationsWereMet()).To(BeNil())
-							})
+					Context("and request to delete group succeeds", func() {
+						BeforeEach(func() {
+							mock.ExpectBegin()
+							mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+								WithArgs(groupName).
+								WillReturnRows(groupRow)
+							mock.ExpectExec("DELETE FROM \"invitations\"").
+								WithArgs(groupID).
+								WillReturnResult(sqlmock.NewResult(0, 0))
+							mock.ExpectExec("UPDATE \"groups\"").
+								WithArgs(false, Any{}, Any{}, groupID).
+								WillReturnResult(sqlmock.NewResult(0, 1))
+							mock.ExpectCommit()
 						})
-
-						Context("and request to delete group succeeds", func() {
-							BeforeEach(func() {
-								mock.ExpectBegin()
-								mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-									WithArgs(groupName).
-									WillReturnRows(groupRow)
-								mock.ExpectExec("DELETE FROM \"memberships\"").
-									WithArgs(groupID).
-									WillReturnResult(sqlmock.NewResult(0, 1))
-								mock.ExpectExec("UPDATE \"groups\"").
-									WithArgs(false, Any{}, groupID).
-									WillReturnResult(sqlmock.NewResult(0, 1))
-								mock.ExpectCommit()
-							})
-							It("propagates error", func() {
-								err := uamDao.DeactivateGroup(uint(userID), groupName)
-								Expect(err).NotTo(HaveOccurred())
-							})
+						It("keeps the memberships of the group", func() {
+							err := uamDao.DeactivateGroup(groupName, nil)
+							Expect(err).NotTo(HaveOccurred())
+							Expect(mock.ExpectationsWereMet()).To(BeNil())
 						})
 					})
-
 				})
 			})
-
 		})
 	})
 
-	Context("MemberExists", func() {
-		When("request to check count of memberships with given user id and group name", func() {
-			Context("and request fails", func() {
-				Context("because of non-client problem", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
-							WithArgs(uint(userID), uint(groupID)).
-							WillReturnError(fmt.Errorf("some error"))
-					})
-
-					It("propagates error", func() {
-						_, err := uamDao.MemberExists(uint(userID), uint(groupID))
-						Expect(err).To(HaveOccurred())
-						_, ok := err.(*myerr.ServerError)
-						Expect(ok).To(Equal(true))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
+	Context("GetDeactivatedGroups", func() {
+		When("the query to the db fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(userID, false).
+					WillReturnError(fmt.Errorf("some error"))
 			})
 
-			Context("and request succeeds", func() {
-				Context("and memberships were not found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
-							WithArgs(uint(userID), uint(groupID)).
-							WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-					})
-
-					It("succeeds", func() {
-						result, err := uamDao.MemberExists(uint(userID), uint(groupID))
-						Expect(err).NotTo(HaveOccurred())
-						Expect(result).To(BeFalse())
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
-
-				Context("and membership was found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
-							WithArgs(uint(userID), uint(groupID)).
-							WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-					})
+			It("propagates error", func() {
+				_, err := uamDao.GetDeactivatedGroups(userID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
 
-					It("succeeds", func() {
-						result, err := uamDao.MemberExists(uint(userID), uint(groupID))
-						Expect(err).NotTo(HaveOccurred())
-						Expect(result).To(BeTrue())
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
+		When("the query to the db succeeds", func() {
+			BeforeEach(func() {
+				rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active", "deactivated_at"}).
+					AddRow(groupID, time.Now(), time.Now(), groupName, userID, false, time.Now())
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(userID, false).
+					WillReturnRows(rows)
+			})
 
+			It("returns the deleted groups of the owner", func() {
+				groups, err := uamDao.GetDeactivatedGroups(userID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(groups).To(HaveLen(1))
+				Expect(groups[0].Name).To(Equal(groupName))
+				Expect(groups[0].DeactivatedAt).NotTo(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
 			})
 		})
 	})
 
-	Context("GetGroup", func() {
-		When("request to get group info is sent", func() {
-			Context("and problem with the db occurrs", func() {
-				BeforeEach(func() {
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-						WithArgs(groupName).
-						WillReturnError(fmt.Errorf("some error"))
-				})
+	Context("RestoreGroup", func() {
+		When("the group doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{}))
+				mock.ExpectRollback()
+			})
 
-				It("propagates error", func() {
-					_, err := uamDao.GetGroup(groupName)
-					Expect(err).To(HaveOccurred())
-					_, ok := err.(*myerr.ServerError)
-					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
-				})
+			It("propagates error", func() {
+				err := uamDao.RestoreGroup(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
 			})
+		})
 
-			Context("and query to db succeeds", func() {
-				Context("and no group is found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-							WithArgs(groupName).
-							WillReturnError(gorm.ErrRecordNotFound)
+		When("the group is active", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).AddRow(groupID, groupName, userID, true))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.RestoreGroup(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the user isnt the owner of the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).AddRow(groupID, groupName, userID+1, false))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.RestoreGroup(userID, groupName, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the owner restores the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).AddRow(groupID, groupName, userID, false))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups"`)).
+					WithArgs(true, nil, Any{}, false, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("succeeds", func() {
+				Expect(uamDao.RestoreGroup(userID, groupName, nil)).To(Succeed())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+
+	Context("UpdateGroupLimits", func() {
+		var groupRow *sqlmock.Rows
+		BeforeEach(func() {
+			groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+				AddRow(groupID, time.Now(), time.Now(), groupName, userID, true)
+		})
+
+		When("you are the owner of the targeted group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectExec("UPDATE \"groups\"").
+					WithArgs(100, 2000, Any{}, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("changes the limits", func() {
+				err := uamDao.UpdateGroupLimits(groupName, 2000, 100, nil)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("GetMembership", func() {
+		When("membership doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "memberships"`)).
+					WithArgs(uint(userID), uint(groupID)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}))
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.GetMembership(uint(userID), uint(groupID))
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("membership exists", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "memberships"`)).
+					WithArgs(uint(userID), uint(groupID)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "user_id", "role"}).
+						AddRow(1, groupID, userID, "admin"))
+			})
+
+			It("returns the membership", func() {
+				membership, err := uamDao.GetMembership(uint(userID), uint(groupID))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(membership.Role).To(Equal("admin"))
+			})
+		})
+	})
+
+	Context("UpdateMemberRole", func() {
+		var groupRow *sqlmock.Rows
+		BeforeEach(func() {
+			groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+				AddRow(groupID, time.Now(), time.Now(), groupName, userID, true)
+		})
+
+		When("the targeted user is the group owner", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, username))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.UpdateMemberRole(username, groupName, "viewer", nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the targeted user isnt a member of the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+1, username))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("viewer", Any{}, userID+1, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.UpdateMemberRole(username, groupName, "viewer", nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the targeted user is a member of the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+1, username))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("viewer", Any{}, userID+1, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("changes the role", func() {
+				err := uamDao.UpdateMemberRole(username, groupName, "viewer", nil)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("TransferGroupOwnership", func() {
+		var groupRow *sqlmock.Rows
+		BeforeEach(func() {
+			groupRow = sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).
+				AddRow(groupID, time.Now(), time.Now(), groupName, userID, true)
+		})
+
+		When("the group is inactive", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+						AddRow(groupID, groupName, userID, false))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.TransferGroupOwnership(groupName, username, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the targeted user is already the owner", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID, username))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.TransferGroupOwnership(groupName, username, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the targeted user isnt a member of the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+1, username))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("owner", Any{}, userID+1, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.TransferGroupOwnership(groupName, username, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("changing the owner of the group fails", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+1, username))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("owner", Any{}, userID+1, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("admin", Any{}, userID, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups" SET "owner_id"`)).
+					WithArgs(userID+1, Any{}, groupID).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.TransferGroupOwnership(groupName, username, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the targeted user is a member of the group", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(groupRow)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(username, true).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+1, username))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("owner", Any{}, userID+1, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "memberships"`)).
+					WithArgs("admin", Any{}, userID, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups" SET "owner_id"`)).
+					WithArgs(userID+1, Any{}, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("transfers the ownership", func() {
+				err := uamDao.TransferGroupOwnership(groupName, username, nil)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("MemberExists", func() {
+		When("request to check count of memberships with given user id and group name", func() {
+			Context("and request fails", func() {
+				Context("because of non-client problem", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
+							WithArgs(uint(userID), uint(groupID)).
+							WillReturnError(fmt.Errorf("some error"))
 					})
 
 					It("propagates error", func() {
-						_, err := uamDao.GetGroup(groupName)
+						_, err := uamDao.MemberExists(uint(userID), uint(groupID))
 						Expect(err).To(HaveOccurred())
-						_, ok := err.(*myerr.ItemNotFoundError)
+						_, ok := err.(*myerr.ServerError)
 						Expect(ok).To(Equal(true))
 						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
+			})
 
-				Context("and the group was found", func() {
+			Context("and request succeeds", func() {
+				Context("and memberships were not found", func() {
 					BeforeEach(func() {
-						mockTime := time.Now()
-						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).AddRow(1, mockTime, mockTime, groupName, userID, true)
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-							WithArgs(groupName).
-							WillReturnRows(rows)
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
+							WithArgs(uint(userID), uint(groupID)).
+							WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 					})
 
-					It("succeds", func() {
-						group, err := uamDao.GetGroup(groupName)
-						Expect(err).ToNot(HaveOccurred())
-						Expect(group.Name).To(Equal(groupName))
-						Expect(group.OwnerID).To(Equal(uint(userID)))
+					It("succeeds", func() {
+						result, err := uamDao.MemberExists(uint(userID), uint(groupID))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(BeFalse())
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
+				Context("and membership was found", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "memberships"`)).
+							WithArgs(uint(userID), uint(groupID)).
+							WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+					})
+
+					It("succeeds", func() {
+						result, err := uamDao.MemberExists(uint(userID), uint(groupID))
+						Expect(err).NotTo(HaveOccurred())
+						Expect(result).To(BeTrue())
 						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
+
 			})
 		})
 	})
 
-	Context("GetDeactivatedGroupNames", func() {
-		When("request for all deactivated group names is sent", func() {
-			Context("and the query to the db fails", func() {
+	Context("GetGroup", func() {
+		When("request to get group info is sent", func() {
+			Context("and problem with the db occurrs", func() {
 				BeforeEach(func() {
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+						WithArgs(groupName).
 						WillReturnError(fmt.Errorf("some error"))
 				})
 
 				It("propagates error", func() {
-					_, err := uamDao.GetDeactivatedGroupNames()
+					_, err := uamDao.GetGroup(groupName)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
@@ -1208,140 +1930,1713 @@ var _ = Describe("UamDAO", func() {
 				})
 			})
 
-			Context("and query to the db succeeds", func() {
-				Context("and no deactivated groups are found", func() {
+			Context("and query to db succeeds", func() {
+				Context("and no group is found", func() {
 					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
-							WillReturnRows(sqlmock.NewRows([]string{}))
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(groupName).
+							WillReturnError(gorm.ErrRecordNotFound)
 					})
 
 					It("propagates error", func() {
-						groups, err := uamDao.GetDeactivatedGroupNames()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(groups).To(BeEmpty())
+						_, err := uamDao.GetGroup(groupName)
+						Expect(err).To(HaveOccurred())
+						_, ok := err.(*myerr.ItemNotFoundError)
+						Expect(ok).To(Equal(true))
 						Expect(mock.ExpectationsWereMet()).To(BeNil())
 					})
 				})
 
-				Context("and deactivated groups are found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
-							WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(groupName))
-					})
+				Context("and the group was found", func() {
+					BeforeEach(func() {
+						mockTime := time.Now()
+						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).AddRow(1, mockTime, mockTime, groupName, userID, true)
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WithArgs(groupName).
+							WillReturnRows(rows)
+					})
+
+					It("succeds", func() {
+						group, err := uamDao.GetGroup(groupName)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(group.Name).To(Equal(groupName))
+						Expect(group.OwnerID).To(Equal(uint(userID)))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+			})
+		})
+	})
+
+	Context("GetDeactivatedUserIDs", func() {
+		var before time.Time
+
+		BeforeEach(func() {
+			before = time.Now().Add(-time.Hour)
+		})
+
+		When("the query to the db fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE active = $1 AND deactivated_at < $2`)).
+					WithArgs(false, before.Local()).
+					WillReturnError(fmt.Errorf("some error"))
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.GetDeactivatedUserIDs(before)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("deactivated users are found", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE active = $1 AND deactivated_at < $2`)).
+					WithArgs(false, before.Local()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+			})
+
+			It("returns their ids", func() {
+				userIDs, err := uamDao.GetDeactivatedUserIDs(before)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(userIDs).To(Equal([]uint{userID}))
+			})
+		})
+	})
+
+	Context("PurgeDeactivatedUsers", func() {
+		When("none of the users is deactivated", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE id IN ($1) AND active = $2`)).
+					WithArgs(userID, false).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			})
+
+			It("deletes nothing", func() {
+				Expect(uamDao.PurgeDeactivatedUsers([]uint{userID})).To(Succeed())
+			})
+		})
+
+		When("the reassignment of the files fails", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE id IN ($1) AND active = $2`)).
+					WithArgs(userID, false).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "file_infos" SET "owner_id"=(SELECT owner_id FROM "groups" WHERE groups.id = file_infos.group_id) WHERE owner_id IN ($1)`)).
+					WithArgs(userID).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.PurgeDeactivatedUsers([]uint{userID})
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the users are deactivated", func() {
+			BeforeEach(func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "users" WHERE id IN ($1,$2) AND active = $3`)).
+					WithArgs(userID, userID+1, false).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "file_infos" SET "owner_id"=(SELECT owner_id FROM "groups" WHERE groups.id = file_infos.group_id) WHERE owner_id IN ($1)`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "users" WHERE id IN ($1)`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("purges only the deactivated ones", func() {
+				Expect(uamDao.PurgeDeactivatedUsers([]uint{userID, userID + 1})).To(Succeed())
+			})
+		})
+	})
+
+	Context("GetDeactivatedGroupNames", func() {
+		When("request for all deactivated group names is sent", func() {
+			Context("and the query to the db fails", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
+						WillReturnError(fmt.Errorf("some error"))
+				})
+
+				It("propagates error", func() {
+					_, err := uamDao.GetDeactivatedGroupNames(time.Now())
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and query to the db succeeds", func() {
+				Context("and no deactivated groups are found", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
+							WillReturnRows(sqlmock.NewRows([]string{}))
+					})
+
+					It("propagates error", func() {
+						groups, err := uamDao.GetDeactivatedGroupNames(time.Now())
+						Expect(err).ToNot(HaveOccurred())
+						Expect(groups).To(BeEmpty())
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
+				Context("and deactivated groups are found", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT "name" FROM "groups"`)).
+							WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(groupName))
+					})
+
+					It("propagates error", func() {
+						groups, err := uamDao.GetDeactivatedGroupNames(time.Now())
+						Expect(err).ToNot(HaveOccurred())
+						Expect(len(groups)).To(Equal(1))
+						Expect(groups[0]).To(Equal(groupName))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+			})
+		})
+	})
+
+	Context("EraseDeactivatedGroups", func() {
+		When("request to delete all deactivated groups is sent", func() {
+			const blobID = 3
+
+			Context("and query for the contents of the groups fails", func() {
+				BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blob_id" FROM "file_infos"`)).
+						WithArgs(groupName).
+						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
+				})
+
+				It("propagates error", func() {
+					err := uamDao.EraseDeactivatedGroups([]string{groupName})
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and deletion query fails", func() {
+				BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blob_id" FROM "file_infos"`)).
+						WithArgs(groupName).
+						WillReturnRows(sqlmock.NewRows([]string{"blob_id"}))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "share_links"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "file_tags"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM \"file_infos\"").
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "upload_chunks"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "upload_sessions"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "memberships"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM \"groups\"").
+						WithArgs(groupName).
+						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
+				})
+
+				It("propagates error", func() {
+					err := uamDao.EraseDeactivatedGroups([]string{groupName})
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and deletion query succeeds", func() {
+				BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blob_id" FROM "file_infos"`)).
+						WithArgs(groupName).
+						WillReturnRows(sqlmock.NewRows([]string{"blob_id"}).AddRow(blobID).AddRow(blobID))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "share_links"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "file_tags"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM \"file_infos\"").
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 2))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "upload_chunks"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "upload_sessions"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "memberships"`)).
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("DELETE FROM \"groups\"").
+						WithArgs(groupName).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec(regexp.QuoteMeta(`UPDATE "blobs" SET "ref_count"=ref_count - $1`)).
+						WithArgs(2, blobID).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				})
+
+				It("releases the blobs of the files", func() {
+					err := uamDao.EraseDeactivatedGroups([]string{groupName})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+	})
+
+	Context("GetAllGroups", func() {
+		When("request to get all existing groups is sent", func() {
+			Context("and query to db fails", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+						WillReturnError(fmt.Errorf("some error"))
+				})
+
+				It("propagates error", func() {
+					_, _, err := uamDao.GetAllGroups(ListOptions{Limit: 50})
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and query to db succeeds", func() {
+				Context("and no group is found", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WillReturnRows(sqlmock.NewRows([]string{}))
+					})
+
+					It("propagates error", func() {
+						groups, cursor, err := uamDao.GetAllGroups(ListOptions{Limit: 50})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(groups).To(BeEmpty())
+						Expect(cursor).To(BeEmpty())
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
+				Context("and the group was found", func() {
+					BeforeEach(func() {
+						mockTime := time.Now()
+						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).AddRow(1, mockTime, mockTime, groupName, userID, true)
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+							WillReturnRows(rows)
+					})
+
+					It("succeds", func() {
+						groups, cursor, err := uamDao.GetAllGroups(ListOptions{Limit: 50})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(cursor).To(BeEmpty())
+						Expect(len(groups)).To(Equal(1))
+						Expect(groups[0].Name).To(Equal(groupName))
+						Expect(groups[0].OwnerID).To(Equal(uint(userID)))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+			})
+		})
+	})
+
+	Context("GetAllUsers", func() {
+		When("request to get all existing users is sent", func() {
+			Context("and query to db fails", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+						WillReturnError(fmt.Errorf("some error"))
+				})
+
+				It("propagates error", func() {
+					_, _, err := uamDao.GetAllUsers(ListOptions{Limit: 50})
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and query to db succeeds", func() {
+				Context("and no users is found", func() {
+					BeforeEach(func() {
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+							WillReturnRows(sqlmock.NewRows([]string{}))
+					})
+
+					It("propagates error", func() {
+						groups, _, err := uamDao.GetAllUsers(ListOptions{Limit: 50})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(groups).To(BeEmpty())
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+
+				Context("and users were found", func() {
+					BeforeEach(func() {
+						mockTime := time.Now()
+						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "username", "password"}).AddRow(userID, mockTime, mockTime, username, password)
+						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+							WillReturnRows(rows)
+					})
+
+					It("succeeds", func() {
+						users, cursor, err := uamDao.GetAllUsers(ListOptions{Limit: 50})
+						Expect(err).ToNot(HaveOccurred())
+						Expect(cursor).To(BeEmpty())
+						Expect(len(users)).To(Equal(1))
+						Expect(users[0].ID).To(Equal(uint(userID)))
+						Expect(users[0].Username).To(Equal(username))
+						Expect(users[0].Password).To(Equal(password))
+						Expect(mock.ExpectationsWereMet()).To(BeNil())
+					})
+				})
+			})
+		})
+	})
+
+	Context("Pagination of the listings", func() {
+		When("the sort field is unknown", func() {
+			It("returns client error", func() {
+				_, _, err := uamDao.GetAllUsers(ListOptions{Limit: 50, SortBy: "password"})
+				Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the cursor is invalid", func() {
+			It("returns client error", func() {
+				_, _, err := uamDao.GetAllGroups(ListOptions{Limit: 50, Cursor: "not-a-cursor"})
+				Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("there are more items than the limit", func() {
+			var cursor string
+
+			BeforeEach(func() {
+				mockTime := time.Now()
+				rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "username", "password"}).
+					AddRow(userID, mockTime, mockTime, "alice", password).
+					AddRow(userID+1, mockTime, mockTime, "bob", password).
+					AddRow(userID+2, mockTime, mockTime, "carol", password)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE users.active = $1 AND lower(users.username) LIKE $2 ESCAPE '\' ORDER BY users.username desc,users.id desc LIMIT 3`)).
+					WithArgs(true, "%a\\_%").
+					WillReturnRows(rows)
+
+				var (
+					users []models.User
+					err   error
+				)
+				users, cursor, err = uamDao.GetAllUsers(ListOptions{Limit: 2, SortBy: "username", Descending: true, Query: "A_"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(users).To(HaveLen(2))
+				Expect(users[1].Username).To(Equal("bob"))
+			})
+
+			It("returns a cursor, which continues after the last item", func() {
+				Expect(cursor).ToNot(BeEmpty())
+
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE users.active = $1 AND ((users.username < $2 OR (users.username = $3 AND users.id < $4))) ORDER BY users.username desc,users.id desc LIMIT 3`)).
+					WithArgs(true, "bob", "bob", userID+1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(userID+2, "carol"))
+
+				users, next, err := uamDao.GetAllUsers(ListOptions{Limit: 2, SortBy: "username", Descending: true, Cursor: cursor})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(users).To(HaveLen(1))
+				Expect(next).To(BeEmpty())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			It("rejects the cursor with a different sorting", func() {
+				_, _, err := uamDao.GetAllUsers(ListOptions{Limit: 2, SortBy: "username", Cursor: cursor})
+				Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+
+	Context("CreateSession", func() {
+		const tokenHash = "token-hash"
+		var expiresAt time.Time
+
+		BeforeEach(func() {
+			expiresAt = time.Now().Add(time.Hour)
+			mock.ExpectBegin()
+		})
+
+		When("the creation of the session fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "sessions"`)).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.CreateSession(userID, tokenHash, expiresAt)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the creation of the session succeeds", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "sessions"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+				mock.ExpectCommit()
+			})
+
+			It("returns the id of the session", func() {
+				sessionID, err := uamDao.CreateSession(userID, tokenHash, expiresAt)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sessionID).To(Equal(uint(3)))
+			})
+		})
+	})
+
+	Context("RotateSession", func() {
+		const (
+			sessionID    = 3
+			tokenHash    = "token-hash"
+			newTokenHash = "new-token-hash"
+		)
+		var (
+			expiresAt   time.Time
+			sessionRows func(bool, time.Time) *sqlmock.Rows
+		)
+
+		BeforeEach(func() {
+			expiresAt = time.Now().Add(time.Hour)
+			sessionRows = func(revoked bool, sessionExpiresAt time.Time) *sqlmock.Rows {
+				return sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "revoked"}).
+					AddRow(sessionID, userID, tokenHash, sessionExpiresAt, revoked)
+			}
+			mock.ExpectBegin()
+		})
+
+		When("the refresh token is unknown", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "sessions"`)).
+					WithArgs(tokenHash).
+					WillReturnError(gorm.ErrRecordNotFound)
+			})
+
+			Context("and it wasnt used before", func() {
+				BeforeEach(func() {
+					mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+						WithArgs(true, Any{}, tokenHash).
+						WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectCommit()
+				})
+
+				It("returns client error", func() {
+					_, err := uamDao.RotateSession(tokenHash, newTokenHash, expiresAt)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Invalid refresh token"))
+				})
+			})
+
+			Context("and it was already rotated", func() {
+				BeforeEach(func() {
+					mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+						WithArgs(true, Any{}, tokenHash).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				})
+
+				It("revokes the session and returns client error", func() {
+					_, err := uamDao.RotateSession(tokenHash, newTokenHash, expiresAt)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("The refresh token was already used"))
+				})
+			})
+		})
+
+		When("the session is revoked", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "sessions"`)).
+					WithArgs(tokenHash).
+					WillReturnRows(sessionRows(true, expiresAt))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				_, err := uamDao.RotateSession(tokenHash, newTokenHash, expiresAt)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("The session is revoked. Please login again"))
+			})
+		})
+
+		When("the session is expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "sessions"`)).
+					WithArgs(tokenHash).
+					WillReturnRows(sessionRows(false, time.Now().Add(-time.Minute)))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				_, err := uamDao.RotateSession(tokenHash, newTokenHash, expiresAt)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("The session has expired. Please login again"))
+			})
+		})
+
+		When("the session is active", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "sessions"`)).
+					WithArgs(tokenHash).
+					WillReturnRows(sessionRows(false, time.Now().Add(time.Minute)))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "expires_at"`)).
+					WithArgs(expiresAt, tokenHash, newTokenHash, Any{}, sessionID, tokenHash).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("rotates the refresh token", func() {
+				session, err := uamDao.RotateSession(tokenHash, newTokenHash, expiresAt)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(session.ID).To(Equal(uint(sessionID)))
+				Expect(session.UserID).To(Equal(uint(userID)))
+				Expect(session.TokenHash).To(Equal(newTokenHash))
+				Expect(session.ExpiresAt).To(Equal(expiresAt))
+			})
+		})
+	})
+
+	Context("RevokeSession", func() {
+		const sessionID = 3
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the session doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, sessionID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			})
+
+			It("returns not found error", func() {
+				err := uamDao.RevokeSession(sessionID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the session exists", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, sessionID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("succeeds", func() {
+				Expect(uamDao.RevokeSession(sessionID)).To(Succeed())
+			})
+		})
+	})
+
+	Context("IsSessionRevoked", func() {
+		const sessionID = 3
+
+		When("the lookup of the session fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "sessions"`)).
+					WillReturnError(fmt.Errorf("some error"))
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.IsSessionRevoked(sessionID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the session is active", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "sessions"`)).
+					WithArgs(sessionID, false, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			})
+
+			It("returns false", func() {
+				revoked, err := uamDao.IsSessionRevoked(sessionID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revoked).To(BeFalse())
+			})
+		})
+
+		When("the session is revoked, expired or deleted", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "sessions"`)).
+					WithArgs(sessionID, false, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			})
+
+			It("returns true", func() {
+				revoked, err := uamDao.IsSessionRevoked(sessionID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revoked).To(BeTrue())
+			})
+		})
+	})
+
+	Context("ChangePassword", func() {
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the user doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"`)).
+					WithArgs(password, Any{}, userID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("returns not found error", func() {
+				err := uamDao.ChangePassword(userID, password)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the revocation of the sessions fails", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"`)).
+					WithArgs(password, Any{}, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, userID, false).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.ChangePassword(userID, password)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the revocation of the access tokens fails", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"`)).
+					WithArgs(password, Any{}, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, userID, false).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+					WithArgs(userID).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error and keeps the password", func() {
+				err := uamDao.ChangePassword(userID, password)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the user exists", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"`)).
+					WithArgs(password, Any{}, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, userID, false).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("changes the password and revokes the sessions and the access tokens", func() {
+				Expect(uamDao.ChangePassword(userID, password)).To(Succeed())
+			})
+		})
+	})
+
+	Context("CreatePasswordReset", func() {
+		const tokenHash = "token-hash"
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_resets"`)).
+				WithArgs(userID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		})
+
+		When("the creation of the reset fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "password_resets"`)).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				err := uamDao.CreatePasswordReset(userID, tokenHash, time.Now().Add(time.Hour))
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the creation of the reset succeeds", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "password_resets"`)).
+					WithArgs(Any{}, Any{}, userID, tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			})
+
+			It("replaces the previous resets", func() {
+				Expect(uamDao.CreatePasswordReset(userID, tokenHash, time.Now().Add(time.Hour))).To(Succeed())
+			})
+		})
+	})
+
+	Context("ResetPassword", func() {
+		const tokenHash = "token-hash"
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the token doesnt exist or has expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "password_resets"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.ResetPassword(tokenHash, password)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the token is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "password_resets"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}).
+						AddRow(1, userID, tokenHash, time.Now().Add(time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "password_resets"`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "password"`)).
+					WithArgs(password, Any{}, userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "sessions" SET "revoked"`)).
+					WithArgs(true, Any{}, userID, false).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			})
+
+			It("consumes the token, changes the password and revokes the access tokens", func() {
+				Expect(uamDao.ResetPassword(tokenHash, password)).To(Succeed())
+			})
+		})
+	})
+
+	Context("GetUserByID", func() {
+		When("the user doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(true, userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			})
+
+			It("returns not found error", func() {
+				_, err := uamDao.GetUserByID(userID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the user exists", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(true, userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password"}).
+						AddRow(userID, username, password))
+			})
+
+			It("returns the user", func() {
+				user, err := uamDao.GetUserByID(userID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(user.Username).To(Equal(username))
+			})
+		})
+	})
+
+	Context("CreateTwoFactor", func() {
+		const secret = "SECRET"
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the two-factor authentication is already enabled", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "two_factors"`)).
+					WithArgs(userID, true).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.CreateTwoFactor(userID, secret, []string{"code-hash"})
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the two-factor authentication isnt enabled", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "two_factors"`)).
+					WithArgs(userID, true).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "two_factors"`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+					WithArgs(userID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "two_factors"`)).
+					WithArgs(Any{}, Any{}, userID, secret, false, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "recovery_codes"`)).
+					WithArgs(Any{}, userID, "first-hash", Any{}, userID, "second-hash").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+				mock.ExpectCommit()
+			})
+
+			It("replaces the pending enrollment", func() {
+				Expect(uamDao.CreateTwoFactor(userID, secret, []string{"first-hash", "second-hash"})).To(Succeed())
+			})
+		})
+	})
+
+	Context("EnableTwoFactor", func() {
+		const step = 100
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("there is no pending enrollment", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factors" SET "enabled"`)).
+					WithArgs(true, step, Any{}, userID, false).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.EnableTwoFactor(userID, step)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("there is a pending enrollment", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factors" SET "enabled"`)).
+					WithArgs(true, step, Any{}, userID, false).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("succeeds", func() {
+				Expect(uamDao.EnableTwoFactor(userID, step)).To(Succeed())
+			})
+		})
+	})
+
+	Context("UseTwoFactorStep", func() {
+		const step = 100
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the code of the period was already used", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factors" SET "last_used_step"`)).
+					WithArgs(step, Any{}, userID, true, step).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.UseTwoFactorStep(userID, step)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the code of the period wasnt used", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "two_factors" SET "last_used_step"`)).
+					WithArgs(step, Any{}, userID, true, step).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("succeeds", func() {
+				Expect(uamDao.UseTwoFactorStep(userID, step)).To(Succeed())
+			})
+		})
+	})
+
+	Context("UseRecoveryCode", func() {
+		const codeHash = "code-hash"
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the code doesnt exist or was already used", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+					WithArgs(userID, codeHash).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.UseRecoveryCode(userID, codeHash)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the code is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "recovery_codes"`)).
+					WithArgs(userID, codeHash).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("consumes the code", func() {
+				Expect(uamDao.UseRecoveryCode(userID, codeHash)).To(Succeed())
+			})
+		})
+	})
+
+	Context("UseLoginChallenge", func() {
+		const (
+			challengeID = 5
+			tokenHash   = "token-hash"
+		)
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the challenge doesnt exist or has expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_challenges"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				_, err := uamDao.UseLoginChallenge(tokenHash)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the challenge is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_challenges"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at"}).
+						AddRow(challengeID, userID, tokenHash, time.Now().Add(time.Minute)))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_challenges"`)).
+					WithArgs(challengeID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("consumes the challenge", func() {
+				challenge, err := uamDao.UseLoginChallenge(tokenHash)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(challenge.UserID).To(Equal(uint(userID)))
+			})
+		})
+	})
+
+	Context("UseExternalLogin", func() {
+		const (
+			loginID   = 5
+			stateHash = "state-hash"
+		)
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the login doesnt exist or has expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_logins"`)).
+					WithArgs(stateHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				_, err := uamDao.UseExternalLogin(stateHash)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the login is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_logins"`)).
+					WithArgs(stateHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "state_hash", "nonce", "code_verifier", "redirect_uri", "expires_at"}).
+						AddRow(loginID, stateHash, "nonce", "code-verifier", "http://localhost/callback", time.Now().Add(time.Minute)))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "external_logins"`)).
+					WithArgs(loginID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("consumes the login", func() {
+				login, err := uamDao.UseExternalLogin(stateHash)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(login.Nonce).To(Equal("nonce"))
+				Expect(login.CodeVerifier).To(Equal("code-verifier"))
+			})
+		})
+	})
+
+	Context("GetOrCreateExternalUser", func() {
+		const (
+			issuer  = "https://idp.example.com"
+			subject = "subject"
+		)
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the external identity is linked to a user", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_identities"`)).
+					WithArgs(issuer, subject).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "issuer", "subject"}).
+						AddRow(1, userID, issuer, subject))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					WithArgs(userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).
+						AddRow(userID, username))
+				mock.ExpectCommit()
+			})
+
+			It("returns the linked user", func() {
+				user, err := uamDao.GetOrCreateExternalUser(issuer, subject, "suggested")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(user.ID).To(Equal(uint(userID)))
+				Expect(user.Username).To(Equal(username))
+			})
+		})
+
+		When("the external identity is unknown", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "external_identities"`)).
+					WithArgs(issuer, subject).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT "username" FROM "users"`)).
+					WithArgs(username, username+"-%").
+					WillReturnRows(sqlmock.NewRows([]string{"username"}).
+						AddRow(username).
+						AddRow(username + "-2"))
+			})
+
+			Context("and the provisioning succeeds", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
+						WithArgs(Any{}, Any{}, username+"-3", "", true, nil).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "external_identities"`)).
+						WithArgs(Any{}, Any{}, userID, issuer, subject).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectCommit()
+				})
+
+				It("creates a user with the first free username", func() {
+					user, err := uamDao.GetOrCreateExternalUser(issuer, subject, username)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(user.ID).To(Equal(uint(userID)))
+					Expect(user.Username).To(Equal(username + "-3"))
+				})
+			})
+
+			Context("and the provisioning fails", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
+						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
+				})
+
+				It("returns server error", func() {
+					_, err := uamDao.GetOrCreateExternalUser(issuer, subject, username)
+					Expect(err).To(HaveOccurred())
+					_, ok := err.(*myerr.ServerError)
+					Expect(ok).To(Equal(true))
+				})
+			})
+		})
+	})
+
+	Context("UpdateGroupTwoFactor", func() {
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the group doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				err := uamDao.UpdateGroupTwoFactor(groupName, true, nil)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the group exists", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
+					WithArgs(groupName).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id", "active"}).
+						AddRow(groupID, groupName, userID, true))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "groups" SET "require_two_factor"`)).
+					WithArgs(true, Any{}, groupID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			})
+
+			Context("and there isnt an audit event", func() {
+				It("succeeds", func() {
+					mock.ExpectCommit()
+					Expect(uamDao.UpdateGroupTwoFactor(groupName, true, nil)).To(Succeed())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			Context("and there is an audit event", func() {
+				var event *models.AuditEvent
+
+				BeforeEach(func() {
+					actorID := uint(userID)
+					event = &models.AuditEvent{ActorID: &actorID, Action: models.AuditGroupTwoFactorChanged, IP: "127.0.0.1"}
+				})
+
+				It("records the event of the group in the same transaction", func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
+						WithArgs(Any{}, userID, nil, groupID, nil, models.AuditGroupTwoFactorChanged, "", "127.0.0.1").
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectCommit()
+
+					Expect(uamDao.UpdateGroupTwoFactor(groupName, true, event)).To(Succeed())
+					Expect(*event.GroupID).To(Equal(uint(groupID)))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+
+				It("rolls back the change, if the event cannot be recorded", func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
+						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
+
+					err := uamDao.UpdateGroupTwoFactor(groupName, true, event)
+					Expect(err).To(BeAssignableToTypeOf(&myerr.ServerError{}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+	})
+
+	Context("CreateAuditEvent", func() {
+		It("stores the event", func() {
+			groupRef, fileRef := uint(groupID), uint(3)
+			event := &models.AuditEvent{GroupID: &groupRef, FileID: &fileRef, Action: models.AuditFileDownloaded}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
+				WithArgs(Any{}, nil, nil, groupID, 3, models.AuditFileDownloaded, "", "").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+
+			Expect(uamDao.CreateAuditEvent(event)).To(Succeed())
+			Expect(mock.ExpectationsWereMet()).To(BeNil())
+		})
+	})
+
+	Context("GetAuditEvents", func() {
+		const selectEvents = `SELECT audit_events.id, audit_events.created_at, audit_events.action, actors.username AS actor`
+
+		When("the query fails", func() {
+			It("propagates error", func() {
+				mock.ExpectQuery(regexp.QuoteMeta(selectEvents)).
+					WillReturnError(fmt.Errorf("some error"))
+
+				_, err := uamDao.GetAuditEvents(groupID, AuditFilter{}, 0, 10)
+				Expect(err).To(BeAssignableToTypeOf(&myerr.ServerError{}))
+			})
+		})
 
-					It("propagates error", func() {
-						groups, err := uamDao.GetDeactivatedGroupNames()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(len(groups)).To(Equal(1))
-						Expect(groups[0]).To(Equal(groupName))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
+		When("filters are given", func() {
+			It("narrows the query and returns the page", func() {
+				since := time.Now().Add(-time.Hour)
+				until := time.Now()
+				filter := AuditFilter{Action: models.AuditFileUploaded, Actor: username, Since: &since, Until: &until}
+
+				mock.ExpectQuery(regexp.QuoteMeta(selectEvents)).
+					WithArgs(groupID, models.AuditFileUploaded, username, since.Local(), until.Local()).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "action", "actor", "target_user", "file_id", "details", "ip"}).
+						AddRow(5, time.Now(), models.AuditFileUploaded, username, nil, 3, "Uploaded [file]", "127.0.0.1"))
+
+				events, err := uamDao.GetAuditEvents(groupID, filter, 20, 10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(HaveLen(1))
+				Expect(*events[0].Actor).To(Equal(username))
+				Expect(events[0].TargetUser).To(BeNil())
+				Expect(*events[0].FileID).To(Equal(uint(3)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
 			})
 		})
 	})
 
-	Context("EraseDeactivatedGroups", func() {
-		When("request to delete all deactivated groups is sent", func() {
-			Context("and deletion query fails", func() {
+	Context("CreateAccessToken", func() {
+		const tokenName = "ci"
+		var token models.AccessToken
+
+		BeforeEach(func() {
+			token = models.AccessToken{
+				UserID:    userID,
+				Name:      tokenName,
+				TokenHash: "token-hash",
+				Scopes:    models.ScopeUpload,
+			}
+			mock.ExpectBegin()
+		})
+
+		When("the user already has a token with the same name", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "access_tokens"`)).
+					WithArgs(userID, tokenName).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			})
+
+			It("returns client error", func() {
+				_, err := uamDao.CreateAccessToken(token)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the name of the token is unique", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(1) FROM "access_tokens"`)).
+					WithArgs(userID, tokenName).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			})
+
+			Context("and the creation of the token fails", func() {
 				BeforeEach(func() {
-					mock.ExpectBegin()
-					mock.ExpectExec("DELETE FROM \"groups\"").
-						WithArgs(groupName).
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "access_tokens"`)).
 						WillReturnError(fmt.Errorf("some error"))
 					mock.ExpectRollback()
 				})
 
 				It("propagates error", func() {
-					err := uamDao.EraseDeactivatedGroups([]string{groupName})
+					_, err := uamDao.CreateAccessToken(token)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 			})
 
-			Context("and deletion query succeeds", func() {
+			Context("and the creation of the token succeeds", func() {
 				BeforeEach(func() {
-					mock.ExpectBegin()
-					mock.ExpectExec("DELETE FROM \"groups\"").
-						WithArgs(groupName).
-						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "access_tokens"`)).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
 					mock.ExpectCommit()
 				})
 
-				It("propagates error", func() {
-					err := uamDao.EraseDeactivatedGroups([]string{groupName})
-					Expect(err).ToNot(HaveOccurred())
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				It("returns the id of the token", func() {
+					tokenID, err := uamDao.CreateAccessToken(token)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(tokenID).To(Equal(uint(4)))
 				})
 			})
 		})
 	})
 
-	Context("GetAllGroups", func() {
-		When("request to get all existing groups is sent", func() {
-			Context("and query to db fails", func() {
-				BeforeEach(func() {
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-						WillReturnError(fmt.Errorf("some error"))
-				})
+	Context("UseAccessToken", func() {
+		const (
+			tokenID   = 4
+			tokenHash = "token-hash"
+		)
 
-				It("propagates error", func() {
-					_, err := uamDao.GetAllGroups()
-					Expect(err).To(HaveOccurred())
-					_, ok := err.(*myerr.ServerError)
-					Expect(ok).To(Equal(true))
-					Expect(mock.ExpectationsWereMet()).To(BeNil())
-				})
+		When("the token doesnt exist or has expired", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "access_tokens"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
 			})
 
-			Context("and query to db succeeds", func() {
-				Context("and no group is found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-							WillReturnRows(sqlmock.NewRows([]string{}))
-					})
+			It("returns not found error", func() {
+				_, err := uamDao.UseAccessToken(tokenHash)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
 
-					It("propagates error", func() {
-						groups, err := uamDao.GetAllGroups()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(groups).To(BeEmpty())
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
+		When("the token is valid", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "access_tokens"`)).
+					WithArgs(tokenHash, Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "token_hash", "scopes"}).
+						AddRow(tokenID, userID, "ci", tokenHash, models.ScopeRead))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "access_tokens" SET "last_used_at"`)).
+					WithArgs(Any{}, Any{}, tokenID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
 
-				Context("and the group was found", func() {
-					BeforeEach(func() {
-						mockTime := time.Now()
-						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "name", "owner_id", "active"}).AddRow(1, mockTime, mockTime, groupName, userID, true)
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "groups"`)).
-							WillReturnRows(rows)
-					})
+			It("returns the token and records its use", func() {
+				token, err := uamDao.UseAccessToken(tokenHash)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(token.UserID).To(Equal(uint(userID)))
+				Expect(token.HasScope(models.ScopeRead)).To(BeTrue())
+				Expect(token.HasScope(models.ScopeUpload)).To(BeFalse())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
 
-					It("succeds", func() {
-						groups, err := uamDao.GetAllGroups()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(len(groups)).To(Equal(1))
-						Expect(groups[0].Name).To(Equal(groupName))
-						Expect(groups[0].OwnerID).To(Equal(uint(userID)))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
-				})
+	Context("GetAccessTokens", func() {
+		When("the query fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT access_tokens.id, access_tokens.name`)).
+					WithArgs(userID).
+					WillReturnError(fmt.Errorf("some error"))
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.GetAccessTokens(userID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the query succeeds", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT access_tokens.id, access_tokens.name`)).
+					WithArgs(userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "group_name", "created_at", "expires_at", "last_used_at"}).
+						AddRow(4, "ci", models.ScopeUpload, groupName, time.Now(), nil, nil))
+			})
+
+			It("returns the tokens", func() {
+				tokens, err := uamDao.GetAccessTokens(userID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tokens).To(HaveLen(1))
+				Expect(tokens[0].Name).To(Equal("ci"))
+				Expect(*tokens[0].GroupName).To(Equal(groupName))
+				Expect(tokens[0].ExpiresAt).To(BeNil())
 			})
 		})
 	})
 
-	Context("GetAllUsers", func() {
-		When("request to get all existing users is sent", func() {
-			Context("and query to db fails", func() {
+	Context("DeleteAccessToken", func() {
+		const tokenID = 4
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+		})
+
+		When("the token doesnt exist", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+					WithArgs(userID, tokenID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			})
+
+			It("returns not found error", func() {
+				err := uamDao.DeleteAccessToken(userID, tokenID)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the token exists", func() {
+			BeforeEach(func() {
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "access_tokens"`)).
+					WithArgs(userID, tokenID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("succeeds", func() {
+				Expect(uamDao.DeleteAccessToken(userID, tokenID)).To(Succeed())
+			})
+		})
+	})
+
+	Context("GetLoginLockout", func() {
+		keys := []string{"user:username", "ip:10.0.0.1"}
+
+		When("the query fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(keys[0], keys[1], Any{}).
+					WillReturnError(fmt.Errorf("some error"))
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.GetLoginLockout(keys)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("some of the keys are locked", func() {
+			var lockedUntil time.Time
+
+			BeforeEach(func() {
+				lockedUntil = time.Now().Add(time.Hour)
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(keys[0], keys[1], Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "failures", "locked_until"}).
+						AddRow(1, keys[0], 5, lockedUntil.Add(-time.Minute)).
+						AddRow(2, keys[1], 20, lockedUntil))
+			})
+
+			It("returns the latest lockout", func() {
+				until, err := uamDao.GetLoginLockout(keys)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(until).To(BeTemporally("==", lockedUntil))
+			})
+		})
+
+		When("none of the keys is locked", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(keys[0], keys[1], Any{}).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			})
+
+			It("returns zero time", func() {
+				until, err := uamDao.GetLoginLockout(keys)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(until.IsZero()).To(BeTrue())
+			})
+		})
+	})
+
+	Context("RecordLoginFailure", func() {
+		const key = "user:username"
+		var staleBefore time.Time
+
+		BeforeEach(func() {
+			staleBefore = time.Now().Add(-time.Hour)
+			mock.ExpectBegin()
+		})
+
+		When("it is the first failure for the key", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "login_failures"`)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			})
+
+			It("returns one failure", func() {
+				failures, err := uamDao.RecordLoginFailure(key, staleBefore)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failures).To(Equal(uint(1)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the key has recent failures", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "failures", "last_failure_at"}).
+						AddRow(1, key, 3, time.Now().Add(-time.Minute)))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "login_failures" SET "failures"=failures + 1,"last_failure_at"=$1`)).
+					WithArgs(Any{}, Any{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("increments the failures", func() {
+				failures, err := uamDao.RecordLoginFailure(key, staleBefore)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failures).To(Equal(uint(4)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the failures of the key are stale", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "failures", "last_failure_at"}).
+						AddRow(1, key, 30, time.Now().Add(-2*time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE "login_failures" SET "failures"=$1,"last_failure_at"=$2,"locked_until"=$3`)).
+					WithArgs(1, Any{}, nil, Any{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			})
+
+			It("starts the counting again", func() {
+				failures, err := uamDao.RecordLoginFailure(key, staleBefore)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(failures).To(Equal(uint(1)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("the lookup of the failures fails", func() {
+			BeforeEach(func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "login_failures"`)).
+					WithArgs(key).
+					WillReturnError(fmt.Errorf("some error"))
+				mock.ExpectRollback()
+			})
+
+			It("propagates error", func() {
+				_, err := uamDao.RecordLoginFailure(key, staleBefore)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+	})
+
+	Context("LockLogin", func() {
+		const key = "user:username"
+		var until time.Time
+
+		BeforeEach(func() {
+			until = time.Now().Add(time.Minute)
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "login_failures" SET "locked_until"`)).
+				WithArgs(until, Any{}, key).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		})
+
+		When("there isnt an audit event", func() {
+			BeforeEach(func() {
+				mock.ExpectCommit()
+			})
+
+			It("locks the logins", func() {
+				Expect(uamDao.LockLogin(key, until, nil)).To(Succeed())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		When("there is an audit event", func() {
+			var event *models.AuditEvent
+
+			BeforeEach(func() {
+				lockedUserID := uint(userID)
+				event = &models.AuditEvent{UserID: &lockedUserID, Action: models.AuditAccountLocked}
+			})
+
+			Context("and its creation fails", func() {
 				BeforeEach(func() {
-					mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
 						WillReturnError(fmt.Errorf("some error"))
+					mock.ExpectRollback()
 				})
 
-				It("propagates error", func() {
-					_, err := uamDao.GetAllUsers()
+				It("propagates error and doesnt lock the logins", func() {
+					err := uamDao.LockLogin(key, until, event)
 					Expect(err).To(HaveOccurred())
 					_, ok := err.(*myerr.ServerError)
 					Expect(ok).To(Equal(true))
@@ -1349,40 +3644,35 @@ var _ = Describe("UamDAO", func() {
 				})
 			})
 
-			Context("and query to db succeeds", func() {
-				Context("and no users is found", func() {
-					BeforeEach(func() {
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-							WillReturnRows(sqlmock.NewRows([]string{}))
-					})
-
-					It("propagates error", func() {
-						groups, err := uamDao.GetAllUsers()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(groups).To(BeEmpty())
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
+			Context("and its creation succeeds", func() {
+				BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_events"`)).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+					mock.ExpectCommit()
 				})
 
-				Context("and users were found", func() {
-					BeforeEach(func() {
-						mockTime := time.Now()
-						rows := sqlmock.NewRows([]string{"id", "created_at", "updated_at", "username", "password"}).AddRow(userID, mockTime, mockTime, username, password)
-						mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users"`)).
-							WillReturnRows(rows)
-					})
-
-					It("succeeds", func() {
-						users, err := uamDao.GetAllUsers()
-						Expect(err).ToNot(HaveOccurred())
-						Expect(len(users)).To(Equal(1))
-						Expect(users[0].ID).To(Equal(uint(userID)))
-						Expect(users[0].Username).To(Equal(username))
-						Expect(users[0].Password).To(Equal(password))
-						Expect(mock.ExpectationsWereMet()).To(BeNil())
-					})
+				It("locks the logins and records the event", func() {
+					Expect(uamDao.LockLogin(key, until, event)).To(Succeed())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 			})
 		})
 	})
+
+	Context("ResetLoginFailures", func() {
+		const key = "user:username"
+
+		BeforeEach(func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "login_failures"`)).
+				WithArgs(key).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		})
+
+		It("deletes the failures of the key", func() {
+			Expect(uamDao.ResetLoginFailures(key)).To(Succeed())
+			Expect(mock.ExpectationsWereMet()).To(BeNil())
+		})
+	})
 })
@@ -9,7 +9,11 @@ import (
 )
 
 const (
+	//DBdriver - name of env variable, containing the database driver - postgres(default) or sqlite
+	dbDriver = "DB_DRIVER"
+
 	//DBname - name of env variable, containing the name of the database
+	//for sqlite it is the path to the database file, InMemoryDB keeps the database in memory
 	dbName = "DB_NAME"
 
 	//DBuser - name of env variable, containing the db username
@@ -25,24 +29,48 @@ const (
 	dbHost = "DB_HOST"
 )
 
+const (
+	//PostgresDriver - the database is a postgres server
+	PostgresDriver = "postgres"
+
+	//SqliteDriver - the database is a sqlite file or an in-memory database
+	SqliteDriver = "sqlite"
+)
+
 var dbConn *gorm.DB
 
+//GetDBConnFromEnv - creates a connection to the database, chosen by the env variables, or returns an already existing one
+func GetDBConnFromEnv() (*gorm.DB, error) {
+	switch driver := os.Getenv(dbDriver); driver {
+	case "", PostgresDriver:
+		return GetDBConn(PostgresDialectorCreator, getPostgresDns())
+	case SqliteDriver:
+		conn, err := GetDBConn(SqliteDialectorCreator, getSqliteDns(os.Getenv(dbName)))
+		if err != nil {
+			return nil, err
+		}
+		return conn, configureSqliteConn(conn)
+	default:
+		return nil, myerr.NewServerError(fmt.Sprintf("Unsupported database driver [%s]", driver))
+	}
+}
+
 //GetDBConn - creates a database connection or returns an already existing one
-func GetDBConn(creator func(string) gorm.Dialector) (*gorm.DB, error) {
+func GetDBConn(creator func(string) gorm.Dialector, dbDns string) (*gorm.DB, error) {
 	if dbConn != nil {
 		return dbConn, nil
 	}
 
-	dbConn, err := gorm.Open(creator(getDBDns()), &gorm.Config{})
+	conn, err := gorm.Open(creator(dbDns), &gorm.Config{})
 	if err != nil {
 		return nil, myerr.NewServerErrorWrap(err, "Cannot create a connection to the database.")
 	}
 
+	dbConn = conn
 	return dbConn, nil
-
 }
 
-func getDBDns() string {
+func getPostgresDns() string {
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
 		os.Getenv(dbHost),
 		os.Getenv(dbUser),
@@ -0,0 +1,52 @@
+package dbconn
+
+import (
+	"fmt"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+//InMemoryDB - name of the sqlite database, which is kept only in memory and is lost when the server stops
+const InMemoryDB = ":memory:"
+
+//busyTimeout - how long(in milliseconds) a sqlite connection waits for a lock, held by another process, before failing
+const busyTimeout = 5000
+
+//SqliteDialectorCreator - creates sqlite database specific dialector
+func SqliteDialectorCreator(dbDNS string) gorm.Dialector {
+	return sqlite.Open(dbDNS)
+}
+
+//NewInMemoryDBConn - creates a new, empty in-memory sqlite database, every call returns a different database
+func NewInMemoryDBConn() (*gorm.DB, error) {
+	conn, err := gorm.Open(SqliteDialectorCreator(getSqliteDns(InMemoryDB)), &gorm.Config{})
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Cannot create a connection to the database.")
+	}
+	return conn, configureSqliteConn(conn)
+}
+
+//getSqliteDns - returns the data source name of a sqlite database file, the empty name is the in-memory database
+//sqlite enforces the foreign keys only if they are enabled for the connection
+func getSqliteDns(name string) string {
+	if name == "" || name == InMemoryDB {
+		return "file::memory:?_foreign_keys=1"
+	}
+	return fmt.Sprintf("file:%s?_busy_timeout=%d&_foreign_keys=1", name, busyTimeout)
+}
+
+//configureSqliteConn - sqlite allows only one writer at a time and every connection to an in-memory database
+//opens a separate database, so all queries go through a single connection, which is never closed
+func configureSqliteConn(conn *gorm.DB) error {
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Cannot configure the connection to the database.")
+	}
+
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+	return nil
+}
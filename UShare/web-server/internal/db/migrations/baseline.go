@@ -0,0 +1,280 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//baselineMigration - the schema, created by AutoMigrate before the introduction of the migrations
+//the tables are copies of the models at that time, so that the later changes of the models dont change the migration
+//a database, created by AutoMigrate, is only completed, because AutoMigrate never drops or changes the existing tables
+var baselineMigration = Migration{
+	Version: 1,
+	Name:    "baseline",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(baselineTables()...); err != nil {
+			return err
+		}
+
+		//the memberships of the group owners, created before the introduction of the roles, get the owner role
+		return tx.Table("memberships").
+			Where("role <> ?", "owner").
+			Where("EXISTS (?)", tx.Table("groups").
+				Select("1").
+				Where("groups.id = memberships.group_id").
+				Where("groups.owner_id = memberships.user_id")).
+			Update("role", "owner").Error
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(baselineTables()...)
+	},
+}
+
+func baselineTables() []interface{} {
+	return []interface{}{
+		&baselineUser{}, &baselineGroup{}, &baselineMembership{}, &baselineInvitation{}, &baselineSession{},
+		&baselineAccessToken{}, &baselineLoginFailure{}, &baselineAuditEvent{}, &baselinePasswordReset{},
+		&baselineTwoFactor{}, &baselineRecoveryCode{}, &baselineLoginChallenge{}, &baselineExternalIdentity{},
+		&baselineExternalLogin{}, &baselineFileInfo{}, &baselineBlob{}, &baselineUploadSession{},
+		&baselineUploadChunk{}, &baselineShareLink{}, &baselineFileTag{},
+	}
+}
+
+type baselineUser struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Username  string `gorm:"type:varchar(20);not null"`
+	Password  string `gorm:"type:varchar(256);not null"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineGroup struct {
+	ID               uint `gorm:"primarykey"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string `gorm:"type:varchar(256);not null"`
+	OwnerID          uint   `gorm:"type:Integer;not null"`
+	Active           bool   `gorm:"type:boolean;not null;default:true"`
+	Quota            int64  `gorm:"type:bigint;not null;default:1073741824"`
+	MaxFileSize      int64  `gorm:"type:bigint;not null;default:104857600"`
+	RequireTwoFactor bool   `gorm:"type:boolean;not null;default:false"`
+}
+
+func (baselineGroup) TableName() string { return "groups" }
+
+type baselineMembership struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	GroupID   uint   `gorm:"type:bigint;not null"`
+	UserID    uint   `gorm:"type:bigint;not null"`
+	Role      string `gorm:"type:varchar(32);not null;default:'contributor'"`
+}
+
+func (baselineMembership) TableName() string { return "memberships" }
+
+type baselineInvitation struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	GroupID   uint `gorm:"type:bigint;not null"`
+	UserID    uint `gorm:"type:bigint;not null"`
+	InviterID uint `gorm:"type:bigint;not null"`
+	ExpiresAt *time.Time
+}
+
+func (baselineInvitation) TableName() string { return "invitations" }
+
+type baselineSession struct {
+	ID                uint `gorm:"primarykey"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	UserID            uint      `gorm:"type:bigint;not null"`
+	TokenHash         string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	PreviousTokenHash string    `gorm:"type:varchar(64);index"`
+	ExpiresAt         time.Time `gorm:"not null"`
+	Revoked           bool      `gorm:"not null;default:false"`
+}
+
+func (baselineSession) TableName() string { return "sessions" }
+
+type baselineAccessToken struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint   `gorm:"type:bigint;not null;uniqueIndex:idx_access_tokens_user_name"`
+	Name       string `gorm:"type:varchar(64);not null;uniqueIndex:idx_access_tokens_user_name"`
+	TokenHash  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes     string `gorm:"type:varchar(256);not null;default:''"`
+	GroupID    *uint  `gorm:"type:bigint"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (baselineAccessToken) TableName() string { return "access_tokens" }
+
+type baselineLoginFailure struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Key           string    `gorm:"type:varchar(128);not null;uniqueIndex"`
+	Failures      uint      `gorm:"type:Integer;not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
+
+func (baselineLoginFailure) TableName() string { return "login_failures" }
+
+type baselineAuditEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	ActorID   *uint     `gorm:"type:bigint;index"`
+	UserID    *uint     `gorm:"type:bigint;index"`
+	GroupID   *uint     `gorm:"type:bigint;index"`
+	FileID    *uint     `gorm:"type:bigint"`
+	Action    string    `gorm:"type:varchar(64);not null"`
+	Details   string    `gorm:"type:varchar(512);not null;default:''"`
+	IP        string    `gorm:"type:varchar(64);not null;default:''"`
+}
+
+func (baselineAuditEvent) TableName() string { return "audit_events" }
+
+type baselinePasswordReset struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint      `gorm:"type:bigint;not null;index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+func (baselinePasswordReset) TableName() string { return "password_resets" }
+
+type baselineTwoFactor struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uint   `gorm:"type:bigint;not null;uniqueIndex"`
+	Secret       string `gorm:"type:varchar(64);not null"`
+	Enabled      bool   `gorm:"not null;default:false"`
+	LastUsedStep int64  `gorm:"type:bigint;not null;default:0"`
+}
+
+func (baselineTwoFactor) TableName() string { return "two_factors" }
+
+type baselineRecoveryCode struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"type:bigint;not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+}
+
+func (baselineRecoveryCode) TableName() string { return "recovery_codes" }
+
+type baselineLoginChallenge struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"type:bigint;not null;index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
+
+func (baselineLoginChallenge) TableName() string { return "login_challenges" }
+
+type baselineExternalIdentity struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `gorm:"type:bigint;not null;index"`
+	Issuer    string `gorm:"type:varchar(256);not null;uniqueIndex:idx_external_identity"`
+	Subject   string `gorm:"type:varchar(256);not null;uniqueIndex:idx_external_identity"`
+}
+
+func (baselineExternalIdentity) TableName() string { return "external_identities" }
+
+type baselineExternalLogin struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	StateHash    string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	RedirectURI  string    `gorm:"type:varchar(512);not null"`
+	ExpiresAt    time.Time `gorm:"not null"`
+}
+
+func (baselineExternalLogin) TableName() string { return "external_logins" }
+
+type baselineFileInfo struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Name      string `gorm:"type:varchar(256);not null"`
+	OwnerID   uint   `gorm:"type:Integer;not null"`
+	GroupID   uint   `gorm:"type:Integer;not null"`
+	ETag      string `gorm:"type:varchar(64)"`
+	Version   uint   `gorm:"type:Integer;not null;default:1"`
+	BlobID    uint   `gorm:"type:Integer"`
+	Size      int64  `gorm:"type:bigint;not null;default:0"`
+}
+
+func (baselineFileInfo) TableName() string { return "file_infos" }
+
+type baselineBlob struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Checksum  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Size      int64  `gorm:"type:bigint;not null"`
+	RefCount  uint   `gorm:"type:Integer;not null;default:0"`
+}
+
+func (baselineBlob) TableName() string { return "blobs" }
+
+type baselineUploadSession struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UploadID  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	FileName  string `gorm:"type:varchar(256);not null"`
+	Size      int64  `gorm:"type:bigint;not null"`
+	OwnerID   uint   `gorm:"type:Integer;not null"`
+	GroupID   uint   `gorm:"type:Integer;not null"`
+}
+
+func (baselineUploadSession) TableName() string { return "upload_sessions" }
+
+type baselineUploadChunk struct {
+	ID              uint `gorm:"primarykey"`
+	CreatedAt       time.Time
+	UploadSessionID uint  `gorm:"type:Integer;not null"`
+	Number          uint  `gorm:"type:Integer;not null"`
+	Offset          int64 `gorm:"column:chunk_offset;type:bigint;not null"`
+	Size            int64 `gorm:"type:bigint;not null"`
+}
+
+func (baselineUploadChunk) TableName() string { return "upload_chunks" }
+
+type baselineShareLink struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FileID    uint      `gorm:"type:bigint;not null"`
+	CreatorID uint      `gorm:"type:bigint;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	MaxUses   uint      `gorm:"type:Integer;not null;default:0"`
+	Uses      uint      `gorm:"type:Integer;not null;default:0"`
+	Revoked   bool      `gorm:"type:boolean;not null;default:false"`
+}
+
+func (baselineShareLink) TableName() string { return "share_links" }
+
+type baselineFileTag struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	GroupID   uint   `gorm:"type:bigint;not null;uniqueIndex:idx_file_tag"`
+	FileName  string `gorm:"type:varchar(256);not null;uniqueIndex:idx_file_tag"`
+	Name      string `gorm:"type:varchar(32);not null;uniqueIndex:idx_file_tag;index"`
+}
+
+func (baselineFileTag) TableName() string { return "file_tags" }
@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"gorm.io/gorm"
+)
+
+//fileVersionsMigration - unique index on the versions of a file, so that concurrent uploads cannot number their versions the same
+//the versions of the files, which the racy numbering has duplicated, are renumbered first by their order
+var fileVersionsMigration = Migration{
+	Version: 6,
+	Name:    "file_versions",
+	Up: func(tx *gorm.DB) error {
+		if err := renumberDuplicateVersions(tx); err != nil {
+			return err
+		} else if tx.Migrator().HasIndex(&versionedFileInfo{}, "idx_file_infos_version") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&versionedFileInfo{}, "idx_file_infos_version")
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropIndex(&versionedFileInfo{}, "idx_file_infos_version")
+	},
+}
+
+//renumberDuplicateVersions - numbers again all versions of the files, which have a duplicate version
+//the older versions keep their order, the duplicates are ordered by their ids
+func renumberDuplicateVersions(tx *gorm.DB) error {
+	var files []struct {
+		GroupID uint
+		Name    string
+	}
+
+	result := tx.Table("file_infos").
+		Select("group_id, name").
+		Group("group_id, name, version").
+		Having("count(*) > 1").
+		Scan(&files)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, file := range files {
+		var versionIDs []uint
+		result = tx.Table("file_infos").
+			Where("group_id = ?", file.GroupID).
+			Where("name = ?", file.Name).
+			Order("version").
+			Order("id").
+			Pluck("id", &versionIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for index, id := range versionIDs {
+			if result = tx.Table("file_infos").Where("id = ?", id).Update("version", index+1); result.Error != nil {
+				return result.Error
+			}
+		}
+	}
+	return nil
+}
+
+type versionedFileInfo struct {
+	ID      uint   `gorm:"primarykey"`
+	GroupID uint   `gorm:"type:Integer;not null;uniqueIndex:idx_file_infos_version"`
+	Name    string `gorm:"type:varchar(256);not null;uniqueIndex:idx_file_infos_version"`
+	Version uint   `gorm:"type:Integer;not null;default:1;uniqueIndex:idx_file_infos_version"`
+}
+
+func (versionedFileInfo) TableName() string { return "file_infos" }
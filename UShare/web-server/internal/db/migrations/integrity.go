@@ -0,0 +1,336 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//integrityMigration - foreign keys with cascade rules and unique indexes on the names and on the memberships
+//the duplicates and the orphans, which the racy checks of the DAOs have let in, are cleaned up first
+//the owner of a group, the uploader of a file and the inviter have no foreign keys, because they can be deleted
+var integrityMigration = Migration{
+	Version: 2,
+	Name:    "integrity",
+	Up: func(tx *gorm.DB) error {
+		if err := cleanUpDuplicates(tx); err != nil {
+			return err
+		} else if err = cleanUpOrphans(tx); err != nil {
+			return err
+		}
+
+		for _, index := range integrityIndexes {
+			if err := tx.Migrator().CreateIndex(index.model, index.name); err != nil {
+				return err
+			}
+		}
+
+		//sqlite cannot add a foreign key to an existing table, so the table is recreated with it
+		if isSqlite(tx) {
+			for _, table := range integrityTables {
+				if err := rebuildSqliteTable(tx, table.model); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, table := range integrityTables {
+			if table.index != "" {
+				if err := tx.Migrator().CreateIndex(table.model, table.index); err != nil {
+					return err
+				}
+			}
+			for _, constraint := range table.constraints {
+				if err := tx.Migrator().CreateConstraint(table.model, constraint); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		if isSqlite(tx) {
+			for index := len(integrityTables) - 1; index >= 0; index-- {
+				if err := rebuildSqliteTable(tx, integrityTables[index].baseline); err != nil {
+					return err
+				}
+			}
+		} else {
+			for _, table := range integrityTables {
+				for _, constraint := range table.constraints {
+					if err := tx.Migrator().DropConstraint(table.model, constraint); err != nil {
+						return err
+					}
+				}
+				if table.index == "" {
+					continue
+				} else if err := tx.Migrator().DropIndex(table.model, table.index); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, index := range integrityIndexes {
+			if err := tx.Migrator().DropIndex(index.model, index.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+//integrityIndexes - unique indexes on the tables, which are only referenced
+var integrityIndexes = []struct {
+	model interface{}
+	name  string
+}{
+	{model: &integrityUser{}, name: "idx_users_username"},
+	{model: &integrityGroup{}, name: "idx_groups_name"},
+}
+
+//integrityTables - tables, which get foreign keys, ordered so that a table is rebuilt before the ones, referencing it
+//the index is the unique index of the table, if it has one
+var integrityTables = []struct {
+	model       interface{}
+	baseline    interface{}
+	index       string
+	constraints []string
+}{
+	{model: &integrityMembership{}, baseline: &baselineMembership{}, index: "idx_memberships_group_user", constraints: []string{"fk_memberships_group", "fk_memberships_user"}},
+	{model: &integrityInvitation{}, baseline: &baselineInvitation{}, constraints: []string{"fk_invitations_group", "fk_invitations_user"}},
+	{model: &integrityFileInfo{}, baseline: &baselineFileInfo{}, constraints: []string{"fk_file_infos_group"}},
+	{model: &integrityFileTag{}, baseline: &baselineFileTag{}, constraints: []string{"fk_file_tags_group"}},
+	{model: &integrityShareLink{}, baseline: &baselineShareLink{}, constraints: []string{"fk_share_links_file"}},
+}
+
+//cleanUpDuplicates - renames the newer users and groups with a taken name, by appending their id,
+//and deletes the newer memberships of a user in the same group
+func cleanUpDuplicates(tx *gorm.DB) error {
+	if err := renameDuplicates(tx, "users", "username", 20); err != nil {
+		return err
+	} else if err = renameDuplicates(tx, "groups", "name", 256); err != nil {
+		return err
+	}
+
+	result := tx.Exec(`DELETE FROM memberships WHERE EXISTS (SELECT 1 FROM memberships AS older
+		WHERE older.group_id = memberships.group_id AND older.user_id = memberships.user_id AND older.id < memberships.id)`)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	//the deleted membership could be the one of the group owner
+	return tx.Table("memberships").
+		Where("role <> ?", "owner").
+		Where("EXISTS (?)", tx.Table("groups").
+			Select("1").
+			Where("groups.id = memberships.group_id").
+			Where("groups.owner_id = memberships.user_id")).
+		Update("role", "owner").Error
+}
+
+//renameDuplicates - appends the id to the name of every newer row with a taken name, the name is shortened to fit in its column
+//if the new name is taken too, a counter is appended after the id, until the name is free
+func renameDuplicates(tx *gorm.DB, table string, column string, maxLength int) error {
+	var duplicates []struct {
+		ID   uint
+		Name string
+	}
+
+	result := tx.Table(table).
+		Select(fmt.Sprintf("id, %s AS name", column)).
+		Where(fmt.Sprintf("EXISTS (SELECT 1 FROM %[1]s AS older WHERE older.%[2]s = %[1]s.%[2]s AND older.id < %[1]s.id)", table, column)).
+		Scan(&duplicates)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, duplicate := range duplicates {
+		name, err := freeName(tx, table, column, maxLength, duplicate.Name, duplicate.ID)
+		if err != nil {
+			return err
+		}
+
+		if result = tx.Table(table).Where("id = ?", duplicate.ID).Update(column, name); result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+//freeName - returns the first name of the form <name>-<id> or <name>-<id>-<counter>, which isnt taken in the table
+func freeName(tx *gorm.DB, table string, column string, maxLength int, name string, id uint) (string, error) {
+	for attempt := 1; ; attempt++ {
+		suffix := fmt.Sprintf("-%d", id)
+		if attempt > 1 {
+			suffix = fmt.Sprintf("-%d-%d", id, attempt)
+		}
+
+		candidate := name
+		if len(candidate)+len(suffix) > maxLength {
+			candidate = candidate[:maxLength-len(suffix)]
+		}
+		candidate += suffix
+
+		var count int64
+		if result := tx.Table(table).Where(fmt.Sprintf("%s = ?", column), candidate).Count(&count); result.Error != nil {
+			return "", result.Error
+		} else if count == 0 {
+			return candidate, nil
+		}
+	}
+}
+
+//cleanUpOrphans - deletes the rows, which reference missing users, groups or files
+//the reference counts of the blobs are recalculated, because some files could be deleted
+//the blobs, left without files, keep their rows with zero reference count, so that the trash purger erases their contents
+func cleanUpOrphans(tx *gorm.DB) error {
+	statements := []string{
+		"DELETE FROM memberships WHERE NOT EXISTS (SELECT 1 FROM groups WHERE groups.id = memberships.group_id) OR NOT EXISTS (SELECT 1 FROM users WHERE users.id = memberships.user_id)",
+		"DELETE FROM invitations WHERE NOT EXISTS (SELECT 1 FROM groups WHERE groups.id = invitations.group_id) OR NOT EXISTS (SELECT 1 FROM users WHERE users.id = invitations.user_id)",
+		"DELETE FROM file_infos WHERE NOT EXISTS (SELECT 1 FROM groups WHERE groups.id = file_infos.group_id)",
+		"DELETE FROM file_tags WHERE NOT EXISTS (SELECT 1 FROM groups WHERE groups.id = file_tags.group_id)",
+		"DELETE FROM share_links WHERE NOT EXISTS (SELECT 1 FROM file_infos WHERE file_infos.id = share_links.file_id)",
+		"UPDATE blobs SET ref_count = (SELECT count(*) FROM file_infos WHERE file_infos.blob_id = blobs.id)",
+	}
+
+	for _, statement := range statements {
+		if result := tx.Exec(statement); result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
+
+func isSqlite(tx *gorm.DB) bool {
+	return tx.Dialector.Name() == "sqlite"
+}
+
+//rebuildSqliteTable - recreates the table of the model with the current definition and copies its rows
+//the indexes are moved together with the renamed table, so they are dropped before the new ones are created
+func rebuildSqliteTable(tx *gorm.DB, model interface{}) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	table := stmt.Schema.Table
+	oldTable := table + "_old"
+
+	var indexes []string
+	result := tx.Table("sqlite_master").
+		Where("type = ?", "index").
+		Where("tbl_name = ?", table).
+		Where("sql IS NOT NULL").
+		Pluck("name", &indexes)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, index := range indexes {
+		if result = tx.Exec(fmt.Sprintf("DROP INDEX %q", index)); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	if err := tx.Migrator().RenameTable(table, oldTable); err != nil {
+		return err
+	} else if err = tx.Migrator().CreateTable(model); err != nil {
+		return err
+	}
+
+	columns := make([]string, 0, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		columns = append(columns, fmt.Sprintf("%q", name))
+	}
+	joined := strings.Join(columns, ", ")
+
+	if result = tx.Exec(fmt.Sprintf("INSERT INTO %q (%s) SELECT %s FROM %q", table, joined, joined, oldTable)); result.Error != nil {
+		return result.Error
+	}
+	return tx.Migrator().DropTable(oldTable)
+}
+
+type integrityUser struct {
+	ID       uint   `gorm:"primarykey"`
+	Username string `gorm:"type:varchar(20);not null;uniqueIndex:idx_users_username"`
+}
+
+func (integrityUser) TableName() string { return "users" }
+
+type integrityGroup struct {
+	ID   uint   `gorm:"primarykey"`
+	Name string `gorm:"type:varchar(256);not null;uniqueIndex:idx_groups_name"`
+}
+
+func (integrityGroup) TableName() string { return "groups" }
+
+type integrityMembership struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	GroupID   uint           `gorm:"type:bigint;not null;uniqueIndex:idx_memberships_group_user"`
+	UserID    uint           `gorm:"type:bigint;not null;uniqueIndex:idx_memberships_group_user"`
+	Role      string         `gorm:"type:varchar(32);not null;default:'contributor'"`
+	Group     integrityGroup `gorm:"constraint:fk_memberships_group,OnDelete:CASCADE"`
+	User      integrityUser  `gorm:"constraint:fk_memberships_user,OnDelete:CASCADE"`
+}
+
+func (integrityMembership) TableName() string { return "memberships" }
+
+type integrityInvitation struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	GroupID   uint `gorm:"type:bigint;not null"`
+	UserID    uint `gorm:"type:bigint;not null"`
+	InviterID uint `gorm:"type:bigint;not null"`
+	ExpiresAt *time.Time
+	Group     integrityGroup `gorm:"constraint:fk_invitations_group,OnDelete:CASCADE"`
+	User      integrityUser  `gorm:"constraint:fk_invitations_user,OnDelete:CASCADE"`
+}
+
+func (integrityInvitation) TableName() string { return "invitations" }
+
+type integrityFileInfo struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Name      string         `gorm:"type:varchar(256);not null"`
+	OwnerID   uint           `gorm:"type:Integer;not null"`
+	GroupID   uint           `gorm:"type:Integer;not null"`
+	ETag      string         `gorm:"type:varchar(64)"`
+	Version   uint           `gorm:"type:Integer;not null;default:1"`
+	BlobID    uint           `gorm:"type:Integer"`
+	Size      int64          `gorm:"type:bigint;not null;default:0"`
+	Group     integrityGroup `gorm:"constraint:fk_file_infos_group,OnDelete:CASCADE"`
+}
+
+func (integrityFileInfo) TableName() string { return "file_infos" }
+
+type integrityFileTag struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	GroupID   uint           `gorm:"type:bigint;not null;uniqueIndex:idx_file_tag"`
+	FileName  string         `gorm:"type:varchar(256);not null;uniqueIndex:idx_file_tag"`
+	Name      string         `gorm:"type:varchar(32);not null;uniqueIndex:idx_file_tag;index:idx_file_tags_name"`
+	Group     integrityGroup `gorm:"constraint:fk_file_tags_group,OnDelete:CASCADE"`
+}
+
+func (integrityFileTag) TableName() string { return "file_tags" }
+
+type integrityShareLink struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FileID    uint              `gorm:"type:bigint;not null"`
+	CreatorID uint              `gorm:"type:bigint;not null"`
+	ExpiresAt time.Time         `gorm:"not null"`
+	MaxUses   uint              `gorm:"type:Integer;not null;default:0"`
+	Uses      uint              `gorm:"type:Integer;not null;default:0"`
+	Revoked   bool              `gorm:"type:boolean;not null;default:false"`
+	File      integrityFileInfo `gorm:"constraint:fk_share_links_file,OnDelete:CASCADE"`
+}
+
+func (integrityShareLink) TableName() string { return "share_links" }
@@ -0,0 +1,216 @@
+package migrations
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"gorm.io/gorm"
+)
+
+//Migration - versioned change of the database schema, the down migration reverts the changes of the up one
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(*gorm.DB) error
+	Down    func(*gorm.DB) error
+}
+
+//MigrationStatus - migration together with the time, when it was applied, the time is missing if it is still pending
+//a migration is unknown, if it was applied by a newer version of the server
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	Known     bool
+}
+
+//All - returns all migrations of the server, ordered by their versions
+//new migrations are only appended, the applied ones must never change
+func All() []Migration {
+	return []Migration{
+		baselineMigration,
+		integrityMigration,
+		userLifecycleMigration,
+		trashMigration,
+		uploadExpiryMigration,
+		fileVersionsMigration,
+	}
+}
+
+//Migrator - applies and reverts migrations, the applied ones are recorded in the schema_migrations table
+type Migrator struct {
+	dbConn     *gorm.DB
+	migrations []Migration
+}
+
+//NewMigrator - creates an instance of Migrator, the migrations must be ordered by their versions
+func NewMigrator(dbConn *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		dbConn:     dbConn,
+		migrations: migrations,
+	}
+}
+
+//LatestVersion - returns the version of the newest known migration
+func (m *Migrator) LatestVersion() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+//CheckSchema - returns an error, if the database was migrated by a newer version of the server
+//the server cannot know, which of its queries are still valid for such schema
+func (m *Migrator) CheckSchema() error {
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for version := range applied {
+		if !m.isKnown(version) {
+			return myerr.NewServerError(fmt.Sprintf("The database schema has a migration [%d], unknown to this server. The latest known version is [%d]. Please upgrade the server", version, m.LatestVersion()))
+		}
+	}
+	return nil
+}
+
+//Up - applies the pending migrations up to the target version, the zero target applies all of them
+//every migration is applied in a separate transaction, returns the applied ones
+func (m *Migrator) Up(target uint) ([]Migration, error) {
+	if err := m.CheckSchema(); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		if target != 0 && migration.Version > target {
+			break
+		} else if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err = m.dbConn.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return myerr.NewServerErrorWrap(err, fmt.Sprintf("Couldnt apply migration [%d] %s", migration.Version, migration.Name))
+			}
+
+			record := models.SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+			if result := tx.Create(&record); result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, fmt.Sprintf("Couldnt record migration [%d]", migration.Version))
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+//Down - reverts the given count of the latest applied migrations, starting from the newest one
+//every migration is reverted in a separate transaction, returns the reverted ones
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, myerr.NewClientError("The count of the migrations to revert should be positive")
+	} else if err := m.CheckSchema(); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for index := len(m.migrations) - 1; index >= 0 && len(done) < steps; index-- {
+		migration := m.migrations[index]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err = m.dbConn.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return myerr.NewServerErrorWrap(err, fmt.Sprintf("Couldnt revert migration [%d] %s", migration.Version, migration.Name))
+			}
+
+			if result := tx.Delete(&models.SchemaMigration{}, migration.Version); result.Error != nil {
+				return myerr.NewServerErrorWrap(result.Error, fmt.Sprintf("Couldnt remove the record of migration [%d]", migration.Version))
+			}
+			return nil
+		})
+		if err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+//Status - returns the known migrations and the unknown applied ones, ordered by their versions
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.getAppliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Known: true}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, record := range applied {
+		if !m.isKnown(version) {
+			appliedAt := record.AppliedAt
+			statuses = append(statuses, MigrationStatus{Version: version, Name: record.Name, AppliedAt: &appliedAt})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+//getAppliedMigrations - returns the records of the applied migrations by their versions
+//the table of the records is created, if it doesnt exist yet
+func (m *Migrator) getAppliedMigrations() (map[uint]models.SchemaMigration, error) {
+	if !m.dbConn.Migrator().HasTable(&models.SchemaMigration{}) {
+		if err := m.dbConn.Migrator().CreateTable(&models.SchemaMigration{}); err != nil {
+			return nil, myerr.NewServerErrorWrap(err, "Couldnt create the table of the schema migrations")
+		}
+	}
+
+	var records []models.SchemaMigration
+	if result := m.dbConn.Find(&records); result.Error != nil {
+		return nil, myerr.NewServerErrorWrap(result.Error, "Couldnt fetch the applied schema migrations")
+	}
+
+	applied := make(map[uint]models.SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *Migrator) isKnown(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
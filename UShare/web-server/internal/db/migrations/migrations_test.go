@@ -0,0 +1,429 @@
+package migrations_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dbconn"
+	. "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/migrations"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gorm.io/gorm"
+)
+
+type firstTable struct {
+	ID uint `gorm:"primarykey"`
+}
+
+type secondTable struct {
+	ID uint `gorm:"primarykey"`
+}
+
+func createTableMigration(version uint, name string, table interface{}) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(table)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(table)
+		},
+	}
+}
+
+var _ = Describe("Migrator", func() {
+	var dbConn *gorm.DB
+
+	BeforeEach(func() {
+		var err error
+		dbConn, err = dbconn.NewInMemoryDBConn()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("with test migrations", func() {
+		var migrator *Migrator
+
+		BeforeEach(func() {
+			migrator = NewMigrator(dbConn, []Migration{
+				createTableMigration(1, "first", &firstTable{}),
+				createTableMigration(2, "second", &secondTable{}),
+			})
+		})
+
+		It("applies the pending migrations up to the target version", func() {
+			applied, err := migrator.Up(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(HaveLen(1))
+			Expect(dbConn.Migrator().HasTable(&firstTable{})).To(BeTrue())
+			Expect(dbConn.Migrator().HasTable(&secondTable{})).To(BeFalse())
+
+			applied, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(HaveLen(1))
+			Expect(applied[0].Version).To(Equal(uint(2)))
+
+			applied, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+		})
+
+		It("reverts the latest migrations", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			reverted, err := migrator.Down(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reverted).To(HaveLen(1))
+			Expect(reverted[0].Version).To(Equal(uint(2)))
+			Expect(dbConn.Migrator().HasTable(&secondTable{})).To(BeFalse())
+			Expect(dbConn.Migrator().HasTable(&firstTable{})).To(BeTrue())
+
+			statuses, err := migrator.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveLen(2))
+			Expect(statuses[0].AppliedAt).NotTo(BeNil())
+			Expect(statuses[1].AppliedAt).To(BeNil())
+		})
+
+		It("rejects a non positive count of migrations to revert", func() {
+			_, err := migrator.Down(0)
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("rolls back a failed migration", func() {
+			failing := createTableMigration(3, "failing", &firstTable{})
+			migrator = NewMigrator(dbConn, []Migration{createTableMigration(1, "first", &firstTable{}), failing})
+			_, err := migrator.Up(1)
+			Expect(err).NotTo(HaveOccurred())
+
+			applied, err := migrator.Up(0)
+			Expect(err).To(HaveOccurred())
+			Expect(applied).To(BeEmpty())
+
+			statuses, err := migrator.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses[1].AppliedAt).To(BeNil())
+		})
+
+		It("refuses to run against a newer schema", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Create(&models.SchemaMigration{Version: 3, Name: "newer"}).Error).To(Succeed())
+
+			err = migrator.CheckSchema()
+			Expect(err).To(HaveOccurred())
+			_, ok := err.(*myerr.ServerError)
+			Expect(ok).To(BeTrue())
+
+			_, err = migrator.Up(0)
+			Expect(err).To(HaveOccurred())
+			_, err = migrator.Down(1)
+			Expect(err).To(HaveOccurred())
+
+			statuses, err := migrator.Status()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveLen(3))
+			Expect(statuses[2].Known).To(BeFalse())
+		})
+	})
+
+	Context("with the migrations of the server", func() {
+		var migrator *Migrator
+
+		//revertedSince - returns the count of the migrations, which have to be reverted, so that the given version is reverted too
+		revertedSince := func(version uint) int {
+			return int(migrator.LatestVersion() - version + 1)
+		}
+
+		BeforeEach(func() {
+			migrator = NewMigrator(dbConn, All())
+		})
+
+		It("orders the migrations by their versions", func() {
+			migrations := All()
+			for i := 1; i < len(migrations); i++ {
+				Expect(migrations[i].Version).To(BeNumerically(">", migrations[i-1].Version))
+			}
+		})
+
+		It("creates and drops the whole schema", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasTable(&models.User{})).To(BeTrue())
+			Expect(dbConn.Migrator().HasTable(&models.FileTag{})).To(BeTrue())
+
+			reverted, err := migrator.Down(len(All()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reverted).To(HaveLen(len(All())))
+			Expect(dbConn.Migrator().HasTable(&models.User{})).To(BeFalse())
+			Expect(dbConn.Migrator().HasTable(&models.FileTag{})).To(BeFalse())
+		})
+
+		It("takes over a database, created before the migrations", func() {
+			Expect(dbConn.AutoMigrate(models.User{}, models.Group{}, models.Membership{})).To(Succeed())
+			Expect(dbConn.Create(&models.User{Username: "owner"}).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Group{Name: "group", OwnerID: 1}).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Membership{GroupID: 1, UserID: 1, Role: models.RoleContributor}).Error).To(Succeed())
+
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			var membership models.Membership
+			Expect(dbConn.First(&membership).Error).To(Succeed())
+			Expect(membership.Role).To(Equal(models.RoleOwner))
+		})
+
+		It("cleans up the duplicates and the orphans before adding the constraints", func() {
+			_, err := migrator.Up(1)
+			Expect(err).NotTo(HaveOccurred())
+
+			users := []models.User{{Username: "user"}, {Username: "user"}}
+			Expect(dbConn.Omit("Active", "DeactivatedAt").Create(&users).Error).To(Succeed())
+			groups := []models.Group{{Name: "group", OwnerID: users[0].ID}, {Name: "group", OwnerID: users[1].ID}}
+			Expect(dbConn.Omit("DeactivatedAt").Create(&groups).Error).To(Succeed())
+			memberships := []models.Membership{
+				{GroupID: groups[0].ID, UserID: users[0].ID, Role: models.RoleContributor},
+				{GroupID: groups[0].ID, UserID: users[0].ID, Role: models.RoleOwner},
+				{GroupID: groups[0].ID, UserID: 100, Role: models.RoleViewer},
+			}
+			Expect(dbConn.Create(&memberships).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Blob{Checksum: "checksum", RefCount: 2}).Error).To(Succeed())
+			Expect(dbConn.Omit("TrashedAt", "TrashedBy").Create(&models.FileInfo{Name: "file", GroupID: 100, BlobID: 1}).Error).To(Succeed())
+			Expect(dbConn.Create(&models.ShareLink{FileID: 1}).Error).To(Succeed())
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			Expect(dbConn.Model(&models.User{}).Order("id").Pluck("username", &names).Error).To(Succeed())
+			Expect(names).To(Equal([]string{"user", fmt.Sprintf("user-%d", users[1].ID)}))
+			Expect(dbConn.Model(&models.Group{}).Order("id").Pluck("name", &names).Error).To(Succeed())
+			Expect(names).To(Equal([]string{"group", fmt.Sprintf("group-%d", groups[1].ID)}))
+
+			var remaining []models.Membership
+			Expect(dbConn.Find(&remaining).Error).To(Succeed())
+			Expect(remaining).To(HaveLen(1))
+			Expect(remaining[0].Role).To(Equal(models.RoleOwner))
+
+			var count int64
+			Expect(dbConn.Model(&models.FileInfo{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+			Expect(dbConn.Model(&models.ShareLink{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+			var blob models.Blob
+			Expect(dbConn.First(&blob).Error).To(Succeed())
+			Expect(blob.RefCount).To(BeZero())
+
+			unreferenced, err := dao.NewFmDAOImpl(dbConn).GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unreferenced).To(HaveLen(1))
+			Expect(unreferenced[0].ID).To(Equal(blob.ID))
+		})
+
+		It("renames the duplicates to names, which arent taken", func() {
+			_, err := migrator.Up(1)
+			Expect(err).NotTo(HaveOccurred())
+
+			longName := "abcdefghijklmnopqrst"
+			shortened := func(suffix string) string {
+				return longName[:len(longName)-len(suffix)] + suffix
+			}
+			users := []models.User{{Username: "user"}, {Username: "user"}, {Username: longName}, {Username: longName}}
+			Expect(dbConn.Omit("Active", "DeactivatedAt").Create(&users).Error).To(Succeed())
+			taken := []models.User{
+				{Username: fmt.Sprintf("user-%d", users[1].ID)},
+				{Username: shortened(fmt.Sprintf("-%d", users[3].ID))},
+			}
+			Expect(dbConn.Omit("Active", "DeactivatedAt").Create(&taken).Error).To(Succeed())
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			Expect(dbConn.Model(&models.User{}).Order("id").Pluck("username", &names).Error).To(Succeed())
+			Expect(names).To(Equal([]string{
+				"user",
+				fmt.Sprintf("user-%d-2", users[1].ID),
+				longName,
+				shortened(fmt.Sprintf("-%d-2", users[3].ID)),
+				taken[0].Username,
+				taken[1].Username,
+			}))
+		})
+
+		It("enforces the constraints", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			user := models.User{Username: "user"}
+			Expect(dbConn.Create(&user).Error).To(Succeed())
+			Expect(dbConn.Create(&models.User{Username: "user"}).Error).NotTo(Succeed())
+
+			group := models.Group{Name: "group", OwnerID: user.ID}
+			Expect(dbConn.Create(&group).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Membership{GroupID: 100, UserID: user.ID}).Error).NotTo(Succeed())
+			Expect(dbConn.Create(&models.Membership{GroupID: group.ID, UserID: user.ID}).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Membership{GroupID: group.ID, UserID: user.ID}).Error).NotTo(Succeed())
+
+			Expect(dbConn.Delete(&group).Error).To(Succeed())
+			var count int64
+			Expect(dbConn.Model(&models.Membership{}).Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+		})
+
+		It("deletes the deactivated users, when the user lifecycle is reverted", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			deactivatedAt := time.Now()
+			users := []models.User{{Username: "active", Active: true}, {Username: "deleted", DeactivatedAt: &deactivatedAt}}
+			Expect(dbConn.Create(&users).Error).To(Succeed())
+			Expect(dbConn.Model(&users[1]).Update("active", false).Error).To(Succeed())
+			group := models.Group{Name: "group", OwnerID: users[0].ID}
+			Expect(dbConn.Create(&group).Error).To(Succeed())
+			Expect(dbConn.Create(&models.Membership{GroupID: group.ID, UserID: users[0].ID}).Error).To(Succeed())
+
+			_, err = migrator.Down(revertedSince(3))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.User{}, "DeactivatedAt")).To(BeFalse())
+
+			var names []string
+			Expect(dbConn.Table("users").Pluck("username", &names).Error).To(Succeed())
+			Expect(names).To(Equal([]string{"active"}))
+			Expect(dbConn.Exec("INSERT INTO users (username, password) VALUES (?, ?)", "active", "").Error).NotTo(Succeed())
+
+			var count int64
+			Expect(dbConn.Table("memberships").Count(&count).Error).To(Succeed())
+			Expect(count).To(Equal(int64(1)))
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.User{}, "DeactivatedAt")).To(BeTrue())
+		})
+
+		It("deletes the trashed files, when the trash is reverted", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			user := models.User{Username: "owner", Active: true}
+			Expect(dbConn.Create(&user).Error).To(Succeed())
+			group := models.Group{Name: "group", OwnerID: user.ID, Active: true}
+			Expect(dbConn.Create(&group).Error).To(Succeed())
+			blob := models.Blob{Checksum: "checksum", RefCount: 2}
+			Expect(dbConn.Create(&blob).Error).To(Succeed())
+			trashedBlob := models.Blob{Checksum: "trashed-checksum", RefCount: 1}
+			Expect(dbConn.Create(&trashedBlob).Error).To(Succeed())
+			trashedAt := time.Now()
+			files := []models.FileInfo{
+				{Name: "kept", GroupID: group.ID, OwnerID: user.ID, BlobID: blob.ID},
+				{Name: "trashed", GroupID: group.ID, OwnerID: user.ID, BlobID: blob.ID, TrashedAt: &trashedAt, TrashedBy: &user.ID},
+				{Name: "trashed-content", GroupID: group.ID, OwnerID: user.ID, BlobID: trashedBlob.ID, TrashedAt: &trashedAt, TrashedBy: &user.ID},
+			}
+			Expect(dbConn.Create(&files).Error).To(Succeed())
+			Expect(dbConn.Create(&models.FileTag{GroupID: group.ID, FileName: "trashed", Name: "tag"}).Error).To(Succeed())
+
+			_, err = migrator.Down(revertedSince(4))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.FileInfo{}, "TrashedAt")).To(BeFalse())
+
+			var names []string
+			Expect(dbConn.Table("file_infos").Pluck("name", &names).Error).To(Succeed())
+			Expect(names).To(Equal([]string{"kept"}))
+			var count int64
+			Expect(dbConn.Table("file_tags").Count(&count).Error).To(Succeed())
+			Expect(count).To(BeZero())
+			Expect(dbConn.First(&blob, blob.ID).Error).To(Succeed())
+			Expect(blob.RefCount).To(Equal(uint(1)))
+			Expect(dbConn.First(&trashedBlob, trashedBlob.ID).Error).To(Succeed())
+			Expect(trashedBlob.RefCount).To(BeZero())
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.FileInfo{}, "TrashedAt")).To(BeTrue())
+
+			unreferenced, err := dao.NewFmDAOImpl(dbConn).GetUnreferencedBlobs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unreferenced).To(HaveLen(1))
+			Expect(unreferenced[0].ID).To(Equal(trashedBlob.ID))
+		})
+
+		It("reverts the trash only after the files, uploaded before the deduplication, are purged from it", func() {
+			_, err := migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			user := models.User{Username: "owner", Active: true}
+			Expect(dbConn.Create(&user).Error).To(Succeed())
+			group := models.Group{Name: "group", OwnerID: user.ID, Active: true}
+			Expect(dbConn.Create(&group).Error).To(Succeed())
+			trashedAt := time.Now().Add(-time.Hour)
+			legacyFile := models.FileInfo{Name: "legacy", GroupID: group.ID, OwnerID: user.ID, TrashedAt: &trashedAt, TrashedBy: &user.ID}
+			Expect(dbConn.Create(&legacyFile).Error).To(Succeed())
+
+			_, err = migrator.Down(revertedSince(4))
+			Expect(err).To(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.FileInfo{}, "TrashedAt")).To(BeTrue())
+			Expect(dbConn.First(&models.FileInfo{}, legacyFile.ID).Error).To(Succeed())
+
+			purged, err := dao.NewFmDAOImpl(dbConn).PurgeTrashedFiles(time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(purged).To(HaveLen(1))
+			Expect(purged[0].ID).To(Equal(legacyFile.ID))
+
+			_, err = migrator.Down(revertedSince(4))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.FileInfo{}, "TrashedAt")).To(BeTrue())
+		})
+
+		It("renumbers the duplicate versions of the files before their versions become unique", func() {
+			_, err := migrator.Up(5)
+			Expect(err).NotTo(HaveOccurred())
+			user := models.User{Username: "owner", Active: true}
+			Expect(dbConn.Create(&user).Error).To(Succeed())
+			group := models.Group{Name: "group", OwnerID: user.ID, Active: true}
+			Expect(dbConn.Create(&group).Error).To(Succeed())
+			files := []models.FileInfo{
+				{Name: "file", GroupID: group.ID, OwnerID: user.ID, Version: 1},
+				{Name: "file", GroupID: group.ID, OwnerID: user.ID, Version: 2},
+				{Name: "file", GroupID: group.ID, OwnerID: user.ID, Version: 2},
+				{Name: "file", GroupID: group.ID, OwnerID: user.ID, Version: 3},
+				{Name: "other", GroupID: group.ID, OwnerID: user.ID, Version: 2},
+			}
+			Expect(dbConn.Create(&files).Error).To(Succeed())
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+
+			var versions []uint
+			Expect(dbConn.Table("file_infos").Order("id").Pluck("version", &versions).Error).To(Succeed())
+			Expect(versions).To(Equal([]uint{1, 2, 3, 4, 2}))
+			Expect(dbConn.Create(&models.FileInfo{Name: "other", GroupID: group.ID, OwnerID: user.ID, Version: 2}).Error).NotTo(Succeed())
+		})
+
+		It("gives the pending uploads a full lifetime, when their expiry is added", func() {
+			_, err := migrator.Up(4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Exec("INSERT INTO upload_sessions (upload_id, file_name, size, owner_id, group_id) VALUES (?, ?, ?, ?, ?)", "upload", "file", 10, 1, 1).Error).To(Succeed())
+
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.UploadSession{}, "ExpiresAt")).To(BeTrue())
+
+			var session models.UploadSession
+			Expect(dbConn.First(&session).Error).To(Succeed())
+			Expect(session.ExpiresAt).To(BeTemporally("~", time.Now().Add(24*time.Hour), time.Minute))
+
+			_, err = migrator.Down(revertedSince(5))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dbConn.Migrator().HasIndex(&models.UploadSession{}, "ExpiresAt")).To(BeFalse())
+			_, err = migrator.Up(0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//trashMigration - deleted files and groups are moved to a trash and erased after the retention period
+//the reverted migration erases the trashed files immediately, as it was done before it
+//the blobs, left without files, keep their rows with zero reference count, their contents are erased by the trash purger,
+//once the migration is applied again, until then the older server only references them again, if the same content is uploaded
+//the migration isnt reverted, while the trash has files from before the deduplication, because their contents are stored under their own keys
+//and only the trash purger erases them
+var trashMigration = Migration{
+	Version: 4,
+	Name:    "trash",
+	Up: func(tx *gorm.DB) error {
+		for _, column := range trashColumns {
+			//when the migration is applied again after a revert on sqlite, the columns are still there
+			if !tx.Migrator().HasColumn(column.model, column.name) {
+				if err := tx.Migrator().AddColumn(column.model, column.name); err != nil {
+					return err
+				}
+			}
+
+			if !column.indexed || tx.Migrator().HasIndex(column.model, column.name) {
+				continue
+			} else if err := tx.Migrator().CreateIndex(column.model, column.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	Down: func(tx *gorm.DB) error {
+		var legacyFiles int64
+		result := tx.Table("file_infos").
+			Where("trashed_at IS NOT NULL").
+			Where("coalesce(blob_id, 0) = 0").
+			Count(&legacyFiles)
+		if result.Error != nil {
+			return result.Error
+		} else if legacyFiles > 0 {
+			return fmt.Errorf("the trash has %d files, uploaded before the deduplication, whose contents would be left in the storage, they have to be purged first", legacyFiles)
+		}
+
+		statements := []string{
+			"DELETE FROM share_links WHERE file_id IN (SELECT id FROM file_infos WHERE trashed_at IS NOT NULL)",
+			"DELETE FROM file_infos WHERE trashed_at IS NOT NULL",
+			"DELETE FROM file_tags WHERE NOT EXISTS (SELECT 1 FROM file_infos WHERE file_infos.group_id = file_tags.group_id AND file_infos.name = file_tags.file_name)",
+			"UPDATE blobs SET ref_count = (SELECT count(*) FROM file_infos WHERE file_infos.blob_id = blobs.id)",
+		}
+
+		for _, statement := range statements {
+			if result := tx.Exec(statement); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, column := range trashColumns {
+			if !column.indexed {
+				continue
+			} else if err := tx.Migrator().DropIndex(column.model, column.name); err != nil {
+				return err
+			}
+		}
+
+		//sqlite can drop a column only by recreating the table, which would delete the rows, referencing the files and the groups
+		//so the columns are kept there, the older server doesnt use them
+		if isSqlite(tx) {
+			return nil
+		}
+		for _, column := range trashColumns {
+			if err := tx.Migrator().DropColumn(column.model, column.name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+//trashColumns - columns, which mark the files and the groups in the trash
+var trashColumns = []struct {
+	model   interface{}
+	name    string
+	indexed bool
+}{
+	{model: &trashFileInfo{}, name: "TrashedAt", indexed: true},
+	{model: &trashFileInfo{}, name: "TrashedBy"},
+	{model: &trashGroup{}, name: "DeactivatedAt", indexed: true},
+}
+
+type trashFileInfo struct {
+	ID        uint       `gorm:"primarykey"`
+	TrashedAt *time.Time `gorm:"index"`
+	TrashedBy *uint      `gorm:"type:bigint"`
+}
+
+func (trashFileInfo) TableName() string { return "file_infos" }
+
+type trashGroup struct {
+	ID            uint       `gorm:"primarykey"`
+	DeactivatedAt *time.Time `gorm:"index"`
+}
+
+func (trashGroup) TableName() string { return "groups" }
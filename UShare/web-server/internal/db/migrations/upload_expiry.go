@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//pendingUploadLifetime - how long the upload sessions, started before the migration, are kept
+const pendingUploadLifetime = 24 * time.Hour
+
+//uploadExpiryMigration - upload sessions expire and are erased together with their chunks
+//the sessions, which are already started, get a full lifetime, so that their uploads can still be completed
+var uploadExpiryMigration = Migration{
+	Version: 5,
+	Name:    "upload_expiry",
+	Up: func(tx *gorm.DB) error {
+		//the column is kept by the reverted migration on sqlite
+		if !tx.Migrator().HasColumn(&expiryUploadSession{}, "ExpiresAt") {
+			if err := tx.Migrator().AddColumn(&expiryUploadSession{}, "ExpiresAt"); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&expiryUploadSession{}).
+			Where("expires_at IS NULL").
+			Update("expires_at", time.Now().Add(pendingUploadLifetime))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if tx.Migrator().HasIndex(&expiryUploadSession{}, "ExpiresAt") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&expiryUploadSession{}, "ExpiresAt")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&expiryUploadSession{}, "ExpiresAt"); err != nil {
+			return err
+		}
+
+		//sqlite can drop a column only by recreating the table, the older server doesnt use it
+		if isSqlite(tx) {
+			return nil
+		}
+		return tx.Migrator().DropColumn(&expiryUploadSession{}, "ExpiresAt")
+	},
+}
+
+type expiryUploadSession struct {
+	ID        uint       `gorm:"primarykey"`
+	ExpiresAt *time.Time `gorm:"index"`
+}
+
+func (expiryUploadSession) TableName() string { return "upload_sessions" }
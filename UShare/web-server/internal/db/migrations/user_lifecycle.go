@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//userLifecycleMigration - deleted users are only deactivated and purged after the retention period
+//the reverted migration deletes the deactivated users immediately, as it was done before it
+var userLifecycleMigration = Migration{
+	Version: 3,
+	Name:    "user_lifecycle",
+	Up: func(tx *gorm.DB) error {
+		//a revert on sqlite leaves Active and DeactivatedAt in place, so applying the migration again skips them
+		for _, column := range []string{"Active", "DeactivatedAt"} {
+			if tx.Migrator().HasColumn(&lifecycleUser{}, column) {
+				continue
+			} else if err := tx.Migrator().AddColumn(&lifecycleUser{}, column); err != nil {
+				return err
+			}
+		}
+
+		if tx.Migrator().HasIndex(&lifecycleUser{}, "DeactivatedAt") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&lifecycleUser{}, "DeactivatedAt")
+	},
+	Down: func(tx *gorm.DB) error {
+		if result := tx.Where("active = ?", false).Delete(&lifecycleUser{}); result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Migrator().DropIndex(&lifecycleUser{}, "DeactivatedAt"); err != nil {
+			return err
+		}
+
+		//sqlite can drop a column only by recreating the table, which would delete the rows, referencing the users
+		//so the columns are kept there, the older server doesnt use them and the new users get their defaults
+		if isSqlite(tx) {
+			return nil
+		}
+		for _, column := range []string{"DeactivatedAt", "Active"} {
+			if err := tx.Migrator().DropColumn(&lifecycleUser{}, column); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+type lifecycleUser struct {
+	ID            uint       `gorm:"primarykey"`
+	Active        bool       `gorm:"type:boolean;not null;default:true"`
+	DeactivatedAt *time.Time `gorm:"index"`
+}
+
+func (lifecycleUser) TableName() string { return "users" }
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	//ScopeRead - scope of an access token, which can view the groups and download their files
+	ScopeRead = "read"
+	//ScopeUpload - scope of an access token, which can upload files
+	ScopeUpload = "upload"
+)
+
+//AccessToken is a model representing a record in the table of personal access tokens
+//only the hash of the token is stored, the scopes are comma separated and no scopes mean full access
+//the token can be restricted to a single group and it never expires, if it doesnt have an expiry time
+type AccessToken struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint   `gorm:"type:bigint;not null;uniqueIndex:idx_access_tokens_user_name"`
+	Name       string `gorm:"type:varchar(64);not null;uniqueIndex:idx_access_tokens_user_name"`
+	TokenHash  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Scopes     string `gorm:"type:varchar(256);not null;default:''"`
+	GroupID    *uint  `gorm:"type:bigint"`
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+//HasScope - checks if the token is allowed to perform operations of the given scope
+func (t AccessToken) HasScope(scope string) bool {
+	if t.Scopes == "" {
+		return true
+	}
+
+	for _, granted := range strings.Split(t.Scopes, ",") {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
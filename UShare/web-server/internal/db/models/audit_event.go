@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+const (
+	//AuditAccountLocked - the logins of an account were blocked, because of too many failed attempts
+	AuditAccountLocked = "account.locked"
+
+	//AuditGroupCreated - a group was created
+	AuditGroupCreated = "group.created"
+	//AuditGroupDeleted - a group was deleted
+	AuditGroupDeleted = "group.deleted"
+	//AuditGroupRecovered - a deleted group was restored from the trash
+	AuditGroupRecovered = "group.recovered"
+	//AuditGroupLimitsChanged - the quota or the maximum file size of a group was changed
+	AuditGroupLimitsChanged = "group.limits_changed"
+	//AuditGroupTwoFactorChanged - the two-factor requirement of a group was changed
+	AuditGroupTwoFactorChanged = "group.two_factor_changed"
+	//AuditGroupOwnershipTransferred - the ownership of a group was given to the target user
+	AuditGroupOwnershipTransferred = "group.ownership_transferred"
+
+	//AuditMemberInvited - the target user was invited to a group
+	AuditMemberInvited = "member.invited"
+	//AuditInvitationRevoked - the invitation of the target user was revoked
+	AuditInvitationRevoked = "invitation.revoked"
+	//AuditInvitationAccepted - the target user accepted an invitation and became a member
+	AuditInvitationAccepted = "invitation.accepted"
+	//AuditInvitationDeclined - the target user declined an invitation
+	AuditInvitationDeclined = "invitation.declined"
+	//AuditMemberRemoved - the target user was removed from a group or left it
+	AuditMemberRemoved = "member.removed"
+	//AuditMemberRoleChanged - the role of the target user was changed
+	AuditMemberRoleChanged = "member.role_changed"
+
+	//AuditFileUploaded - a file (or a new version of it) was uploaded
+	AuditFileUploaded = "file.uploaded"
+	//AuditFileDownloaded - a file was downloaded by a member or through a share link
+	AuditFileDownloaded = "file.downloaded"
+	//AuditFileDeleted - a file was deleted
+	AuditFileDeleted = "file.deleted"
+	//AuditFileRestored - an older version of a file was restored
+	AuditFileRestored = "file.restored"
+	//AuditFileRecovered - a deleted file was restored from the trash
+	AuditFileRecovered = "file.recovered"
+	//AuditFileTagged - the tags of a file were changed
+	AuditFileTagged = "file.tagged"
+	//AuditShareLinkCreated - a public link to a file was created
+	AuditShareLinkCreated = "share_link.created"
+	//AuditShareLinkRevoked - a public link to a file was revoked
+	AuditShareLinkRevoked = "share_link.revoked"
+)
+
+//AuditEvent is a model representing a record in the table of audit events
+//the actor is the one, who made the change, it is missing for the events, caused by the system or by anonymous users
+//the user is the one, whom the event concerns, it is missing if the event concerns an unknown username
+//the group and the file are missing for the events, which arent related to them
+type AuditEvent struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	ActorID   *uint     `gorm:"type:bigint;index"`
+	UserID    *uint     `gorm:"type:bigint;index"`
+	GroupID   *uint     `gorm:"type:bigint;index"`
+	FileID    *uint     `gorm:"type:bigint"`
+	Action    string    `gorm:"type:varchar(64);not null"`
+	Details   string    `gorm:"type:varchar(512);not null;default:''"`
+	IP        string    `gorm:"type:varchar(64);not null;default:''"`
+}
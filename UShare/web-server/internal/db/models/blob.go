@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+//Blob is a model representing a stored file content, shared by all files with the same checksum
+//the content is deleted from the storage, when no file references it anymore
+type Blob struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Checksum  string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Size      int64  `gorm:"type:bigint;not null"`
+	RefCount  uint   `gorm:"type:Integer;not null;default:0"`
+}
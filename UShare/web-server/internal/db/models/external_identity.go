@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+//ExternalIdentity is a model representing a record in the table of external identities
+//it links a user to his identity in an external identity provider, the issuer and the subject identify it uniquely
+type ExternalIdentity struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `gorm:"type:bigint;not null;index"`
+	Issuer    string `gorm:"type:varchar(256);not null;uniqueIndex:idx_external_identity"`
+	Subject   string `gorm:"type:varchar(256);not null;uniqueIndex:idx_external_identity"`
+}
+
+//ExternalLogin is a model representing a record in the table of pending logins through an external identity provider
+//only the hash of the state is stored, the nonce and the code verifier are needed for the exchange of the authorization code
+type ExternalLogin struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	StateHash    string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	RedirectURI  string    `gorm:"type:varchar(512);not null"`
+	ExpiresAt    time.Time `gorm:"not null"`
+}
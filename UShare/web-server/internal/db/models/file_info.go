@@ -3,10 +3,20 @@ package models
 import "time"
 
 //FileInfo is a model representing the most important info for a file
+//every upload of a file with the same name in a group is saved as a new version of it, the versions are unique per file
+//the ETag is the sha256 checksum of the content, which is kept in the referenced Blob
+//files, uploaded before the deduplication of the contents, dont reference a Blob
+//a deleted file is only moved to the trash of its group, where it is kept until the retention period expires
 type FileInfo struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
-	Name      string `gorm:"type:varchar(256);not null"`
-	OwnerID   uint   `gorm:"type:Integer;not null"`
-	GroupID   uint   `gorm:"type:Integer;not null"`
+	Name      string     `gorm:"type:varchar(256);not null;uniqueIndex:idx_file_infos_version"`
+	OwnerID   uint       `gorm:"type:Integer;not null"`
+	GroupID   uint       `gorm:"type:Integer;not null;uniqueIndex:idx_file_infos_version"`
+	ETag      string     `gorm:"type:varchar(64)"`
+	Version   uint       `gorm:"type:Integer;not null;default:1;uniqueIndex:idx_file_infos_version"`
+	BlobID    uint       `gorm:"type:Integer"`
+	Size      int64      `gorm:"type:bigint;not null;default:0"`
+	TrashedAt *time.Time `gorm:"index"`
+	TrashedBy *uint      `gorm:"type:bigint"`
 }
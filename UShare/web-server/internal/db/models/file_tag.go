@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+//FileTag is a model representing a record in the table of the tags of the files
+//the tags belong to the file name in the group, so all versions of the file share them
+type FileTag struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	GroupID   uint   `gorm:"type:bigint;not null;uniqueIndex:idx_file_tag"`
+	FileName  string `gorm:"type:varchar(256);not null;uniqueIndex:idx_file_tag"`
+	Name      string `gorm:"type:varchar(32);not null;uniqueIndex:idx_file_tag;index"`
+}
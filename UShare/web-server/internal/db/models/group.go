@@ -3,11 +3,19 @@ package models
 import "time"
 
 //Group is a model representing a record in the table of groups
+//the quota limits the total size of the group files (in bytes), the max file size limits the size of a single file
+//the files of the groups, which require two-factor authentication, are accessible only to members with enabled totp
+//deleting a group deletes its memberships, invitations and files (on delete cascade)
+//a deactivated group stays in the trash of its owner and its name stays taken, until it is erased after the retention period
 type Group struct {
-	ID        uint `gorm:"primarykey"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string `gorm:"type:varchar(256);not null"`
-	OwnerID   uint   `gorm:"type:Integer;not null"`
-	Active    bool   `gorm:"type:boolean;not null;default:true"`
+	ID               uint `gorm:"primarykey"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Name             string     `gorm:"type:varchar(256);not null;uniqueIndex:idx_groups_name"`
+	OwnerID          uint       `gorm:"type:Integer;not null"`
+	Active           bool       `gorm:"type:boolean;not null;default:true"`
+	Quota            int64      `gorm:"type:bigint;not null;default:1073741824"`
+	MaxFileSize      int64      `gorm:"type:bigint;not null;default:104857600"`
+	RequireTwoFactor bool       `gorm:"type:boolean;not null;default:false"`
+	DeactivatedAt    *time.Time `gorm:"index"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+//Invitation is a model representing a record in the table of invitations
+//the invitation is pending until the invited user accepts or declines it, or until it expires (if it has an expiry time)
+type Invitation struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	GroupID   uint `gorm:"type:bigint;not null"`
+	UserID    uint `gorm:"type:bigint;not null"`
+	InviterID uint `gorm:"type:bigint;not null"`
+	ExpiresAt *time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+//LoginFailure is a model representing a record in the table of failed logins
+//the key identifies what the failures are counted for - a username or an ip address
+//the logins for the key are blocked until LockedUntil, if it is set
+type LoginFailure struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Key           string    `gorm:"type:varchar(128);not null;uniqueIndex"`
+	Failures      uint      `gorm:"type:Integer;not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+}
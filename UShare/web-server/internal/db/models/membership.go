@@ -2,11 +2,25 @@ package models
 
 import "time"
 
+const (
+	//RoleOwner - role of the group owner, who has full control over the group
+	RoleOwner = "owner"
+	//RoleAdmin - role of a member, who can manage the files and the members with lower roles
+	RoleAdmin = "admin"
+	//RoleContributor - role of a member, who can upload files and delete his own files
+	RoleContributor = "contributor"
+	//RoleViewer - role of a member, who can only view and download files
+	RoleViewer = "viewer"
+)
+
 //Membership is a model representing a record in the table of Memberships
+//the role of the membership determines what the member is allowed to do in the group
+//a user has at most one membership in a group, it is deleted together with the user or the group
 type Membership struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	GroupID   uint `gorm:"type:bigint;not null"`
-	UserID    uint `gorm:"type:bigint;not null"`
+	GroupID   uint   `gorm:"type:bigint;not null;uniqueIndex:idx_memberships_group_user"`
+	UserID    uint   `gorm:"type:bigint;not null;uniqueIndex:idx_memberships_group_user"`
+	Role      string `gorm:"type:varchar(32);not null;default:'contributor'"`
 }
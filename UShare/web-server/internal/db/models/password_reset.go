@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+//PasswordReset is a model representing a record in the table of password resets
+//only the hash of the one-time reset token is stored, the reset is deleted after its use
+type PasswordReset struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint      `gorm:"type:bigint;not null;index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+//SchemaMigration is a model representing a versioned migration of the database schema, which was applied
+type SchemaMigration struct {
+	Version   uint      `gorm:"primarykey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(128);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
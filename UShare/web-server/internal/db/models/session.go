@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+//Session is a model representing a record in the table of login sessions
+//only the hashes of the refresh tokens are stored, the previous one is kept to detect the reuse of rotated tokens
+type Session struct {
+	ID                uint `gorm:"primarykey"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	UserID            uint      `gorm:"type:bigint;not null"`
+	TokenHash         string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	PreviousTokenHash string    `gorm:"type:varchar(64);index"`
+	ExpiresAt         time.Time `gorm:"not null"`
+	Revoked           bool      `gorm:"not null;default:false"`
+}
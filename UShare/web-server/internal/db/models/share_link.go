@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+//ShareLink is a model representing a record in the table of share links
+//the link gives access to a single file to anyone, who has its token, until it expires, is revoked or is used up
+//zero max uses means that the number of uses isnt limited
+type ShareLink struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	FileID    uint      `gorm:"type:bigint;not null"`
+	CreatorID uint      `gorm:"type:bigint;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	MaxUses   uint      `gorm:"type:Integer;not null;default:0"`
+	Uses      uint      `gorm:"type:Integer;not null;default:0"`
+	Revoked   bool      `gorm:"type:boolean;not null;default:false"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+//TwoFactor is a model representing a record in the table of the totp enrollments
+//the enrollment is pending until the user confirms it with a code, the last used period prevents the reuse of codes
+type TwoFactor struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	UserID       uint   `gorm:"type:bigint;not null;uniqueIndex"`
+	Secret       string `gorm:"type:varchar(64);not null"`
+	Enabled      bool   `gorm:"not null;default:false"`
+	LastUsedStep int64  `gorm:"type:bigint;not null;default:0"`
+}
+
+//RecoveryCode is a model representing a record in the table of the recovery codes of the totp enrollments
+//only the hash of the one-time code is stored, the code is deleted after its use
+type RecoveryCode struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"type:bigint;not null;index"`
+	CodeHash  string `gorm:"type:varchar(64);not null"`
+}
+
+//LoginChallenge is a model representing a record in the table of the pending logins of users with two-factor authentication
+//the challenge is issued after the password is verified and is exchanged for a session with a totp or a recovery code
+type LoginChallenge struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"type:bigint;not null;index"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
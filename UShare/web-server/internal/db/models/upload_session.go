@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+//UploadSession is a model representing an unfinished chunked upload of a file
+type UploadSession struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UploadID  string    `gorm:"type:varchar(64);not null;uniqueIndex"`
+	FileName  string    `gorm:"type:varchar(256);not null"`
+	Size      int64     `gorm:"type:bigint;not null"`
+	OwnerID   uint      `gorm:"type:Integer;not null"`
+	GroupID   uint      `gorm:"type:Integer;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+//UploadChunk is a model representing a chunk of data, already received for an upload session
+type UploadChunk struct {
+	ID              uint `gorm:"primarykey"`
+	CreatedAt       time.Time
+	UploadSessionID uint  `gorm:"type:Integer;not null"`
+	Number          uint  `gorm:"type:Integer;not null"`
+	Offset          int64 `gorm:"column:chunk_offset;type:bigint;not null"`
+	Size            int64 `gorm:"type:bigint;not null"`
+}
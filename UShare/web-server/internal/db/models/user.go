@@ -3,10 +3,13 @@ package models
 import "time"
 
 //User is a model representing a record in the table of Users
+//a deleted user is only deactivated and his username stays taken, until he is purged after the retention period
 type User struct {
-	ID        uint `gorm:"primarykey"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Username  string `gorm:"type:varchar(20);not null"`
-	Password  string `gorm:"type:varchar(256);not null"`
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Username      string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_users_username"`
+	Password      string     `gorm:"type:varchar(256);not null"`
+	Active        bool       `gorm:"type:boolean;not null;default:true"`
+	DeactivatedAt *time.Time `gorm:"index"`
 }
@@ -1,6 +1,10 @@
 package error
 
-import "github.com/pkg/errors"
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 //ClientError represents a problem with client request
 type ClientError struct {
@@ -43,6 +47,26 @@ func NewItemNotFoundError(description string) *ItemNotFoundError {
 	}
 }
 
+//LockedError - the operation is temporarily blocked, because of too many failed attempts
+//the operation can be retried after RetryAfter
+type LockedError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+//Error - returns description of the error
+func (e *LockedError) Error() string {
+	return e.Err.Error()
+}
+
+//NewLockedError - creates an instance of LockedError
+func NewLockedError(description string, retryAfter time.Duration) *LockedError {
+	return &LockedError{
+		Err:        errors.New(description),
+		RetryAfter: retryAfter,
+	}
+}
+
 //ServerError represents a problem with server
 type ServerError struct {
 	Err error
@@ -0,0 +1,194 @@
+package lockout
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+//go:generate mockgen --source=lockout.go --destination lockout_mocks/lockout.go --package lockout_mocks
+
+const (
+	maxFailuresKey   = "LOGIN_MAX_FAILURES"
+	maxIPFailuresKey = "LOGIN_MAX_IP_FAILURES"
+	lockoutKey       = "LOGIN_LOCKOUT"
+	maxLockoutKey    = "LOGIN_MAX_LOCKOUT"
+
+	defaultMaxFailures       = 5
+	defaultMaxIPFailures     = 20
+	defaultLockoutMinutes    = 1
+	defaultMaxLockoutMinutes = 60
+
+	maxKeyUsernameLength = 100
+)
+
+//Service - interface for protection of the logins against brute-force attacks
+type Service interface {
+	Check(username string, ip string) error
+	RecordFailure(userID uint, username string, ip string) error
+	RecordSuccess(username string) error
+}
+
+//ServiceImpl - implementation of Service, which counts the failed logins per username and per ip address
+//after MaxFailures failed logins for a username (MaxIPFailures for an ip address) the logins are locked
+//the lockout starts from Lockout and doubles with every next failure, up to MaxLockout
+type ServiceImpl struct {
+	uamDAO        dao.UamDAO
+	MaxFailures   uint
+	MaxIPFailures uint
+	Lockout       time.Duration
+	MaxLockout    time.Duration
+}
+
+//NewServiceImpl - creates an instance of ServiceImpl, configured with the env variables
+func NewServiceImpl(uamDAO dao.UamDAO) (*ServiceImpl, error) {
+	maxFailures, err := getPositiveFromEnv(maxFailuresKey, defaultMaxFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	maxIPFailures, err := getPositiveFromEnv(maxIPFailuresKey, defaultMaxIPFailures)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutMinutes, err := getPositiveFromEnv(lockoutKey, defaultLockoutMinutes)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLockoutMinutes, err := getPositiveFromEnv(maxLockoutKey, defaultMaxLockoutMinutes)
+	if err != nil {
+		return nil, err
+	} else if maxLockoutMinutes < lockoutMinutes {
+		return nil, myerr.NewServerError(fmt.Sprintf("The value of \"%s\" cannot be lower than the value of \"%s\"", maxLockoutKey, lockoutKey))
+	}
+
+	return &ServiceImpl{
+		uamDAO:        uamDAO,
+		MaxFailures:   uint(maxFailures),
+		MaxIPFailures: uint(maxIPFailures),
+		Lockout:       time.Duration(lockoutMinutes) * time.Minute,
+		MaxLockout:    time.Duration(maxLockoutMinutes) * time.Minute,
+	}, nil
+}
+
+//Check - checks if the logins of the username or from the ip address are locked
+//returns LockedError, containing the remaining time of the lockout, if they are
+func (s *ServiceImpl) Check(username string, ip string) error {
+	lockedUntil, err := s.uamDAO.GetLoginLockout([]string{getUsernameKey(username), getIPKey(ip)})
+	if err != nil {
+		return err
+	}
+
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		return newLockedError(retryAfter)
+	}
+	return nil
+}
+
+//RecordFailure - counts a failed login for the username and the ip address and locks them, if they have too many failures
+//the locking of an account is recorded in the audit events, the user id is zero if the username doesnt exist
+//returns LockedError, if the failure caused a lockout
+func (s *ServiceImpl) RecordFailure(userID uint, username string, ip string) error {
+	staleBefore := time.Now().Add(-s.MaxLockout)
+
+	userFailures, err := s.uamDAO.RecordLoginFailure(getUsernameKey(username), staleBefore)
+	if err != nil {
+		return err
+	}
+
+	ipFailures, err := s.uamDAO.RecordLoginFailure(getIPKey(ip), staleBefore)
+	if err != nil {
+		return err
+	}
+
+	var retryAfter time.Duration
+	if userFailures >= s.MaxFailures {
+		retryAfter = s.getLockout(userFailures - s.MaxFailures)
+		event := &models.AuditEvent{
+			Action:  models.AuditAccountLocked,
+			Details: fmt.Sprintf("The logins of [%s] are locked for %s after %d failed attempts", username, retryAfter, userFailures),
+			IP:      ip,
+		}
+		if userID != 0 {
+			event.UserID = &userID
+		}
+
+		log.Printf("Locking the logins of [%s] for %s\n", username, retryAfter)
+		if err = s.uamDAO.LockLogin(getUsernameKey(username), time.Now().Add(retryAfter), event); err != nil {
+			return err
+		}
+	}
+
+	if ipFailures >= s.MaxIPFailures {
+		ipLockout := s.getLockout(ipFailures - s.MaxIPFailures)
+		log.Printf("Locking the logins from [%s] for %s\n", ip, ipLockout)
+		if err = s.uamDAO.LockLogin(getIPKey(ip), time.Now().Add(ipLockout), nil); err != nil {
+			return err
+		}
+
+		if ipLockout > retryAfter {
+			retryAfter = ipLockout
+		}
+	}
+
+	if retryAfter > 0 {
+		return newLockedError(retryAfter)
+	}
+	return nil
+}
+
+//RecordSuccess - forgets the failed logins of the username
+//the failures from the ip address are kept, so that a valid account cannot be used to reset them
+func (s *ServiceImpl) RecordSuccess(username string) error {
+	return s.uamDAO.ResetLoginFailures(getUsernameKey(username))
+}
+
+//getLockout - returns the lockout after the given number of failures over the limit
+func (s *ServiceImpl) getLockout(excessFailures uint) time.Duration {
+	lockout := s.Lockout
+	for i := uint(0); i < excessFailures && lockout < s.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	if lockout > s.MaxLockout {
+		return s.MaxLockout
+	}
+	return lockout
+}
+
+func newLockedError(retryAfter time.Duration) *myerr.LockedError {
+	retryAfter = retryAfter.Round(time.Second)
+	return myerr.NewLockedError(fmt.Sprintf("Too many failed login attempts. Please try again in %s", retryAfter), retryAfter)
+}
+
+func getUsernameKey(username string) string {
+	if len(username) > maxKeyUsernameLength {
+		username = username[:maxKeyUsernameLength]
+	}
+	return "user:" + username
+}
+
+func getIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func getPositiveFromEnv(key string, defaultValue int64) (int64, error) {
+	valueStr := os.Getenv(key)
+	if len(valueStr) == 0 {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, myerr.NewServerError(fmt.Sprintf("Wrong value for \"%s\" config", key))
+	}
+	return value, nil
+}
@@ -0,0 +1,209 @@
+package lockout_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/lockout"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lockout service", func() {
+	const (
+		userID   = 1
+		username = "username"
+		ip       = "10.0.0.1"
+		userKey  = "user:" + username
+		ipKey    = "ip:" + ip
+	)
+
+	var (
+		controller *gomock.Controller
+		uamDAO     *dao_mocks.MockUamDAO
+		service    *lockout.ServiceImpl
+	)
+
+	BeforeEach(func() {
+		os.Clearenv()
+		controller = gomock.NewController(GinkgoT())
+		uamDAO = dao_mocks.NewMockUamDAO(controller)
+
+		var err error
+		service, err = lockout.NewServiceImpl(uamDAO)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		controller.Finish()
+	})
+
+	Context("NewServiceImpl", func() {
+		It("uses the default configuration", func() {
+			Expect(service.MaxFailures).To(Equal(uint(5)))
+			Expect(service.MaxIPFailures).To(Equal(uint(20)))
+			Expect(service.Lockout).To(Equal(time.Minute))
+			Expect(service.MaxLockout).To(Equal(time.Hour))
+		})
+
+		When("the configuration is invalid", func() {
+			It("returns error", func() {
+				os.Setenv("LOGIN_MAX_FAILURES", "0")
+				_, err := lockout.NewServiceImpl(uamDAO)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the maximum lockout is lower than the lockout", func() {
+			It("returns error", func() {
+				os.Setenv("LOGIN_LOCKOUT", "10")
+				os.Setenv("LOGIN_MAX_LOCKOUT", "5")
+				_, err := lockout.NewServiceImpl(uamDAO)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("Check", func() {
+		When("neither the username, nor the ip address is locked", func() {
+			It("succeeds", func() {
+				uamDAO.EXPECT().
+					GetLoginLockout([]string{userKey, ipKey}).
+					Return(time.Time{}, nil)
+
+				Expect(service.Check(username, ip)).To(Succeed())
+			})
+		})
+
+		When("the logins are locked", func() {
+			It("returns locked error with the remaining time", func() {
+				uamDAO.EXPECT().
+					GetLoginLockout([]string{userKey, ipKey}).
+					Return(time.Now().Add(2*time.Minute), nil)
+
+				err := service.Check(username, ip)
+				Expect(err).To(HaveOccurred())
+				lockedErr, ok := err.(*myerr.LockedError)
+				Expect(ok).To(Equal(true))
+				Expect(lockedErr.RetryAfter).To(BeNumerically("~", 2*time.Minute, time.Second))
+			})
+		})
+
+		When("the lookup of the lockouts fails", func() {
+			It("propagates error", func() {
+				uamDAO.EXPECT().
+					GetLoginLockout(gomock.Any()).
+					Return(time.Time{}, myerr.NewServerError("test-error"))
+
+				err := service.Check(username, ip)
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+	})
+
+	Context("RecordFailure", func() {
+		When("the failures are under the limits", func() {
+			It("doesnt lock the logins", func() {
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(4), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(4), nil)
+				uamDAO.EXPECT().LockLogin(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				Expect(service.RecordFailure(userID, username, ip)).To(Succeed())
+			})
+		})
+
+		When("the failures of the username reach the limit", func() {
+			It("locks the account and records an audit event", func() {
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(5), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(5), nil)
+				uamDAO.EXPECT().
+					LockLogin(userKey, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(key string, until time.Time, event *models.AuditEvent) error {
+						Expect(until).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+						Expect(*event.UserID).To(Equal(uint(userID)))
+						Expect(event.Action).To(Equal(models.AuditAccountLocked))
+						Expect(event.IP).To(Equal(ip))
+						return nil
+					})
+
+				err := service.RecordFailure(userID, username, ip)
+				lockedErr, ok := err.(*myerr.LockedError)
+				Expect(ok).To(Equal(true))
+				Expect(lockedErr.RetryAfter).To(Equal(time.Minute))
+			})
+		})
+
+		When("the failures of the username exceed the limit", func() {
+			It("doubles the lockout with every failure up to the maximum", func() {
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(8), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(8), nil)
+				uamDAO.EXPECT().LockLogin(userKey, gomock.Any(), gomock.Any()).Return(nil)
+
+				err := service.RecordFailure(userID, username, ip)
+				Expect(err.(*myerr.LockedError).RetryAfter).To(Equal(8 * time.Minute))
+
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(50), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(8), nil)
+				uamDAO.EXPECT().LockLogin(userKey, gomock.Any(), gomock.Any()).Return(nil)
+
+				err = service.RecordFailure(userID, username, ip)
+				Expect(err.(*myerr.LockedError).RetryAfter).To(Equal(time.Hour))
+			})
+		})
+
+		When("the username doesnt exist", func() {
+			It("locks the username without user in the audit event", func() {
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(5), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(5), nil)
+				uamDAO.EXPECT().
+					LockLogin(userKey, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(key string, until time.Time, event *models.AuditEvent) error {
+						Expect(event.UserID).To(BeNil())
+						return nil
+					})
+
+				err := service.RecordFailure(0, username, ip)
+				_, ok := err.(*myerr.LockedError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the failures from the ip address reach the limit", func() {
+			It("locks the ip address without audit event", func() {
+				uamDAO.EXPECT().RecordLoginFailure(userKey, gomock.Any()).Return(uint(1), nil)
+				uamDAO.EXPECT().RecordLoginFailure(ipKey, gomock.Any()).Return(uint(20), nil)
+				uamDAO.EXPECT().LockLogin(ipKey, gomock.Any(), nil).Return(nil)
+
+				err := service.RecordFailure(userID, username, ip)
+				_, ok := err.(*myerr.LockedError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+
+		When("the recording of the failure fails", func() {
+			It("propagates error", func() {
+				uamDAO.EXPECT().
+					RecordLoginFailure(userKey, gomock.Any()).
+					Return(uint(0), myerr.NewServerError("test-error"))
+
+				err := service.RecordFailure(userID, username, ip)
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(Equal(true))
+			})
+		})
+	})
+
+	Context("RecordSuccess", func() {
+		It("resets the failures of the username", func() {
+			uamDAO.EXPECT().ResetLoginFailures(userKey).Return(nil)
+			Expect(service.RecordSuccess(username)).To(Succeed())
+		})
+	})
+})
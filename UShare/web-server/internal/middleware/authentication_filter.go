@@ -1,40 +1,60 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
 	"github.com/gin-gonic/gin"
 )
 
 //AuthzFilter - middleware for filtering unauthorized requests
 type AuthzFilter interface {
 	Authz(c *gin.Context)
+	AuthzScope(scope string) gin.HandlerFunc
 }
 
 //AuthzFilterImpl - implementation of AuthorizationFilter
+//the tokens of revoked sessions are rejected, even if they arent expired yet
+//the personal access tokens are accepted too, but only for the operations allowed by their scopes and group
 type AuthzFilterImpl struct {
 	jwtCreator auth.JwtCreator
+	uamDAO     dao.UamDAO
 }
 
 //NewAuthzFilterImpl - creates a new instance of AuthzFilterImpl
-func NewAuthzFilterImpl(creator auth.JwtCreator) *AuthzFilterImpl {
+func NewAuthzFilterImpl(creator auth.JwtCreator, uamDAO dao.UamDAO) *AuthzFilterImpl {
 	return &AuthzFilterImpl{
 		jwtCreator: creator,
+		uamDAO:     uamDAO,
 	}
 }
 
 //Authz - creating handlers for filtering unauthorized requests
+//only the personal access tokens with full access are accepted
 func (f *AuthzFilterImpl) Authz(c *gin.Context) {
+	f.authorize(c, "")
+}
+
+//AuthzScope - creates handler for filtering unauthorized requests
+//the personal access tokens with the given scope are accepted too
+func (f *AuthzFilterImpl) AuthzScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f.authorize(c, scope)
+	}
+}
+
+func (f *AuthzFilterImpl) authorize(c *gin.Context, scope string) {
 	clientToken := c.Request.Header.Get("Authorization")
 	if clientToken == "" {
-		c.JSON(http.StatusForbidden, common.ErrorResponse{
-			ErrorCode: http.StatusForbidden,
-			ErrorMsg:  "No Authorization header provided",
-		})
-		c.Abort() //stop the propagation of the request to the next handler
+		abortWithError(c, http.StatusForbidden, "No Authorization header provided")
 		return
 	}
 
@@ -42,24 +62,95 @@ func (f *AuthzFilterImpl) Authz(c *gin.Context) {
 	if len(extractedToken) == 2 {
 		clientToken = strings.TrimSpace(extractedToken[1])
 	} else {
-		c.JSON(http.StatusBadRequest, common.ErrorResponse{
-			ErrorCode: http.StatusBadRequest,
-			ErrorMsg:  "Incorrect Format of Authorization Token",
-		})
-		c.Abort()
+		abortWithError(c, http.StatusBadRequest, "Incorrect Format of Authorization Token")
+		return
+	}
+
+	if auth.IsAccessToken(clientToken) {
+		f.authorizeAccessToken(c, clientToken, scope)
 		return
 	}
 
 	claims, err := f.jwtCreator.ValidateToken(clientToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, common.ErrorResponse{
-			ErrorCode: http.StatusUnauthorized,
-			ErrorMsg:  "Invalid Authorization token",
-		})
-		c.Abort()
+		abortWithError(c, http.StatusUnauthorized, "Invalid Authorization token")
+		return
+	}
+
+	revoked, err := f.uamDAO.IsSessionRevoked(claims.SessionID)
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, "Problem with the server, please try again later")
+		return
+	} else if revoked {
+		abortWithError(c, http.StatusUnauthorized, "The Authorization token was revoked")
 		return
 	}
 
 	c.Set("userID", claims.UserID)
+	c.Set("sessionID", claims.SessionID)
 	c.Next()
 }
+
+//authorizeAccessToken - checks if the personal access token exists, hasnt expired and allows the request
+func (f *AuthzFilterImpl) authorizeAccessToken(c *gin.Context, clientToken string, scope string) {
+	token, err := f.uamDAO.UseAccessToken(auth.HashToken(clientToken))
+	if _, ok := err.(*myerr.ItemNotFoundError); ok {
+		abortWithError(c, http.StatusUnauthorized, "Invalid Authorization token")
+		return
+	} else if err != nil {
+		log.Println(err)
+		abortWithError(c, http.StatusInternalServerError, "Problem with the server, please try again later")
+		return
+	}
+
+	if !token.HasScope(scope) {
+		abortWithError(c, http.StatusForbidden, "The access token doesnt allow this operation")
+		return
+	}
+
+	if token.GroupID != nil {
+		group, err := f.uamDAO.GetGroup(getRequestGroupName(c))
+		if err != nil {
+			log.Println(err)
+			abortWithError(c, http.StatusInternalServerError, "Problem with the server, please try again later")
+			return
+		} else if group.ID != *token.GroupID {
+			abortWithError(c, http.StatusForbidden, "The access token is restricted to a single group")
+			return
+		}
+	}
+
+	c.Set("userID", token.UserID)
+	c.Set("accessTokenID", token.ID)
+	c.Next()
+}
+
+//getRequestGroupName - returns the name of the group, specified either in the query or in the JSON body of the request
+//the body is restored, so that the handlers can still read it
+func getRequestGroupName(c *gin.Context) string {
+	if groupName := c.Query("group_name"); groupName != "" {
+		return groupName
+	} else if c.Request.Body == nil || c.ContentType() != gin.MIMEJSON {
+		return ""
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload common.GroupPayload
+	if err = json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.GroupName
+}
+
+func abortWithError(c *gin.Context, errorCode int, errorMsg string) {
+	c.JSON(errorCode, common.ErrorResponse{
+		ErrorCode: errorCode,
+		ErrorMsg:  errorMsg,
+	})
+	c.Abort() //stop the propagation of the request to the next handler
+}
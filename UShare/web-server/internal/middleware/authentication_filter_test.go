@@ -5,10 +5,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/api/common"
 	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
 	authMock "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth/auth_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
 	mw "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -20,7 +24,17 @@ func setupRouter(filter mw.AuthzFilter) *gin.Engine {
 	r := gin.Default()
 	v1 := r.Group("/protected").Use(filter.Authz)
 	v1.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, "")
+		userID, _ := c.Get("userID")
+		sessionID, _ := c.Get("sessionID")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "session_id": sessionID})
+	})
+
+	upload := r.Group("/upload").Use(filter.AuthzScope(models.ScopeUpload))
+	upload.POST("/file", func(c *gin.Context) {
+		var rq common.GroupPayload
+		c.ShouldBindJSON(&rq)
+		userID, _ := c.Get("userID")
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "group_name": rq.GroupName})
 	})
 	return r
 }
@@ -38,13 +52,15 @@ var _ = Describe("AuthzFilter", func() {
 		router     *gin.Engine
 		recorder   *httptest.ResponseRecorder
 		jwtCreator *authMock.MockJwtCreator
+		uamDAO     *dao_mocks.MockUamDAO
 	)
 
 	BeforeEach(func() {
 		controller := gomock.NewController(GinkgoT())
 
 		jwtCreator = authMock.NewMockJwtCreator(controller)
-		filter := mw.NewAuthzFilterImpl(jwtCreator)
+		uamDAO = dao_mocks.NewMockUamDAO(controller)
+		filter := mw.NewAuthzFilterImpl(jwtCreator, uamDAO)
 		router = setupRouter(filter)
 		recorder = httptest.NewRecorder()
 	})
@@ -98,15 +114,50 @@ var _ = Describe("AuthzFilter", func() {
 							jwtCreator.EXPECT().
 								ValidateToken(gomock.Any()).
 								Return(&auth.JwtClaim{
-									UserID: 1,
+									UserID:    1,
+									SessionID: 2,
 								}, nil)
 						})
 
-						It("returns success", func() {
-							router.ServeHTTP(recorder, req)
-							Expect(recorder.Code).To(Equal(http.StatusOK))
+						Context("and the revocation check fails", func() {
+							BeforeEach(func() {
+								uamDAO.EXPECT().
+									IsSessionRevoked(uint(2)).
+									Return(false, errors.New("test error"))
+							})
+
+							It("returns error response", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
+							})
 						})
 
+						Context("and the session of the token is revoked", func() {
+							BeforeEach(func() {
+								uamDAO.EXPECT().
+									IsSessionRevoked(uint(2)).
+									Return(true, nil)
+							})
+
+							It("returns error response", func() {
+								router.ServeHTTP(recorder, req)
+								assertErrorResponse(recorder, http.StatusUnauthorized, "The Authorization token was revoked")
+							})
+						})
+
+						Context("and the session of the token is active", func() {
+							BeforeEach(func() {
+								uamDAO.EXPECT().
+									IsSessionRevoked(uint(2)).
+									Return(false, nil)
+							})
+
+							It("returns success", func() {
+								router.ServeHTTP(recorder, req)
+								Expect(recorder.Code).To(Equal(http.StatusOK))
+								Expect(recorder.Body.String()).To(MatchJSON(`{"user_id":1,"session_id":2}`))
+							})
+						})
 					})
 				})
 			})
@@ -114,4 +165,135 @@ var _ = Describe("AuthzFilter", func() {
 		})
 	})
 
+	Context("AuthzScope()", func() {
+		const (
+			accessToken = "ushare_pat_token"
+			groupID     = 5
+		)
+
+		var (
+			req   *http.Request
+			token models.AccessToken
+		)
+
+		BeforeEach(func() {
+			req, _ = http.NewRequest("POST", "/upload/file", strings.NewReader(`{"group_name":"group"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			token = models.AccessToken{ID: 4, UserID: 1}
+		})
+
+		When("the access token doesnt exist or has expired", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					UseAccessToken(auth.HashToken(accessToken)).
+					Return(models.AccessToken{}, myerr.NewItemNotFoundError("Access token not found"))
+			})
+
+			It("returns error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusUnauthorized, "Invalid Authorization token")
+			})
+		})
+
+		When("the lookup of the access token fails", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().
+					UseAccessToken(auth.HashToken(accessToken)).
+					Return(models.AccessToken{}, myerr.NewServerError("test error"))
+			})
+
+			It("returns error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusInternalServerError, "Problem with the server")
+			})
+		})
+
+		When("the access token doesnt have the scope", func() {
+			BeforeEach(func() {
+				token.Scopes = models.ScopeRead
+				uamDAO.EXPECT().
+					UseAccessToken(auth.HashToken(accessToken)).
+					Return(token, nil)
+			})
+
+			It("returns error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusForbidden, "The access token doesnt allow this operation")
+			})
+		})
+
+		When("the access token has the scope", func() {
+			BeforeEach(func() {
+				token.Scopes = models.ScopeRead + "," + models.ScopeUpload
+			})
+
+			Context("and it isnt restricted to a group", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().
+						UseAccessToken(auth.HashToken(accessToken)).
+						Return(token, nil)
+				})
+
+				It("returns success", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(MatchJSON(`{"user_id":1,"group_name":"group"}`))
+				})
+			})
+
+			Context("and it is restricted to another group", func() {
+				BeforeEach(func() {
+					restrictedGroupID := uint(groupID + 1)
+					token.GroupID = &restrictedGroupID
+					uamDAO.EXPECT().
+						UseAccessToken(auth.HashToken(accessToken)).
+						Return(token, nil)
+					uamDAO.EXPECT().
+						GetGroup("group").
+						Return(models.Group{ID: groupID, Name: "group"}, nil)
+				})
+
+				It("returns error response", func() {
+					router.ServeHTTP(recorder, req)
+					assertErrorResponse(recorder, http.StatusForbidden, "The access token is restricted to a single group")
+				})
+			})
+
+			Context("and it is restricted to the group of the request", func() {
+				BeforeEach(func() {
+					restrictedGroupID := uint(groupID)
+					token.GroupID = &restrictedGroupID
+					uamDAO.EXPECT().
+						UseAccessToken(auth.HashToken(accessToken)).
+						Return(token, nil)
+					uamDAO.EXPECT().
+						GetGroup("group").
+						Return(models.Group{ID: groupID, Name: "group"}, nil)
+				})
+
+				It("returns success and keeps the body of the request", func() {
+					router.ServeHTTP(recorder, req)
+					Expect(recorder.Code).To(Equal(http.StatusOK))
+					Expect(recorder.Body.String()).To(MatchJSON(`{"user_id":1,"group_name":"group"}`))
+				})
+			})
+		})
+
+		When("a scoped access token is used for an operation, which requires full access", func() {
+			BeforeEach(func() {
+				req, _ = http.NewRequest("GET", "/protected/ping", nil)
+				req.Header.Set("Authorization", "Bearer "+accessToken)
+				token.Scopes = models.ScopeUpload
+				uamDAO.EXPECT().
+					UseAccessToken(auth.HashToken(accessToken)).
+					Return(token, nil)
+			})
+
+			It("returns error response", func() {
+				router.ServeHTTP(recorder, req)
+				assertErrorResponse(recorder, http.StatusForbidden, "The access token doesnt allow this operation")
+			})
+		})
+	})
 })
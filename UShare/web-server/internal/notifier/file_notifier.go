@@ -0,0 +1,44 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+//FileNotifier - implementation of Notifier, which appends the notifications to a file, one per line
+type FileNotifier struct {
+	filePath string
+	mutex    sync.Mutex
+}
+
+//NewFileNotifier - creates an instance of FileNotifier
+func NewFileNotifier(filePath string) *FileNotifier {
+	return &FileNotifier{
+		filePath: filePath,
+	}
+}
+
+//NotifyPasswordReset - appends the reset token to the file
+func (n *FileNotifier) NotifyPasswordReset(username string, token string, expiresAt time.Time) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	file, err := os.OpenFile(n.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with opening the notification file")
+	}
+
+	if _, err = fmt.Fprintln(file, passwordResetMessage(username, token, expiresAt)); err != nil {
+		file.Close()
+		return myerr.NewServerErrorWrap(err, "Problem with writing the notification")
+	}
+
+	if err = file.Close(); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with writing the notification")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package notifier_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/notifier"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileNotifier", func() {
+	var (
+		rootDir  string
+		filePath string
+		sink     notifier.Notifier
+	)
+
+	BeforeEach(func() {
+		rootDir, _ = ioutil.TempDir("", "notifications")
+		filePath = path.Join(rootDir, "notifications.log")
+		sink = notifier.NewFileNotifier(filePath)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(rootDir)
+	})
+
+	When("password resets are sent", func() {
+		BeforeEach(func() {
+			expiresAt := time.Now().Add(time.Hour)
+			Expect(sink.NotifyPasswordReset("first", "first-token", expiresAt)).To(Succeed())
+			Expect(sink.NotifyPasswordReset("second", "second-token", expiresAt)).To(Succeed())
+		})
+
+		It("appends them to the file", func() {
+			content, err := ioutil.ReadFile(filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(ContainSubstring("[first]"))
+			Expect(lines[0]).To(ContainSubstring("[first-token]"))
+			Expect(lines[1]).To(ContainSubstring("[second-token]"))
+		})
+	})
+
+	When("the file cannot be opened", func() {
+		BeforeEach(func() {
+			sink = notifier.NewFileNotifier(path.Join(rootDir, "missing", "notifications.log"))
+		})
+
+		It("returns error", func() {
+			Expect(sink.NotifyPasswordReset("first", "token", time.Now())).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,21 @@
+package notifier
+
+import (
+	"log"
+	"time"
+)
+
+//LogNotifier - implementation of Notifier, which writes the notifications to the server log
+//it is meant for local testing, the tokens are visible to everyone with access to the log
+type LogNotifier struct{}
+
+//NewLogNotifier - creates an instance of LogNotifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+//NotifyPasswordReset - writes the reset token to the log
+func (n *LogNotifier) NotifyPasswordReset(username string, token string, expiresAt time.Time) error {
+	log.Println(passwordResetMessage(username, token, expiresAt))
+	return nil
+}
@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+const (
+	notifierKey     = "NOTIFIER"
+	notifierFileKey = "NOTIFIER_FILE"
+
+	//BackendLog - the notifications are written to the server log
+	BackendLog = "log"
+	//BackendFile - the notifications are appended to a file
+	BackendFile = "file"
+)
+
+//go:generate mockgen --source=notifier.go --destination notifier_mocks/notifier.go --package notifier_mocks
+
+//Notifier - interface for delivering messages to the users, independent of the channel
+type Notifier interface {
+	NotifyPasswordReset(username string, token string, expiresAt time.Time) error
+}
+
+//NewNotifierFromEnv - creates the notifier, configured with the env variables
+//the notifications are written to the server log by default
+func NewNotifierFromEnv() (Notifier, error) {
+	switch backend := os.Getenv(notifierKey); backend {
+	case "", BackendLog:
+		return NewLogNotifier(), nil
+	case BackendFile:
+		filePath := os.Getenv(notifierFileKey)
+		if filePath == "" {
+			return nil, myerr.NewServerError(fmt.Sprintf("Please set %s env variable", notifierFileKey))
+		}
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, myerr.NewServerError(fmt.Sprintf("The env variable %s has unknown notifier [%s]", notifierKey, backend))
+	}
+}
+
+//passwordResetMessage - the text of the notification, containing the reset token
+func passwordResetMessage(username string, token string, expiresAt time.Time) string {
+	return fmt.Sprintf("Password reset for user [%s]: token [%s], valid until %s", username, token, expiresAt.Format(time.RFC3339))
+}
@@ -0,0 +1,409 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const (
+	issuerKey       = "OIDC_ISSUER"
+	clientIDKey     = "OIDC_CLIENT_ID"
+	clientSecretKey = "OIDC_CLIENT_SECRET"
+	redirectURLKey  = "OIDC_REDIRECT_URL"
+
+	discoveryPath   = "/.well-known/openid-configuration"
+	scopes          = "openid profile email"
+	randomValueSize = 32
+	clockSkew       = time.Minute
+
+	minUsernameLength = 8
+	maxUsernameLength = 16
+)
+
+var invalidUsernameSymbols = regexp.MustCompile("[^-_0-9a-zA-Z]")
+
+//go:generate mockgen --source=oidc.go --destination oidc_mocks/oidc.go --package oidc_mocks
+
+//Provider - interface for the login of users through an external OpenID Connect identity provider
+type Provider interface {
+	AuthorizationURL(redirectURI, state, nonce, codeVerifier string) (string, error)
+	Exchange(code, redirectURI, codeVerifier, nonce string) (Identity, error)
+	RedirectURL() string
+}
+
+//Identity - the user, as known by the identity provider
+//the issuer and the subject identify him uniquely, the other claims are only hints
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	PreferredUsername string
+}
+
+//ProviderImpl - implementation of Provider, using the authorization code flow with PKCE (RFC 7636)
+//the endpoints of the provider are discovered from its issuer url
+type ProviderImpl struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	Client       *http.Client
+
+	mutex    sync.Mutex
+	metadata *providerMetadata
+	keys     map[string]*rsa.PublicKey
+}
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type idTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+//audience - the aud claim is either a single string or an array of strings
+type audience []string
+
+//NewProviderFromEnv - creates the provider, configured with the env variables
+//returns nil, if the login through an identity provider isnt configured
+func NewProviderFromEnv() (Provider, error) {
+	issuer := strings.TrimSuffix(os.Getenv(issuerKey), "/")
+	if issuer == "" {
+		return nil, nil
+	}
+
+	clientID := os.Getenv(clientIDKey)
+	if clientID == "" {
+		return nil, myerr.NewServerError(fmt.Sprintf("Please set %s env variable", clientIDKey))
+	}
+	return NewProviderImpl(issuer, clientID, os.Getenv(clientSecretKey), os.Getenv(redirectURLKey)), nil
+}
+
+//NewProviderImpl - creates a provider for the given issuer
+//the client secret is optional, the public clients rely only on PKCE
+func NewProviderImpl(issuer, clientID, clientSecret, redirectURI string) *ProviderImpl {
+	return &ProviderImpl{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+//RedirectURL - the redirect uri, used when the client doesnt provide one
+func (p *ProviderImpl) RedirectURL() string {
+	return p.RedirectURI
+}
+
+//AuthorizationURL - returns the url, where the user authenticates to the identity provider
+//only the S256 challenge of the code verifier is sent, the verifier itself is sent with the exchange of the code
+func (p *ProviderImpl) AuthorizationURL(redirectURI, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.getMetadata()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", scopes)
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+//Exchange - exchanges the authorization code for an id token and returns the identity in it
+//returns client error if the provider rejects the code or the id token is invalid
+func (p *ProviderImpl) Exchange(code, redirectURI, codeVerifier, nonce string) (Identity, error) {
+	metadata, err := p.getMetadata()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	resp, err := p.Client.PostForm(metadata.TokenEndpoint, form)
+	if err != nil {
+		return Identity{}, myerr.NewServerErrorWrap(err, "Problem with the request to the identity provider")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return Identity{}, myerr.NewClientError(fmt.Sprintf("The identity provider rejected the login. %s", strings.TrimSpace(string(body))))
+	} else if resp.StatusCode != http.StatusOK {
+		return Identity{}, myerr.NewServerError(fmt.Sprintf("The identity provider responded with status %d", resp.StatusCode))
+	}
+
+	var token tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil || token.IDToken == "" {
+		return Identity{}, myerr.NewServerError("The identity provider didnt return an id token")
+	}
+
+	return p.verifyIDToken(token.IDToken, nonce)
+}
+
+//verifyIDToken - checks the signature, the issuer, the audience, the expiry and the nonce of the id token
+func (p *ProviderImpl) verifyIDToken(rawToken, nonce string) (Identity, error) {
+	claims := &idTokenClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, p.getVerificationKey)
+	if err != nil {
+		if validationErr, ok := err.(*jwt.ValidationError); ok {
+			if inner, ok := validationErr.Inner.(*myerr.ServerError); ok {
+				return Identity{}, inner
+			}
+		}
+		return Identity{}, myerr.NewClientError("Invalid id token")
+	}
+
+	switch {
+	case claims.Issuer != p.Issuer:
+		return Identity{}, myerr.NewClientError("The id token is issued by another provider")
+	case !claims.Audience.contains(p.ClientID):
+		return Identity{}, myerr.NewClientError("The id token is issued for another client")
+	case claims.Nonce != nonce:
+		return Identity{}, myerr.NewClientError("The id token belongs to another login")
+	case claims.Subject == "":
+		return Identity{}, myerr.NewClientError("The id token doesnt identify the user")
+	}
+
+	return Identity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+//getVerificationKey - finds the key of the provider, which signed the token
+//the keys are fetched again, when the kid is unknown, because the provider could have rotated them
+func (p *ProviderImpl) getVerificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method != jwt.SigningMethodRS256 {
+		return nil, fmt.Errorf("Unexpected signing method [%v]", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	p.mutex.Lock()
+	key, ok := p.keys[kid]
+	p.mutex.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	if err := p.refreshKeys(); err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if key, ok = p.keys[kid]; !ok {
+		return nil, fmt.Errorf("Unknown key [%s]", kid)
+	}
+	return key, nil
+}
+
+func (p *ProviderImpl) refreshKeys() error {
+	metadata, err := p.getMetadata()
+	if err != nil {
+		return err
+	}
+
+	var keySet auth.JSONWebKeySet
+	if err = p.getJSON(metadata.JWKSURI, &keySet); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+
+		modulus, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			continue
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(jwk.Exponent)
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	p.mutex.Lock()
+	p.keys = keys
+	p.mutex.Unlock()
+	return nil
+}
+
+//getMetadata - discovers the endpoints of the provider, the result is cached after the first success
+func (p *ProviderImpl) getMetadata() (*providerMetadata, error) {
+	p.mutex.Lock()
+	metadata := p.metadata
+	p.mutex.Unlock()
+	if metadata != nil {
+		return metadata, nil
+	}
+
+	metadata = &providerMetadata{}
+	if err := p.getJSON(p.Issuer+discoveryPath, metadata); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(metadata.Issuer, "/") != p.Issuer {
+		return nil, myerr.NewServerError(fmt.Sprintf("The identity provider has another issuer [%s]", metadata.Issuer))
+	} else if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, myerr.NewServerError("The configuration of the identity provider is incomplete")
+	}
+
+	p.mutex.Lock()
+	p.metadata = metadata
+	p.mutex.Unlock()
+	return metadata, nil
+}
+
+func (p *ProviderImpl) getJSON(url string, target interface{}) error {
+	resp, err := p.Client.Get(url)
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with the request to the identity provider")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return myerr.NewServerError(fmt.Sprintf("The identity provider responded with status %d to [%s]", resp.StatusCode, url))
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with the response of the identity provider")
+	}
+	return nil
+}
+
+//Valid - checks the expiry of the id token, the other claims are checked after the signature
+func (c *idTokenClaims) Valid() error {
+	if c.ExpiresAt == 0 || time.Now().Add(-clockSkew).Unix() > c.ExpiresAt {
+		return fmt.Errorf("The id token is expired")
+	}
+	return nil
+}
+
+//UnmarshalJSON - accepts both forms of the aud claim
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = audience(multiple)
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, value := range a {
+		if value == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+//GenerateRandomValue - generates a random url-safe value, used for the state, the nonce and the code verifier
+func GenerateRandomValue() (string, error) {
+	value := make([]byte, randomValueSize)
+	if _, err := rand.Read(value); err != nil {
+		return "", myerr.NewServerErrorWrap(err, "Couldnt generate a random value")
+	}
+	return base64.RawURLEncoding.EncodeToString(value), nil
+}
+
+//CodeChallenge - derives the S256 code challenge from the code verifier
+func CodeChallenge(codeVerifier string) string {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+//Username - suggests a username for the auto-provisioned user, which satisfies the username rules
+//the preferred username is used, otherwise the local part of the email
+//the result is at most 16 symbols, so that a suffix can be added, if the username is taken
+func (i Identity) Username() string {
+	username := i.PreferredUsername
+	if username == "" {
+		username = strings.SplitN(i.Email, "@", 2)[0]
+	}
+
+	username = invalidUsernameSymbols.ReplaceAllString(username, "_")
+	if username == "" || !isLetter(username[0]) {
+		username = "u" + username
+	}
+
+	if len(username) > maxUsernameLength {
+		username = username[:maxUsernameLength]
+	}
+	for len(username) < minUsernameLength {
+		username += "0"
+	}
+	return username
+}
+
+func isLetter(symbol byte) bool {
+	return (symbol >= 'a' && symbol <= 'z') || (symbol >= 'A' && symbol <= 'Z')
+}
@@ -0,0 +1,288 @@
+package oidc_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/auth"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/oidc"
+	jwt "github.com/dgrijalva/jwt-go"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	clientID    = "ushare"
+	redirectURI = "http://127.0.0.1:8080/callback"
+	subject     = "user-subject"
+)
+
+type authorization struct {
+	challenge   string
+	redirectURI string
+	nonce       string
+}
+
+//fakeProvider - minimal in-memory OpenID Connect identity provider, which authenticates every user immediately
+type fakeProvider struct {
+	sync.Mutex
+	server         *httptest.Server
+	key            *rsa.PrivateKey
+	keyID          string
+	audience       interface{}
+	expiresIn      time.Duration
+	claims         map[string]interface{}
+	authorizations map[string]authorization
+}
+
+func newFakeProvider() *fakeProvider {
+	provider := &fakeProvider{
+		audience:       clientID,
+		expiresIn:      time.Hour,
+		claims:         map[string]interface{}{"preferred_username": "john.doe", "email": "john@example.com"},
+		authorizations: map[string]authorization{},
+	}
+	provider.rotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/jwks", provider.jwks)
+	provider.server = httptest.NewServer(mux)
+	return provider
+}
+
+func (p *fakeProvider) rotateKey() {
+	p.Lock()
+	defer p.Unlock()
+	p.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	p.keyID = fmt.Sprintf("key-%d", time.Now().UnixNano())
+}
+
+func (p *fakeProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.server.URL,
+		"authorization_endpoint": p.server.URL + "/authorize",
+		"token_endpoint":         p.server.URL + "/token",
+		"jwks_uri":               p.server.URL + "/jwks",
+	})
+}
+
+func (p *fakeProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+
+	query := r.URL.Query()
+	if query.Get("client_id") != clientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	code := fmt.Sprintf("code-%d", len(p.authorizations))
+	p.authorizations[code] = authorization{
+		challenge:   query.Get("code_challenge"),
+		redirectURI: query.Get("redirect_uri"),
+		nonce:       query.Get("nonce"),
+	}
+	http.Redirect(w, r, query.Get("redirect_uri")+"?code="+code+"&state="+url.QueryEscape(query.Get("state")), http.StatusFound)
+}
+
+func (p *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+
+	r.ParseForm()
+	auth, ok := p.authorizations[r.PostForm.Get("code")]
+	delete(p.authorizations, r.PostForm.Get("code"))
+	if !ok || r.PostForm.Get("client_id") != clientID || r.PostForm.Get("redirect_uri") != auth.redirectURI ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"sub":   subject,
+		"aud":   p.audience,
+		"exp":   time.Now().Add(p.expiresIn).Unix(),
+		"nonce": auth.nonce,
+	}
+	for name, value := range p.claims {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.keyID
+	idToken, _ := token.SignedString(p.key)
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "access-token", "token_type": "Bearer"})
+}
+
+func (p *fakeProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.Lock()
+	defer p.Unlock()
+
+	json.NewEncoder(w).Encode(auth.JSONWebKeySet{Keys: []auth.JSONWebKey{{
+		KeyType:   "RSA",
+		KeyID:     p.keyID,
+		Use:       "sig",
+		Algorithm: "RS256",
+		Modulus:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+//authenticate - follows the authorization url like a browser and returns the code and the state from the redirect
+func authenticate(authorizationURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(authorizationURL)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusFound))
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	Expect(err).NotTo(HaveOccurred())
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+var _ = Describe("Provider", func() {
+	const (
+		state        = "state"
+		nonce        = "nonce"
+		codeVerifier = "code-verifier-code-verifier-code-verifier-123"
+	)
+
+	var (
+		fake     *fakeProvider
+		provider *oidc.ProviderImpl
+	)
+
+	BeforeEach(func() {
+		fake = newFakeProvider()
+		provider = oidc.NewProviderImpl(fake.server.URL, clientID, "", redirectURI)
+	})
+
+	AfterEach(func() {
+		fake.server.Close()
+	})
+
+	login := func(verifier string) (oidc.Identity, error) {
+		authorizationURL, err := provider.AuthorizationURL(redirectURI, state, nonce, codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+
+		code, returnedState := authenticate(authorizationURL)
+		Expect(returnedState).To(Equal(state))
+		return provider.Exchange(code, redirectURI, verifier, nonce)
+	}
+
+	It("returns the identity of the authenticated user", func() {
+		identity, err := login(codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity).To(Equal(oidc.Identity{
+			Issuer:            fake.server.URL,
+			Subject:           subject,
+			Email:             "john@example.com",
+			PreferredUsername: "john.doe",
+		}))
+	})
+
+	It("sends only the challenge of the code verifier", func() {
+		authorizationURL, err := provider.AuthorizationURL(redirectURI, state, nonce, codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(authorizationURL).NotTo(ContainSubstring(codeVerifier))
+		Expect(authorizationURL).To(ContainSubstring("code_challenge=" + oidc.CodeChallenge(codeVerifier)))
+	})
+
+	It("rejects the login with another code verifier", func() {
+		_, err := login("another-code-verifier")
+		Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+		Expect(err.Error()).To(ContainSubstring("The identity provider rejected the login"))
+	})
+
+	It("rejects an id token with another nonce", func() {
+		authorizationURL, _ := provider.AuthorizationURL(redirectURI, state, nonce, codeVerifier)
+		code, _ := authenticate(authorizationURL)
+
+		_, err := provider.Exchange(code, redirectURI, codeVerifier, "another-nonce")
+		Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+		Expect(err.Error()).To(ContainSubstring("The id token belongs to another login"))
+	})
+
+	It("rejects an id token for another client", func() {
+		fake.audience = []string{"another-client"}
+
+		_, err := login(codeVerifier)
+		Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+		Expect(err.Error()).To(ContainSubstring("The id token is issued for another client"))
+	})
+
+	It("accepts an audience with multiple clients", func() {
+		fake.audience = []string{"another-client", clientID}
+
+		_, err := login(codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects an expired id token", func() {
+		fake.expiresIn = -time.Hour
+
+		_, err := login(codeVerifier)
+		Expect(err).To(BeAssignableToTypeOf(&myerr.ClientError{}))
+		Expect(err.Error()).To(ContainSubstring("Invalid id token"))
+	})
+
+	It("fetches the keys again after their rotation", func() {
+		_, err := login(codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+
+		fake.rotateKey()
+		_, err = login(codeVerifier)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns server error if the provider is unreachable", func() {
+		fake.server.Close()
+
+		_, err := provider.AuthorizationURL(redirectURI, state, nonce, codeVerifier)
+		Expect(err).To(BeAssignableToTypeOf(&myerr.ServerError{}))
+	})
+})
+
+var _ = Describe("Identity", func() {
+	Context("Username", func() {
+		It("uses the preferred username", func() {
+			Expect(oidc.Identity{PreferredUsername: "john.doe", Email: "jd@example.com"}.Username()).To(Equal("john_doe"))
+		})
+
+		It("uses the local part of the email", func() {
+			Expect(oidc.Identity{Email: "jane.doe@example.com"}.Username()).To(Equal("jane_doe"))
+		})
+
+		It("begins with a letter", func() {
+			Expect(oidc.Identity{PreferredUsername: "42-answers"}.Username()).To(Equal("u42-answers"))
+		})
+
+		It("is padded to the minimal length", func() {
+			Expect(oidc.Identity{PreferredUsername: "bob"}.Username()).To(Equal("bob00000"))
+			Expect(oidc.Identity{}.Username()).To(Equal("u0000000"))
+		})
+
+		It("is truncated", func() {
+			Expect(oidc.Identity{PreferredUsername: "a-very-long-preferred-username"}.Username()).To(Equal("a-very-long-pref"))
+		})
+	})
+})
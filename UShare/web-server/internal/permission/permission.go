@@ -0,0 +1,168 @@
+package permission
+
+import (
+	"fmt"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+//go:generate mockgen --source=permission.go --destination permission_mocks/permission.go --package permission_mocks
+
+//Permission - action in a group, which is allowed only for some of the roles
+//the value describes the action and is used in the error messages
+type Permission string
+
+const (
+	//ViewGroup - viewing the group, its members and files and downloading the files
+	ViewGroup Permission = "view the group"
+	//UploadFiles - uploading files and restoring versions of own files
+	UploadFiles Permission = "upload files"
+	//DeleteOwnFiles - deleting files, uploaded by the member
+	DeleteOwnFiles Permission = "delete your files"
+	//ManageFiles - deleting and restoring files of other members
+	ManageFiles Permission = "manage the files of other members"
+	//ManageMembers - adding members and removing members with lower role
+	ManageMembers Permission = "manage the members"
+	//ManageRoles - changing the roles of the members
+	ManageRoles Permission = "change the roles of the members"
+	//ManageGroup - changing the limits of the group, transferring its ownership and deleting it
+	ManageGroup Permission = "manage the group"
+	//ViewAuditLog - viewing the audit trail of the group
+	ViewAuditLog Permission = "view the audit log"
+	//ManageTrash - viewing the deleted files of the group and restoring them
+	ManageTrash Permission = "manage the trash"
+	//TagFiles - changing the tags of files, uploaded by the member
+	TagFiles Permission = "tag your files"
+)
+
+//rolePermissions - permissions, granted to every role
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner:       {ViewGroup, UploadFiles, DeleteOwnFiles, TagFiles, ManageFiles, ManageMembers, ManageRoles, ManageGroup, ViewAuditLog, ManageTrash},
+	models.RoleAdmin:       {ViewGroup, UploadFiles, DeleteOwnFiles, TagFiles, ManageFiles, ManageMembers},
+	models.RoleContributor: {ViewGroup, UploadFiles, DeleteOwnFiles, TagFiles},
+	models.RoleViewer:      {ViewGroup},
+}
+
+//roleRanks - ranks of the roles, a member can manage only members with lower rank
+var roleRanks = map[string]int{
+	models.RoleOwner:       4,
+	models.RoleAdmin:       3,
+	models.RoleContributor: 2,
+	models.RoleViewer:      1,
+}
+
+//Service - interface for checking what the members are allowed to do in their groups
+type Service interface {
+	Authorize(userID uint, groupName string, permission Permission) (models.Group, string, error)
+	AuthorizeFileAccess(userID uint, groupName string, permission Permission) (models.Group, string, error)
+	AuthorizeFileChange(userID uint, role string, fileInfo models.FileInfo, permission Permission) error
+	AuthorizeMemberChange(userID uint, groupName string, username string) (models.Group, error)
+}
+
+//ServiceImpl - implementation of Service, based on the roles of the memberships
+type ServiceImpl struct {
+	uamDAO dao.UamDAO
+}
+
+//NewServiceImpl - creates an instance of ServiceImpl
+func NewServiceImpl(uamDAO dao.UamDAO) *ServiceImpl {
+	return &ServiceImpl{uamDAO: uamDAO}
+}
+
+//Authorize - checks if the user is a member of an active group and if his role grants the permission
+//returns the group and the role of the user in it
+func (s *ServiceImpl) Authorize(userID uint, groupName string, permission Permission) (models.Group, string, error) {
+	group, err := s.uamDAO.GetGroup(groupName)
+	if err != nil {
+		return models.Group{}, "", err
+	} else if group.ID == 0 || !group.Active {
+		return models.Group{}, "", myerr.NewClientError("Invalid group")
+	}
+
+	membership, err := s.uamDAO.GetMembership(userID, group.ID)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok {
+		return models.Group{}, "", myerr.NewClientError("You arent a member of the group")
+	} else if err != nil {
+		return models.Group{}, "", err
+	}
+
+	if !HasPermission(membership.Role, permission) {
+		return models.Group{}, "", myerr.NewClientError(fmt.Sprintf("Your role (%s) doesnt allow you to %s", membership.Role, permission))
+	}
+	return group, membership.Role, nil
+}
+
+//AuthorizeFileAccess - checks the same as Authorize and, if the group requires two-factor authentication,
+//that the user has enabled it, it guards the endpoints, which access the files of the group
+func (s *ServiceImpl) AuthorizeFileAccess(userID uint, groupName string, permission Permission) (models.Group, string, error) {
+	group, role, err := s.Authorize(userID, groupName, permission)
+	if err != nil || !group.RequireTwoFactor {
+		return group, role, err
+	}
+
+	twoFactor, err := s.uamDAO.GetTwoFactor(userID)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok || (err == nil && !twoFactor.Enabled) {
+		return models.Group{}, "", myerr.NewClientError("The group requires two-factor authentication. Please enable it first")
+	} else if err != nil {
+		return models.Group{}, "", err
+	}
+	return group, role, nil
+}
+
+//AuthorizeFileChange - checks if a member with the given role can make the change, guarded by the permission, to the file
+//the permission is enough for the own files of the member, the files of others require ManageFiles
+func (s *ServiceImpl) AuthorizeFileChange(userID uint, role string, fileInfo models.FileInfo, permission Permission) error {
+	if fileInfo.OwnerID == userID && HasPermission(role, permission) {
+		return nil
+	} else if HasPermission(role, ManageFiles) {
+		return nil
+	}
+	return myerr.NewClientError(fmt.Sprintf("Your role (%s) doesnt allow you to %s", role, ManageFiles))
+}
+
+//AuthorizeMemberChange - checks if the user can remove the membership of another user
+//every member can leave the group, the others can be removed only by members with higher role
+func (s *ServiceImpl) AuthorizeMemberChange(userID uint, groupName string, username string) (models.Group, error) {
+	group, role, err := s.Authorize(userID, groupName, ViewGroup)
+	if err != nil {
+		return models.Group{}, err
+	}
+
+	user, err := s.uamDAO.GetUser(username)
+	if err != nil {
+		return models.Group{}, err
+	} else if user.ID == userID {
+		return group, nil
+	} else if !HasPermission(role, ManageMembers) {
+		return models.Group{}, myerr.NewClientError(fmt.Sprintf("Your role (%s) doesnt allow you to %s", role, ManageMembers))
+	}
+
+	membership, err := s.uamDAO.GetMembership(user.ID, group.ID)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok {
+		return models.Group{}, myerr.NewClientError("Membership not found")
+	} else if err != nil {
+		return models.Group{}, err
+	} else if roleRanks[membership.Role] >= roleRanks[role] {
+		return models.Group{}, myerr.NewClientError("You cannot manage members with the same or higher role")
+	}
+	return group, nil
+}
+
+//HasPermission - checks if the role grants the permission
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+//IsAssignableRole - checks if a member can be given the role
+//the owner role can be given only with transfer of the ownership
+func IsAssignableRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok && role != models.RoleOwner
+}
@@ -0,0 +1,13 @@
+package permission_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPermission(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Permission Suite")
+}
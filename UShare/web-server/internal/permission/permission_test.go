@@ -0,0 +1,322 @@
+package permission_test
+
+import (
+	"fmt"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/dao/dao_mocks"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/db/models"
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/permission"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Permission service", func() {
+	const (
+		userID    = 1
+		groupID   = 2
+		groupName = "test-group"
+		username  = "username"
+	)
+
+	var (
+		controller *gomock.Controller
+		uamDAO     *dao_mocks.MockUamDAO
+		service    *permission.ServiceImpl
+		group      models.Group
+	)
+
+	BeforeEach(func() {
+		controller = gomock.NewController(GinkgoT())
+		uamDAO = dao_mocks.NewMockUamDAO(controller)
+		service = permission.NewServiceImpl(uamDAO)
+		group = models.Group{ID: groupID, Name: groupName, OwnerID: userID + 1, Active: true}
+	})
+
+	AfterEach(func() {
+		controller.Finish()
+	})
+
+	Context("HasPermission", func() {
+		It("grants every permission to the owner", func() {
+			Expect(permission.HasPermission(models.RoleOwner, permission.ManageGroup)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleOwner, permission.ManageRoles)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleOwner, permission.ViewAuditLog)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleOwner, permission.ManageTrash)).To(BeTrue())
+		})
+
+		It("grants management of files and members to the admins", func() {
+			Expect(permission.HasPermission(models.RoleAdmin, permission.ManageFiles)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleAdmin, permission.ManageMembers)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleAdmin, permission.ManageRoles)).To(BeFalse())
+			Expect(permission.HasPermission(models.RoleAdmin, permission.ViewAuditLog)).To(BeFalse())
+			Expect(permission.HasPermission(models.RoleAdmin, permission.ManageTrash)).To(BeFalse())
+		})
+
+		It("grants upload, deletion and tagging of own files to the contributors", func() {
+			Expect(permission.HasPermission(models.RoleContributor, permission.UploadFiles)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleContributor, permission.DeleteOwnFiles)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleContributor, permission.TagFiles)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleContributor, permission.ManageFiles)).To(BeFalse())
+		})
+
+		It("grants only viewing to the viewers", func() {
+			Expect(permission.HasPermission(models.RoleViewer, permission.ViewGroup)).To(BeTrue())
+			Expect(permission.HasPermission(models.RoleViewer, permission.UploadFiles)).To(BeFalse())
+			Expect(permission.HasPermission(models.RoleViewer, permission.TagFiles)).To(BeFalse())
+		})
+
+		It("grants nothing to unknown roles", func() {
+			Expect(permission.HasPermission("unknown", permission.ViewGroup)).To(BeFalse())
+		})
+	})
+
+	Context("IsAssignableRole", func() {
+		It("allows every role except the owner one", func() {
+			Expect(permission.IsAssignableRole(models.RoleAdmin)).To(BeTrue())
+			Expect(permission.IsAssignableRole(models.RoleContributor)).To(BeTrue())
+			Expect(permission.IsAssignableRole(models.RoleViewer)).To(BeTrue())
+			Expect(permission.IsAssignableRole(models.RoleOwner)).To(BeFalse())
+			Expect(permission.IsAssignableRole("unknown")).To(BeFalse())
+		})
+	})
+
+	Context("Authorize", func() {
+		When("the group lookup fails", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(models.Group{}, myerr.NewServerError("some error"))
+			})
+
+			It("propagates error", func() {
+				_, _, err := service.Authorize(userID, groupName, permission.ViewGroup)
+				Expect(err).To(HaveOccurred())
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("the group is deactivated", func() {
+			BeforeEach(func() {
+				group.Active = false
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+			})
+
+			It("returns client error", func() {
+				_, _, err := service.Authorize(userID, groupName, permission.ViewGroup)
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("the user isnt a member of the group", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{}, myerr.NewItemNotFoundError("Membership not found"))
+			})
+
+			It("returns client error", func() {
+				_, _, err := service.Authorize(userID, groupName, permission.ViewGroup)
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("the role of the user doesnt grant the permission", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{Role: models.RoleViewer}, nil)
+			})
+
+			It("returns client error", func() {
+				_, _, err := service.Authorize(userID, groupName, permission.UploadFiles)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(fmt.Sprintf("Your role (viewer) doesnt allow you to %s", permission.UploadFiles)))
+			})
+		})
+
+		When("the role of the user grants the permission", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{Role: models.RoleContributor}, nil)
+			})
+
+			It("returns the group and the role", func() {
+				result, role, err := service.Authorize(userID, groupName, permission.UploadFiles)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(group))
+				Expect(role).To(Equal(models.RoleContributor))
+			})
+		})
+	})
+
+	Context("AuthorizeFileAccess", func() {
+		BeforeEach(func() {
+			uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+				Return(models.Membership{Role: models.RoleContributor}, nil)
+		})
+
+		When("the group doesnt require two-factor authentication", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+			})
+
+			It("returns the group without checking the user", func() {
+				uamDAO.EXPECT().GetTwoFactor(gomock.Any()).Times(0)
+
+				result, _, err := service.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal(group))
+			})
+		})
+
+		When("the group requires two-factor authentication", func() {
+			BeforeEach(func() {
+				group.RequireTwoFactor = true
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+			})
+
+			Context("and the user hasnt enrolled it", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().GetTwoFactor(uint(userID)).
+						Return(models.TwoFactor{}, myerr.NewItemNotFoundError("Two-factor authentication isnt enrolled"))
+				})
+
+				It("returns client error", func() {
+					_, _, err := service.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+					_, ok := err.(*myerr.ClientError)
+					Expect(ok).To(BeTrue())
+				})
+			})
+
+			Context("and the enrollment of the user is pending", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().GetTwoFactor(uint(userID)).
+						Return(models.TwoFactor{UserID: userID}, nil)
+				})
+
+				It("returns client error", func() {
+					_, _, err := service.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+					_, ok := err.(*myerr.ClientError)
+					Expect(ok).To(BeTrue())
+				})
+			})
+
+			Context("and the user has enabled it", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().GetTwoFactor(uint(userID)).
+						Return(models.TwoFactor{UserID: userID, Enabled: true}, nil)
+				})
+
+				It("returns the group and the role", func() {
+					result, role, err := service.AuthorizeFileAccess(userID, groupName, permission.ViewGroup)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(group))
+					Expect(role).To(Equal(models.RoleContributor))
+				})
+			})
+		})
+	})
+
+	Context("AuthorizeFileChange", func() {
+		It("allows the contributors to change their own files", func() {
+			err := service.AuthorizeFileChange(userID, models.RoleContributor, models.FileInfo{OwnerID: userID}, permission.DeleteOwnFiles)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("forbids the contributors to change files of others", func() {
+			err := service.AuthorizeFileChange(userID, models.RoleContributor, models.FileInfo{OwnerID: userID + 1}, permission.DeleteOwnFiles)
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("forbids the viewers to change even their own files", func() {
+			err := service.AuthorizeFileChange(userID, models.RoleViewer, models.FileInfo{OwnerID: userID}, permission.DeleteOwnFiles)
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("allows the admins to change files of others", func() {
+			err := service.AuthorizeFileChange(userID, models.RoleAdmin, models.FileInfo{OwnerID: userID + 1}, permission.DeleteOwnFiles)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("checks the given permission for the own files", func() {
+			err := service.AuthorizeFileChange(userID, models.RoleViewer, models.FileInfo{OwnerID: userID}, permission.ViewGroup)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = service.AuthorizeFileChange(userID, models.RoleViewer, models.FileInfo{OwnerID: userID}, permission.TagFiles)
+			_, ok := err.(*myerr.ClientError)
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Context("AuthorizeMemberChange", func() {
+		When("the user removes himself", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{Role: models.RoleViewer}, nil)
+				uamDAO.EXPECT().GetUser(username).Return(models.User{ID: userID, Username: username}, nil)
+			})
+
+			It("succeeds", func() {
+				_, err := service.AuthorizeMemberChange(userID, groupName, username)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("a contributor removes another member", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{Role: models.RoleContributor}, nil)
+				uamDAO.EXPECT().GetUser(username).Return(models.User{ID: userID + 2, Username: username}, nil)
+			})
+
+			It("returns client error", func() {
+				_, err := service.AuthorizeMemberChange(userID, groupName, username)
+				_, ok := err.(*myerr.ClientError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("an admin removes another member", func() {
+			BeforeEach(func() {
+				uamDAO.EXPECT().GetGroup(groupName).Return(group, nil)
+				uamDAO.EXPECT().GetMembership(uint(userID), uint(groupID)).
+					Return(models.Membership{Role: models.RoleAdmin}, nil)
+				uamDAO.EXPECT().GetUser(username).Return(models.User{ID: userID + 2, Username: username}, nil)
+			})
+
+			Context("and the member is an admin too", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().GetMembership(uint(userID+2), uint(groupID)).
+						Return(models.Membership{Role: models.RoleAdmin}, nil)
+				})
+
+				It("returns client error", func() {
+					_, err := service.AuthorizeMemberChange(userID, groupName, username)
+					_, ok := err.(*myerr.ClientError)
+					Expect(ok).To(BeTrue())
+				})
+			})
+
+			Context("and the member has lower role", func() {
+				BeforeEach(func() {
+					uamDAO.EXPECT().GetMembership(uint(userID+2), uint(groupID)).
+						Return(models.Membership{Role: models.RoleContributor}, nil)
+				})
+
+				It("succeeds", func() {
+					result, err := service.AuthorizeMemberChange(userID, groupName, username)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(group))
+				})
+			})
+		})
+	})
+})
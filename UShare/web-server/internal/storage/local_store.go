@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+//LocalBlobStore - implementation of BlobStore, which keeps the files in a directory of the local filesystem
+type LocalBlobStore struct {
+	rootDir string
+}
+
+//NewLocalBlobStore - creates an instance of LocalBlobStore
+func NewLocalBlobStore(rootDir string) *LocalBlobStore {
+	return &LocalBlobStore{
+		rootDir: rootDir,
+	}
+}
+
+//Put - saves the content under the given key, replacing the previous content if any
+func (s *LocalBlobStore) Put(key string, content io.Reader, size int64) error {
+	filePath, err := s.getPath(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filePath)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with creation of the blob directory")
+	}
+
+	tmpFile, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with creation of the blob")
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = io.Copy(tmpFile, content); err != nil {
+		tmpFile.Close()
+		return myerr.NewServerErrorWrap(err, "Problem with writing the blob")
+	}
+
+	if err = tmpFile.Close(); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with writing the blob")
+	}
+
+	if err = os.Rename(tmpFile.Name(), filePath); err != nil {
+		return myerr.NewServerErrorWrap(err, "Problem with saving the blob")
+	}
+	return nil
+}
+
+//Get - opens the content, saved under the given key
+func (s *LocalBlobStore) Get(key string) (Blob, error) {
+	filePath, err := s.getPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, myerr.NewItemNotFoundError("Blob does not exist")
+	} else if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Problem with opening the blob")
+	}
+	return file, nil
+}
+
+//Delete - deletes the content, saved under the given key
+//the directories, which become empty, are also deleted
+func (s *LocalBlobStore) Delete(key string) error {
+	filePath, err := s.getPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return myerr.NewServerErrorWrap(err, "Problem with deletion of the blob")
+	}
+
+	for dir := filepath.Dir(filePath); dir != filepath.Clean(s.rootDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+//List - returns the keys of all blobs, which begin with the given prefix
+func (s *LocalBlobStore) List(prefix string) ([]string, error) {
+	startDir := s.rootDir
+	if dir := path.Dir(prefix + "_"); dir != "." {
+		startDir = filepath.Join(s.rootDir, filepath.FromSlash(dir))
+	}
+
+	keys := make([]string, 0)
+	err := filepath.Walk(startDir, func(filePath string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(s.rootDir, filePath)
+		if err != nil {
+			return err
+		}
+
+		if key := filepath.ToSlash(relPath); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Problem with listing the blobs")
+	}
+	return keys, nil
+}
+
+//Stat - returns metadata about the content, saved under the given key
+func (s *LocalBlobStore) Stat(key string) (BlobInfo, error) {
+	filePath, err := s.getPath(key)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return BlobInfo{}, myerr.NewItemNotFoundError("Blob does not exist")
+	} else if err != nil {
+		return BlobInfo{}, myerr.NewServerErrorWrap(err, "Problem with the lookup of the blob")
+	}
+
+	return BlobInfo{
+		Key:     key,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+//getPath - maps the key to a path in the root directory
+//keys, pointing outside of the root directory, are rejected
+func (s *LocalBlobStore) getPath(key string) (string, error) {
+	cleanKey := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleanKey == "" || cleanKey != key {
+		return "", myerr.NewClientError("Invalid blob key")
+	}
+	return filepath.Join(s.rootDir, filepath.FromSlash(cleanKey)), nil
+}
@@ -0,0 +1,94 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LocalBlobStore", func() {
+	var (
+		rootDir   string
+		blobStore storage.BlobStore
+	)
+
+	BeforeEach(func() {
+		rootDir, _ = ioutil.TempDir("", "blobs")
+		blobStore = storage.NewLocalBlobStore(rootDir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(rootDir)
+	})
+
+	When("content is saved", func() {
+		const key = "group/1"
+
+		BeforeEach(func() {
+			Expect(blobStore.Put(key, strings.NewReader("content"), 7)).To(Succeed())
+		})
+
+		It("can be read from any position", func() {
+			blob, err := blobStore.Get(key)
+			Expect(err).NotTo(HaveOccurred())
+			defer blob.Close()
+
+			_, err = blob.Seek(3, 0)
+			Expect(err).NotTo(HaveOccurred())
+			content, _ := ioutil.ReadAll(blob)
+			Expect(string(content)).To(Equal("tent"))
+		})
+
+		It("is listed by its prefix", func() {
+			Expect(blobStore.Put("other/1", strings.NewReader("other"), 5)).To(Succeed())
+
+			keys, err := blobStore.List("group/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(Equal([]string{key}))
+		})
+
+		It("returns its metadata", func() {
+			info, err := blobStore.Stat(key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Key).To(Equal(key))
+			Expect(info.Size).To(Equal(int64(7)))
+		})
+
+		It("removes the empty directories on deletion", func() {
+			Expect(blobStore.Delete(key)).To(Succeed())
+
+			_, err := blobStore.Get(key)
+			_, ok := err.(*myerr.ItemNotFoundError)
+			Expect(ok).To(BeTrue())
+			_, err = os.Stat(path.Join(rootDir, "group"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	When("content is missing", func() {
+		It("returns not found error", func() {
+			_, err := blobStore.Get("group/missing")
+			_, ok := err.(*myerr.ItemNotFoundError)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("lists no keys", func() {
+			keys, err := blobStore.List("group/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(BeEmpty())
+		})
+	})
+
+	When("the key points outside of the root dir", func() {
+		It("returns error", func() {
+			err := blobStore.Put("../escape", strings.NewReader("content"), 7)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,383 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+const (
+	s3EndpointKey  = "S3_ENDPOINT"
+	s3BucketKey    = "S3_BUCKET"
+	s3RegionKey    = "S3_REGION"
+	s3AccessKeyKey = "S3_ACCESS_KEY"
+	s3SecretKeyKey = "S3_SECRET_KEY"
+
+	defaultS3Region    = "us-east-1"
+	unsignedPayload    = "UNSIGNED-PAYLOAD"
+	amzDateFormat      = "20060102T150405Z"
+	amzShortDateFormat = "20060102"
+)
+
+//S3BlobStore - implementation of BlobStore, which keeps the files in a bucket of S3 compatible object storage
+//the requests use path-style addressing, so that it works with self-hosted storages like MinIO
+type S3BlobStore struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+//NewS3BlobStore - creates an instance of S3BlobStore, configured through env variables
+func NewS3BlobStore() (*S3BlobStore, error) {
+	store := &S3BlobStore{
+		Endpoint:  strings.TrimSuffix(os.Getenv(s3EndpointKey), "/"),
+		Bucket:    os.Getenv(s3BucketKey),
+		Region:    os.Getenv(s3RegionKey),
+		AccessKey: os.Getenv(s3AccessKeyKey),
+		SecretKey: os.Getenv(s3SecretKeyKey),
+		Client:    http.DefaultClient,
+	}
+
+	if store.Endpoint == "" {
+		return nil, myerr.NewServerError("Missing value for \"endpoint\" s3 config")
+	} else if store.Bucket == "" {
+		return nil, myerr.NewServerError("Missing value for \"bucket\" s3 config")
+	} else if store.AccessKey == "" || store.SecretKey == "" {
+		return nil, myerr.NewServerError("Missing value for \"credentials\" s3 config")
+	}
+
+	if store.Region == "" {
+		store.Region = defaultS3Region
+	}
+	return store, nil
+}
+
+//Put - saves the content under the given key, replacing the previous content if any
+func (s *S3BlobStore) Put(key string, content io.Reader, size int64) error {
+	req, err := s.newRequest(http.MethodPut, key, nil, content)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+//Get - opens the content, saved under the given key
+//the content is fetched lazily, seeking in it results in a ranged request
+func (s *S3BlobStore) Get(key string) (Blob, error) {
+	info, err := s.Stat(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Blob{
+		store: s,
+		key:   key,
+		size:  info.Size,
+	}, nil
+}
+
+//Delete - deletes the content, saved under the given key
+func (s *S3BlobStore) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if _, ok := err.(*myerr.ItemNotFoundError); ok {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+//List - returns the keys of all blobs, which begin with the given prefix
+func (s *S3BlobStore) List(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := s.newRequest(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, myerr.NewServerErrorWrap(err, "Problem with parsing the list of blobs")
+		}
+
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+//Stat - returns metadata about the content, saved under the given key
+func (s *S3BlobStore) Stat(key string) (BlobInfo, error) {
+	req, err := s.newRequest(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return BlobInfo{
+		Key:     key,
+		Size:    resp.ContentLength,
+		ModTime: modTime,
+	}, nil
+}
+
+func (s *S3BlobStore) newRequest(method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	objectPath := "/" + s.Bucket
+	if key != "" {
+		objectPath += "/" + key
+	}
+
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Invalid s3 endpoint")
+	}
+	endpoint.Path = objectPath
+	endpoint.RawPath = encodeS3Path(objectPath)
+	endpoint.RawQuery = encodeS3Query(query)
+
+	req, err := http.NewRequest(method, endpoint.String(), body)
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Problem with creation of s3 request")
+	}
+	return req, nil
+}
+
+//do - signs and executes the request
+//returns ItemNotFoundError if the object doesnt exist and ServerError for any other unsuccessful response
+func (s *S3BlobStore) do(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, myerr.NewServerErrorWrap(err, "Problem with the request to the s3 storage")
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, myerr.NewItemNotFoundError("Blob does not exist")
+	} else if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, myerr.NewServerError(fmt.Sprintf("Unexpected response from the s3 storage [%d]: %s", resp.StatusCode, message))
+	}
+	return resp, nil
+}
+
+//sign - signs the request with AWS Signature Version 4
+func (s *S3BlobStore) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	shortDate := now.Format(amzShortDateFormat)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", unsignedPayload)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, unsignedPayload, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		unsignedPayload,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", shortDate, s.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.SecretKey), shortDate)
+	signingKey = hmacSHA256(signingKey, s.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+//s3Blob - lazily fetched content of an object, which supports seeking through ranged requests
+type s3Blob struct {
+	store  *S3BlobStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (b *s3Blob) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+
+	if b.body == nil {
+		req, err := b.store.newRequest(http.MethodGet, b.key, nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.offset))
+
+		resp, err := b.store.do(req)
+		if err != nil {
+			return 0, err
+		}
+		b.body = resp.Body
+	}
+
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+	return n, err
+}
+
+func (b *s3Blob) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = b.offset + offset
+	case io.SeekEnd:
+		newOffset = b.size + offset
+	default:
+		return 0, myerr.NewServerError("Invalid whence")
+	}
+
+	if newOffset < 0 {
+		return 0, myerr.NewServerError("Negative position")
+	}
+
+	if newOffset != b.offset && b.body != nil {
+		b.body.Close()
+		b.body = nil
+	}
+	b.offset = newOffset
+	return newOffset, nil
+}
+
+func (b *s3Blob) Close() error {
+	if b.body == nil {
+		return nil
+	}
+	return b.body.Close()
+}
+
+//encodeS3Path - escapes every segment of the path, as required by the signature
+func encodeS3Path(objectPath string) string {
+	segments := strings.Split(objectPath, "/")
+	for idx, segment := range segments {
+		segments[idx] = encodeS3Component(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+//encodeS3Query - builds the canonical query string - sorted by key and escaped
+func encodeS3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, encodeS3Component(key)+"="+encodeS3Component(value))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func encodeS3Component(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return builder.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,221 @@
+package storage_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	bucket    = "ushare"
+	accessKey = "access-key"
+	secretKey = "secret-key"
+)
+
+//fakeS3Server - minimal in-memory stand-in for a S3 compatible storage like MinIO
+type fakeS3Server struct {
+	sync.Mutex
+	objects  map[string][]byte
+	pageSize int
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+accessKey+"/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/"+bucket)
+	if key == "" && r.Method == http.MethodGet {
+		s.list(w, r)
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+
+	switch r.Method {
+	case http.MethodPut:
+		content, _ := ioutil.ReadAll(r.Body)
+		s.objects[key] = content
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		content, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		status := http.StatusOK
+		if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rangeHeader, "bytes="), "-"))
+			content = content[start:]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(content)
+		}
+	}
+}
+
+func (s *fakeS3Server) list(w http.ResponseWriter, r *http.Request) {
+	keys := make([]string, 0)
+	for key := range s.objects {
+		if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+	end := start + s.pageSize
+	truncated := end < len(keys)
+	if !truncated {
+		end = len(keys)
+	}
+
+	fmt.Fprint(w, "<ListBucketResult>")
+	for _, key := range keys[start:end] {
+		fmt.Fprint(w, "<Contents><Key>")
+		xml.EscapeText(w, []byte(key))
+		fmt.Fprint(w, "</Key></Contents>")
+	}
+	fmt.Fprintf(w, "<IsTruncated>%t</IsTruncated><NextContinuationToken>%d</NextContinuationToken></ListBucketResult>", truncated, end)
+}
+
+var _ = Describe("S3BlobStore", func() {
+	Context("NewS3BlobStore", func() {
+		BeforeEach(func() {
+			os.Clearenv()
+		})
+
+		When("endpoint env variable is missing", func() {
+			It("returns error", func() {
+				_, err := storage.NewS3BlobStore()
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("all env variables are set", func() {
+			BeforeEach(func() {
+				os.Setenv("S3_ENDPOINT", "http://localhost:9000/")
+				os.Setenv("S3_BUCKET", bucket)
+				os.Setenv("S3_ACCESS_KEY", accessKey)
+				os.Setenv("S3_SECRET_KEY", secretKey)
+			})
+
+			It("uses the default region", func() {
+				blobStore, err := storage.NewS3BlobStore()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(blobStore.Endpoint).To(Equal("http://localhost:9000"))
+				Expect(blobStore.Region).To(Equal("us-east-1"))
+			})
+		})
+	})
+
+	Context("BlobStore", func() {
+		var (
+			server    *httptest.Server
+			fakeS3    *fakeS3Server
+			blobStore storage.BlobStore
+		)
+
+		BeforeEach(func() {
+			fakeS3 = &fakeS3Server{objects: map[string][]byte{}, pageSize: 1}
+			server = httptest.NewServer(fakeS3)
+			blobStore = &storage.S3BlobStore{
+				Endpoint:  server.URL,
+				Bucket:    bucket,
+				Region:    "us-east-1",
+				AccessKey: accessKey,
+				SecretKey: secretKey,
+				Client:    server.Client(),
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		When("content is saved", func() {
+			const key = "group name/1"
+
+			BeforeEach(func() {
+				Expect(blobStore.Put(key, strings.NewReader("content"), 7)).To(Succeed())
+			})
+
+			It("is stored in the bucket", func() {
+				Expect(fakeS3.objects).To(HaveKeyWithValue(key, []byte("content")))
+			})
+
+			It("can be read from any position", func() {
+				blob, err := blobStore.Get(key)
+				Expect(err).NotTo(HaveOccurred())
+				defer blob.Close()
+
+				_, err = blob.Seek(3, 0)
+				Expect(err).NotTo(HaveOccurred())
+				content, _ := ioutil.ReadAll(blob)
+				Expect(string(content)).To(Equal("tent"))
+			})
+
+			It("returns its size", func() {
+				info, err := blobStore.Stat(key)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Size).To(Equal(int64(7)))
+			})
+
+			It("is listed through all pages", func() {
+				Expect(blobStore.Put("group name/2", strings.NewReader("other"), 5)).To(Succeed())
+				Expect(blobStore.Put("other/1", strings.NewReader("other"), 5)).To(Succeed())
+
+				keys, err := blobStore.List("group name/")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keys).To(Equal([]string{key, "group name/2"}))
+			})
+
+			It("is removed on deletion", func() {
+				Expect(blobStore.Delete(key)).To(Succeed())
+				Expect(fakeS3.objects).To(BeEmpty())
+			})
+		})
+
+		When("content is missing", func() {
+			It("returns not found error", func() {
+				_, err := blobStore.Get("group/missing")
+				_, ok := err.(*myerr.ItemNotFoundError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+
+		When("the storage rejects the request", func() {
+			BeforeEach(func() {
+				blobStore.(*storage.S3BlobStore).AccessKey = "wrong-key"
+			})
+
+			It("returns server error", func() {
+				err := blobStore.Put("group/1", strings.NewReader("content"), 7)
+				_, ok := err.(*myerr.ServerError)
+				Expect(ok).To(BeTrue())
+			})
+		})
+	})
+})
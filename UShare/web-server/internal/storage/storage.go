@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+//contentKeyPrefix - prefix of the keys of the deduplicated contents
+//group names are at least 8 symbols long, so it cannot clash with the keys of a group
+const contentKeyPrefix = "blobs"
+
+//uploadsDirName - key prefix in the group, under which the chunks of unfinished uploads are kept
+const uploadsDirName = "uploads"
+
+//go:generate mockgen --source=storage.go --destination storage_mocks/storage.go --package storage_mocks
+
+//BlobStore - interface for storing the content of the files, independent of where it is kept
+//the keys are slash separated paths, for instance "<group name>/<file id>"
+type BlobStore interface {
+	Put(key string, content io.Reader, size int64) error
+	Get(key string) (Blob, error)
+	Delete(key string) error
+	List(prefix string) ([]string, error)
+	Stat(key string) (BlobInfo, error)
+}
+
+//Blob - content of a stored file, which supports random access
+type Blob interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+//BlobInfo - metadata of a stored file
+type BlobInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+//ContentKey - returns the key of a deduplicated content, given its sha256 checksum
+//the contents are spread in subdirectories by the first two symbols of the checksum
+func ContentKey(checksum string) string {
+	if len(checksum) < 2 {
+		return fmt.Sprintf("%s/%s", contentKeyPrefix, checksum)
+	}
+	return fmt.Sprintf("%s/%s/%s", contentKeyPrefix, checksum[:2], checksum)
+}
+
+//FileKey - returns the key of the content of a file, uploaded before the deduplication of the contents
+func FileKey(groupName string, fileID uint) string {
+	return fmt.Sprintf("%s/%d", groupName, fileID)
+}
+
+//UploadKeyPrefix - returns the prefix of the keys of the chunks of an unfinished upload in a group
+func UploadKeyPrefix(groupName string, uploadID string) string {
+	return fmt.Sprintf("%s/%s/%s/", groupName, uploadsDirName, uploadID)
+}
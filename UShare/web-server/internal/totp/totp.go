@@ -0,0 +1,134 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	myerr "github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/error"
+)
+
+//the parameters of the codes, these are the defaults of the authenticator apps, so they arent configurable
+const (
+	//Period - the number of seconds, for which a code is valid
+	Period = 30
+	//Digits - the number of digits of a code
+	Digits = 6
+	//skew - the number of periods before and after the current one, whose codes are accepted as well
+	skew = 1
+
+	secretSize       = 20
+	recoveryCodeSize = 5
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//GenerateSecret - generates a random secret, encoded in base32 as expected by the authenticator apps
+func GenerateSecret() (string, error) {
+	randomBytes := make([]byte, secretSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", myerr.NewServerErrorWrap(err, "Problem with the generation of the totp secret")
+	}
+	return secretEncoding.EncodeToString(randomBytes), nil
+}
+
+//ProvisioningURI - returns the otpauth uri of the secret, which is imported in the authenticator apps (usually as a QR code)
+func ProvisioningURI(issuer string, account string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+//Step - returns the number of the period, which contains the given time
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+//GenerateCode - generates the code of the secret for the given period (RFC 6238)
+func GenerateCode(secret string, step int64) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", myerr.NewServerErrorWrap(err, "Invalid totp secret")
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%modulo), nil
+}
+
+//Validate - checks the code against the periods around the given time, to tolerate clock drift
+//returns the period of the matching code, which is used to reject its reuse
+func Validate(secret string, code string, t time.Time) (int64, bool) {
+	if !IsCode(code) {
+		return 0, false
+	}
+
+	current := Step(t)
+	for step := current - skew; step <= current+skew; step++ {
+		expected, err := GenerateCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+//IsCode - checks if the value has the format of a totp code, otherwise it could be a recovery code
+func IsCode(value string) bool {
+	if len(value) != Digits {
+		return false
+	}
+	for _, symbol := range value {
+		if symbol < '0' || symbol > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//GenerateRecoveryCodes - generates one-time codes, which replace the totp codes, if the authenticator is lost
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		randomBytes := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return nil, myerr.NewServerErrorWrap(err, "Problem with the generation of the recovery codes")
+		}
+		code := hex.EncodeToString(randomBytes)
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+//NormalizeRecoveryCode - returns the recovery code in the format, in which it was generated
+func NormalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
@@ -0,0 +1,101 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/danielpenchev98/FMI-Golang/UShare/web-server/internal/totp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Totp", func() {
+	//the secret of the test vectors of RFC 6238
+	rfcSecret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	Context("GenerateCode", func() {
+		It("matches the test vectors of RFC 6238", func() {
+			vectors := map[int64]string{
+				59:         "287082",
+				1111111109: "081804",
+				1234567890: "005924",
+				2000000000: "279037",
+			}
+
+			for seconds, expected := range vectors {
+				code, err := totp.GenerateCode(rfcSecret, totp.Step(time.Unix(seconds, 0)))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(code).To(Equal(expected))
+			}
+		})
+
+		It("rejects invalid secrets", func() {
+			_, err := totp.GenerateCode("not base32!", 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Validate", func() {
+		now := time.Unix(1234567890, 0)
+
+		It("accepts the code of the current period", func() {
+			step, ok := totp.Validate(rfcSecret, "005924", now)
+			Expect(ok).To(BeTrue())
+			Expect(step).To(Equal(totp.Step(now)))
+		})
+
+		It("accepts the code of the previous period", func() {
+			step, ok := totp.Validate(rfcSecret, "005924", now.Add(totp.Period*time.Second))
+			Expect(ok).To(BeTrue())
+			Expect(step).To(Equal(totp.Step(now)))
+		})
+
+		It("rejects the codes of older periods", func() {
+			_, ok := totp.Validate(rfcSecret, "005924", now.Add(3*totp.Period*time.Second))
+			Expect(ok).To(BeFalse())
+		})
+
+		It("rejects malformed codes", func() {
+			_, ok := totp.Validate(rfcSecret, "5924", now)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("GenerateSecret", func() {
+		It("generates a secret, which produces codes", func() {
+			secret, err := totp.GenerateSecret()
+			Expect(err).NotTo(HaveOccurred())
+
+			code, err := totp.GenerateCode(secret, totp.Step(time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+			_, ok := totp.Validate(secret, code, time.Now())
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Context("ProvisioningURI", func() {
+		It("contains the account, the issuer and the secret", func() {
+			uri := totp.ProvisioningURI("UShare", "user name", "SECRET")
+			Expect(uri).To(HavePrefix("otpauth://totp/UShare:user%20name?"))
+			Expect(uri).To(ContainSubstring("secret=SECRET"))
+			Expect(uri).To(ContainSubstring("issuer=UShare"))
+		})
+	})
+
+	Context("GenerateRecoveryCodes", func() {
+		It("generates distinct codes, which arent mistaken for totp codes", func() {
+			codes, err := totp.GenerateRecoveryCodes(10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(codes).To(HaveLen(10))
+
+			unique := make(map[string]bool)
+			for _, code := range codes {
+				Expect(totp.IsCode(code)).To(BeFalse())
+				Expect(totp.NormalizeRecoveryCode(" "+strings.ToUpper(code)+" ")).To(Equal(code))
+				unique[code] = true
+			}
+			Expect(unique).To(HaveLen(10))
+		})
+	})
+})